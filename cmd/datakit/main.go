@@ -174,11 +174,22 @@ func startIO() {
 		dkio.WithMaxCacheCount(c.MaxCacheCount),
 		dkio.WithOutputFile(c.OutputFile),
 		dkio.WithOutputFileOnInputs(c.OutputFileInputs),
+		dkio.WithOutputFileMaxSize(c.OutputFileMaxSizeMB),
+		dkio.WithOutputFileMaxFiles(c.OutputFileMaxFiles),
+		dkio.WithOutputFileCompress(c.OutputFileCompress),
+		dkio.WithOutputFilePerInput(c.OutputFilePerInput),
 		dkio.WithDiskCache(c.EnableCache),
 		dkio.WithDiskCacheSize(c.CacheSizeGB),
 		dkio.WithFilters(c.Filters),
 		dkio.WithCacheAll(c.CacheAll),
 		dkio.WithFlushWorkers(c.FlushWorkers),
+		dkio.WithWALCapacity(c.WALCapacityMB),
+		dkio.WithWALNoSync(c.WALNoSync),
+		dkio.WithWAL(c.EnableWAL),
+		dkio.WithOutputs(c.Outputs),
+		dkio.WithCategoryRateLimits(c.CategoryRateLimits),
+		dkio.WithInputRateLimits(c.InputRateLimits),
+		dkio.WithFeedPriority(c.FeedPriority, c.ShedWatermark),
 	}
 
 	du, err := time.ParseDuration(c.FlushInterval)
@@ -187,6 +198,15 @@ func startIO() {
 		opts = append(opts, dkio.WithFlushInterval(du))
 	}
 
+	if c.OutputFileRotateInterval != "" {
+		du, err = time.ParseDuration(c.OutputFileRotateInterval)
+		if err != nil {
+			l.Warnf("parse OutputFileRotateInterval failed: %s, ignored", err)
+		} else {
+			opts = append(opts, dkio.WithOutputFileRotateInterval(du))
+		}
+	}
+
 	du, err = time.ParseDuration(c.CacheCleanInterval)
 	if err != nil {
 		l.Warnf("parse CacheCleanInterval failed: %s, use default 5s", err)
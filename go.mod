@@ -34,6 +34,7 @@ require (
 	github.com/elastic/go-lumber v0.1.1
 	github.com/elazarl/goproxy v0.0.0-20210801061803-8e322dfb79c4
 	github.com/fatih/color v1.13.0
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/gdamore/tcell/v2 v2.4.1-0.20210905002822-f057f0a857a1
 	github.com/gin-gonic/gin v1.9.0
 	github.com/go-git/go-git/v5 v5.4.2
@@ -70,6 +71,7 @@ require (
 	github.com/ory/dockertest/v3 v3.9.1
 	github.com/oschwald/geoip2-golang v1.7.0
 	github.com/pborman/ansi v1.0.0
+	github.com/pierrec/lz4/v4 v4.1.17
 	github.com/pkg/sftp v1.11.0
 	github.com/prometheus-operator/prometheus-operator/pkg/client v0.51.2
 	github.com/prometheus/client_golang v1.14.0
@@ -113,7 +115,6 @@ require (
 	k8s.io/metrics v0.20.5
 	modernc.org/sqlite v1.20.0
 	sigs.k8s.io/yaml v1.3.0
-
 )
 
 // indirect
@@ -179,7 +180,6 @@ require (
 	github.com/emirpasic/gods v1.12.0 // indirect
 	github.com/felixge/httpsnoop v1.0.3 // indirect
 	github.com/florianl/go-tc v0.2.0 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/garyburd/redigo v1.6.4 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
 	github.com/gin-contrib/sse v0.1.0 // indirect
@@ -341,7 +341,7 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/square/go-jose.v2 v2.6.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/apiextensions-apiserver v0.25.0 // indirect
 	k8s.io/klog/v2 v2.80.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20220803164354-a70c9af30aea // indirect
@@ -361,14 +361,15 @@ require (
 
 require github.com/jmoiron/sqlx v1.3.4
 
-require github.com/GuanceCloud/tracing-protos v0.0.0-20230619071516-54c8cff1b6b3
+require (
+	github.com/GuanceCloud/tracing-protos v0.0.0-20230619071516-54c8cff1b6b3
+	github.com/yuin/goldmark v1.5.4
+	github.com/yuin/goldmark-meta v1.1.0
+)
 
 require (
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
-	github.com/pierrec/lz4/v4 v4.1.17 // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
-	github.com/yuin/goldmark v1.5.4 // indirect
-	github.com/yuin/goldmark-meta v1.1.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.2 // indirect
 )
 
@@ -12,6 +12,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput/funcs"
 )
 
 var (
@@ -44,22 +45,18 @@ func (c *completer) Complete(d prompt.Document) []prompt.Suggest {
 }
 
 func ipInfo(ip string) (map[string]string, error) {
-	if ipdbInstance, err := pipeline.InitIPdb(config.Cfg.Pipeline); err != nil {
+	if _, err := pipeline.InitIPdb(config.Cfg.Pipeline); err != nil {
 		return nil, err
-	} else {
-		x, err := ipdbInstance.Geo(ip)
-		if err != nil {
-			return nil, err
-		}
-
-		return map[string]string{
-			"city":     x.City,
-			"province": x.Region,
-			"country":  x.Country,
-			"isp":      ipdbInstance.SearchIsp(ip),
-			"ip":       ip,
-		}, nil
 	}
+
+	// same as geoip() within pipeline, the custom CIDR database consulted first
+	res, err := funcs.GeoIPHandle(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	res["ip"] = ip
+	return res, nil
 }
 
 func setCmdRootLog(rl string) {
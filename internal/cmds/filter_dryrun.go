@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	cp "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/colorprint"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/httpapi"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/parser"
+)
+
+// loadFilterConds load filter conditions of category from JSON file f,
+// if f not set, conditions within datakit.conf used.
+func loadFilterConds(f, category string) (filter.FilterConditions, error) {
+	if f == "" {
+		tryLoadMainCfg()
+		if config.Cfg.IO == nil {
+			return nil, fmt.Errorf("no filters configured in datakit.conf")
+		}
+
+		return config.Cfg.IO.Filters[category], nil
+	}
+
+	x, err := ioutil.ReadFile(filepath.Clean(f))
+	if err != nil {
+		return nil, err
+	}
+
+	var filters filter.Filters
+	if err := json.Unmarshal(x, &filters); err != nil {
+		return nil, err
+	}
+
+	return filters.Filters[category], nil
+}
+
+func filterDryRun() error {
+	parser.Init() // reset logger to the root logger of the command
+
+	data, err := ioutil.ReadFile(filepath.Clean(*flagToolFilterDryRun))
+	if err != nil {
+		cp.Errorf("ioutil.ReadFile: %s\n", err.Error())
+		return err
+	}
+
+	conds, err := loadFilterConds(*flagToolFilterConds, *flagToolFilterCategory)
+	if err != nil {
+		cp.Errorf("load filter conditions: %s\n", err.Error())
+		return err
+	}
+
+	resp, err := httpapi.FilterDryRun(&httpapi.FilterDryRunRequest{
+		Category:   *flagToolFilterCategory,
+		Conditions: conds,
+		Data:       string(data),
+		JSON:       strings.HasSuffix(*flagToolFilterDryRun, ".json"),
+	})
+	if err != nil {
+		msg := err.Error()
+
+		var me *uhttp.MsgError
+		if errors.As(err, &me) {
+			msg += ": " + fmt.Sprintf(me.Fmt, me.Args...)
+		}
+
+		cp.Errorf("filter dry-run: %s\n", msg)
+		return err
+	}
+
+	if *flagToolJSON {
+		j, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			cp.Errorf("Marshal: %s\n", err.Error())
+			return err
+		}
+
+		cp.Output("%s\n", string(j))
+		return nil
+	}
+
+	for _, r := range resp.Results {
+		switch {
+		case r.Error != "":
+			cp.Errorf("[E] %s\n\terror: %s\n", r.Point, r.Error)
+		case r.Dropped:
+			cp.Warnf("[DROP] %s\n\tmatched #%d: %s\n", r.Point, r.Index, r.Condition)
+		default:
+			cp.Infof("[KEEP] %s\n", r.Point)
+		}
+	}
+
+	cp.Output("%d of %d points dropped.\n", resp.Dropped, resp.Total)
+	return nil
+}
@@ -83,11 +83,20 @@ var (
 	flagPLTable    = fsPL.Bool("tab", false, "output result in table format")
 	flagPLDate     = fsPL.Bool("date", false, "append date display(according to local timezone) on timestamp")
 	fsPLUsage      = func() {
-		fmt.Printf("usage: datakit pipeline -P [pipeline-script-name.p] -T [text] [other-options...]\n\n")
-		fmt.Printf("Pipeline used to debug exists pipeline script.\n\n")
+		fmt.Printf("usage: datakit pipeline -P [pipeline-script-name.p] -T [text] [other-options...]\n")
+		fmt.Printf("       datakit pipeline test [pipeline-dir] [test-options...]\n\n")
+		fmt.Printf("Pipeline used to debug exists pipeline script, or run test cases(*.p.test) of pipeline scripts.\n\n")
 		fmt.Println(fsPL.FlagUsagesWrapped(0))
+		fmt.Printf("test-options:\n\n")
+		fmt.Println(fsPLTest.FlagUsagesWrapped(0))
 	}
 
+	fsPLTestName      = "test"
+	fsPLTest          = pflag.NewFlagSet(fsPLTestName, pflag.ContinueOnError)
+	flagPLTestUpdate  = fsPLTest.Bool("update", false, "update expect of test cases with the actual result")
+	flagPLTestVerbose = fsPLTest.BoolP("verbose", "V", false, "show passed cases and scripts without test cases")
+	flagPLTestLogPath = fsPLTest.String("log", commonLogFlag(), "log path")
+
 	//
 	// version related flags.
 	//
@@ -216,6 +225,10 @@ var (
 	flagToolJSON              = fsTool.Bool("json", false, "output in JSON format(partially supported)")
 	flagToolUpdateIPDB        = fsTool.Bool("update-ipdb", false, "update local IPDB")
 
+	flagToolFilterDryRun   = fsTool.String("filter-dryrun", "", "test filter conditions against sample data file(line-protocol, or JSON with .json extension)")
+	flagToolFilterCategory = fsTool.String("filter-category", "logging", "category of the sample data on filter dry-run")
+	flagToolFilterConds    = fsTool.String("filter-conds", "", "JSON file of filter conditions(same as pulled from Dataway), default use filters in datakit.conf")
+
 	fsToolUsage = func() {
 		fmt.Printf("usage: datakit tool [options]\n\n")
 		fmt.Printf("Various tools for DataKit\n\n")
@@ -417,6 +430,29 @@ func doParseAndRunFlags() {
 
 		case fsPLName:
 
+			if len(os.Args) > 2 && os.Args[2] == fsPLTestName {
+				if err := fsPLTest.Parse(os.Args[3:]); err != nil {
+					cp.Errorf("[E] Parse: %s\n", err)
+					fsPLUsage()
+					os.Exit(-1)
+				}
+
+				if fsPLTest.NArg() != 1 {
+					fsPLUsage()
+					os.Exit(-1)
+				}
+
+				setCmdRootLog(*flagPLTestLogPath)
+				tryLoadMainCfg()
+
+				if err := runPLTest(fsPLTest.Arg(0)); err != nil {
+					cp.Errorf("[E] %s\n", err)
+					os.Exit(-1)
+				}
+
+				os.Exit(0)
+			}
+
 			if len(os.Args) < 6 {
 				fsPLUsage()
 				os.Exit(-1)
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package cmds
+
+import (
+	"fmt"
+
+	cp "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/colorprint"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/config"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/pltest"
+)
+
+func runPLTest(dir string) error {
+	if err := pipeline.Init(config.Cfg.Pipeline); err != nil {
+		return err
+	}
+
+	res, err := pltest.Run(dir, *flagPLTestUpdate)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range res.Cases {
+		name := c.Script
+		if c.Case != "" {
+			name += "/" + c.Case
+		}
+
+		switch {
+		case c.Error != nil:
+			cp.Errorf("[FAIL] %s\n\terror: %s\n", name, c.Error)
+		case len(c.Diffs) > 0:
+			cp.Errorf("[FAIL] %s\n", name)
+			for _, d := range c.Diffs {
+				cp.Errorf("\t%s\n", d)
+			}
+		default:
+			if *flagPLTestVerbose {
+				cp.Infof("[PASS] %s\n", name)
+			}
+		}
+	}
+
+	if *flagPLTestVerbose {
+		for _, s := range res.NoTest {
+			cp.Warnf("[NO TEST] %s\n", s)
+		}
+	}
+
+	for _, f := range res.Updated {
+		cp.Infof("[UPDATED] %s\n", f)
+	}
+
+	failed := res.Failed()
+	cp.Output("%d cases, %d passed, %d failed, %d scripts without test\n",
+		len(res.Cases), len(res.Cases)-failed, failed, len(res.NoTest))
+
+	if failed > 0 && !*flagPLTestUpdate {
+		return fmt.Errorf("%d cases failed", failed)
+	}
+
+	return nil
+}
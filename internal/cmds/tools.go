@@ -36,6 +36,13 @@ func runToolFlags() error {
 			os.Exit(0)
 		}
 
+	case *flagToolFilterDryRun != "":
+		if err := filterDryRun(); err != nil {
+			os.Exit(1)
+		} else {
+			os.Exit(0)
+		}
+
 	case *flagToolSetupCompleterScripts:
 		setupCompleterScripts()
 		os.Exit(0)
@@ -5,7 +5,10 @@
 
 package config
 
-import "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+import (
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/output"
+)
 
 type IOConf struct {
 	FeedChanSize int `toml:"feed_chan_size,omitzero"`
@@ -16,13 +19,31 @@ type IOConf struct {
 	FlushInterval string `toml:"flush_interval"`
 	FlushWorkers  int    `toml:"flush_workers"`
 
-	OutputFile       string   `toml:"output_file"`
-	OutputFileInputs []string `toml:"output_file_inputs"`
+	OutputFile               string   `toml:"output_file"`
+	OutputFileInputs         []string `toml:"output_file_inputs"`
+	OutputFileMaxSizeMB      int      `toml:"output_file_max_size_mb"`
+	OutputFileRotateInterval string   `toml:"output_file_rotate_interval"`
+	OutputFileMaxFiles       int      `toml:"output_file_max_files"`
+	OutputFileCompress       bool     `toml:"output_file_compress"`
+	OutputFilePerInput       bool     `toml:"output_file_per_input"`
 
 	EnableCache        bool   `toml:"enable_cache"`
 	CacheAll           bool   `toml:"cache_all"`
 	CacheSizeGB        int    `toml:"cache_max_size_gb"`
 	CacheCleanInterval string `toml:"cache_clean_interval"`
 
+	EnableWAL     bool `toml:"enable_wal"`
+	WALNoSync     bool `toml:"wal_no_sync"`
+	WALCapacityMB int  `toml:"wal_max_size_mb"`
+
+	// Max points per second of each category/input.
+	CategoryRateLimits map[string]float64 `toml:"category_rate_limits,omitempty"`
+	InputRateLimits    map[string]float64 `toml:"input_rate_limits,omitempty"`
+
+	FeedPriority  []string `toml:"feed_priority"`
+	ShedWatermark float64  `toml:"shed_watermark"`
+
 	Filters map[string]filter.FilterConditions `toml:"filters"`
+
+	Outputs []*output.Output `toml:"outputs,omitempty"`
 }
@@ -17,6 +17,8 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/parser"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/offload"
+	plrefertable "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/refertable"
+	plscript "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
 )
 
 // LoadSink unmarshal sinker JSON string to dataway's sinker.
@@ -92,6 +94,68 @@ func (c *Config) loadDataway() {
 			l.Warnf("invalid ENV_DATAWAY_IDLE_TIMEOUT(%q): %s, ignored", v, err)
 		}
 	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_LOAD_BALANCE"); v != "" {
+		c.Dataway.LoadBalance = v
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_HEALTH_CHECK_INTERVAL"); v != "" {
+		du, err := time.ParseDuration(v)
+		if err == nil {
+			c.Dataway.HealthCheckInterval = du
+		} else {
+			l.Warnf("invalid ENV_DATAWAY_HEALTH_CHECK_INTERVAL(%q): %s, ignored", v, err)
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_CIRCUIT_BREAKER_FAILURES"); v != "" {
+		value, err := strconv.ParseInt(v, 10, 64)
+		if err == nil && value > 0 {
+			c.Dataway.CircuitBreakerFailures = int(value)
+		} else {
+			l.Warnf("invalid ENV_DATAWAY_CIRCUIT_BREAKER_FAILURES(%q), ignored", v)
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_CIRCUIT_BREAKER_TIMEOUT"); v != "" {
+		du, err := time.ParseDuration(v)
+		if err == nil {
+			c.Dataway.CircuitBreakerTimeout = du
+		} else {
+			l.Warnf("invalid ENV_DATAWAY_CIRCUIT_BREAKER_TIMEOUT(%q): %s, ignored", v, err)
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_CONTENT_ENCODING"); v != "" {
+		c.Dataway.ContentEncoding = v
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_ADAPTIVE_BATCH"); v != "" {
+		c.Dataway.AdaptiveBatch = true
+	}
+
+	for env, x := range map[string]*int{
+		"ENV_DATAWAY_MAX_BODY_BYTES": &c.Dataway.MaxBodyBytes,
+		"ENV_DATAWAY_MIN_BODY_BYTES": &c.Dataway.MinBodyBytes,
+	} {
+		if v := datakit.GetEnv(env); v != "" {
+			value, err := strconv.ParseInt(v, 10, 64)
+			if err == nil && value > 0 {
+				*x = int(value)
+			} else {
+				l.Warnf("invalid %s(%q), ignored", env, v)
+			}
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_DATAWAY_BATCH_TARGET_LATENCY"); v != "" {
+		du, err := time.ParseDuration(v)
+		if err == nil {
+			c.Dataway.BatchTargetLatency = du
+		} else {
+			l.Warnf("invalid ENV_DATAWAY_BATCH_TARGET_LATENCY(%q): %s, ignored", v, err)
+		}
+	}
 }
 
 func (c *Config) loadElectionEnvs() {
@@ -193,6 +257,46 @@ func (c *Config) loadIOEnvs() {
 			c.IO.CacheCleanInterval = v
 		}
 	}
+
+	if v := datakit.GetEnv("ENV_IO_ENABLE_WAL"); v != "" {
+		l.Info("ENV_IO_ENABLE_WAL enabled")
+		c.IO.EnableWAL = true
+	}
+
+	if v := datakit.GetEnv("ENV_IO_WAL_NO_SYNC"); v != "" {
+		l.Info("ENV_IO_WAL_NO_SYNC enabled")
+		c.IO.WALNoSync = true
+	}
+
+	if v := datakit.GetEnv("ENV_IO_WAL_MAX_SIZE_MB"); v != "" {
+		val, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			l.Warnf("invalid env key ENV_IO_WAL_MAX_SIZE_MB, value %s, err: %s ignored", v, err)
+		} else {
+			l.Infof("set ENV_IO_WAL_MAX_SIZE_MB to %d", val)
+			c.IO.WALCapacityMB = int(val)
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_FEED_PRIORITY"); v != "" {
+		l.Infof("set ENV_IO_FEED_PRIORITY to %s", v)
+		c.IO.FeedPriority = nil
+		for _, x := range strings.Split(v, ",") {
+			if x = strings.TrimSpace(x); x != "" {
+				c.IO.FeedPriority = append(c.IO.FeedPriority, x)
+			}
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_IO_SHED_WATERMARK"); v != "" {
+		val, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			l.Warnf("invalid env key ENV_IO_SHED_WATERMARK, value %s, err: %s ignored", v, err)
+		} else {
+			l.Infof("set ENV_IO_SHED_WATERMARK to %f", val)
+			c.IO.ShedWatermark = val
+		}
+	}
 }
 
 //nolint:funlen
@@ -212,6 +316,14 @@ func (c *Config) LoadEnvs() error {
 		}
 	}
 
+	if v := datakit.GetEnv("ENV_IPDB_CIDR_FILE"); v != "" {
+		c.Pipeline.IPdbCIDRFile = v
+	}
+
+	if v := datakit.GetEnv("ENV_IPDB_RELOAD_INTERVAL"); v != "" {
+		c.Pipeline.IPdbReloadInterval = v
+	}
+
 	if v := datakit.GetEnv("ENV_REFER_TABLE_URL"); v != "" {
 		c.Pipeline.ReferTableURL = v
 	}
@@ -228,6 +340,82 @@ func (c *Config) LoadEnvs() error {
 		c.Pipeline.SQLiteMemMode = true
 	}
 
+	if v := datakit.GetEnv("ENV_REFER_TABLE_SOURCES"); v != "" {
+		var arr []*plrefertable.SourceConfig
+		if err := json.Unmarshal([]byte(v), &arr); err != nil {
+			l.Warnf("invalid ENV_REFER_TABLE_SOURCES %q: %s, ignored", v, err)
+		} else {
+			c.Pipeline.ReferTableSources = arr
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_SCRIPT_VERSIONS"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err != nil {
+			l.Warnf("invalid ENV_PIPELINE_SCRIPT_VERSIONS %q: %s, ignored", v, err)
+		} else {
+			c.Pipeline.ScriptVersions = int(n)
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_AUTO_ROLLBACK"); v != "" {
+		c.Pipeline.AutoRollback = true
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_ROLLBACK_ERROR_RATE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err != nil {
+			l.Warnf("invalid ENV_PIPELINE_ROLLBACK_ERROR_RATE %q: %s, ignored", v, err)
+		} else {
+			c.Pipeline.RollbackErrorRate = f
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_ROLLBACK_MIN_POINTS"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err != nil {
+			l.Warnf("invalid ENV_PIPELINE_ROLLBACK_MIN_POINTS %q: %s, ignored", v, err)
+		} else {
+			c.Pipeline.RollbackMinPoints = n
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_REMOTE_CANARY"); v != "" {
+		if c.Pipeline.RemoteCanary == nil {
+			c.Pipeline.RemoteCanary = plscript.DefaultCanaryConfig()
+		}
+		c.Pipeline.RemoteCanary.Enable = true
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_REMOTE_CANARY_SAMPLE_RATE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err != nil {
+			l.Warnf("invalid ENV_PIPELINE_REMOTE_CANARY_SAMPLE_RATE %q: %s, ignored", v, err)
+		} else if c.Pipeline.RemoteCanary != nil {
+			c.Pipeline.RemoteCanary.SampleRate = f
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_REMOTE_CANARY_MIN_POINTS"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err != nil {
+			l.Warnf("invalid ENV_PIPELINE_REMOTE_CANARY_MIN_POINTS %q: %s, ignored", v, err)
+		} else if c.Pipeline.RemoteCanary != nil {
+			c.Pipeline.RemoteCanary.MinPoints = n
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_ANNOTATE_ERROR"); v != "" {
+		c.Pipeline.AnnotateError = true
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_FUNC_COST"); v != "" {
+		c.Pipeline.FuncCost = true
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_SCRIPT_TIMEOUT"); v != "" {
+		c.Pipeline.ScriptTimeout = v
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_SCRIPT_TIMEOUT_ACTION"); v != "" {
+		c.Pipeline.ScriptTimeoutAction = v
+	}
+
 	if v := datakit.GetEnv("ENV_PIPELINE_OFFLOAD_RECEIVER"); v != "" {
 		if c.Pipeline.Offload == nil {
 			c.Pipeline.Offload = &offload.OffloadConfig{
@@ -252,6 +440,22 @@ func (c *Config) LoadEnvs() error {
 		}
 	}
 
+	if v := datakit.GetEnv("ENV_PIPELINE_OFFLOAD_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil {
+			l.Warnf("invalid ENV_PIPELINE_OFFLOAD_BATCH_SIZE %q: %s, ignored", v, err)
+		} else if c.Pipeline.Offload != nil {
+			c.Pipeline.Offload.BatchSize = n
+		}
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_OFFLOAD_HASH_TAG"); v != "" && c.Pipeline.Offload != nil {
+		c.Pipeline.Offload.HashTag = v
+	}
+
+	if v := datakit.GetEnv("ENV_PIPELINE_OFFLOAD_FORMAT"); v != "" && c.Pipeline.Offload != nil {
+		c.Pipeline.Offload.Format = v
+	}
+
 	if v := datakit.GetEnv("ENV_REQUEST_RATE_LIMIT"); v != "" {
 		if x, err := strconv.ParseFloat(v, 64); err != nil {
 			l.Warnf("invalid ENV_REQUEST_RATE_LIMIT, expect int or float, got %s, ignored", v)
@@ -326,6 +530,14 @@ func (c *Config) LoadEnvs() error {
 		c.LoadSink(v)
 	}
 
+	if v := datakit.GetEnv("ENV_SINKER_FAN_OUT"); v != "" {
+		c.Dataway.SinkerFanOut = true
+	}
+
+	if v := datakit.GetEnv("ENV_SINKER_DROP_UNMATCHED"); v != "" {
+		c.Dataway.SinkerDropUnmatched = true
+	}
+
 	if v := datakit.GetEnv("ENV_HOSTNAME"); v != "" {
 		c.Hostname = v
 	}
@@ -14,6 +14,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/offload"
+	plrefertable "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/refertable"
 )
 
 func TestLoadEnv(t *testing.T) {
@@ -48,6 +49,36 @@ func TestLoadEnv(t *testing.T) {
 			}(),
 		},
 
+		{
+			name: `sinkers-fan-out`,
+			envs: map[string]string{
+				"ENV_SINKER": `[
+					{
+						"categories": ["L"],
+						"url": "http://dataway-host?token=audit-token",
+						"mirror": true,
+						"percent": 10
+					}
+				]`,
+				"ENV_SINKER_FAN_OUT":        "on",
+				"ENV_SINKER_DROP_UNMATCHED": "on",
+			},
+
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Dataway.Sinkers = append(cfg.Dataway.Sinkers, &dataway.Sinker{
+					Categories: []string{"L"},
+					URL:        "http://dataway-host?token=audit-token",
+					Mirror:     true,
+					Percent:    10,
+				})
+				cfg.Dataway.SinkerFanOut = true
+				cfg.Dataway.SinkerDropUnmatched = true
+
+				return cfg
+			}(),
+		},
+
 		{
 			name: `bad-sinkers`,
 			envs: map[string]string{
@@ -89,6 +120,9 @@ func TestLoadEnv(t *testing.T) {
 				"ENV_ENABLE_ELECTION_NAMESPACE_TAG":   "ok",
 				"ENV_PIPELINE_OFFLOAD_RECEIVER":       offload.DKRcv,
 				"ENV_PIPELINE_OFFLOAD_ADDRESSES":      "http://aaa:123,http://1.2.3.4:1234",
+				"ENV_PIPELINE_OFFLOAD_BATCH_SIZE":     "256",
+				"ENV_PIPELINE_OFFLOAD_HASH_TAG":       "host",
+				"ENV_PIPELINE_OFFLOAD_FORMAT":         "json",
 			},
 			expect: func() *Config {
 				cfg := DefaultConfig()
@@ -118,6 +152,9 @@ func TestLoadEnv(t *testing.T) {
 				cfg.Pipeline.Offload = &offload.OffloadConfig{}
 				cfg.Pipeline.Offload.Receiver = offload.DKRcv
 				cfg.Pipeline.Offload.Addresses = []string{"http://aaa:123", "http://1.2.3.4:1234"}
+				cfg.Pipeline.Offload.BatchSize = 256
+				cfg.Pipeline.Offload.HashTag = "host"
+				cfg.Pipeline.Offload.Format = "json"
 				cfg.EnablePProf = true
 				cfg.Hostname = "1024.coding"
 				cfg.ProtectMode = false
@@ -273,6 +310,106 @@ func TestLoadEnv(t *testing.T) {
 			}(),
 		},
 
+		{
+			name: "test-ENV_IPDB_CIDR_FILE",
+			envs: map[string]string{
+				"ENV_IPDB_CIDR_FILE":       "/usr/local/datakit/data/ipdb/internal.csv",
+				"ENV_IPDB_RELOAD_INTERVAL": "5m",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.IPdbCIDRFile = "/usr/local/datakit/data/ipdb/internal.csv"
+				cfg.Pipeline.IPdbReloadInterval = "5m"
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-ENV_REFER_TABLE_SOURCES",
+			envs: map[string]string{
+				"ENV_REFER_TABLE_SOURCES": `[{"table_name": "cmdb_host", "primary_key": "host", "path": "/data/cmdb_host.csv"}]`,
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.ReferTableSources = []*plrefertable.SourceConfig{
+					{TableName: "cmdb_host", PrimaryKey: "host", Path: "/data/cmdb_host.csv"},
+				}
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-pipeline-rollback",
+			envs: map[string]string{
+				"ENV_PIPELINE_SCRIPT_VERSIONS":     "10",
+				"ENV_PIPELINE_AUTO_ROLLBACK":       "on",
+				"ENV_PIPELINE_ROLLBACK_ERROR_RATE": "0.8",
+				"ENV_PIPELINE_ROLLBACK_MIN_POINTS": "1000",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.ScriptVersions = 10
+				cfg.Pipeline.AutoRollback = true
+				cfg.Pipeline.RollbackErrorRate = 0.8
+				cfg.Pipeline.RollbackMinPoints = 1000
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-pipeline-remote-canary",
+			envs: map[string]string{
+				"ENV_PIPELINE_REMOTE_CANARY":             "on",
+				"ENV_PIPELINE_REMOTE_CANARY_SAMPLE_RATE": "0.2",
+				"ENV_PIPELINE_REMOTE_CANARY_MIN_POINTS":  "500",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.RemoteCanary.Enable = true
+				cfg.Pipeline.RemoteCanary.SampleRate = 0.2
+				cfg.Pipeline.RemoteCanary.MinPoints = 500
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-pipeline-annotate-error",
+			envs: map[string]string{
+				"ENV_PIPELINE_ANNOTATE_ERROR": "on",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.AnnotateError = true
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-pipeline-func-cost",
+			envs: map[string]string{
+				"ENV_PIPELINE_FUNC_COST": "on",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.FuncCost = true
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-pipeline-script-timeout",
+			envs: map[string]string{
+				"ENV_PIPELINE_SCRIPT_TIMEOUT":        "100ms",
+				"ENV_PIPELINE_SCRIPT_TIMEOUT_ACTION": "drop",
+			},
+			expect: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Pipeline.ScriptTimeout = "100ms"
+				cfg.Pipeline.ScriptTimeoutAction = "drop"
+				return cfg
+			}(),
+		},
+
 		{
 			name: "test-ENV_ENABLE_INPUTS",
 			envs: map[string]string{
@@ -325,6 +462,53 @@ func TestLoadEnv(t *testing.T) {
 			}(),
 		},
 
+		{
+			name: "test-ENV_DATAWAY-load-balance",
+			envs: map[string]string{
+				"ENV_DATAWAY":                          "http://host1.org,http://host2.com",
+				"ENV_DATAWAY_LOAD_BALANCE":             "least_latency",
+				"ENV_DATAWAY_HEALTH_CHECK_INTERVAL":    "5s",
+				"ENV_DATAWAY_CIRCUIT_BREAKER_FAILURES": "5",
+				"ENV_DATAWAY_CIRCUIT_BREAKER_TIMEOUT":  "1m",
+			},
+
+			expect: func() *Config {
+				cfg := DefaultConfig()
+
+				cfg.Dataway.URLs = []string{"http://host1.org", "http://host2.com"}
+				cfg.Dataway.LoadBalance = "least_latency"
+				cfg.Dataway.HealthCheckInterval = 5 * time.Second
+				cfg.Dataway.CircuitBreakerFailures = 5
+				cfg.Dataway.CircuitBreakerTimeout = time.Minute
+
+				return cfg
+			}(),
+		},
+
+		{
+			name: "test-ENV_DATAWAY-body",
+			envs: map[string]string{
+				"ENV_DATAWAY":                      "http://host1.org",
+				"ENV_DATAWAY_CONTENT_ENCODING":     "zstd",
+				"ENV_DATAWAY_ADAPTIVE_BATCH":       "on",
+				"ENV_DATAWAY_MAX_BODY_BYTES":       "1048576",
+				"ENV_DATAWAY_MIN_BODY_BYTES":       "-1",
+				"ENV_DATAWAY_BATCH_TARGET_LATENCY": "500ms",
+			},
+
+			expect: func() *Config {
+				cfg := DefaultConfig()
+
+				cfg.Dataway.URLs = []string{"http://host1.org"}
+				cfg.Dataway.ContentEncoding = "zstd"
+				cfg.Dataway.AdaptiveBatch = true
+				cfg.Dataway.MaxBodyBytes = 1048576
+				cfg.Dataway.BatchTargetLatency = 500 * time.Millisecond
+
+				return cfg
+			}(),
+		},
+
 		{
 			name: "test-io-envs",
 			envs: map[string]string{
@@ -338,6 +522,10 @@ func TestLoadEnv(t *testing.T) {
 				"ENV_IO_QUEUE_SIZE":           "123",
 				"ENV_IO_CACHE_CLEAN_INTERVAL": "100s",
 				"ENV_IO_CACHE_ALL":            "on",
+				"ENV_IO_ENABLE_WAL":           "on",
+				"ENV_IO_WAL_MAX_SIZE_MB":      "256",
+				"ENV_IO_FEED_PRIORITY":        "metric, keyevent ,logging,",
+				"ENV_IO_SHED_WATERMARK":       "0.9",
 			},
 
 			expect: func() *Config {
@@ -352,6 +540,10 @@ func TestLoadEnv(t *testing.T) {
 				cfg.IO.FlushWorkers = 1
 				cfg.IO.CacheCleanInterval = "100s"
 				cfg.IO.CacheAll = true
+				cfg.IO.EnableWAL = true
+				cfg.IO.WALCapacityMB = 256
+				cfg.IO.FeedPriority = []string{"metric", "keyevent", "logging"}
+				cfg.IO.ShedWatermark = 0.9
 
 				return cfg
 			}(),
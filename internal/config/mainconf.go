@@ -18,6 +18,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/operator"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/offload"
+	plscript "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
 )
 
 type SinkerDeprecated struct {
@@ -128,11 +129,23 @@ func DefaultConfig() *Config {
 			FlushInterval:    "10s",
 			OutputFileInputs: []string{},
 
+			// Rotate output file on 32MB, keep 3 rotated files.
+			OutputFileMaxSizeMB: 32,
+			OutputFileMaxFiles:  3,
+
 			// Enable disk cache on datakit send fail.
 			EnableCache:        false,
 			CacheSizeGB:        10,
 			CacheCleanInterval: "5s",
 
+			// WAL on feeding data.
+			EnableWAL:     false,
+			WALCapacityMB: 1024,
+
+			// Shed lower priority points on io queue usage reached 80%.
+			FeedPriority:  []string{},
+			ShedWatermark: 0.8,
+
 			Filters: nil,
 		},
 
@@ -162,6 +175,7 @@ func DefaultConfig() *Config {
 
 		Pipeline: &pipeline.PipelineCfg{
 			IPdbType:               "-",
+			IPdbReloadInterval:     "1m",
 			RemotePullInterval:     "1m",
 			ReferTableURL:          "",
 			ReferTablePullInterval: "5m",
@@ -169,6 +183,10 @@ func DefaultConfig() *Config {
 				Receiver:  offload.DKRcv,
 				Addresses: []string{},
 			},
+			ScriptVersions:    5,
+			RollbackErrorRate: 0.5,
+			RollbackMinPoints: 100,
+			RemoteCanary:      plscript.DefaultCanaryConfig(),
 		},
 
 		Logging: &LoggerCfg{
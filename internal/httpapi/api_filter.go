@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	"github.com/GuanceCloud/cliutils/point"
+	influxdb "github.com/influxdata/influxdb1-client/v2"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+// FilterDryRunRequest is the request body of filter dry-run.
+type FilterDryRunRequest struct {
+	Category   string                  `json:"category"`
+	Conditions filter.FilterConditions `json:"conditions"`
+
+	// Data is the sample data in line-protocol, or in JSON if JSON set.
+	Data string `json:"data"`
+	JSON bool   `json:"json"`
+}
+
+// FilterDryRunResponse is the response body of filter dry-run.
+type FilterDryRunResponse struct {
+	Total   int                    `json:"total"`
+	Dropped int                    `json:"dropped"`
+	Results []*filter.DryRunResult `json:"results"`
+}
+
+// FilterDryRun checks the sample data of r against its filter conditions,
+// no point is really dropped.
+func FilterDryRun(r *FilterDryRunRequest) (*FilterDryRunResponse, error) {
+	category := normalizeCategory(r.Category)
+	if category == point.UnknownCategory {
+		return nil, uhttp.Error(ErrInvalidCategory, "invalid category")
+	}
+
+	if len(r.Conditions) == 0 {
+		return nil, uhttp.Error(ErrInvalidFilter, "no filter conditions")
+	}
+
+	if r.Data == "" {
+		return nil, ErrEmptyBody
+	}
+
+	if len(r.Data) > DataByteSizeLimit {
+		return nil, uhttp.Errorf(ErrInvalidData, "data size exceeded %d bytes", DataByteSizeLimit)
+	}
+
+	pts, err := HandleWriteBody([]byte(r.Data), r.JSON, point.WithPrecision(point.NS))
+	if err != nil {
+		return nil, uhttp.Error(ErrInvalidData, err.Error())
+	}
+
+	var arr []*dkpt.Point
+	for _, pt := range pts {
+		x, err := influxdb.NewPoint(string(pt.Name()), pt.InfluxTags(), pt.InfluxFields(), pt.Time())
+		if err != nil {
+			return nil, uhttp.Error(ErrInvalidData, err.Error())
+		}
+
+		arr = append(arr, &dkpt.Point{Point: x})
+	}
+
+	if len(arr) == 0 {
+		return nil, ErrNoPoints
+	}
+
+	res, err := filter.DryRun(category, r.Conditions, arr)
+	if err != nil {
+		return nil, uhttp.Error(ErrInvalidFilter, err.Error())
+	}
+
+	resp := &FilterDryRunResponse{Total: len(res), Results: res}
+	for _, x := range res {
+		if x.Dropped {
+			resp.Dropped++
+		}
+	}
+
+	return resp, nil
+}
+
+func apiFilterDryRun(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	tid := req.Header.Get(uhttp.XTraceID)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		l.Errorf("[%s] %s", tid, err.Error())
+		return nil, uhttp.Error(ErrInvalidRequest, err.Error())
+	}
+
+	var r FilterDryRunRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		l.Errorf("[%s] %s", tid, err.Error())
+		return nil, uhttp.Error(ErrInvalidRequest, err.Error())
+	}
+
+	resp, err := FilterDryRun(&r)
+	if err != nil {
+		l.Errorf("[%s] %s", tid, err.Error())
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func apiFilterStats(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	return filter.GetStats(), nil
+}
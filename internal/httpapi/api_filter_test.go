@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+)
+
+func TestFilterDryRun(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      *FilterDryRunRequest
+		fail     bool
+		dropped  int
+		matchIdx []int
+	}{
+		{
+			name: "line-protocol",
+			req: &FilterDryRunRequest{
+				Category:   "logging",
+				Conditions: []string{`{ source = "nginx" and status = "info" }`, `{ host = "def" }`},
+				Data: `nginx,host=abc status="info" 123
+nginx,host=abc status="error" 123
+redis,host=def status="info" 123`,
+			},
+			dropped:  2,
+			matchIdx: []int{0, -1, 1},
+		},
+
+		{
+			name: "json",
+			req: &FilterDryRunRequest{
+				Category:   "metric",
+				Conditions: []string{`{ measurement = "cpu" and usage > 90.0 }`},
+				Data:       `[{"measurement":"cpu","tags":{"host":"abc"},"fields":{"usage":95.0}},{"measurement":"cpu","tags":{"host":"abc"},"fields":{"usage":5.0}}]`,
+				JSON:       true,
+			},
+			dropped:  1,
+			matchIdx: []int{0, -1},
+		},
+
+		{
+			name: "invalid-category",
+			req:  &FilterDryRunRequest{Category: "no-such-category", Conditions: []string{`{ host = "abc" }`}, Data: `abc f1=1i 123`},
+			fail: true,
+		},
+
+		{
+			name: "invalid-condition",
+			req:  &FilterDryRunRequest{Category: "metric", Conditions: []string{`{ host = }`}, Data: `abc f1=1i 123`},
+			fail: true,
+		},
+
+		{
+			name: "no-condition",
+			req:  &FilterDryRunRequest{Category: "metric", Data: `abc f1=1i 123`},
+			fail: true,
+		},
+
+		{
+			name: "invalid-data",
+			req:  &FilterDryRunRequest{Category: "metric", Conditions: []string{`{ host = "abc" }`}, Data: `abc`},
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := FilterDryRun(tc.req)
+			if tc.fail {
+				assert.Error(t, err)
+				t.Logf("expected error: %s", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.matchIdx), resp.Total)
+			assert.Equal(t, tc.dropped, resp.Dropped)
+
+			for i, r := range resp.Results {
+				assert.Equal(t, tc.matchIdx[i], r.Index, "point %s", r.Point)
+			}
+		})
+	}
+
+	t.Run("http", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/v1/filter/dryrun", rawHTTPWraper(nil, apiFilterDryRun))
+
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		post := func(r *FilterDryRunRequest) (int, []byte) {
+			j, err := json.Marshal(r)
+			require.NoError(t, err)
+
+			resp, err := http.Post(ts.URL+"/v1/filter/dryrun", "application/json", bytes.NewReader(j))
+			require.NoError(t, err)
+			defer resp.Body.Close() //nolint:errcheck
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return resp.StatusCode, body
+		}
+
+		code, body := post(cases[0].req)
+		assert.Equal(t, http.StatusOK, code)
+
+		var x struct {
+			Content *FilterDryRunResponse `json:"content"`
+		}
+		require.NoError(t, json.Unmarshal(body, &x))
+		require.NotNil(t, x.Content)
+		assert.Equal(t, 2, x.Content.Dropped)
+
+		code, _ = post(&FilterDryRunRequest{Category: "metric", Data: `abc f1=1i 123`})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestFilterStats(t *testing.T) {
+	router := gin.New()
+	router.GET("/v1/filter/stats", rawHTTPWraper(nil, apiFilterStats))
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/v1/filter/stats")
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var x struct {
+		Content *filter.Stats `json:"content"`
+	}
+	require.NoError(t, json.Unmarshal(body, &x))
+	require.NotNil(t, x.Content)
+	assert.Empty(t, x.Content.Rules) // filter not started
+}
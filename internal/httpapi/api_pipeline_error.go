@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"net/http"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
+	plstats "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/stats"
+)
+
+// PipelineErrors is the run errors of a pipeline script.
+type PipelineErrors struct {
+	Category string `json:"category"`
+	NS       string `json:"ns"`
+	Name     string `json:"name"`
+
+	// count of errors by function name
+	FuncErrors map[string]uint64 `json:"function_errors"`
+
+	// recent failures, the latest first
+	Samples []plstats.ErrorSample `json:"samples"`
+}
+
+func apiPipelineErrors(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	q := req.URL.Query()
+
+	category := normalizeCategory(q.Get("category"))
+	if category == point.UnknownCategory {
+		return nil, uhttp.Error(ErrInvalidCategory, "invalid category")
+	}
+
+	name := q.Get("name")
+	if name == "" {
+		return nil, uhttp.Error(ErrInvalidPipeline, "script name not set")
+	}
+
+	// default to the namespace of the script in use
+	ns := q.Get("ns")
+	if ns == "" {
+		if s, ok := script.QueryScript(category, name); ok {
+			ns = s.NS()
+		}
+	}
+
+	res := &PipelineErrors{
+		Category:   category.String(),
+		NS:         ns,
+		Name:       name,
+		FuncErrors: map[string]uint64{},
+		Samples:    []plstats.ErrorSample{},
+	}
+
+	if stats, ok := plstats.ReadScriptStats(category, ns, name); ok {
+		if stats.FuncErrors != nil {
+			res.FuncErrors = stats.FuncErrors
+		}
+		if stats.ErrorSamples != nil {
+			res.Samples = stats.ErrorSamples
+		}
+	}
+
+	return res, nil
+}
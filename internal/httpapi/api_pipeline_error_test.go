@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
+)
+
+func TestPipelineErrors(t *testing.T) {
+	const name = "api-errors-test.p"
+
+	script.LoadScript(point.Logging, script.ConfdScriptNS, map[string]string{
+		name: "arr = []\nadd_key(a, arr[1])",
+	}, nil)
+	defer script.CleanAllScript(script.ConfdScriptNS)
+
+	s, ok := script.QueryScript(point.Logging, name)
+	require.True(t, ok)
+	for i := 0; i < 3; i++ {
+		plpt := ptinput.NewPlPoint(point.Logging, "test", nil, map[string]any{"message": "hello"}, time.Now())
+		assert.Error(t, s.Run(plpt, nil, nil))
+	}
+
+	router := gin.New()
+	router.GET("/v1/pipeline/errors", rawHTTPWraper(nil, apiPipelineErrors))
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	get := func(query string, v interface{}) int {
+		resp, err := http.Get(ts.URL + "/v1/pipeline/errors?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		if v != nil {
+			x := struct {
+				Content interface{} `json:"content"`
+			}{Content: v}
+			require.NoError(t, json.Unmarshal(body, &x), string(body))
+		}
+		return resp.StatusCode
+	}
+
+	var res PipelineErrors
+	assert.Equal(t, http.StatusOK, get("category=logging&name="+name, &res))
+	assert.Equal(t, script.ConfdScriptNS, res.NS)
+	assert.Equal(t, map[string]uint64{"add_key": 3}, res.FuncErrors)
+	require.Len(t, res.Samples, 3)
+	assert.Equal(t, "hello", res.Samples[0].Input)
+	assert.Equal(t, name+":2:16", res.Samples[0].Pos)
+
+	res = PipelineErrors{}
+	assert.Equal(t, http.StatusOK, get("category=logging&name=not-exist.p", &res))
+	assert.Empty(t, res.FuncErrors)
+	assert.Empty(t, res.Samples)
+
+	assert.Equal(t, http.StatusBadRequest, get("category=xxx&name="+name, nil))
+	assert.Equal(t, http.StatusBadRequest, get("category=logging", nil))
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
+)
+
+// PipelineRollbackRequest is the request body of pipeline rollback.
+type PipelineRollbackRequest struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+
+	// NS is the namespace of the script, default to the namespace of
+	// the script in use.
+	NS string `json:"ns"`
+
+	// Version to rollback to, default to the version before current one.
+	Version int `json:"version"`
+}
+
+// PipelineRollbackResponse is the response body of pipeline rollback.
+type PipelineRollbackResponse struct {
+	NS      string `json:"ns"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+}
+
+func apiPipelineVersions(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	q := req.URL.Query()
+
+	category := normalizeCategory(q.Get("category"))
+	if category == point.UnknownCategory {
+		return nil, uhttp.Error(ErrInvalidCategory, "invalid category")
+	}
+
+	name := q.Get("name")
+	if name == "" {
+		return nil, uhttp.Error(ErrInvalidPipeline, "script name not set")
+	}
+
+	return script.ScriptVersionsList(category, q.Get("ns"), name), nil
+}
+
+func apiPipelineRollback(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	tid := req.Header.Get(uhttp.XTraceID)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		l.Errorf("[%s] %s", tid, err.Error())
+		return nil, uhttp.Error(ErrInvalidRequest, err.Error())
+	}
+
+	var r PipelineRollbackRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		l.Errorf("[%s] %s", tid, err.Error())
+		return nil, uhttp.Error(ErrInvalidRequest, err.Error())
+	}
+
+	category := normalizeCategory(r.Category)
+	if category == point.UnknownCategory {
+		return nil, uhttp.Error(ErrInvalidCategory, "invalid category")
+	}
+
+	if r.Name == "" {
+		return nil, uhttp.Error(ErrInvalidPipeline, "script name not set")
+	}
+
+	s, err := script.RollbackScript(category, r.NS, r.Name, r.Version)
+	if err != nil {
+		l.Errorf("[%s] %s", tid, err.Error())
+		return nil, uhttp.Error(ErrRollbackFailed, err.Error())
+	}
+
+	return &PipelineRollbackResponse{
+		NS:      s.NS(),
+		Name:    s.Name(),
+		Version: s.Version(),
+		Hash:    s.Hash(),
+	}, nil
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
+)
+
+func TestPipelineRollback(t *testing.T) {
+	const name = "api-rollback-test.p"
+
+	for _, x := range []string{`add_key(v, 1)`, `add_key(v, 2)`} {
+		script.LoadScript(point.Logging, script.ConfdScriptNS, map[string]string{name: x}, nil)
+	}
+	defer script.CleanAllScript(script.ConfdScriptNS)
+
+	router := gin.New()
+	router.GET("/v1/pipeline/versions", rawHTTPWraper(nil, apiPipelineVersions))
+	router.POST("/v1/pipeline/rollback", rawHTTPWraper(nil, apiPipelineRollback))
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	read := func(resp *http.Response, v interface{}) int {
+		defer resp.Body.Close() //nolint:errcheck
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		if v != nil {
+			x := struct {
+				Content interface{} `json:"content"`
+			}{Content: v}
+			require.NoError(t, json.Unmarshal(body, &x), string(body))
+		}
+
+		return resp.StatusCode
+	}
+
+	rollback := func(r *PipelineRollbackRequest, v interface{}) int {
+		j, err := json.Marshal(r)
+		require.NoError(t, err)
+
+		resp, err := http.Post(ts.URL+"/v1/pipeline/rollback", "application/json", bytes.NewReader(j))
+		require.NoError(t, err)
+		return read(resp, v)
+	}
+
+	resp, err := http.Get(ts.URL + "/v1/pipeline/versions?category=logging&name=" + name)
+	require.NoError(t, err)
+
+	var versions []*script.ScriptVersions
+	assert.Equal(t, http.StatusOK, read(resp, &versions))
+	require.Len(t, versions, 1)
+	assert.Equal(t, script.ConfdScriptNS, versions[0].NS)
+	require.Len(t, versions[0].Versions, 2)
+	assert.True(t, versions[0].Versions[1].Current)
+
+	var rr PipelineRollbackResponse
+	assert.Equal(t, http.StatusOK, rollback(&PipelineRollbackRequest{Category: "logging", Name: name}, &rr))
+	assert.Equal(t, 1, rr.Version)
+	assert.Equal(t, script.ConfdScriptNS, rr.NS)
+
+	s, ok := script.QueryScript(point.Logging, name)
+	require.True(t, ok)
+	assert.Equal(t, 1, s.Version())
+
+	assert.Equal(t, http.StatusBadRequest, rollback(&PipelineRollbackRequest{Category: "logging", Name: name}, nil))
+	assert.Equal(t, http.StatusBadRequest, rollback(&PipelineRollbackRequest{Category: "no-such-category", Name: name}, nil))
+	assert.Equal(t, http.StatusBadRequest, rollback(&PipelineRollbackRequest{Category: "logging"}, nil))
+
+	resp, err = http.Get(ts.URL + "/v1/pipeline/versions?category=logging")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, read(resp, nil))
+}
@@ -22,6 +22,8 @@ var (
 	ErrInvalidPipeline = newErr(errors.New("invalid pipeline"), http.StatusBadRequest)
 	ErrInvalidData     = newErr(errors.New("invalid data"), http.StatusBadRequest)
 	ErrCompiledFailed  = newErr(errors.New("pipeline compile failed"), http.StatusBadRequest)
+	ErrInvalidFilter   = newErr(errors.New("invalid filter"), http.StatusBadRequest)
+	ErrRollbackFailed  = newErr(errors.New("pipeline rollback failed"), http.StatusBadRequest)
 
 	ErrInvalidPrecision       = newErr(errors.New("invalid precision"), http.StatusBadRequest)
 	ErrHTTPReadErr            = newErr(errors.New("HTTP read error"), http.StatusInternalServerError)
@@ -268,7 +268,12 @@ func setupRouter() *gin.Engine {
 	router.DELETE("/v1/object/labels", ginLimiter(reqLimiter), apiDeleteObjectLabel)
 
 	router.POST("/v1/pipeline/debug", rawHTTPWraper(reqLimiter, apiPipelineDebugHandler))
+	router.GET("/v1/pipeline/versions", rawHTTPWraper(reqLimiter, apiPipelineVersions))
+	router.POST("/v1/pipeline/rollback", rawHTTPWraper(reqLimiter, apiPipelineRollback))
+	router.GET("/v1/pipeline/errors", rawHTTPWraper(reqLimiter, apiPipelineErrors))
 	router.POST("/v1/dialtesting/debug", rawHTTPWraper(reqLimiter, apiDebugDialtestingHandler))
+	router.POST("/v1/filter/dryrun", rawHTTPWraper(reqLimiter, apiFilterDryRun))
+	router.GET("/v1/filter/stats", rawHTTPWraper(reqLimiter, apiFilterStats))
 	return router
 }
 
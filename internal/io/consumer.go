@@ -12,6 +12,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/failcache"
 	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/wal"
 )
 
 type consumer struct {
@@ -23,6 +24,8 @@ type consumer struct {
 
 	pts       []*dkpt.Point
 	lastFlush time.Time
+
+	walAcks []wal.Position // WAL positions of data cached in pts
 }
 
 func (x *dkIO) runConsumer(cat point.Category) {
@@ -8,13 +8,19 @@ package dataway
 import (
 	"bytes"
 	"fmt"
+	"sync"
+	"time"
 
-	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
 )
 
 type bodyPayload int
 
+const (
+	defaultMinBodyBytes       = 64 * 1024
+	defaultBatchTargetLatency = time.Second
+)
+
 const (
 	payloadLineProtocol bodyPayload = iota
 	// TODO: used to cache protobuf point.
@@ -23,38 +29,82 @@ const (
 type body struct {
 	buf     []byte
 	rawLen  int
-	gzon    bool
+	enc     string // content encoding of buf, empty if not encoded
 	npts    int
 	payload bodyPayload
 }
 
 func (b *body) String() string {
-	return fmt.Sprintf("gzon: %v, pts: %d, buf bytes: %d", b.gzon, b.npts, len(b.buf))
+	return fmt.Sprintf("enc: %q, pts: %d, buf bytes: %d", b.enc, b.npts, len(b.buf))
 }
 
 // getBody buidl a body instance.
-func getBody(lines [][]byte, idxBegin, idxEnd, curPartSize int) (*body, error) {
+func getBody(lines [][]byte, idxBegin, idxEnd, curPartSize int, enc string) (*body, error) {
+	return newBody(bytes.Join(lines, seprator), idxEnd-idxBegin, enc)
+}
+
+func newBody(raw []byte, npts int, enc string) (*body, error) {
 	out := &body{
-		buf:     bytes.Join(lines, seprator),
 		payload: payloadLineProtocol,
-		npts:    idxEnd - idxBegin,
+		npts:    npts,
+		rawLen:  len(raw),
+		enc:     enc,
 	}
 
-	out.rawLen = len(out.buf)
-	gzbuf, err := datakit.GZip(out.buf)
+	buf, err := encodeBody(enc, raw)
 	if err != nil {
-		log.Errorf("gz: %s", err.Error())
+		log.Errorf("encode(%s): %s", enc, err.Error())
 		return nil, err
-	} else {
-		out.buf = gzbuf
-		out.gzon = true
 	}
 
+	out.buf = buf
 	return out, nil
 }
 
-// buildBody convert pts to lineprotocol body.
-func buildBody(pts []*point.Point, max int) ([]*body, error) {
+// transcode re-encode b with encoding enc.
+func (b *body) transcode(enc string) (*body, error) {
+	if b.enc == enc {
+		return b, nil
+	}
+
+	raw, err := decodeBody(b.enc, b.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBody(raw, b.npts, enc)
+}
+
+// split split b into 2 bodies on points.
+func (b *body) split() ([]*body, error) {
+	raw, err := decodeBody(b.enc, b.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := bytes.Split(raw, seprator)
+	if len(lines) < 2 {
+		return []*body{b}, nil
+	}
+
+	half := len(lines) / 2
+
+	left, err := newBody(bytes.Join(lines[:half], seprator), half, b.enc)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := newBody(bytes.Join(lines[half:], seprator), len(lines)-half, b.enc)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*body{left, right}, nil
+}
+
+// buildBody convert pts to lineprotocol body, each body not exceed max
+// bytes(before encoding).
+func buildBody(pts []*point.Point, max int, enc string) ([]*body, error) {
 	lines := [][]byte{}
 	curPartSize := 0
 
@@ -69,7 +119,7 @@ func buildBody(pts []*point.Point, max int) ([]*body, error) {
 		if curPartSize+len(lines)+len(ptbytes) >= max {
 			log.Debugf("merge %d points as body", len(lines))
 
-			if body, err := getBody(lines, idxBegin, idx, curPartSize); err != nil {
+			if body, err := getBody(lines, idxBegin, idx, curPartSize, enc); err != nil {
 				return nil, err
 			} else {
 				idxBegin = idx
@@ -86,7 +136,7 @@ func buildBody(pts []*point.Point, max int) ([]*body, error) {
 	}
 
 	if len(lines) > 0 { // 尾部 lines 单独打包一下
-		if body, err := getBody(lines, idxBegin, len(pts), curPartSize); err != nil {
+		if body, err := getBody(lines, idxBegin, len(pts), curPartSize, enc); err != nil {
 			return nil, err
 		} else {
 			return append(bodies, body), nil
@@ -95,3 +145,89 @@ func buildBody(pts []*point.Point, max int) ([]*body, error) {
 		return bodies, nil
 	}
 }
+
+// batchSizer adjust max body size on upload latency: if upload
+// slower than target latency, body size shrinked, and body size
+// grows if upload fast enough. This makes body smaller on slow network,
+// and less requests on fast network.
+type batchSizer struct {
+	mtx sync.Mutex
+
+	cur, min, max int
+	target        time.Duration
+	adaptive      bool
+}
+
+func newBatchSizer(min, max int, target time.Duration, adaptive bool) *batchSizer {
+	bs := &batchSizer{
+		cur:      max,
+		min:      min,
+		max:      max,
+		target:   target,
+		adaptive: adaptive,
+	}
+
+	bodySizeLimitGauge.Set(float64(bs.cur))
+	return bs
+}
+
+// size get current max body size.
+func (bs *batchSizer) size() int {
+	if bs == nil {
+		return MaxKodoBody
+	}
+
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	return bs.cur
+}
+
+// observe adjust body size on upload latency of body with rawLen bytes.
+func (bs *batchSizer) observe(rawLen int, latency time.Duration) {
+	if bs == nil || !bs.adaptive {
+		return
+	}
+
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+
+	switch {
+	case latency > bs.target:
+		bs.cur /= 2
+
+	// only grow on body that filled(nearly) the limit, small body can't
+	// tell how the bigger body performs.
+	case latency < bs.target/2 && rawLen >= bs.cur/2:
+		bs.cur += bs.cur / 4
+
+	default:
+		return
+	}
+
+	bs.clamp()
+}
+
+// shrink halve body size, used on body too large.
+func (bs *batchSizer) shrink() {
+	if bs == nil || !bs.adaptive {
+		return
+	}
+
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+
+	bs.cur /= 2
+	bs.clamp()
+}
+
+func (bs *batchSizer) clamp() {
+	if bs.cur < bs.min {
+		bs.cur = bs.min
+	}
+
+	if bs.cur > bs.max {
+		bs.cur = bs.max
+	}
+
+	bodySizeLimitGauge.Set(float64(bs.cur))
+}
@@ -7,6 +7,7 @@ package dataway
 
 import (
 	T "testing"
+	"time"
 
 	lp "github.com/GuanceCloud/cliutils/lineproto"
 	uhttp "github.com/GuanceCloud/cliutils/network/http"
@@ -30,7 +31,7 @@ func TestBuildBody(t *T.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *T.T) {
-			bodies, err := buildBody(tc.pts, maxBody)
+			bodies, err := buildBody(tc.pts, maxBody, EncodingGzip)
 			if err != nil {
 				t.Error(err)
 			}
@@ -45,7 +46,7 @@ func TestBuildBody(t *T.T) {
 	// test body === pts
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *T.T) {
-			bodies, err := buildBody(tc.pts, maxBody)
+			bodies, err := buildBody(tc.pts, maxBody, EncodingGzip)
 			if err != nil {
 				t.Error(err)
 			}
@@ -53,7 +54,7 @@ func TestBuildBody(t *T.T) {
 			var totalBodies []byte
 
 			for _, b := range bodies {
-				if b.gzon {
+				if b.enc == EncodingGzip {
 					x, err := uhttp.Unzip(b.buf)
 					if err != nil {
 						assert.NoError(t, err)
@@ -71,6 +72,113 @@ func TestBuildBody(t *T.T) {
 	}
 }
 
+func TestBodyCodec(t *T.T) {
+	pts := dkpt.RandPoints(100)
+
+	for _, enc := range []string{EncodingGzip, EncodingZstd, EncodingSnappy, ""} {
+		t.Run("enc-"+enc, func(t *T.T) {
+			bodies, err := buildBody(pts, MaxKodoBody, enc)
+			require.NoError(t, err)
+			require.Len(t, bodies, 1)
+
+			b := bodies[0]
+			assert.Equal(t, enc, b.enc)
+
+			raw, err := decodeBody(b.enc, b.buf)
+			require.NoError(t, err)
+			assert.Equal(t, b.rawLen, len(raw))
+
+			got, err := lp.ParsePoints(raw, nil)
+			require.NoError(t, err)
+			require.Len(t, got, len(pts))
+
+			t.Logf("body: %s, compress ratio: %.3f", b, float64(len(b.buf))/float64(b.rawLen))
+		})
+	}
+
+	t.Run("transcode", func(t *T.T) {
+		bodies, err := buildBody(pts, MaxKodoBody, EncodingZstd)
+		require.NoError(t, err)
+
+		b, err := bodies[0].transcode(EncodingGzip)
+		require.NoError(t, err)
+		assert.Equal(t, EncodingGzip, b.enc)
+		assert.True(t, isGzip(b.buf))
+		assert.Equal(t, bodies[0].npts, b.npts)
+		assert.Equal(t, bodies[0].rawLen, b.rawLen)
+	})
+
+	t.Run("split", func(t *T.T) {
+		bodies, err := buildBody(pts, MaxKodoBody, EncodingSnappy)
+		require.NoError(t, err)
+
+		parts, err := bodies[0].split()
+		require.NoError(t, err)
+		require.Len(t, parts, 2)
+		assert.Equal(t, 50, parts[0].npts)
+		assert.Equal(t, 50, parts[1].npts)
+
+		for _, part := range parts {
+			raw, err := decodeBody(part.enc, part.buf)
+			require.NoError(t, err)
+
+			got, err := lp.ParsePoints(raw, nil)
+			require.NoError(t, err)
+			assert.Len(t, got, 50)
+		}
+	})
+
+	t.Run("accept-encoding", func(t *T.T) {
+		assert.Equal(t, EncodingZstd, acceptedEncoding("br, zstd;q=0.9, gzip"))
+		assert.Equal(t, EncodingSnappy, acceptedEncoding("snappy"))
+		assert.Equal(t, EncodingGzip, acceptedEncoding("br, deflate"))
+		assert.Equal(t, EncodingGzip, acceptedEncoding(""))
+	})
+}
+
+func TestBatchSizer(t *T.T) {
+	t.Run("adaptive", func(t *T.T) {
+		bs := newBatchSizer(1000, 8000, time.Second, true)
+		assert.Equal(t, 8000, bs.size())
+
+		bs.observe(8000, 2*time.Second) // too slow
+		assert.Equal(t, 4000, bs.size())
+
+		bs.shrink()
+		assert.Equal(t, 2000, bs.size())
+
+		bs.shrink()
+		bs.shrink()
+		assert.Equal(t, 1000, bs.size()) // not less than min
+
+		bs.observe(100, time.Millisecond) // small body do not grow the size
+		assert.Equal(t, 1000, bs.size())
+
+		bs.observe(1000, time.Millisecond)
+		assert.Equal(t, 1250, bs.size())
+
+		bs.observe(1000, 800*time.Millisecond) // within target, not changed
+		assert.Equal(t, 1250, bs.size())
+
+		for i := 0; i < 100; i++ {
+			bs.observe(bs.size(), time.Millisecond)
+		}
+		assert.Equal(t, 8000, bs.size()) // not exceed max
+	})
+
+	t.Run("not-adaptive", func(t *T.T) {
+		bs := newBatchSizer(1000, 8000, time.Second, false)
+		bs.observe(8000, 2*time.Second)
+		bs.shrink()
+		assert.Equal(t, 8000, bs.size())
+	})
+
+	t.Run("nil", func(t *T.T) {
+		var bs *batchSizer
+		assert.Equal(t, MaxKodoBody, bs.size())
+	})
+}
+
 func BenchmarkBuildBody(b *T.B) {
 	cases := []struct {
 		name string
@@ -85,7 +193,7 @@ func BenchmarkBuildBody(b *T.B) {
 	for _, tc := range cases {
 		b.Run(tc.name, func(t *T.B) {
 			for i := 0; i < b.N; i++ {
-				_, err := buildBody(tc.pts, MaxKodoBody)
+				_, err := buildBody(tc.pts, MaxKodoBody, EncodingGzip)
 				if err != nil {
 					t.Error(err)
 				}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+)
+
+// Content encodings of upload body.
+const (
+	EncodingGzip   = "gzip"
+	EncodingZstd   = "zstd"
+	EncodingSnappy = "snappy"
+)
+
+var (
+	// zstd encoder/decoder are safe for concurrent EncodeAll/DecodeAll.
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func validEncoding(enc string) bool {
+	switch enc {
+	case EncodingGzip, EncodingZstd, EncodingSnappy:
+		return true
+	default:
+		return false
+	}
+}
+
+func encodeBody(enc string, raw []byte) ([]byte, error) {
+	switch enc {
+	case EncodingGzip:
+		return datakit.GZip(raw)
+
+	case EncodingZstd:
+		return zstdEncoder.EncodeAll(raw, make([]byte, 0, len(raw)/4)), nil
+
+	case EncodingSnappy:
+		return snappy.Encode(nil, raw), nil
+
+	case "":
+		return raw, nil
+
+	default:
+		return nil, fmt.Errorf("unknown content encoding %q", enc)
+	}
+}
+
+func decodeBody(enc string, data []byte) ([]byte, error) {
+	switch enc {
+	case EncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close() //nolint:errcheck
+
+		return ioutil.ReadAll(zr)
+
+	case EncodingZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+
+	case EncodingSnappy:
+		return snappy.Decode(nil, data)
+
+	case "":
+		return data, nil
+
+	default:
+		return nil, fmt.Errorf("unknown content encoding %q", enc)
+	}
+}
+
+// acceptedEncoding select the preferred one within HTTP header
+// Accept-Encoding(such as `zstd, gzip;q=0.8`), gzip used if none accepted.
+func acceptedEncoding(accept string) string {
+	for _, x := range strings.Split(accept, ",") {
+		enc := strings.TrimSpace(strings.Split(x, ";")[0])
+		if validEncoding(enc) {
+			return enc
+		}
+	}
+
+	return EncodingGzip
+}
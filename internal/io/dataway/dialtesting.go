@@ -47,11 +47,11 @@ func (d *DialtestingSender) WriteData(url string, pts []*dkpt.Point) error {
 		return fmt.Errorf("endpoint is not set correctly")
 	}
 
-	if bodies, err := buildBody(w.pts, MaxKodoBody); err != nil {
+	if bodies, err := buildBody(w.pts, MaxKodoBody, EncodingGzip); err != nil {
 		return nil
 	} else {
 		for _, body := range bodies {
-			if err := d.ep.writeBody(w, body); err != nil {
+			if _, err := d.ep.writeBody(w, body); err != nil {
 				return err
 			}
 		}
@@ -50,6 +50,7 @@ var (
 		datakit.PipelinePull,
 		datakit.ProfilingUpload,
 		datakit.TokenCheck,
+		datakit.Ping,
 	}
 
 	AvailableDataways          = []string{}
@@ -69,6 +70,14 @@ type Dataway struct {
 
 	Sinkers []*Sinker `toml:"sinkers,omitempty"`
 
+	// SinkerFanOut copy point to every matched sinker, or the point only
+	// sinked to the first matched one.
+	SinkerFanOut bool `toml:"sinker_fan_out,omitempty"`
+
+	// SinkerDropUnmatched drop points that not sinked, or these points
+	// send to default dataway.
+	SinkerDropUnmatched bool `toml:"sinker_drop_unmatched,omitempty"`
+
 	// Deprecated
 	DeprecatedHost   string `toml:"host,omitempty"`
 	DeprecatedScheme string `toml:"scheme,omitempty"`
@@ -83,9 +92,36 @@ type Dataway struct {
 
 	EnableHTTPTrace bool `toml:"enable_httptrace"`
 
+	// LoadBalance set how points written among multiple URLs. If not set,
+	// points written to all URLs. With round_robin/least_latency, points
+	// written to one of the healthy URLs, and failover to others on error.
+	LoadBalance string `toml:"load_balance,omitempty"`
+
+	// HealthCheckInterval set interval to ping URLs, 0 to disable.
+	HealthCheckInterval time.Duration `toml:"health_check_interval,omitempty"`
+
+	// Circuit breaker of each URL: after CircuitBreakerFailures consecutive
+	// failures, no request sent to the URL within CircuitBreakerTimeout.
+	CircuitBreakerFailures int           `toml:"circuit_breaker_failures,omitempty"`
+	CircuitBreakerTimeout  time.Duration `toml:"circuit_breaker_timeout,omitempty"`
+
+	// ContentEncoding set compression of upload body: gzip(default)/zstd/snappy.
+	// zstd got better compression ratio, and snappy cost less CPU.
+	ContentEncoding string `toml:"content_encoding,omitempty"`
+
+	// MaxBodyBytes limit body size(before encoding) of each upload. With
+	// AdaptiveBatch, body size adjusted within [MinBodyBytes, MaxBodyBytes]
+	// to keep upload latency around BatchTargetLatency.
+	MaxBodyBytes       int           `toml:"max_body_bytes,omitempty"`
+	AdaptiveBatch      bool          `toml:"adaptive_batch,omitempty"`
+	MinBodyBytes       int           `toml:"min_body_bytes,omitempty"`
+	BatchTargetLatency time.Duration `toml:"batch_target_latency,omitempty"`
+
 	eps        []*endPoint
 	locker     sync.RWMutex
 	dnsCachers []*dnsCacher
+	rrIndex    uint32
+	batch      *batchSizer
 
 	// metrics
 }
@@ -95,6 +131,8 @@ func (dw *Dataway) Init() error {
 		return err
 	}
 
+	dw.startHealthCheck()
+
 	return nil
 }
 
@@ -151,6 +189,48 @@ func (dw *Dataway) doInit() error {
 		dw.MaxIdleConnsPerHost = 64
 	}
 
+	switch dw.LoadBalance {
+	case "":
+	case LoadBalanceRoundRobin, LoadBalanceLeastLatency:
+		// URLs unhealthy should be skipped ASAP under load balance mode
+		if dw.CircuitBreakerFailures == 0 {
+			dw.CircuitBreakerFailures = defaultCircuitBreakerFailures
+		}
+
+		if dw.HealthCheckInterval == 0 {
+			dw.HealthCheckInterval = defaultHealthCheckInterval
+		}
+	default:
+		log.Warnf("invalid load_balance %q, ignored", dw.LoadBalance)
+		dw.LoadBalance = ""
+	}
+
+	switch dw.ContentEncoding {
+	case "":
+		dw.ContentEncoding = EncodingGzip
+	case EncodingGzip, EncodingZstd, EncodingSnappy:
+	default:
+		log.Warnf("invalid content_encoding %q, use %q", dw.ContentEncoding, EncodingGzip)
+		dw.ContentEncoding = EncodingGzip
+	}
+
+	if dw.MaxBodyBytes <= 0 || dw.MaxBodyBytes > MaxKodoBody {
+		dw.MaxBodyBytes = MaxKodoBody
+	}
+
+	if dw.MinBodyBytes <= 0 || dw.MinBodyBytes > dw.MaxBodyBytes {
+		dw.MinBodyBytes = defaultMinBodyBytes
+		if dw.MinBodyBytes > dw.MaxBodyBytes {
+			dw.MinBodyBytes = dw.MaxBodyBytes
+		}
+	}
+
+	if dw.BatchTargetLatency <= 0 {
+		dw.BatchTargetLatency = defaultBatchTargetLatency
+	}
+
+	dw.batch = newBatchSizer(dw.MinBodyBytes, dw.MaxBodyBytes, dw.BatchTargetLatency, dw.AdaptiveBatch)
+
 	var setupOKSinker []*Sinker
 	for _, s := range dw.Sinkers {
 		if err := s.Setup(); err != nil {
@@ -172,6 +252,9 @@ func (dw *Dataway) doInit() error {
 			withMaxHTTPIdleConnectionPerHost(dw.MaxIdleConnsPerHost),
 			withMaxHTTPConnections(dw.MaxIdleConns),
 			withHTTPIdleTimeout(dw.IdleTimeout),
+			withCircuitBreaker(dw.CircuitBreakerFailures, dw.CircuitBreakerTimeout),
+			withContentEncoding(dw.ContentEncoding),
+			withBatchSizer(dw.batch),
 		)
 		if err != nil {
 			log.Errorf("init dataway url %s failed: %s", u, err.Error())
@@ -17,6 +17,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -81,6 +82,15 @@ type endPoint struct {
 	httpIdleTimeout              time.Duration
 
 	httpTrace bool
+
+	breakerFailures int
+	breakerTimeout  time.Duration
+	health          *epHealth
+
+	encMtx   sync.RWMutex
+	encoding string // content encoding of body, may changed on negotiation
+
+	batch *batchSizer
 }
 
 func (ep *endPoint) String() string {
@@ -134,6 +144,27 @@ func withHTTPTimeout(timeout time.Duration) endPointOption {
 	}
 }
 
+func withCircuitBreaker(failures int, timeout time.Duration) endPointOption {
+	return func(ep *endPoint) {
+		ep.breakerFailures = failures
+		ep.breakerTimeout = timeout
+	}
+}
+
+func withContentEncoding(enc string) endPointOption {
+	return func(ep *endPoint) {
+		if validEncoding(enc) {
+			ep.encoding = enc
+		}
+	}
+}
+
+func withBatchSizer(bs *batchSizer) endPointOption {
+	return func(ep *endPoint) {
+		ep.batch = bs
+	}
+}
+
 func withProxy(proxy string) endPointOption {
 	return func(ep *endPoint) {
 		ep.proxy = proxy
@@ -152,6 +183,7 @@ func newEndpoint(urlstr string, opts ...endPointOption) (*endPoint, error) {
 		token:       u.Query().Get("token"),
 		host:        u.Host,
 		scheme:      u.Scheme,
+		encoding:    EncodingGzip,
 	}
 
 	// apply options
@@ -161,6 +193,8 @@ func newEndpoint(urlstr string, opts ...endPointOption) (*endPoint, error) {
 		}
 	}
 
+	ep.health = newEPHealth(ep.host, ep.breakerFailures, ep.breakerTimeout)
+
 	for _, api := range ep.apis {
 		if q := u.Query().Encode(); q != "" {
 			ep.categoryURL[api] = fmt.Sprintf("%s://%s%s?%s",
@@ -225,50 +259,75 @@ func (ep *endPoint) Transport() *http.Transport {
 	return httpcli.Transport(ep.getHTTPCliOpts())
 }
 
-func (ep *endPoint) writeBody(w *writer, b *body) (err error) {
-	w.gzip = b.gzon
-
-	// if send failed, do nothing.
-	if err = ep.writePointData(b, w); err != nil {
-		// 4xx error do not cache data.
-		// If the error is token-not-found or beyond-usage, datakit
-		// will write all data to disk, this may cause unexpected I/O cost
-		// on host.
-		if errors.Is(err, errWritePoints4XX) {
-			return
-		}
+func (ep *endPoint) getEncoding() string {
+	ep.encMtx.RLock()
+	defer ep.encMtx.RUnlock()
 
-		if w.fc == nil { // no cache
-			return
-		}
+	if ep.encoding == "" {
+		return EncodingGzip
+	}
+	return ep.encoding
+}
 
-		// do cache: write them to disk.
-		if w.cacheAll {
-			if err := doCache(w, b); err != nil {
-				log.Errorf("doCache %d pts on %s: %s", b.npts, w.category, err)
-			} else {
-				log.Infof("ok on doCache %d pts on %s", b.npts, w.category)
-			}
-		} else {
-			//nolint:exhaustive
-			switch w.category {
-			case point.Metric, // these categories are not cache.
-				point.MetricDeprecated,
-				point.Object,
-				point.CustomObject,
-				point.DynamicDWCategory:
-
-				log.Warnf("drop %d pts on %s, not cached", b.npts, w.category)
-
-			default:
-				if err := doCache(w, b); err != nil {
-					log.Errorf("doCache %v pts on %s: %s", b.npts, w.category, err)
-				}
-			}
+func (ep *endPoint) setEncoding(enc string) {
+	ep.encMtx.Lock()
+	defer ep.encMtx.Unlock()
+	ep.encoding = enc
+}
+
+// writeBody send b to the endpoint, and cache it on failure. On send
+// failure, cached is true if b put into failcache.
+func (ep *endPoint) writeBody(w *writer, b *body) (cached bool, err error) {
+	// circuit open, do not wait timeout on the endpoint, cache data ASAP.
+	if !ep.health.allow() {
+		err = errCircuitOpen
+	} else {
+		err = ep.sendBody(b, w)
+	}
+
+	if err != nil {
+		cached = cacheBody(w, b, err)
+	}
+
+	return cached, err
+}
+
+// cacheBody cache body b on send failure, false returned if b not cached.
+func cacheBody(w *writer, b *body, err error) bool {
+	// 4xx error do not cache data.
+	// If the error is token-not-found or beyond-usage, datakit
+	// will write all data to disk, this may cause unexpected I/O cost
+	// on host.
+	if errors.Is(err, errWritePoints4XX) {
+		return false
+	}
+
+	if w.fc == nil { // no cache
+		return false
+	}
+
+	// do cache: write them to disk.
+	if !w.cacheAll {
+		//nolint:exhaustive
+		switch w.category {
+		case point.Metric, // these categories are not cache.
+			point.MetricDeprecated,
+			point.Object,
+			point.CustomObject,
+			point.DynamicDWCategory:
+
+			log.Warnf("drop %d pts on %s, not cached", b.npts, w.category)
+			return false
 		}
 	}
 
-	return err
+	if err := doCache(w, b); err != nil {
+		log.Errorf("doCache %d pts on %s: %s", b.npts, w.category, err)
+		return false
+	}
+
+	log.Debugf("ok on doCache %d pts on %s", b.npts, w.category)
+	return true
 }
 
 func (ep *endPoint) writePoints(w *writer) error {
@@ -277,26 +336,33 @@ func (ep *endPoint) writePoints(w *writer) error {
 		err    error
 	)
 
-	bodies, err = buildBody(w.pts, MaxKodoBody)
+	bodies, err = buildBody(w.pts, ep.batch.size(), ep.getEncoding())
 	if err != nil {
 		return err
 	}
 
+	// error returned if any body neither sent nor cached.
+	var lastErr error
 	for _, body := range bodies {
-		if err := ep.writeBody(w, body); err != nil {
-			log.Warnf("send %d points to %q(gzip: %v) bytes failed: %q, ignored",
-				len(w.pts), w.category, w.gzip, err.Error())
+		if cached, err := ep.writeBody(w, body); err != nil {
+			log.Warnf("send %d points to %q(enc: %q) bytes failed: %q, cached: %v",
+				body.npts, w.category, body.enc, err.Error(), cached)
+
+			if !cached {
+				lastErr = err
+			}
 		}
 	}
 
-	return nil
+	return lastErr
 }
 
 func doCache(w *writer, b *body) error {
 	if cachedata, err := pb.Marshal(&CacheData{
-		Category:    int32(w.category),
-		PayloadType: int32(b.payload),
-		Payload:     b.buf,
+		Category:        int32(w.category),
+		PayloadType:     int32(b.payload),
+		Payload:         b.buf,
+		ContentEncoding: b.enc,
 	}); err != nil {
 		return err
 	} else {
@@ -304,6 +370,71 @@ func doCache(w *writer, b *body) error {
 	}
 }
 
+// sendBody send b to the endpoint. If the endpoint not accept encoding of b,
+// b re-encoded with accepted encoding; if b too large, b split and sent
+// in parts.
+func (ep *endPoint) sendBody(b *body, w *writer) error {
+	if enc := ep.getEncoding(); b.enc != "" && b.enc != enc {
+		x, err := b.transcode(enc)
+		if err != nil {
+			return err
+		}
+		b = x
+	}
+
+	start := time.Now()
+	err := ep.writePointData(b, w)
+
+	switch {
+	case err == nil:
+		ep.batch.observe(b.rawLen, time.Since(start))
+		return nil
+
+	case errors.Is(err, errUnsupportedEncoding):
+		enc := ep.getEncoding()
+		if enc == b.enc { // encoding not changed
+			return err
+		}
+
+		x, err := b.transcode(enc)
+		if err != nil {
+			return err
+		}
+
+		return ep.writePointData(x, w)
+
+	case errors.Is(err, errBodyTooLarge):
+		ep.batch.shrink()
+
+		if b.npts == 1 { // npts of cached body is unknown(0)
+			return err
+		}
+
+		parts, err := b.split()
+		if err != nil {
+			return err
+		}
+
+		if len(parts) < 2 {
+			return errBodyTooLarge
+		}
+
+		log.Infof("body too large, split %d points into %d parts", b.npts, len(parts))
+
+		var lastErr error
+		for _, part := range parts {
+			if err := ep.sendBody(part, w); err != nil {
+				lastErr = err
+			}
+		}
+
+		return lastErr
+
+	default:
+		return err
+	}
+}
+
 func (ep *endPoint) writePointData(b *body, w *writer) error {
 	httpCodeStr := "unknown"
 	requrl, catNotFound := ep.categoryURL[w.category.URL()]
@@ -331,8 +462,13 @@ func (ep *endPoint) writePointData(b *body, w *writer) error {
 			cat = point.DynamicDWCategory.String()
 		}
 
-		bytesCounterVec.WithLabelValues(cat, "gzip", "total").Add(float64(len(b.buf)))
-		bytesCounterVec.WithLabelValues(cat, "gzip", httpCodeStr).Add(float64(len(b.buf)))
+		enc := b.enc
+		if enc == "" {
+			enc = "raw"
+		}
+
+		bytesCounterVec.WithLabelValues(cat, enc, "total").Add(float64(len(b.buf)))
+		bytesCounterVec.WithLabelValues(cat, enc, httpCodeStr).Add(float64(len(b.buf)))
 		bytesCounterVec.WithLabelValues(cat, "raw", "total").Add(float64(b.rawLen))
 		bytesCounterVec.WithLabelValues(cat, "raw", httpCodeStr).Add(float64(b.rawLen))
 
@@ -350,8 +486,8 @@ func (ep *endPoint) writePointData(b *body, w *writer) error {
 		return err
 	}
 
-	if w.gzip {
-		req.Header.Set("Content-Encoding", "gzip")
+	if b.enc != "" {
+		req.Header.Set("Content-Encoding", b.enc)
 	}
 
 	req.Header.Set("X-Points", fmt.Sprintf("%d", b.npts))
@@ -379,7 +515,7 @@ func (ep *endPoint) writePointData(b *body, w *writer) error {
 
 	switch resp.StatusCode / 100 {
 	case 2:
-		log.Debugf("post %d bytes to %s ok(gz: %v)", len(b.buf), requrl, w.gzip)
+		log.Debugf("post %d bytes to %s ok(enc: %q)", len(b.buf), requrl, b.enc)
 
 		// Send data ok, it means the error `beyond-usage` error is cleared by kodo server,
 		// we have to clear the hint in monitor too.
@@ -399,6 +535,23 @@ func (ep *endPoint) writePointData(b *body, w *writer) error {
 			strBody)
 
 		switch resp.StatusCode {
+		case http.StatusRequestEntityTooLarge:
+			return errBodyTooLarge
+
+		case http.StatusUnsupportedMediaType:
+			if b.enc != "" && b.enc != EncodingGzip {
+				enc := acceptedEncoding(resp.Header.Get("Accept-Encoding"))
+				if enc == b.enc {
+					enc = EncodingGzip
+				}
+
+				log.Warnf("%s not accept content encoding %q, fallback to %q", ep.host, b.enc, enc)
+				encodingFallbackVec.WithLabelValues(ep.host, b.enc, enc).Inc()
+
+				ep.setEncoding(enc)
+				return errUnsupportedEncoding
+			}
+
 		case http.StatusForbidden:
 			if strings.Contains(strBody, "beyondDataUsage") {
 				atomic.AddInt64(&metrics.BeyondUsage, time.Now().Unix()) // will set `beyond-usage' hint in monitor.
@@ -518,6 +671,9 @@ func (ep *endPoint) sendReq(req *http.Request) (resp *http.Response, err error)
 
 		retry.Attempts(4),
 		retry.Delay(time.Second*1),
+		retry.RetryIf(func(error) bool {
+			return ep.health.allow() // no more retry if circuit opened
+		}),
 		retry.OnRetry(func(n uint, err error) {
 			log.Warnf("on %dth retry, error: %s", n, err)
 			httpRetry.WithLabelValues(req.URL.Path, status).Inc()
@@ -573,12 +729,19 @@ func (ep *endPoint) doSendReq(req *http.Request) (*http.Response, error) {
 
 	resp, err = ep.httpCli.Do(req)
 	if err != nil {
+		ep.health.onFailure()
 		return nil, fmt.Errorf("httpCli.Do: %w, resp: %+#v", err, resp)
 	}
 
 end:
 	if resp != nil {
 		httpCodeStr = http.StatusText(resp.StatusCode)
+
+		if resp.StatusCode/100 == 5 {
+			ep.health.onFailure()
+		} else {
+			ep.health.onSuccess(time.Since(start))
+		}
 	}
 
 	return resp, nil
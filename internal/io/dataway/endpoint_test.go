@@ -122,7 +122,7 @@ test-2 f1=1i,f2=false 123`), x)
 			},
 		}
 
-		assert.NoError(t, ep.writePoints(s))
+		assert.Error(t, ep.writePoints(s)) // 4xx not cached
 
 		mfs, err := reg.Gather()
 		require.NoError(t, err)
@@ -308,3 +308,80 @@ test-2 f1=1i,f2=false 123`), x)
 		})
 	})
 }
+
+func TestEndpointNegotiation(t *T.T) {
+	t.Run("encoding-fallback", func(t *T.T) {
+		var encs []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enc := r.Header.Get("Content-Encoding")
+			encs = append(encs, enc)
+
+			if enc != EncodingGzip {
+				w.Header().Set("Accept-Encoding", "gzip")
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			defer r.Body.Close() //nolint:errcheck
+
+			_, err = uhttp.Unzip(body)
+			assert.NoError(t, err)
+
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		ep, err := newEndpoint(fmt.Sprintf("%s?token=abc", ts.URL),
+			withAPIs([]string{datakit.Logging}),
+			withContentEncoding(EncodingZstd))
+		require.NoError(t, err)
+
+		w := &writer{category: point.Logging, pts: dkpt.RandPoints(10)}
+
+		assert.NoError(t, ep.writePoints(w))
+		assert.Equal(t, EncodingGzip, ep.getEncoding())
+		assert.Equal(t, []string{EncodingZstd, EncodingGzip}, encs)
+
+		// following bodies are gzip
+		assert.NoError(t, ep.writePoints(w))
+		assert.Equal(t, []string{EncodingZstd, EncodingGzip, EncodingGzip}, encs)
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("body-too-large", func(t *T.T) {
+		var npts []int
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n, err := strconv.ParseInt(r.Header.Get("X-Points"), 10, 64)
+			assert.NoError(t, err)
+			npts = append(npts, int(n))
+
+			if n > 25 {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		bs := newBatchSizer(1024, MaxKodoBody, time.Second, true)
+
+		ep, err := newEndpoint(fmt.Sprintf("%s?token=abc", ts.URL),
+			withAPIs([]string{datakit.Logging}),
+			withBatchSizer(bs))
+		require.NoError(t, err)
+
+		w := &writer{category: point.Logging, pts: dkpt.RandPoints(100)}
+
+		assert.NoError(t, ep.writePoints(w))
+		assert.Equal(t, []int{100, 50, 25, 25, 50, 25, 25}, npts)
+		assert.Equal(t, MaxKodoBody/8, bs.size()) // shrinked on each 413
+
+		t.Cleanup(metricsReset)
+	})
+}
@@ -7,6 +7,13 @@ package dataway
 
 import (
 	"errors"
+	"fmt"
 )
 
-var errWritePoints4XX = errors.New("write point 4xx")
+var (
+	errWritePoints4XX = errors.New("write point 4xx")
+
+	// both are 4xx errors, but we can retry with smaller body or other encoding.
+	errBodyTooLarge        = fmt.Errorf("body too large: %w", errWritePoints4XX)
+	errUnsupportedEncoding = fmt.Errorf("unsupported content encoding: %w", errWritePoints4XX)
+)
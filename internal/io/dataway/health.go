@@ -0,0 +1,334 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+)
+
+const (
+	LoadBalanceRoundRobin   = "round_robin"
+	LoadBalanceLeastLatency = "least_latency"
+
+	// defaults under load balance mode.
+	defaultHealthCheckInterval    = 10 * time.Second
+	defaultCircuitBreakerFailures = 3
+	defaultCircuitBreakerTimeout  = 30 * time.Second
+
+	// weight of the latest latency within the EWMA latency.
+	latencyEWMAWeight = 0.3
+)
+
+var (
+	errCircuitOpen       = errors.New("circuit breaker open")
+	errNoHealthyEndpoint = errors.New("no healthy dataway endpoint")
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// epHealth is the health state of an endpoint, it's a simple circuit
+// breaker: the circuit opened on maxFailures consecutive failures, and
+// during openTimeout, no request sent to the endpoint. After openTimeout,
+// the circuit become half-open, the next request(or health check) decides
+// whether the circuit closed or opened again.
+type epHealth struct {
+	mtx sync.Mutex
+
+	name     string
+	state    breakerState
+	failures int // consecutive failures
+	openedAt time.Time
+	latency  time.Duration // EWMA latency of requests
+
+	// 0 to disable the circuit breaker
+	maxFailures int
+	openTimeout time.Duration
+}
+
+func newEPHealth(name string, maxFailures int, openTimeout time.Duration) *epHealth {
+	if openTimeout <= 0 {
+		openTimeout = defaultCircuitBreakerTimeout
+	}
+
+	h := &epHealth{
+		name:        name,
+		maxFailures: maxFailures,
+		openTimeout: openTimeout,
+	}
+
+	endpointHealthyVec.WithLabelValues(name).Set(1)
+	return h
+}
+
+// allow check if request can be sent to the endpoint. Endpoint without
+// health(such as dialtesting sender) always allowed.
+func (h *epHealth) allow() bool {
+	if h == nil {
+		return true
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.state == breakerOpen && time.Since(h.openedAt) >= h.openTimeout {
+		log.Infof("circuit breaker of %s half-open", h.name)
+		h.state = breakerHalfOpen
+	}
+
+	return h.state != breakerOpen
+}
+
+func (h *epHealth) onSuccess(latency time.Duration) {
+	if h == nil {
+		return
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration(latencyEWMAWeight*float64(latency) + (1-latencyEWMAWeight)*float64(h.latency))
+	}
+
+	if h.state != breakerClosed {
+		log.Infof("circuit breaker of %s closed", h.name)
+	}
+
+	h.state = breakerClosed
+	h.failures = 0
+
+	endpointHealthyVec.WithLabelValues(h.name).Set(1)
+	endpointLatencyVec.WithLabelValues(h.name).Set(float64(h.latency) / float64(time.Second))
+}
+
+func (h *epHealth) onFailure() {
+	if h == nil {
+		return
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.failures++
+
+	if h.maxFailures <= 0 || h.state == breakerOpen {
+		return
+	}
+
+	// half-open circuit opened again on any failure
+	if h.state == breakerHalfOpen || h.failures >= h.maxFailures {
+		log.Warnf("circuit breaker of %s open after %d failures", h.name, h.failures)
+
+		h.state = breakerOpen
+		h.openedAt = time.Now()
+
+		circuitBreakerTripVec.WithLabelValues(h.name).Inc()
+		endpointHealthyVec.WithLabelValues(h.name).Set(0)
+	}
+}
+
+func (h *epHealth) getLatency() time.Duration {
+	if h == nil {
+		return 0
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.latency
+}
+
+func (h *epHealth) getState() breakerState {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.state
+}
+
+// ping request the ping API of the endpoint, the request result also
+// update health of the endpoint.
+func (ep *endPoint) ping() error {
+	url, ok := ep.categoryURL[datakit.Ping]
+	if !ok {
+		return fmt.Errorf("ping API missing, should not been here")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ep.doSendReq(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("ping %s: %s", ep.host, resp.Status)
+	}
+
+	return nil
+}
+
+func (dw *Dataway) balancing() bool {
+	return dw.LoadBalance == LoadBalanceRoundRobin || dw.LoadBalance == LoadBalanceLeastLatency
+}
+
+// pickEndpoints select endpoints that available for request, these
+// endpoints ordered by the load balance policy, the first one is preferred
+// and the others are for failover.
+func (dw *Dataway) pickEndpoints() []*endPoint {
+	var arr []*endPoint
+	for _, ep := range dw.eps {
+		if ep.health.allow() {
+			arr = append(arr, ep)
+		}
+	}
+
+	if len(arr) <= 1 {
+		return arr
+	}
+
+	switch dw.LoadBalance {
+	case LoadBalanceLeastLatency:
+		// endpoints without latency(not requested yet) are preferred, so that
+		// we can get their latency ASAP.
+		sort.SliceStable(arr, func(i, j int) bool {
+			return arr[i].health.getLatency() < arr[j].health.getLatency()
+		})
+
+	default: // round-robin
+		n := int(atomic.AddUint32(&dw.rrIndex, 1) % uint32(len(arr)))
+		arr = append(arr[n:], arr[:n]...)
+	}
+
+	return arr
+}
+
+// writeBalanced write points to one of the endpoints, and failover to
+// other endpoints on failure. Error returned if any body neither sent
+// nor cached.
+func (dw *Dataway) writeBalanced(w *writer) error {
+	eps := dw.pickEndpoints()
+
+	// bodies encoded in the negotiated encoding of the preferred endpoint,
+	// failover endpoints transcode them if needed.
+	enc := dw.ContentEncoding
+	if len(eps) > 0 {
+		enc = eps[0].getEncoding()
+	}
+
+	bodies, err := buildBody(w.pts, dw.batch.size(), enc)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for j, b := range bodies {
+		arr := eps
+		if dw.LoadBalance != LoadBalanceLeastLatency && len(eps) > 1 {
+			// bodies still round-robin among endpoints
+			k := j % len(eps)
+			arr = append(append([]*endPoint{}, eps[k:]...), eps[:k]...)
+		}
+
+		err := errNoHealthyEndpoint
+		tried := 0
+		for _, ep := range arr {
+			if !ep.health.allow() { // circuit opened during the write
+				continue
+			}
+
+			if tried > 0 {
+				failoverVec.WithLabelValues(w.category.String()).Inc()
+				log.Infof("failover %d points(%q) to %s", b.npts, w.category, ep.host)
+			}
+			tried++
+
+			if err = ep.sendBody(b, w); err == nil || errors.Is(err, errWritePoints4XX) {
+				break
+			}
+		}
+
+		if err != nil {
+			cached := cacheBody(w, b, err)
+
+			log.Warnf("send %d points to %q(enc: %q) bytes failed: %q, cached: %v",
+				b.npts, w.category, b.enc, err.Error(), cached)
+
+			if !cached {
+				lastErr = err
+			}
+		}
+	}
+
+	return lastErr
+}
+
+// checkHealth ping all endpoints, health of these endpoints updated.
+func (dw *Dataway) checkHealth() {
+	for _, ep := range dw.eps {
+		status := "ok"
+		if err := ep.ping(); err != nil {
+			log.Warnf("health check on %s failed: %s", ep.host, err.Error())
+			status = "failed"
+		}
+
+		healthCheckVec.WithLabelValues(ep.host, status).Inc()
+	}
+}
+
+func (dw *Dataway) startHealthCheck() {
+	if dw.HealthCheckInterval <= 0 || len(dw.eps) == 0 {
+		return
+	}
+
+	g := datakit.G("dataway")
+	g.Go(func(_ context.Context) error {
+		tick := time.NewTicker(dw.HealthCheckInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				dw.checkHealth()
+
+			case <-datakit.Exit.Wait():
+				log.Info("dataway health check exits")
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,345 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dataway
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/diskcache"
+	lp "github.com/GuanceCloud/cliutils/lineproto"
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	pb "google.golang.org/protobuf/proto"
+)
+
+func TestEPHealth(t *T.T) {
+	t.Run("breaker", func(t *T.T) {
+		h := newEPHealth("test", 3, 100*time.Millisecond)
+
+		h.onFailure()
+		h.onFailure()
+		assert.True(t, h.allow())
+
+		h.onFailure()
+		assert.False(t, h.allow())
+		assert.Equal(t, breakerOpen, h.getState())
+
+		time.Sleep(150 * time.Millisecond)
+		assert.True(t, h.allow())
+		assert.Equal(t, breakerHalfOpen, h.getState())
+
+		// half-open opened again on failure
+		h.onFailure()
+		assert.False(t, h.allow())
+
+		time.Sleep(150 * time.Millisecond)
+		assert.True(t, h.allow())
+
+		h.onSuccess(time.Millisecond)
+		assert.Equal(t, breakerClosed, h.getState())
+		assert.Equal(t, 0, h.failures)
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("breaker-disabled", func(t *T.T) {
+		h := newEPHealth("test", 0, 0)
+		for i := 0; i < 10; i++ {
+			h.onFailure()
+		}
+
+		assert.True(t, h.allow())
+		assert.Equal(t, breakerClosed, h.getState())
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("nil-health", func(t *T.T) {
+		var h *epHealth
+
+		h.onFailure()
+		h.onSuccess(time.Second)
+		assert.True(t, h.allow())
+		assert.Equal(t, time.Duration(0), h.getLatency())
+	})
+
+	t.Run("latency", func(t *T.T) {
+		h := newEPHealth("test", 0, 0)
+
+		h.onSuccess(100 * time.Millisecond)
+		assert.Equal(t, 100*time.Millisecond, h.getLatency())
+
+		h.onSuccess(200 * time.Millisecond)
+		assert.Equal(t, 130*time.Millisecond, h.getLatency())
+
+		t.Cleanup(metricsReset)
+	})
+}
+
+func TestWriteBalanced(t *T.T) {
+	newServer := func(status int, hits *int64) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(hits, 1)
+			w.WriteHeader(status)
+		}))
+	}
+
+	t.Run("round-robin", func(t *T.T) {
+		var okHits1, okHits2 int64
+		ts1 := newServer(200, &okHits1)
+		ts2 := newServer(200, &okHits2)
+		defer ts1.Close()
+		defer ts2.Close()
+
+		dw := &Dataway{
+			URLs: []string{
+				fmt.Sprintf("%s?token=tkn_for_test", ts1.URL),
+				fmt.Sprintf("%s?token=tkn_for_test", ts2.URL),
+			},
+			LoadBalance: LoadBalanceRoundRobin,
+		}
+		require.NoError(t, dw.doInit())
+
+		assert.Equal(t, defaultCircuitBreakerFailures, dw.CircuitBreakerFailures)
+		assert.Equal(t, defaultHealthCheckInterval, dw.HealthCheckInterval)
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, dw.Write(WithCategory(point.Logging), WithPoints(dkpt.RandPoints(10))))
+		}
+
+		// each point write to only one endpoint
+		assert.Equal(t, int64(5), atomic.LoadInt64(&okHits1))
+		assert.Equal(t, int64(5), atomic.LoadInt64(&okHits2))
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("failover", func(t *T.T) {
+		var badHits, okHits int64
+		bad := newServer(500, &badHits)
+		ok := newServer(200, &okHits)
+		defer bad.Close()
+		defer ok.Close()
+
+		dw := &Dataway{
+			URLs: []string{
+				fmt.Sprintf("%s?token=tkn_for_test", bad.URL),
+				fmt.Sprintf("%s?token=tkn_for_test", ok.URL),
+			},
+			LoadBalance:            LoadBalanceRoundRobin,
+			CircuitBreakerFailures: 1,
+			CircuitBreakerTimeout:  time.Minute,
+		}
+		require.NoError(t, dw.doInit())
+
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, dw.Write(WithCategory(point.Logging), WithPoints(dkpt.RandPoints(10))))
+		}
+
+		// the bad endpoint requested only once, then its circuit opened.
+		assert.Equal(t, int64(1), atomic.LoadInt64(&badHits))
+		assert.Equal(t, int64(10), atomic.LoadInt64(&okHits))
+		assert.Equal(t, breakerOpen, dw.eps[0].health.getState())
+
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "datakit_io_dataway_circuit_breaker_trip_total", bad.Listener.Addr().String())
+		require.NotNil(t, m)
+		assert.Equal(t, 1.0, m.GetCounter().GetValue())
+
+		m = metrics.GetMetricOnLabels(mfs, "datakit_io_dataway_failover_total", point.Logging.String())
+		require.NotNil(t, m)
+		assert.Equal(t, 1.0, m.GetCounter().GetValue())
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("all-unhealthy", func(t *T.T) {
+		var badHits int64
+		bad := newServer(500, &badHits)
+		defer bad.Close()
+
+		dw := &Dataway{
+			URLs:                   []string{fmt.Sprintf("%s?token=tkn_for_test", bad.URL)},
+			LoadBalance:            LoadBalanceLeastLatency,
+			CircuitBreakerFailures: 1,
+			CircuitBreakerTimeout:  time.Minute,
+		}
+		require.NoError(t, dw.doInit())
+
+		for i := 0; i < 3; i++ {
+			// no failcache, points neither sent nor cached
+			assert.Error(t, dw.Write(WithCategory(point.Logging), WithPoints(dkpt.RandPoints(10))))
+		}
+
+		// no more request after circuit opened
+		assert.Equal(t, int64(1), atomic.LoadInt64(&badHits))
+		assert.Empty(t, dw.pickEndpoints())
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("least-latency", func(t *T.T) {
+		dw := &Dataway{
+			URLs: []string{
+				"http://host1?token=tkn_for_test",
+				"http://host2?token=tkn_for_test",
+				"http://host3?token=tkn_for_test",
+			},
+			LoadBalance: LoadBalanceLeastLatency,
+		}
+		require.NoError(t, dw.doInit())
+
+		dw.eps[0].health.onSuccess(300 * time.Millisecond)
+		dw.eps[1].health.onSuccess(100 * time.Millisecond)
+		dw.eps[2].health.onSuccess(200 * time.Millisecond)
+
+		arr := dw.pickEndpoints()
+		require.Len(t, arr, 3)
+		assert.Equal(t, "host2", arr[0].host)
+		assert.Equal(t, "host3", arr[1].host)
+		assert.Equal(t, "host1", arr[2].host)
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("negotiated-encoding", func(t *T.T) {
+		var (
+			status int64 = 500
+			mtx    sync.Mutex
+			encs   []string
+		)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mtx.Lock()
+			encs = append(encs, r.Header.Get("Content-Encoding"))
+			mtx.Unlock()
+			w.WriteHeader(int(atomic.LoadInt64(&status)))
+		}))
+		defer ts.Close()
+
+		fc, err := diskcache.Open(diskcache.WithPath(t.TempDir()))
+		require.NoError(t, err)
+		defer fc.Close() //nolint:errcheck
+
+		dw := &Dataway{
+			URLs:                   []string{fmt.Sprintf("%s?token=tkn_for_test", ts.URL)},
+			LoadBalance:            LoadBalanceRoundRobin,
+			CircuitBreakerFailures: 100,
+		}
+		require.NoError(t, dw.doInit())
+
+		// fallen back to snappy before
+		dw.eps[0].setEncoding(EncodingSnappy)
+
+		assert.NoError(t, dw.Write(WithCategory(point.Logging), WithPoints(dkpt.RandPoints(10)), WithFailCache(fc)))
+		require.NoError(t, fc.Rotate())
+
+		// encoding kept within cache
+		require.NoError(t, fc.Get(func(data []byte) error {
+			pd := &CacheData{}
+			require.NoError(t, pb.Unmarshal(data, pd))
+			assert.Equal(t, EncodingSnappy, pd.ContentEncoding)
+
+			raw, err := decodeBody(pd.ContentEncoding, pd.Payload)
+			require.NoError(t, err)
+
+			pts, err := lp.ParsePoints(raw, nil)
+			require.NoError(t, err)
+			assert.Len(t, pts, 10)
+
+			return fmt.Errorf("put back") // keep it in cache
+		}))
+
+		// clean cache
+		atomic.StoreInt64(&status, 200)
+		require.NoError(t, fc.Rotate())
+		assert.NoError(t, dw.Write(WithCategory(point.Logging), WithCacheClean(true), WithFailCache(fc)))
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		require.NotEmpty(t, encs)
+		for _, enc := range encs { // both 5xx retries and cache-clean sent in snappy
+			assert.Equal(t, EncodingSnappy, enc)
+		}
+
+		t.Cleanup(metricsReset)
+	})
+
+	t.Run("invalid-load-balance", func(t *T.T) {
+		dw := &Dataway{
+			URLs:        []string{"http://host1?token=tkn_for_test"},
+			LoadBalance: "no-such-balance",
+		}
+		require.NoError(t, dw.doInit())
+		assert.Equal(t, "", dw.LoadBalance)
+		assert.False(t, dw.balancing())
+	})
+}
+
+func TestCheckHealth(t *T.T) {
+	var down int64 = 1
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, datakit.Ping, r.URL.Path)
+
+		if atomic.LoadInt64(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+
+	dw := &Dataway{
+		URLs:                   []string{fmt.Sprintf("%s?token=tkn_for_test", ts.URL)},
+		LoadBalance:            LoadBalanceRoundRobin,
+		CircuitBreakerFailures: 2,
+		CircuitBreakerTimeout:  time.Minute,
+	}
+	require.NoError(t, dw.doInit())
+
+	ep := dw.eps[0]
+
+	dw.checkHealth()
+	assert.Equal(t, breakerClosed, ep.health.getState())
+	dw.checkHealth()
+	assert.Equal(t, breakerOpen, ep.health.getState())
+
+	// ping ok close the circuit
+	atomic.StoreInt64(&down, 0)
+	dw.checkHealth()
+	assert.Equal(t, breakerClosed, ep.health.getState())
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(Metrics()...)
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	m := metrics.GetMetricOnLabels(mfs, "datakit_io_dataway_endpoint_healthy", ts.Listener.Addr().String())
+	require.NotNil(t, m)
+	assert.Equal(t, 1.0, m.GetGauge().GetValue())
+
+	m = metrics.GetMetricOnLabels(mfs, "datakit_io_dataway_health_check_total", ts.Listener.Addr().String(), "failed")
+	require.NotNil(t, m)
+	assert.Equal(t, 2.0, m.GetCounter().GetValue())
+
+	t.Cleanup(metricsReset)
+}
@@ -16,7 +16,16 @@ var (
 	sinkCounterVec,
 	httpRetry,
 	notSinkPtsVec,
-	sinkPtsVec *prometheus.CounterVec
+	sinkPtsVec,
+	circuitBreakerTripVec,
+	healthCheckVec,
+	failoverVec,
+	encodingFallbackVec *prometheus.CounterVec
+
+	bodySizeLimitGauge prometheus.Gauge
+
+	endpointHealthyVec,
+	endpointLatencyVec *prometheus.GaugeVec
 
 	flushFailCacheVec,
 	apiSumVec *prometheus.SummaryVec
@@ -33,6 +42,13 @@ func Metrics() []prometheus.Collector {
 		notSinkPtsVec,
 		sinkPtsVec,
 		flushFailCacheVec,
+		circuitBreakerTripVec,
+		healthCheckVec,
+		failoverVec,
+		endpointHealthyVec,
+		endpointLatencyVec,
+		encodingFallbackVec,
+		bodySizeLimitGauge,
 	}
 }
 
@@ -47,6 +63,12 @@ func metricsReset() {
 	flushFailCacheVec.Reset()
 	notSinkPtsVec.Reset()
 	sinkPtsVec.Reset()
+	circuitBreakerTripVec.Reset()
+	healthCheckVec.Reset()
+	failoverVec.Reset()
+	endpointHealthyVec.Reset()
+	endpointLatencyVec.Reset()
+	encodingFallbackVec.Reset()
 }
 
 func doRegister() {
@@ -60,6 +82,14 @@ func doRegister() {
 		httpRetry,
 		notSinkPtsVec,
 		sinkPtsVec,
+
+		circuitBreakerTripVec,
+		healthCheckVec,
+		failoverVec,
+		endpointHealthyVec,
+		endpointLatencyVec,
+		encodingFallbackVec,
+		bodySizeLimitGauge,
 	)
 }
 
@@ -149,5 +179,74 @@ func init() {
 		[]string{"category", "status"},
 	)
 
+	circuitBreakerTripVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_circuit_breaker_trip_total",
+			Help:      "Dataway circuit breaker opened count, partitioned by endpoint",
+		},
+		[]string{"endpoint"},
+	)
+
+	healthCheckVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_health_check_total",
+			Help:      "Dataway health check count, partitioned by endpoint and check status(ok/failed)",
+		},
+		[]string{"endpoint", "status"},
+	)
+
+	failoverVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_failover_total",
+			Help:      "Dataway write failover count under load balance mode, partitioned by category",
+		},
+		[]string{"category"},
+	)
+
+	endpointHealthyVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_endpoint_healthy",
+			Help:      "Dataway endpoint healthy(1) or not(0, circuit breaker opened)",
+		},
+		[]string{"endpoint"},
+	)
+
+	endpointLatencyVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_endpoint_latency_seconds",
+			Help:      "Dataway endpoint request latency(EWMA) used for least-latency load balance",
+		},
+		[]string{"endpoint"},
+	)
+
+	encodingFallbackVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_encoding_fallback_total",
+			Help:      "Dataway content encoding fallback count, partitioned by endpoint, original and fallback encoding",
+		},
+		[]string{"endpoint", "from", "to"},
+	)
+
+	bodySizeLimitGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "dataway_body_size_limit_bytes",
+			Help:      "Dataway max body size(before encoding) of each upload, adjusted under adaptive batching",
+		},
+	)
+
 	doRegister()
 }
@@ -26,7 +26,6 @@ func putWriter(w *writer) {
 	w.category = point.UnknownCategory
 	w.dynamicURL = ""
 	w.pts = w.pts[:0]
-	w.gzip = false
 	w.cacheClean = false
 	w.cacheAll = false
 	w.fc = nil
@@ -7,6 +7,7 @@ package dataway
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -22,6 +23,13 @@ type Sinker struct {
 	Proxy           string   `toml:"proxy" json:"proxy"`
 	TokenDeprecated string   `toml:"token,omitempty" json:"token,omitempty"`
 
+	// Percent(0~100) of matched points sinked, 0 means all.
+	Percent float64 `toml:"percent,omitempty" json:"percent,omitempty"`
+
+	// Mirror matched points to the sinker, these points still routed to
+	// other sinkers or default dataway.
+	Mirror bool `toml:"mirror,omitempty" json:"mirror,omitempty"`
+
 	conditions parser.WhereConditions
 	ep         *endPoint
 	cats       []point.Category
@@ -43,13 +51,22 @@ var sinkerAPIs = []point.Category{
 
 func (s *Sinker) String() string {
 	// TODO: make it more human readable.
-	return fmt.Sprintf("[categories: %s][filters: %s][URL: %s][proxy: %s]",
+	return fmt.Sprintf("[categories: %s][filters: %s][URL: %s][proxy: %s][percent: %v][mirror: %v]",
 		strings.Join(s.Categories, ","),
 		strings.Join(s.Filters, ","),
-		s.URL, s.Proxy,
+		s.URL, s.Proxy, s.Percent, s.Mirror,
 	)
 }
 
+// sampled check if a matched point selected on s.Percent.
+func (s *Sinker) sampled() bool {
+	if s.Percent <= 0 || s.Percent >= 100 {
+		return true
+	}
+
+	return rand.Float64()*100 < s.Percent //nolint:gosec
+}
+
 func (s *Sinker) expectedCategory(cat point.Category) bool {
 	for _, c := range s.cats {
 		if c == cat {
@@ -83,22 +100,20 @@ func (s *Sinker) sink(cat point.Category, pts []*dkpt.Point) (remainIndices []in
 
 	log.Debugf("category %q expected for my categories %v", cat, s.Categories)
 
-	// Unconditional: all points send to the sinker
-	if len(s.conditions) == 0 {
-		sinkPts = pts
-		goto __write
-	}
-
 	for i, pt := range pts {
-		fok, err = filter.CheckPointFiltered(s.conditions, cat, pt)
-		if err != nil {
-			log.Warnf("pt.Fields: %s, ignored", err.Error())
+		fok = true // Unconditional: all points send to the sinker
+
+		if len(s.conditions) > 0 {
+			fok, err = filter.CheckPointFiltered(s.conditions, cat, pt)
+			if err != nil {
+				log.Warnf("pt.Fields: %s, ignored", err.Error())
 
-			remainIndices = append(remainIndices, i) // filter failed point NOT sinked
-			continue
+				remainIndices = append(remainIndices, i) // filter failed point NOT sinked
+				continue
+			}
 		}
 
-		if fok {
+		if fok && s.sampled() {
 			sinkPts = append(sinkPts, pt)
 		} else {
 			remainIndices = append(remainIndices, i)
@@ -106,10 +121,9 @@ func (s *Sinker) sink(cat point.Category, pts []*dkpt.Point) (remainIndices []in
 	}
 
 	if len(sinkPts) == 0 {
-		return
+		return remainIndices, nil
 	}
 
-__write:
 	if err = s.write(cat, sinkPts); err != nil {
 		return
 	}
@@ -130,6 +144,10 @@ func (s *Sinker) write(category point.Category, pts []*dkpt.Point) error {
 }
 
 func (s *Sinker) Setup() error {
+	if s.Percent < 0 || s.Percent > 100 {
+		return fmt.Errorf("invalid percent %v, should be 0~100", s.Percent)
+	}
+
 	if err := s.setupFilters(); err != nil {
 		return err
 	}
@@ -6,10 +6,12 @@
 package dataway
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	T "testing"
 	"time"
 
@@ -184,3 +186,143 @@ test f1="1",f2="2" 123`), x)
 		assert.Lenf(t, remains, 1, "expect only 1 remains, got %+#v", remains)
 	})
 }
+
+func TestSinkPoints(t *T.T) {
+	// sinkServer count points received
+	sinkServer := func(t *T.T) (*httptest.Server, *int64) {
+		t.Helper()
+
+		var n int64
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			defer r.Body.Close() //nolint:errcheck
+
+			if isGzip(body) {
+				body, err = uhttp.Unzip(body)
+				assert.NoError(t, err)
+			}
+
+			atomic.AddInt64(&n, int64(len(bytes.Split(bytes.TrimSpace(body), []byte("\n")))))
+			w.WriteHeader(200)
+		}))
+
+		t.Cleanup(ts.Close)
+		return ts, &n
+	}
+
+	newPts := func() []*dkpt.Point {
+		return []*dkpt.Point{
+			dkpt.MustNewPoint("test", nil, map[string]any{"fa": "a"},
+				&dkpt.PointOption{Category: datakit.Logging, Time: time.Unix(0, 123)}),
+			dkpt.MustNewPoint("test", nil, map[string]any{"fb": "b"},
+				&dkpt.PointOption{Category: datakit.Logging, Time: time.Unix(0, 123)}),
+		}
+	}
+
+	t.Run("first-match", func(t *T.T) {
+		tsA, nA := sinkServer(t)
+		tsB, nB := sinkServer(t)
+
+		dw := &Dataway{Sinkers: []*Sinker{
+			{Categories: []string{"L"}, Filters: []string{"{fa='a'}"}, URL: tsA.URL},
+			{Categories: []string{"L"}, URL: tsB.URL},
+		}}
+
+		for _, s := range dw.Sinkers {
+			require.NoError(t, s.Setup())
+		}
+
+		remains := dw.sinkPoints(point.Logging, newPts())
+		assert.Len(t, remains, 0)
+		assert.Equal(t, int64(1), atomic.LoadInt64(nA))
+		assert.Equal(t, int64(1), atomic.LoadInt64(nB))
+	})
+
+	t.Run("fan-out", func(t *T.T) {
+		tsA, nA := sinkServer(t)
+		tsB, nB := sinkServer(t)
+
+		dw := &Dataway{SinkerFanOut: true, Sinkers: []*Sinker{
+			{Categories: []string{"L"}, Filters: []string{"{fa='a'}"}, URL: tsA.URL},
+			{Categories: []string{"L"}, URL: tsB.URL},
+		}}
+
+		for _, s := range dw.Sinkers {
+			require.NoError(t, s.Setup())
+		}
+
+		remains := dw.sinkPoints(point.Logging, newPts())
+		assert.Len(t, remains, 0)
+		assert.Equal(t, int64(1), atomic.LoadInt64(nA))
+		assert.Equal(t, int64(2), atomic.LoadInt64(nB))
+	})
+
+	t.Run("mirror", func(t *T.T) {
+		tsM, nM := sinkServer(t)
+		tsA, nA := sinkServer(t)
+
+		dw := &Dataway{Sinkers: []*Sinker{
+			{Categories: []string{"L"}, URL: tsM.URL, Mirror: true},
+			{Categories: []string{"L"}, Filters: []string{"{fa='a'}"}, URL: tsA.URL},
+		}}
+
+		for _, s := range dw.Sinkers {
+			require.NoError(t, s.Setup())
+		}
+
+		remains := dw.sinkPoints(point.Logging, newPts())
+		require.Len(t, remains, 1) // fb=b not sinked
+		assert.Equal(t, int64(2), atomic.LoadInt64(nM))
+		assert.Equal(t, int64(1), atomic.LoadInt64(nA))
+	})
+
+	t.Run("drop-unmatched", func(t *T.T) {
+		tsA, nA := sinkServer(t)
+
+		dw := &Dataway{SinkerDropUnmatched: true, Sinkers: []*Sinker{
+			{Categories: []string{"L"}, Filters: []string{"{fa='a'}"}, URL: tsA.URL},
+		}}
+
+		require.NoError(t, dw.Sinkers[0].Setup())
+
+		remains := dw.sinkPoints(point.Logging, newPts())
+		assert.Len(t, remains, 0)
+		assert.Equal(t, int64(1), atomic.LoadInt64(nA))
+
+		mfs := metrics.MustGather()
+		m := metrics.GetMetricOnLabels(mfs, "datakit_io_dataway_sink_point_total", point.Logging.String(), "dropped")
+		require.NotNil(t, m)
+		assert.Equal(t, float64(1), m.GetCounter().GetValue())
+
+		t.Cleanup(func() {
+			metricsReset()
+		})
+	})
+
+	t.Run("percent", func(t *T.T) {
+		tsA, nA := sinkServer(t)
+
+		dw := &Dataway{Sinkers: []*Sinker{
+			{Categories: []string{"L"}, URL: tsA.URL, Percent: 30},
+		}}
+
+		require.NoError(t, dw.Sinkers[0].Setup())
+
+		var pts []*dkpt.Point
+		for i := 0; i < 1000; i++ {
+			pts = append(pts, newPts()...)
+		}
+
+		remains := dw.sinkPoints(point.Logging, pts)
+		n := atomic.LoadInt64(nA)
+
+		assert.Equal(t, int64(len(pts)), n+int64(len(remains)))
+		assert.Truef(t, n > 400 && n < 800, "got %d sinked", n)
+	})
+
+	t.Run("invalid-percent", func(t *T.T) {
+		s := &Sinker{Categories: []string{"L"}, URL: "http://somewhere.com", Percent: 101}
+		assert.Error(t, s.Setup())
+	})
+}
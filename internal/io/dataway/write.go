@@ -58,18 +58,11 @@ func WithCacheClean(on bool) WriteOption {
 	}
 }
 
-func WithGzip(on bool) WriteOption {
-	return func(w *writer) {
-		w.gzip = on
-	}
-}
-
 type writer struct {
 	category   point.Category
 	dynamicURL string
 
 	pts                  []*dkpt.Point
-	gzip                 bool
 	isSinker             bool
 	cacheClean, cacheAll bool
 
@@ -94,17 +87,42 @@ func (dw *Dataway) cleanCache(w *writer, data []byte) error {
 
 	cat := point.Category(pd.Category)
 
-	WithGzip(isGzip(pd.Payload))(w) // check if bytes is gzipped
-	WithCategory(cat)(w)            // use category in cached data
+	WithCategory(cat)(w) // use category in cached data
 
-	for _, ep := range dw.eps {
-		// If some of endpoint send ok, any failed write will cause re-write on these ok ones.
-		// So, do NOT configure multiple endpoint in dataway URL list.
-		if err := ep.writePointData(
-			&body{buf: pd.Payload}, w); err != nil {
+	enc := pd.ContentEncoding
+	if enc == "" && isGzip(pd.Payload) { // cached by old version, gzip or not encoded
+		enc = EncodingGzip
+	}
+
+	b := &body{buf: pd.Payload, enc: enc}
+
+	if dw.balancing() {
+		// clean ok if any endpoint send ok
+		err := errNoHealthyEndpoint
+		for _, ep := range dw.pickEndpoints() {
+			if err = ep.sendBody(b, w); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
 			log.Warnf("cleanCache: %s", err)
 			return err
 		}
+	} else {
+		for _, ep := range dw.eps {
+			// If some of endpoint send ok, any failed write will cause re-write on these ok ones.
+			// So, do NOT configure multiple endpoint in dataway URL list.
+			if !ep.health.allow() {
+				log.Warnf("cleanCache: %s on %s", errCircuitOpen, ep.host)
+				return errCircuitOpen
+			}
+
+			if err := ep.sendBody(b, w); err != nil {
+				log.Warnf("cleanCache: %s", err)
+				return err
+			}
+		}
 	}
 
 	// only set metric on clean-ok
@@ -149,34 +167,89 @@ func (dw *Dataway) Write(opts ...WriteOption) error {
 	// sink points to multiple sinkers, after sinker, not-sinked points
 	// are passed to default dataway.
 	if len(dw.Sinkers) > 0 {
-		var remainPts []*dkpt.Point
-		for _, sinker := range dw.Sinkers {
-			log.Debugf("try sink %q to %s...", w.category, sinker)
+		w.pts = dw.sinkPoints(w.category, w.pts)
+		if len(w.pts) == 0 { // no point remaining
+			return nil
+		}
+	}
 
-			arr, err := sinker.sink(w.category, w.pts)
-			if err != nil {
-				log.Warnf("sink %d points to %q failed, remains %d, ignored", len(w.pts), w.category, len(arr))
-			}
+	if dw.balancing() {
+		return dw.writeBalanced(w)
+	}
 
-			for _, i := range arr {
-				remainPts = append(remainPts, w.pts[i])
+	// write points to all endpoints. Error returned if some of the points
+	// neither sent nor cached on any endpoint, so that the caller can keep
+	// them for later retry, though endpoints that accepted them may get them
+	// again on the retry.
+	var lastErr error
+	for _, ep := range dw.eps {
+		if err := ep.writePoints(w); err != nil {
+			log.Warnf("write %d points(%q) to %s: %s", len(w.pts), w.category, ep.host, err)
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// sinkPoints route pts to sinkers, points not sinked returned.
+func (dw *Dataway) sinkPoints(cat point.Category, pts []*dkpt.Point) []*dkpt.Point {
+	sinked := make([]bool, len(pts))
+
+	for _, sinker := range dw.Sinkers {
+		// Points already sinked are not routed to following sinkers,
+		// unless in fan-out mode or mirroring.
+		var (
+			indices []int
+			arr     []*dkpt.Point
+		)
+
+		for i, pt := range pts {
+			if !sinked[i] || dw.SinkerFanOut || sinker.Mirror {
+				indices = append(indices, i)
+				arr = append(arr, pt)
 			}
 		}
 
-		if len(remainPts) == 0 { // no point remaining
-			return nil
+		if len(arr) == 0 {
+			continue
+		}
+
+		log.Debugf("try sink %d points(%q) to %s...", len(arr), cat, sinker)
+
+		remains, err := sinker.sink(cat, arr)
+		if err != nil {
+			log.Warnf("sink %d points to %q failed, remains %d, ignored", len(arr), cat, len(remains))
+		}
+
+		if sinker.Mirror { // mirrored points still need routing
+			continue
 		}
 
-		// sending remaining points
-		w.pts = remainPts
+		// remains are in ascending order, points not within remains are sinked
+		j := 0
+		for k, i := range indices {
+			if j < len(remains) && remains[j] == k {
+				j++
+				continue
+			}
+
+			sinked[i] = true
+		}
 	}
 
-	// write points to multiple endpoints
-	for _, ep := range dw.eps {
-		if err := ep.writePoints(w); err != nil {
-			return err
+	var remainPts []*dkpt.Point
+	for i, pt := range pts {
+		if !sinked[i] {
+			remainPts = append(remainPts, pt)
 		}
 	}
 
-	return nil
+	if dw.SinkerDropUnmatched && len(remainPts) > 0 {
+		log.Debugf("drop %d unmatched points(%q)", len(remainPts), cat)
+		sinkPtsVec.WithLabelValues(cat.String(), "dropped").Add(float64(len(remainPts)))
+		return nil
+	}
+
+	return remainPts
 }
@@ -63,14 +63,14 @@ func TestWriteWithCache(t *T.T) {
 
 		pts := dkpt.RandPoints(100)
 
-		// write dialtesting on category logging
-		assert.NoError(t, dw.Write(
+		// write dialtesting on category logging, dynamic category not cached
+		assert.Error(t, dw.Write(
 			WithCategory(point.DynamicDWCategory),
 			WithFailCache(fc),
 			WithPoints(pts), WithDynamicURL(fmt.Sprintf("%s/v1/write/logging?token=tkn_for_dialtesting", ts.URL))))
 
-		// write metric
-		assert.NoError(t, dw.Write(WithCategory(point.Metric), WithPoints(pts)))
+		// write metric, no failcache
+		assert.Error(t, dw.Write(WithCategory(point.Metric), WithPoints(pts)))
 
 		// check cache content
 		assert.NoError(t, fc.Rotate()) // force rotate
@@ -17,6 +17,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/wal"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/offload"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plmap"
@@ -37,6 +38,8 @@ type iodata struct {
 	filtered int
 	opt      *Option
 	pts      []*dkpt.Point
+
+	walPos wal.Position // WAL position the data logged at, zero if not logged
 }
 
 // Option used to define various feed options.
@@ -156,8 +159,10 @@ func plAggFeed(name string, data any) error {
 		catStr,
 	).Add(float64(bf - len(pts)))
 
+	pts = defIO.limitPts(point.Metric, name, pts)
+
 	if defIO.fo != nil {
-		return defIO.fo.Write(&iodata{
+		return defIO.writeIOData(&iodata{
 			category: point.Metric,
 			pts:      pts,
 			filtered: 0,
@@ -235,13 +240,16 @@ func (x *dkIO) doFeed(pts []*dkpt.Point, category, from string, opt *Option) err
 		point.CatURL(category).String(), // /v1/write/metric -> metric
 	).Add(float64(filtered))
 
+	cat := point.CatURL(category)
+	after = x.limitPts(cat, from, after)
+
 	// Maybe all points been filtered, but we still send the feeding into io.
 	// We can still see some inputs/data are sending to io in monitor. Do not
 	// optimize the feeding, or we see nothing on monitor about these filtered
 	// points.
 	if x.fo != nil {
-		return x.fo.Write(&iodata{
-			category: point.CatURL(category),
+		return x.writeIOData(&iodata{
+			category: cat,
 			pts:      after,
 			filtered: filtered,
 			from:     from,
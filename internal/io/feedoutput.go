@@ -30,6 +30,16 @@ func (fo *datawayOutput) Reader(cat point.Category) <-chan *iodata {
 	return fo.chans[cat.String()]
 }
 
+// QueueUsage return the usage of the queue of category cat.
+func (fo *datawayOutput) QueueUsage(cat point.Category) float64 {
+	ch := fo.chans[cat.String()]
+	if cap(ch) == 0 {
+		return 0
+	}
+
+	return float64(len(ch)) / float64(cap(ch))
+}
+
 // WriteLastError send any error info into Prometheus metrics.
 func (fo *datawayOutput) WriteLastError(source, err string, cat ...point.Category) {
 	catStr := "unknown"
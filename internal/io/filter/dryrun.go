@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package filter
+
+import (
+	"fmt"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/parser"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+// DryRunResult is the filter result of a single point.
+type DryRunResult struct {
+	// Point is the line-protocol of the point.
+	Point string `json:"point"`
+
+	// Dropped is true if the point matched any condition.
+	Dropped bool `json:"dropped"`
+
+	// Condition is the first matched condition, and Index is its
+	// index within all the conditions(starts from 0, -1 if not matched).
+	Condition string `json:"condition,omitempty"`
+	Index     int    `json:"index"`
+
+	// Error is set if the point can't be checked against the conditions.
+	Error string `json:"error,omitempty"`
+}
+
+// DryRun checks pts against conds without dropping any point, and reports
+// which condition matched each point. Each condition within conds may
+// contain multiple where-conditions, they are indexed one by one.
+func DryRun(category point.Category, conds FilterConditions, pts []*dkpt.Point) ([]*DryRunResult, error) {
+	var all parser.WhereConditions
+	for i, v := range conds {
+		arr, err := parser.ParseConds(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition #%d %q: %w", i, v, err)
+		}
+
+		if len(arr) == 0 {
+			return nil, fmt.Errorf("invalid condition #%d %q: condition empty", i, v)
+		}
+
+		all = append(all, arr...)
+	}
+
+	res := make([]*DryRunResult, 0, len(pts))
+
+	for _, pt := range pts {
+		r := &DryRunResult{Point: pt.String(), Index: -1}
+		res = append(res, r)
+
+		data, err := newTFData(category, pt)
+		if err != nil {
+			r.Error = err.Error()
+			continue
+		}
+
+		if idx := matchedCond(all, data); idx >= 0 {
+			r.Dropped = true
+			r.Index = idx
+			r.Condition = all[idx].String()
+		}
+	}
+
+	return res, nil
+}
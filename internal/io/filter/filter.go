@@ -43,6 +43,8 @@ type filter struct {
 	puller IPuller
 	md5    string
 
+	stats *ruleStats
+
 	dumpDir string
 
 	// Mutex to R/W on rules: rules are updated(Write) from remote center, or
@@ -108,6 +110,18 @@ func filtered(conds parser.WhereConditions, data parser.KVs) bool {
 	return conds.Eval(data)
 }
 
+// matchedCond get the index of the first condition that data matched,
+// -1 returned if none matched.
+func matchedCond(conds parser.WhereConditions, data parser.KVs) int {
+	for i, c := range conds {
+		if (parser.WhereConditions{c}).Eval(data) {
+			return i
+		}
+	}
+
+	return -1
+}
+
 func (f *filter) doFilter(category point.Category, pts []*dkpt.Point) ([]*dkpt.Point, int) {
 	l.Debugf("doFilter: %+#v", f)
 
@@ -134,14 +148,21 @@ func (f *filter) doFilter(category point.Category, pts []*dkpt.Point) ([]*dkpt.P
 	}()
 
 	for _, pt := range pts {
-		isFiltered, err := CheckPointFiltered(conds, category, pt)
+		tfData, err := newTFData(category, pt)
 		if err != nil {
 			l.Errorf("pt.Fields: %s, ignored", err.Error())
 			continue // filter it!
 		}
-		if !isFiltered { // Pick those points that not matched filter rules.
+
+		idx := matchedCond(conds, tfData)
+		if idx < 0 { // Pick those points that not matched filter rules.
 			after = append(after, pt)
-		} else if datakit.LogSinkDetail {
+			continue
+		}
+
+		f.stats.hit(catStr, idx, pt, f.source)
+
+		if datakit.LogSinkDetail {
 			l.Infof("(sink_detail) filtered point: (%s) (%s)", category, pt.String())
 		}
 	}
@@ -185,6 +206,7 @@ func newFilter(p IPuller) *filter {
 		rawConditions: map[string]string{},
 
 		puller: p,
+		stats:  newRuleStats(),
 
 		mtx: &sync.RWMutex{},
 
@@ -7,15 +7,21 @@ package filter
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	lp "github.com/GuanceCloud/cliutils/lineproto"
+	"github.com/GuanceCloud/cliutils/metrics"
 	tu "github.com/GuanceCloud/cliutils/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/GuanceCloud/cliutils/point"
 
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/parser"
 	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
 )
 
@@ -134,3 +140,149 @@ func TestGetConds(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRun(t *testing.T) {
+	pts, err := lp.ParsePoints([]byte(`nginx,host=abc status="error" 123
+nginx,host=def status="info" 123
+redis,host=abc status="info" 123`), nil)
+	assert.NoError(t, err)
+
+	t.Run("basic", func(t *testing.T) {
+		res, err := DryRun(point.Logging, FilterConditions{
+			`{ source = "redis" }`,
+			`{ status = "error" }; { host = "def" }`,
+		}, dkpt.WrapPoint(pts))
+		assert.NoError(t, err)
+		assert.Len(t, res, 3)
+
+		assert.True(t, res[0].Dropped)
+		assert.Equal(t, 1, res[0].Index)
+
+		assert.True(t, res[1].Dropped)
+		assert.Equal(t, 2, res[1].Index)
+		assert.Equal(t, `{host = 'def'}`, res[1].Condition)
+
+		assert.True(t, res[2].Dropped)
+		assert.Equal(t, 0, res[2].Index)
+	})
+
+	t.Run("not-matched", func(t *testing.T) {
+		res, err := DryRun(point.Logging, FilterConditions{`{ source = "mysql" }`}, dkpt.WrapPoint(pts))
+		assert.NoError(t, err)
+
+		for _, r := range res {
+			assert.False(t, r.Dropped)
+			assert.Equal(t, -1, r.Index)
+		}
+	})
+
+	t.Run("invalid-condition", func(t *testing.T) {
+		_, err := DryRun(point.Logging, FilterConditions{`{ source = "redis" }`, `{ source = }`}, dkpt.WrapPoint(pts))
+		assert.Error(t, err)
+		t.Logf("expected error: %s", err)
+	})
+}
+
+func TestRuleStats(t *testing.T) {
+	t.Run("hits", func(t *testing.T) {
+		f := newFilter(&pullMock{})
+		f.dumpDir = t.TempDir()
+		f.pull("")
+
+		pts, err := lp.ParsePoints([]byte(`test1 f1="1" 123
+test2 f1="2" 124
+test2 f1="3" 125
+nginx f1="4" 125`), nil)
+		assert.NoError(t, err)
+
+		after, _ := f.doFilter(point.Logging, dkpt.WrapPoint(pts))
+		assert.Len(t, after, 1)
+
+		s := f.stats.stats()
+		assert.Len(t, s.Rules, 5)
+
+		hits := map[string]int64{}
+		for _, r := range s.Rules {
+			assert.Len(t, r.Hash, 8)
+			if r.Category == "logging" {
+				hits[r.Rule] = r.Hits
+			}
+		}
+
+		assert.Equal(t, int64(1), hits[`{source = 'test1' and (f1 in ['1', '2', '3'])}`], "hits: %+#v", hits)
+		assert.Equal(t, int64(2), hits[`{source = 'test2' and (f1 in ['1', '2', '3'])}`], "hits: %+#v", hits)
+
+		require.Len(t, s.Recent, 3)
+		assert.Contains(t, s.Recent[0].Point, `f1="3"`) // latest first
+
+		// hits kept on refresh
+		f.md5 = ""
+		f.pull("")
+		s = f.stats.stats()
+		var total int64
+		for _, r := range s.Rules {
+			total += r.Hits
+		}
+		assert.Equal(t, int64(3), total)
+	})
+
+	t.Run("ring", func(t *testing.T) {
+		rs := newRuleStats()
+		rs.reset(map[string]parser.WhereConditions{"logging": parser.GetConds(`{ source = "abc" }`)})
+
+		pt := func(i int) *dkpt.Point {
+			pts, err := lp.ParsePoints([]byte(fmt.Sprintf("pt-%d f=1i 123", i)), nil)
+			require.NoError(t, err)
+			return dkpt.WrapPoint(pts)[0]
+		}
+
+		for i := 0; i < maxRecentMatched+10; i++ {
+			rs.hit("logging", 0, pt(i), "remote")
+		}
+
+		rs.hit("logging", 1, pt(0), "remote") // ignored
+
+		s := rs.stats()
+		require.Len(t, s.Recent, maxRecentMatched)
+		assert.Equal(t, fmt.Sprintf("pt-%d f=1i 123", maxRecentMatched+9), s.Recent[0].Point)
+		assert.Equal(t, "pt-10 f=1i 123", s.Recent[maxRecentMatched-1].Point)
+		assert.Equal(t, int64(maxRecentMatched+10), s.Rules[0].Hits)
+		assert.Equal(t, s.Recent[0].Point, s.Rules[0].LastMatched)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		pts, err := lp.ParsePoints([]byte(`abc f="`+strings.Repeat("中", maxMatchedPointLen)+`" 123`), nil)
+		require.NoError(t, err)
+
+		x := matchedString(dkpt.WrapPoint(pts)[0])
+		assert.True(t, utf8.ValidString(x))
+		assert.True(t, strings.HasSuffix(x, "..."))
+		assert.True(t, len(x) <= maxMatchedPointLen+3)
+	})
+
+	t.Run("metrics-on-reset", func(t *testing.T) {
+		rs := newRuleStats()
+		conds := map[string]parser.WhereConditions{
+			"logging": append(parser.GetConds(`{ source = "reset-abc" }`), parser.GetConds(`{ source = "reset-def" }`)...),
+		}
+		rs.reset(conds)
+
+		pts, err := lp.ParsePoints([]byte(`abc f=1i 123`), nil)
+		require.NoError(t, err)
+
+		rs.hit("logging", 0, dkpt.WrapPoint(pts)[0], "remote")
+		rs.hit("logging", 1, dkpt.WrapPoint(pts)[0], "remote")
+
+		abc, def := rs.rules["logging"][0].Hash, rs.rules["logging"][1].Hash
+		m := metrics.GetMetricOnLabels(metrics.MustGather(), "datakit_filter_rule_hit_total", "logging", abc, "remote")
+		require.NotNil(t, m)
+		assert.Equal(t, 1.0, m.GetCounter().GetValue())
+
+		// rule abc removed
+		rs.reset(map[string]parser.WhereConditions{"logging": parser.GetConds(`{ source = "reset-def" }`)})
+
+		mfs := metrics.MustGather()
+		assert.Nil(t, metrics.GetMetricOnLabels(mfs, "datakit_filter_rule_hit_total", "logging", abc, "remote"))
+		assert.NotNil(t, metrics.GetMetricOnLabels(mfs, "datakit_filter_rule_hit_total", "logging", def, "remote"))
+	})
+}
@@ -12,6 +12,7 @@ import (
 
 var (
 	filterDroppedPtsVec,
+	filterRuleHitVec,
 	filterPtsVec *prometheus.CounterVec
 
 	filtersUpdateCount prometheus.Counter
@@ -64,6 +65,20 @@ func setupMetrics() {
 		},
 	)
 
+	filterRuleHitVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "filter",
+			Name:      "rule_hit_total",
+			Help:      "Dropped points of each single filter rule",
+		},
+		[]string{
+			"category",
+			"hash",
+			"source",
+		},
+	)
+
 	filterPullLatencyVec = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace: "datakit",
@@ -99,6 +114,7 @@ func setupMetrics() {
 
 	metrics.MustRegister(
 		filterDroppedPtsVec,
+		filterRuleHitVec,
 		filterPtsVec,
 		lastUpdate,
 		filterPullLatencyVec,
@@ -60,6 +60,8 @@ func (f *filter) refresh(body []byte) error {
 		f.rawConditions[k] = strings.Join(v, " ")
 	}
 
+	f.stats.reset(f.conditions)
+
 	if err := dump(body, f.dumpDir); err != nil {
 		l.Warnf("dump: %s, ignored", err)
 	}
@@ -0,0 +1,207 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package filter
+
+import (
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/parser"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+const (
+	// max matched points retained.
+	maxRecentMatched = 64
+
+	// matched point truncated on too long.
+	maxMatchedPointLen = 1024
+)
+
+// RuleStats is the hit stats of a single where-condition.
+type RuleStats struct {
+	Category string `json:"category"`
+	Hash     string `json:"hash"`
+	Rule     string `json:"rule"`
+
+	Hits        int64     `json:"hits"`
+	LastHit     time.Time `json:"last_hit"`
+	LastMatched string    `json:"last_matched,omitempty"`
+
+	lastMatched *dkpt.Point
+}
+
+// MatchedPoint is a point dropped by some rule.
+type MatchedPoint struct {
+	Time     time.Time `json:"time"`
+	Category string    `json:"category"`
+	Hash     string    `json:"hash"`
+	Point    string    `json:"point"`
+
+	pt *dkpt.Point
+}
+
+// Stats is the running stats of current filters.
+type Stats struct {
+	Source string `json:"source"`
+
+	// Rules sorted by category and position within the category.
+	Rules []*RuleStats `json:"rules"`
+
+	// Recent matched points, latest first.
+	Recent []*MatchedPoint `json:"recent"`
+}
+
+type ruleStats struct {
+	mtx sync.Mutex
+
+	// category -> rules in order of the where-conditions
+	rules map[string][]*RuleStats
+
+	recent []*MatchedPoint // ring buffer
+	next   int
+}
+
+func newRuleStats() *ruleStats {
+	return &ruleStats{
+		rules:  map[string][]*RuleStats{},
+		recent: make([]*MatchedPoint, 0, maxRecentMatched),
+	}
+}
+
+func ruleHash(rule string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(rule)))[:8] //nolint:gosec
+}
+
+// matchedString stringify the matched point, truncated on too long.
+func matchedString(pt *dkpt.Point) string {
+	if pt == nil {
+		return ""
+	}
+
+	s := pt.String()
+	if len(s) <= maxMatchedPointLen {
+		return s
+	}
+
+	n := maxMatchedPointLen
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + "..."
+}
+
+// reset rebuild rules on new conditions, hits of unchanged rules kept and
+// metrics of removed rules deleted.
+func (rs *ruleStats) reset(conds map[string]parser.WhereConditions) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+
+	old := map[string]*RuleStats{}
+	for _, arr := range rs.rules {
+		for _, r := range arr {
+			old[r.Category+"/"+r.Hash] = r
+		}
+	}
+
+	rs.rules = map[string][]*RuleStats{}
+	for cat, arr := range conds {
+		for _, c := range arr {
+			rule := c.String()
+			hash := ruleHash(rule)
+
+			if r, ok := old[cat+"/"+hash]; ok {
+				rs.rules[cat] = append(rs.rules[cat], r)
+				delete(old, cat+"/"+hash)
+			} else {
+				rs.rules[cat] = append(rs.rules[cat], &RuleStats{Category: cat, Hash: hash, Rule: rule})
+			}
+		}
+	}
+
+	for _, r := range old {
+		filterRuleHitVec.DeletePartialMatch(prometheus.Labels{"category": r.Category, "hash": r.Hash})
+	}
+}
+
+// hit record point pt matched the idx-th rule of category cat. The point
+// stringified only when stats fetched.
+func (rs *ruleStats) hit(cat string, idx int, pt *dkpt.Point, source string) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+
+	arr := rs.rules[cat]
+	if idx < 0 || idx >= len(arr) {
+		return
+	}
+
+	now := time.Now()
+
+	r := arr[idx]
+	r.Hits++
+	r.LastHit = now
+	r.lastMatched = pt
+
+	filterRuleHitVec.WithLabelValues(cat, r.Hash, source).Inc()
+
+	mp := &MatchedPoint{Time: now, Category: cat, Hash: r.Hash, pt: pt}
+	if len(rs.recent) < maxRecentMatched {
+		rs.recent = append(rs.recent, mp)
+	} else {
+		rs.recent[rs.next] = mp
+	}
+	rs.next = (rs.next + 1) % maxRecentMatched
+}
+
+func (rs *ruleStats) stats() *Stats {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+
+	s := &Stats{}
+
+	cats := make([]string, 0, len(rs.rules))
+	for k := range rs.rules {
+		cats = append(cats, k)
+	}
+	sort.Strings(cats)
+
+	for _, cat := range cats {
+		for _, r := range rs.rules[cat] {
+			x := *r
+			x.LastMatched = matchedString(r.lastMatched)
+			x.lastMatched = nil
+			s.Rules = append(s.Rules, &x)
+		}
+	}
+
+	// latest first
+	n := len(rs.recent)
+	for i := 1; i <= n; i++ {
+		x := *rs.recent[(rs.next-i+n)%n]
+		x.Point = matchedString(x.pt)
+		x.pt = nil
+		s.Recent = append(s.Recent, &x)
+	}
+
+	return s
+}
+
+// GetStats get running stats of current filters.
+func GetStats() *Stats {
+	if defaultFilter == nil {
+		return &Stats{}
+	}
+
+	s := defaultFilter.stats.stats()
+	s.Source = defaultFilter.source
+	return s
+}
@@ -8,7 +8,6 @@ package io
 
 import (
 	"context"
-	"os"
 	"runtime"
 	"sync"
 	"time"
@@ -19,7 +18,10 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/failcache"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/output"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/wal"
 	plscript "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -36,6 +38,7 @@ type dkIO struct {
 	//////////////////////////
 	dw      dataway.IDataway
 	filters map[string]filter.FilterConditions
+	outputs []*output.Output
 
 	cacheSizeGB        int
 	cacheCleanInterval time.Duration
@@ -48,25 +51,43 @@ type dkIO struct {
 	outputFile       string
 	outputFileInputs []string
 
+	outputFileMaxSizeMB,
+	outputFileMaxFiles int
+	outputFileRotateInterval time.Duration
+	outputFileCompress,
+	outputFilePerInput bool
+
 	flushInterval time.Duration
 	flushWorkers  int
 
 	maxCacheCount int
 
+	enableWAL,
+	walNoSync bool
+	walCapacityMB int
+
+	categoryLimiters map[point.Category]*rate.Limiter
+	inputLimiters    map[string]*rate.Limiter
+	feedPriority     []point.Category
+	shedWatermark    float64
+
 	//////////////////////////
 	// inner fields
 	//////////////////////////
 	// chans map[string]chan *iodata
 	fo FeederOutputer
 
-	fcs map[string]failcache.Cache
+	fcs  map[string]failcache.Cache
+	wals map[string]*wal.WAL
+
+	consumers sync.WaitGroup
 
 	lock sync.RWMutex
 
-	fd *os.File
+	outputFileWriters map[string]*rotateWriter
+	outputFileEvicted time.Time
 
-	droppedTotal   int64
-	outputFileSize int64
+	droppedTotal int64
 }
 
 func Start(opts ...IOOption) {
@@ -96,7 +117,18 @@ func getIO() *dkIO {
 		flushInterval: time.Second * 10,
 		maxCacheCount: 1024,
 
-		fcs: map[string]failcache.Cache{},
+		walCapacityMB: 1024,
+
+		categoryLimiters: map[point.Category]*rate.Limiter{},
+		inputLimiters:    map[string]*rate.Limiter{},
+		shedWatermark:    defaultShedWatermark,
+
+		outputFileMaxSizeMB: 32,
+		outputFileMaxFiles:  3,
+
+		fcs:               map[string]failcache.Cache{},
+		wals:              map[string]*wal.WAL{},
+		outputFileWriters: map[string]*rotateWriter{},
 
 		lock: sync.RWMutex{},
 	}
@@ -105,6 +137,12 @@ func getIO() *dkIO {
 }
 
 func (x *dkIO) start() {
+	if x.enableWAL {
+		x.openWALs()
+	}
+
+	x.setupOutputs()
+
 	if x.withFilter {
 		g.Go(func(_ context.Context) error {
 			if defIO.filters != nil {
@@ -123,7 +161,9 @@ func (x *dkIO) start() {
 		fn := func(cat point.Category, n int) {
 			log.Infof("start %d workers on %q", n, cat)
 			for i := 0; i < n; i++ {
+				x.consumers.Add(1)
 				g.Go(func(_ context.Context) error {
+					defer x.consumers.Done()
 					x.runConsumer(cat)
 					return nil
 				})
@@ -153,7 +193,21 @@ func (x *dkIO) start() {
 				flushWorkersVec.WithLabelValues(c.String()).Set(1)
 			}
 		}
+
+		// replay WAL after consumers started, or the replay may blocked.
+		x.replayWALs()
 	}
+
+	g.Go(func(_ context.Context) error {
+		<-datakit.Exit.Wait()
+
+		// no more flush after all consumers exited
+		x.consumers.Wait()
+		x.closeWALs()
+		x.closeOutputs()
+		x.closeOutputFiles()
+		return nil
+	})
 }
 
 func (x *dkIO) DroppedTotal() int64 {
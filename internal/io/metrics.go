@@ -18,6 +18,10 @@ var (
 	inputsFeedPtsVec,
 	errCountVec,
 	flushVec,
+	walRecordsVec,
+	walReplayPtsVec,
+	walDroppedBytesVec,
+	feedLimitedPtsVec,
 	inputsFilteredPtsVec *prometheus.CounterVec
 
 	feedCost       prometheus.Summary
@@ -30,6 +34,7 @@ var (
 	inputsLastFeedVec,
 	lastErrVec,
 	ioChanCap,
+	walSizeVec,
 	ioChanLen *prometheus.GaugeVec
 )
 
@@ -197,6 +202,68 @@ func metricsSetup() {
 		},
 	)
 
+	walRecordsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "wal_record_total",
+			Help:      "IO WAL appended records",
+		},
+		[]string{
+			"category",
+		},
+	)
+
+	walReplayPtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "wal_replay_point_total",
+			Help:      "IO WAL replayed points on startup",
+		},
+		[]string{
+			"category",
+		},
+	)
+
+	walDroppedBytesVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "wal_dropped_bytes_total",
+			Help:      "IO WAL dropped bytes on capacity exceeded",
+		},
+		[]string{
+			"category",
+		},
+	)
+
+	walSizeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "wal_size_bytes",
+			Help:      "IO WAL disk usage",
+		},
+		[]string{
+			"category",
+		},
+	)
+
+	feedLimitedPtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "feed_limited_point_total",
+			Help:      "IO feed dropped points on rate limit(reason `rate_limit') or priority shedding(reason `shed')",
+		},
+		[]string{
+			"name",
+			"category",
+			"reason",
+		},
+	)
+
 	// add more...
 }
 
@@ -222,6 +289,11 @@ func Metrics() []prometheus.Collector {
 		flushWorkersVec,
 		feedCost,
 		feedDropPoints,
+		walRecordsVec,
+		walReplayPtsVec,
+		walDroppedBytesVec,
+		walSizeVec,
+		feedLimitedPtsVec,
 	}
 }
 
@@ -240,6 +312,11 @@ func MetricsReset() {
 	ioChanLen.Reset()
 	flushVec.Reset()
 	flushWorkersVec.Reset()
+	walRecordsVec.Reset()
+	walReplayPtsVec.Reset()
+	walDroppedBytesVec.Reset()
+	walSizeVec.Reset()
+	feedLimitedPtsVec.Reset()
 }
 
 // A CollectorStatus used to describe a input's status.
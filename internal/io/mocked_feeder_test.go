@@ -6,6 +6,7 @@
 package io
 
 import (
+	"sync"
 	T "testing"
 	"time"
 
@@ -19,7 +20,12 @@ func TestNPoints(t *T.T) {
 	n := 10
 
 	t.Run("wait-forever", func(t *T.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		defer wg.Wait() // do not feed after test completed
+
 		go func() {
+			defer wg.Done()
 			pt, _ := point.NewPoint(t.Name(), nil, map[string]any{"abc": 123})
 			pts := []*point.Point{pt}
 
@@ -39,7 +45,12 @@ func TestNPoints(t *T.T) {
 	})
 
 	t.Run("wait-10ms-and-timeout", func(t *T.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		defer wg.Wait() // do not feed after test completed
+
 		go func() {
+			defer wg.Done()
 			pt, _ := point.NewPoint(t.Name(), nil, map[string]any{"abc": 123})
 			pts := []*point.Point{pt}
 
@@ -55,7 +66,12 @@ func TestNPoints(t *T.T) {
 	})
 
 	t.Run("wait-1s", func(t *T.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		defer wg.Wait() // do not feed after test completed
+
 		go func() {
+			defer wg.Done()
 			pt, _ := point.NewPoint(t.Name(), nil, map[string]any{"abc": 123})
 			pts := []*point.Point{pt}
 
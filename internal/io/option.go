@@ -6,8 +6,8 @@
 package io
 
 import (
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/diskcache"
@@ -15,6 +15,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/output"
 )
 
 // IOOption used to add various options to setup io module.
@@ -48,6 +49,13 @@ func WithDataway(dw dataway.IDataway) IOOption {
 	}
 }
 
+// WithOutputs used to add extra outputs besides dataway.
+func WithOutputs(outputs []*output.Output) IOOption {
+	return func(x *dkIO) {
+		x.outputs = append(x.outputs, outputs...)
+	}
+}
+
 // WithFilters used to setup point filter.
 func WithFilters(filters map[string]filter.FilterConditions) IOOption {
 	return func(x *dkIO) {
@@ -109,6 +117,94 @@ func WithDiskCache(on bool) IOOption {
 	}
 }
 
+// WithWAL used to enable WAL on feeding data. All feeding data are logged
+// to disk before queued, and replayed on next start if not flushed.
+func WithWAL(on bool) IOOption {
+	return func(x *dkIO) {
+		x.enableWAL = on
+	}
+}
+
+// WithWALCapacity set max WAL disk usage(in MB) of each category.
+func WithWALCapacity(mb int) IOOption {
+	return func(x *dkIO) {
+		if mb > 0 {
+			x.walCapacityMB = mb
+		}
+	}
+}
+
+// WithWALNoSync disable fsync on each WAL append. Data may lost on host
+// crash, but not on datakit crash.
+func WithWALNoSync(on bool) IOOption {
+	return func(x *dkIO) {
+		x.walNoSync = on
+	}
+}
+
+// WithCategoryRateLimits set max points per second fed into io of each
+// category, the key of limits is category name(such as logging/L).
+// Points exceeded are dropped.
+func WithCategoryRateLimits(limits map[string]float64) IOOption {
+	return func(x *dkIO) {
+		for k, v := range limits {
+			if v <= 0 {
+				continue
+			}
+
+			cat := point.CatAlias(k)
+			if cat == point.UnknownCategory {
+				cat = point.CatString(k)
+			}
+
+			if cat == point.UnknownCategory {
+				log.Warnf("invalid category %q on rate limit, ignored", k)
+				continue
+			}
+
+			x.categoryLimiters[cat] = newPtsLimiter(v)
+		}
+	}
+}
+
+// WithInputRateLimits set max points per second fed into io of each input.
+// Points exceeded are dropped.
+func WithInputRateLimits(limits map[string]float64) IOOption {
+	return func(x *dkIO) {
+		for k, v := range limits {
+			if v > 0 {
+				x.inputLimiters[k] = newPtsLimiter(v)
+			}
+		}
+	}
+}
+
+// WithFeedPriority set priority of categories, the higher priority in
+// front. If io queue usage reached the watermark(0.0~1.0), points of lower
+// priority categories are shed before they enter the queue.
+func WithFeedPriority(cats []string, watermark float64) IOOption {
+	return func(x *dkIO) {
+		for _, k := range cats {
+			k = strings.TrimSpace(k)
+			cat := point.CatAlias(k)
+			if cat == point.UnknownCategory {
+				cat = point.CatString(k)
+			}
+
+			if cat == point.UnknownCategory {
+				log.Warnf("invalid category %q on feed priority, ignored", k)
+				continue
+			}
+
+			x.feedPriority = append(x.feedPriority, cat)
+		}
+
+		if watermark > 0 && watermark < 1.0 {
+			x.shedWatermark = watermark
+		}
+	}
+}
+
 // WithOutputFile used to set a local file, the points will write
 // to the file(in the form line-protocol).
 func WithOutputFile(fpath string) IOOption {
@@ -117,14 +213,51 @@ func WithOutputFile(fpath string) IOOption {
 			return
 		}
 
-		x.outputFile = fpath
+		x.outputFile = filepath.Clean(fpath)
+	}
+}
+
+// WithOutputFileMaxSize set max size(in MB) of the output file, the file
+// rotated if exceeded. 0 means no limit.
+func WithOutputFileMaxSize(mb int) IOOption {
+	return func(x *dkIO) {
+		if mb >= 0 {
+			x.outputFileMaxSizeMB = mb
+		}
+	}
+}
+
+// WithOutputFileRotateInterval set the interval the output file rotated.
+// 0 means never rotate on time.
+func WithOutputFileRotateInterval(du time.Duration) IOOption {
+	return func(x *dkIO) {
+		if du >= 0 {
+			x.outputFileRotateInterval = du
+		}
+	}
+}
 
-		// if file open failed, ignored.
-		f, err := os.OpenFile(filepath.Clean(fpath), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
-		if err != nil {
-			log.Warnf("OpenFile: %s, ignored", err)
+// WithOutputFileMaxFiles set max rotated output files kept, old ones are
+// removed. 0 means keep all.
+func WithOutputFileMaxFiles(n int) IOOption {
+	return func(x *dkIO) {
+		if n >= 0 {
+			x.outputFileMaxFiles = n
 		}
-		x.fd = f
+	}
+}
+
+// WithOutputFileCompress enable gzip on rotated output files.
+func WithOutputFileCompress(on bool) IOOption {
+	return func(x *dkIO) {
+		x.outputFileCompress = on
+	}
+}
+
+// WithOutputFilePerInput write points of each input to its own file.
+func WithOutputFilePerInput(on bool) IOOption {
+	return func(x *dkIO) {
+		x.outputFilePerInput = on
 	}
 }
 
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/output"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+// setupOutputs setup all configured outputs, failed ones are removed.
+func (x *dkIO) setupOutputs() {
+	var okOutputs []*output.Output
+
+	for _, o := range x.outputs {
+		if err := o.Setup(); err != nil {
+			log.Warnf("output %s setup failed: %s", o, err)
+			continue
+		}
+
+		log.Infof("output %s setup ok", o)
+		okOutputs = append(okOutputs, o)
+	}
+
+	x.outputs = okOutputs
+}
+
+// closeOutputs close all outputs, buffered data within them flushed.
+func (x *dkIO) closeOutputs() {
+	for _, o := range x.outputs {
+		if err := o.Close(); err != nil {
+			log.Warnf("close output %s: %s, ignored", o, err)
+		}
+	}
+}
+
+// writeOutputs write pts to all outputs matched cat. If all matched outputs
+// skip dataway, skipDataway set to true. Only the last write error returned.
+func (x *dkIO) writeOutputs(cat point.Category, pts []*dkpt.Point) (skipDataway bool, err error) {
+	matched := 0
+	skipped := 0
+	for _, o := range x.outputs {
+		if !o.Match(cat) {
+			continue
+		}
+
+		matched++
+		if o.SkipDataway {
+			skipped++
+		}
+
+		if e := o.Write(cat, pts); e != nil {
+			err = e
+		}
+	}
+
+	return matched > 0 && matched == skipped, err
+}
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+// FileOutput write points into local file in line-protocol.
+type FileOutput struct {
+	Path string `toml:"path" json:"path"`
+}
+
+func (fo *FileOutput) setup() (Writer, error) {
+	if fo.Path == "" {
+		return nil, fmt.Errorf("file path not set")
+	}
+
+	path := filepath.Clean(fo.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileWriter{fd: fd}, nil
+}
+
+type fileWriter struct {
+	mtx sync.Mutex
+	fd  *os.File
+}
+
+func (fw *fileWriter) Write(cat point.Category, pts []*dkpt.Point) error {
+	var sb strings.Builder
+	for _, pt := range pts {
+		sb.WriteString(pt.String())
+		sb.WriteByte('\n')
+	}
+
+	fw.mtx.Lock()
+	defer fw.mtx.Unlock()
+
+	if fw.fd == nil {
+		return fmt.Errorf("file output closed")
+	}
+
+	_, err := fw.fd.WriteString(sb.String())
+	return err
+}
+
+func (fw *fileWriter) Close() error {
+	fw.mtx.Lock()
+	defer fw.mtx.Unlock()
+
+	if fw.fd == nil {
+		return nil
+	}
+
+	err := fw.fd.Close()
+	fw.fd = nil
+	return err
+}
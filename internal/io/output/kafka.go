@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/Shopify/sarama"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+// KafkaProducerConfig is the common settings of Kafka producers, shared by
+// Kafka output and pipeline offload receiver.
+type KafkaProducerConfig struct {
+	KafkaVersion string `toml:"kafka_version" json:"kafka_version"`
+	Compression  string `toml:"compression" json:"compression"` // none/gzip/snappy/lz4/zstd
+	Timeout      string `toml:"timeout" json:"timeout"`
+
+	SASLUser     string `toml:"sasl_user" json:"sasl_user"`
+	SASLPassword string `toml:"sasl_password" json:"-"`
+}
+
+// SaramaConfig build sync producer config with the client ID.
+func (kc *KafkaProducerConfig) SaramaConfig(clientID string) (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Successes = true // required by sync producer
+	cfg.Producer.RequiredAcks = sarama.WaitForLocal
+	cfg.ClientID = clientID
+
+	if kc.KafkaVersion != "" {
+		v, err := sarama.ParseKafkaVersion(kc.KafkaVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kafka_version %q: %w", kc.KafkaVersion, err)
+		}
+		cfg.Version = v
+	}
+
+	if kc.Compression != "" {
+		if err := cfg.Producer.Compression.UnmarshalText([]byte(kc.Compression)); err != nil {
+			return nil, err
+		}
+	}
+
+	if kc.Timeout != "" {
+		du, err := time.ParseDuration(kc.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", kc.Timeout, err)
+		}
+		cfg.Producer.Timeout = du
+		cfg.Net.DialTimeout = du
+	}
+
+	if kc.SASLUser != "" {
+		cfg.Net.SASL.Enable = true
+		cfg.Net.SASL.User = kc.SASLUser
+		cfg.Net.SASL.Password = kc.SASLPassword
+	}
+
+	return cfg, nil
+}
+
+// KafkaOutput write points into Kafka topic, each point(in line-protocol)
+// as a single message.
+type KafkaOutput struct {
+	Addrs []string `toml:"addrs" json:"addrs"`
+
+	// Topic of the messages, the placeholder `{category}` replaced with
+	// the category name(metric/logging/...). Default `datakit_{category}`.
+	Topic string `toml:"topic" json:"topic"`
+
+	KafkaProducerConfig
+}
+
+func (ko *KafkaOutput) setup() (Writer, error) {
+	if len(ko.Addrs) == 0 {
+		return nil, fmt.Errorf("kafka addrs not set")
+	}
+
+	cfg, err := ko.SaramaConfig("datakit")
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := sarama.NewSyncProducer(ko.Addrs, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewSyncProducer: %w", err)
+	}
+
+	return newKafkaWriter(ko.Topic, p), nil
+}
+
+type kafkaWriter struct {
+	topic    string
+	producer sarama.SyncProducer
+}
+
+func newKafkaWriter(topic string, p sarama.SyncProducer) *kafkaWriter {
+	if topic == "" {
+		topic = "datakit_{category}"
+	}
+
+	return &kafkaWriter{topic: topic, producer: p}
+}
+
+func (kw *kafkaWriter) topicOf(cat point.Category) string {
+	return strings.ReplaceAll(kw.topic, "{category}", cat.String())
+}
+
+func (kw *kafkaWriter) Write(cat point.Category, pts []*dkpt.Point) error {
+	topic := kw.topicOf(cat)
+
+	msgs := make([]*sarama.ProducerMessage, 0, len(pts))
+	for _, pt := range pts {
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: topic,
+			Key:   sarama.StringEncoder(pt.Name()),
+			Value: sarama.StringEncoder(pt.String()),
+		})
+	}
+
+	return kw.producer.SendMessages(msgs)
+}
+
+func (kw *kafkaWriter) Close() error {
+	return kw.producer.Close()
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package output
+
+import (
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	writePtsVec,
+	writeErrVec *prometheus.CounterVec
+
+	writeCostVec *prometheus.SummaryVec
+)
+
+// Metrics get all metrics about outputs.
+func Metrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		writePtsVec,
+		writeErrVec,
+		writeCostVec,
+	}
+}
+
+func metricsReset() {
+	writePtsVec.Reset()
+	writeErrVec.Reset()
+	writeCostVec.Reset()
+}
+
+// nolint:gochecknoinits
+func init() {
+	writePtsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "output_point_total",
+			Help:      "Points written to extra outputs",
+		},
+		[]string{"output", "category"},
+	)
+
+	writeErrVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "output_error_total",
+			Help:      "Write errors on extra outputs",
+		},
+		[]string{"output", "category"},
+	)
+
+	writeCostVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: "datakit",
+			Subsystem: "io",
+			Name:      "output_cost_seconds",
+			Help:      "Write cost of extra outputs",
+		},
+		[]string{"output", "category"},
+	)
+
+	metrics.MustRegister(Metrics()...)
+}
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package output
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	collectormetrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/collector/metrics/v1"
+	common "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/common/v1"
+	metrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/metrics/v1"
+	resource "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/resource/v1"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// OTLPOutput export points to OTLP/gRPC collector. Only metric category
+// supported: each numeric field of a point exported as a gauge named
+// `<measurement>_<field>`, with point tags as attributes.
+type OTLPOutput struct {
+	Endpoint string            `toml:"endpoint" json:"endpoint"` // host:port of the collector
+	Headers  map[string]string `toml:"headers" json:"headers"`
+	Timeout  string            `toml:"timeout" json:"timeout"`
+}
+
+func (oo *OTLPOutput) setup() (Writer, error) {
+	if oo.Endpoint == "" {
+		return nil, fmt.Errorf("otlp endpoint not set")
+	}
+
+	timeout := 30 * time.Second
+	if oo.Timeout != "" {
+		du, err := time.ParseDuration(oo.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", oo.Timeout, err)
+		}
+		timeout = du
+	}
+
+	conn, err := grpc.Dial(oo.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("grpc.Dial(%s): %w", oo.Endpoint, err)
+	}
+
+	return &otlpWriter{
+		conn:    conn,
+		cli:     collectormetrics.NewMetricsServiceClient(conn),
+		headers: oo.Headers,
+		timeout: timeout,
+	}, nil
+}
+
+type otlpWriter struct {
+	conn    *grpc.ClientConn
+	cli     collectormetrics.MetricsServiceClient
+	headers map[string]string
+	timeout time.Duration
+}
+
+func (ow *otlpWriter) Write(cat point.Category, pts []*dkpt.Point) error {
+	if cat != point.Metric && cat != point.MetricDeprecated {
+		return fmt.Errorf("category %q not supported by otlp output", cat)
+	}
+
+	req := &collectormetrics.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metrics.ResourceMetrics{
+			{
+				Resource: &resource.Resource{
+					Attributes: []*common.KeyValue{
+						strAttr("service.name", "datakit"),
+						strAttr("service.version", datakit.Version),
+					},
+				},
+				ScopeMetrics: []*metrics.ScopeMetrics{
+					{
+						Scope:   &common.InstrumentationScope{Name: "datakit"},
+						Metrics: pts2OTLPMetrics(pts),
+					},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ow.timeout)
+	defer cancel()
+
+	if len(ow.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(ow.headers))
+	}
+
+	_, err := ow.cli.Export(ctx, req)
+	return err
+}
+
+func (ow *otlpWriter) Close() error {
+	return ow.conn.Close()
+}
+
+func strAttr(k, v string) *common.KeyValue {
+	return &common.KeyValue{
+		Key:   k,
+		Value: &common.AnyValue{Value: &common.AnyValue_StringValue{StringValue: v}},
+	}
+}
+
+func pts2OTLPMetrics(pts []*dkpt.Point) (res []*metrics.Metric) {
+	for _, pt := range pts {
+		fields, err := pt.Fields()
+		if err != nil {
+			continue
+		}
+
+		tags := pt.Tags()
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		attrs := make([]*common.KeyValue, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, strAttr(k, tags[k]))
+		}
+
+		ts := uint64(pt.Time().UnixNano())
+
+		fkeys := make([]string, 0, len(fields))
+		for k := range fields {
+			fkeys = append(fkeys, k)
+		}
+		sort.Strings(fkeys)
+
+		for _, k := range fkeys {
+			dp := &metrics.NumberDataPoint{
+				Attributes:   attrs,
+				TimeUnixNano: ts,
+			}
+
+			switch v := fields[k].(type) {
+			case int64:
+				dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: v}
+			case uint64:
+				dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: int64(v)}
+			case float64:
+				dp.Value = &metrics.NumberDataPoint_AsDouble{AsDouble: v}
+			case bool:
+				if v {
+					dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: 1}
+				} else {
+					dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: 0}
+				}
+			default: // string fields ignored
+				continue
+			}
+
+			res = append(res, &metrics.Metric{
+				Name: pt.Name() + "_" + k,
+				Data: &metrics.Metric_Gauge{
+					Gauge: &metrics.Gauge{DataPoints: []*metrics.NumberDataPoint{dp}},
+				},
+			})
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package output implements extra backends(Kafka, OTLP and local files)
+// that io flushed points written to, besides Dataway.
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/GuanceCloud/cliutils/point"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+const (
+	TypeKafka = "kafka"
+	TypeOTLP  = "otlp"
+	TypeFile  = "file"
+)
+
+var log = logger.DefaultSLogger("io-output")
+
+// Writer is the backend of an output.
+type Writer interface {
+	Write(cat point.Category, pts []*dkpt.Point) error
+	Close() error
+}
+
+// Output is a configured output backend, selected by categories.
+type Output struct {
+	Type       string   `toml:"type" json:"type"`
+	Categories []string `toml:"categories" json:"categories"`
+
+	// If all matched outputs of a category skip dataway, points of that
+	// category are not sent to Dataway.
+	SkipDataway bool `toml:"skip_dataway" json:"skip_dataway"`
+
+	Kafka *KafkaOutput `toml:"kafka,omitempty" json:"kafka,omitempty"`
+	OTLP  *OTLPOutput  `toml:"otlp,omitempty" json:"otlp,omitempty"`
+	File  *FileOutput  `toml:"file,omitempty" json:"file,omitempty"`
+
+	w    Writer
+	cats []point.Category
+}
+
+func (o *Output) String() string {
+	return fmt.Sprintf("[type: %s][categories: %s][skip_dataway: %v]",
+		o.Type, strings.Join(o.Categories, ","), o.SkipDataway)
+}
+
+// Setup create the backend writer of the output.
+func (o *Output) Setup() error {
+	log = logger.SLogger("io-output")
+
+	if len(o.Categories) == 0 {
+		return fmt.Errorf("no category configured on output %s", o.Type)
+	}
+
+	for _, c := range o.Categories {
+		cat := point.CatAlias(c)
+		if cat == point.UnknownCategory {
+			cat = point.CatString(c)
+		}
+
+		if cat == point.UnknownCategory {
+			return fmt.Errorf("invalid category %q on output %s", c, o.Type)
+		}
+
+		o.cats = append(o.cats, cat)
+	}
+
+	var err error
+	switch o.Type {
+	case TypeKafka:
+		if o.Kafka == nil {
+			return fmt.Errorf("kafka output not configured")
+		}
+		o.w, err = o.Kafka.setup()
+
+	case TypeOTLP:
+		if o.OTLP == nil {
+			return fmt.Errorf("otlp output not configured")
+		}
+
+		for _, c := range o.cats {
+			if c != point.Metric && c != point.MetricDeprecated {
+				return fmt.Errorf("category %q not supported by otlp output", c)
+			}
+		}
+
+		o.w, err = o.OTLP.setup()
+
+	case TypeFile:
+		if o.File == nil {
+			return fmt.Errorf("file output not configured")
+		}
+		o.w, err = o.File.setup()
+
+	default:
+		return fmt.Errorf("unknown output type %q", o.Type)
+	}
+
+	return err
+}
+
+// Match test if points of cat should write to the output.
+func (o *Output) Match(cat point.Category) bool {
+	for _, c := range o.cats {
+		if c == cat {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write write pts of cat to the output backend.
+func (o *Output) Write(cat point.Category, pts []*dkpt.Point) error {
+	if o.w == nil {
+		return fmt.Errorf("output %s not setup", o.Type)
+	}
+
+	if len(pts) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := o.w.Write(cat, pts)
+
+	writeCostVec.WithLabelValues(o.Type, cat.String()).Observe(float64(time.Since(start)) / float64(time.Second))
+
+	if err != nil {
+		writeErrVec.WithLabelValues(o.Type, cat.String()).Inc()
+		return fmt.Errorf("output %s: %w", o.Type, err)
+	}
+
+	writePtsVec.WithLabelValues(o.Type, cat.String()).Add(float64(len(pts)))
+	return nil
+}
+
+// Close close the output backend.
+func (o *Output) Close() error {
+	if o.w == nil {
+		return nil
+	}
+
+	return o.w.Close()
+}
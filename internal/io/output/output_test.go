@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	metrics "github.com/GuanceCloud/tracing-protos/opentelemetry-gen-go/metrics/v1"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+func TestSetup(t *T.T) {
+	cases := []struct {
+		name string
+		o    *Output
+		fail bool
+	}{
+		{
+			name: "no-category",
+			o:    &Output{Type: TypeFile, File: &FileOutput{Path: "abc"}},
+			fail: true,
+		},
+
+		{
+			name: "invalid-category",
+			o:    &Output{Type: TypeFile, Categories: []string{"no-such-category"}, File: &FileOutput{Path: "abc"}},
+			fail: true,
+		},
+
+		{
+			name: "unknown-type",
+			o:    &Output{Type: "no-such-type", Categories: []string{"logging"}},
+			fail: true,
+		},
+
+		{
+			name: "otlp-on-logging",
+			o:    &Output{Type: TypeOTLP, Categories: []string{"logging"}, OTLP: &OTLPOutput{Endpoint: "localhost:4317"}},
+			fail: true,
+		},
+
+		{
+			name: "file",
+			o: &Output{
+				Type:       TypeFile,
+				Categories: []string{"logging", "M"},
+				File:       &FileOutput{Path: filepath.Join(t.TempDir(), "out.lp")},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *T.T) {
+			err := tc.o.Setup()
+			if tc.fail {
+				assert.Error(t, err)
+				t.Logf("expected error: %s", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tc.o.Match(point.Logging))
+			assert.True(t, tc.o.Match(point.Metric))
+			assert.False(t, tc.o.Match(point.Object))
+			assert.NoError(t, tc.o.Close())
+		})
+	}
+}
+
+func TestFileOutput(t *T.T) {
+	f := filepath.Join(t.TempDir(), "out.lp")
+
+	o := &Output{Type: TypeFile, Categories: []string{"logging"}, File: &FileOutput{Path: f}}
+	require.NoError(t, o.Setup())
+
+	pts := dkpt.RandPoints(3)
+	require.NoError(t, o.Write(point.Logging, pts))
+	require.NoError(t, o.Close())
+
+	data, err := os.ReadFile(f)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"))
+
+	// no more write after closed
+	assert.Error(t, o.Write(point.Logging, pts))
+}
+
+func TestKafkaWriter(t *T.T) {
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Successes = true
+
+	p := mocks.NewSyncProducer(t, cfg)
+
+	pts := dkpt.RandPoints(3)
+	for range pts {
+		p.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			assert.Equal(t, "datakit_logging", msg.Topic)
+			return nil
+		})
+	}
+
+	w := newKafkaWriter("", p)
+	assert.NoError(t, w.Write(point.Logging, pts))
+	assert.NoError(t, w.Close())
+}
+
+func TestOTLPMetrics(t *T.T) {
+	pt, err := dkpt.NewPoint("cpu",
+		map[string]string{"host": "abc"},
+		map[string]any{"usage": 1.5, "cores": 4, "ok": true, "info": "some string"},
+		nil)
+	require.NoError(t, err)
+
+	arr := pts2OTLPMetrics([]*dkpt.Point{pt})
+	require.Len(t, arr, 3) // string field ignored
+
+	names := []string{}
+	for _, m := range arr {
+		names = append(names, m.Name)
+		g, ok := m.Data.(*metrics.Metric_Gauge)
+		require.True(t, ok)
+		require.Len(t, g.Gauge.DataPoints, 1)
+		assert.Equal(t, "host", g.Gauge.DataPoints[0].Attributes[0].Key)
+	}
+
+	assert.Equal(t, []string{"cpu_cores", "cpu_ok", "cpu_usage"}, names)
+}
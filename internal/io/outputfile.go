@@ -6,10 +6,14 @@
 package io
 
 import (
-	"fmt"
-	"io"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// per-input writers without write for the duration are closed and removed.
+var outputFileIdleTimeout = 10 * time.Minute
+
 func (x *dkIO) matchOutputFileInput(feedName string) bool {
 	if len(x.outputFileInputs) == 0 { // no inputs configure, all inputs matched
 		return true
@@ -23,35 +27,104 @@ func (x *dkIO) matchOutputFileInput(feedName string) bool {
 	return false
 }
 
+// outputFilePath get the file path that points from input written to.
+// If per-input enabled, the input name inserted before the file
+// extension, i.e., for input cpu, /path/to/out.lp => /path/to/out.cpu.lp.
+func (x *dkIO) outputFilePath(from string) string {
+	if !x.outputFilePerInput {
+		return x.outputFile
+	}
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, from)
+
+	ext := filepath.Ext(x.outputFile)
+	return strings.TrimSuffix(x.outputFile, ext) + "." + name + ext
+}
+
+// outputFileWriter get (or create) the rotate writer of the input.
+// Caller should hold x.lock.
+func (x *dkIO) outputFileWriter(from string) *rotateWriter {
+	key := ""
+	if x.outputFilePerInput {
+		key = from
+	}
+
+	if w, ok := x.outputFileWriters[key]; ok {
+		return w
+	}
+
+	w := &rotateWriter{
+		path:     x.outputFilePath(from),
+		maxSize:  int64(x.outputFileMaxSizeMB) * 1024 * 1024,
+		interval: x.outputFileRotateInterval,
+		maxFiles: x.outputFileMaxFiles,
+		compress: x.outputFileCompress,
+	}
+
+	x.outputFileWriters[key] = w
+	return w
+}
+
 func (x *dkIO) fileOutput(d *iodata) error {
 	// concurrent write
 	x.lock.Lock()
 	defer x.lock.Unlock()
 
-	if n, err := x.fd.WriteString("# " + d.from + " > " + d.category.String() + "\n"); err != nil {
-		return err
-	} else {
-		x.outputFileSize += int64(n)
-	}
+	var sb strings.Builder
 
+	sb.WriteString("# " + d.from + " > " + d.category.String() + "\n")
 	for _, pt := range d.pts {
-		if n, err := x.fd.WriteString(pt.String() + "\n"); err != nil {
-			return err
-		} else {
-			x.outputFileSize += int64(n)
-			if x.outputFileSize > 32*1024*1024 { // truncate file on 32MB
-				if err := x.fd.Truncate(0); err != nil {
-					return fmt.Errorf("truncate error: %w", err)
-				}
-
-				if _, err := x.fd.Seek(0, io.SeekStart); err != nil {
-					return fmt.Errorf("seek error: %w", err)
-				}
-
-				x.outputFileSize = 0
-			}
+		sb.WriteString(pt.String() + "\n")
+	}
+
+	// all points of the iodata written in one shot, so a rotation
+	// never splits them into different files.
+	_, err := x.outputFileWriter(d.from).WriteString(sb.String())
+
+	x.evictOutputFileWriters()
+	return err
+}
+
+// evictOutputFileWriters close and remove idle per-input writers, so that
+// files of inputs no longer running are not kept open. Caller should hold
+// x.lock.
+func (x *dkIO) evictOutputFileWriters() {
+	if !x.outputFilePerInput || time.Since(x.outputFileEvicted) < outputFileIdleTimeout/10 {
+		return
+	}
+
+	x.outputFileEvicted = time.Now()
+
+	for k, w := range x.outputFileWriters {
+		if !w.idle(outputFileIdleTimeout) {
+			continue
+		}
+
+		if err := w.Close(); err != nil {
+			log.Warnf("close output file %s: %s, ignored", w.path, err)
 		}
+
+		delete(x.outputFileWriters, k)
 	}
+}
+
+// closeOutputFiles close all output file writers.
+func (x *dkIO) closeOutputFiles() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	for k, w := range x.outputFileWriters {
+		if err := w.Close(); err != nil {
+			log.Warnf("close output file %s: %s, ignored", w.path, err)
+		}
 
-	return nil
+		delete(x.outputFileWriters, k)
+	}
 }
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+func TestFileOutput(t *T.T) {
+	t.Run("per-input", func(t *T.T) {
+		dir := t.TempDir()
+
+		x := getIO()
+		WithOutputFile(filepath.Join(dir, "out.lp"))(x)
+		WithOutputFilePerInput(true)(x)
+
+		assert.Equal(t, filepath.Join(dir, "out.cpu.lp"), x.outputFilePath("cpu"))
+		assert.Equal(t, filepath.Join(dir, "out.logging_some_log.lp"), x.outputFilePath("logging/some.log"))
+
+		require.NoError(t, x.fileOutput(&iodata{category: point.Metric, from: "cpu", pts: dkpt.RandPoints(3)}))
+		require.NoError(t, x.fileOutput(&iodata{category: point.Metric, from: "mem", pts: dkpt.RandPoints(3)}))
+
+		for _, f := range []string{"out.cpu.lp", "out.mem.lp"} {
+			data, err := os.ReadFile(filepath.Join(dir, f))
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			assert.Len(t, lines, 4) // with header line
+		}
+
+		_, err := os.Stat(filepath.Join(dir, "out.lp"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("rotate", func(t *T.T) {
+		dir := t.TempDir()
+
+		x := getIO()
+		WithOutputFile(filepath.Join(dir, "out.lp"))(x)
+		WithOutputFileMaxSize(1)(x)
+		WithOutputFileMaxFiles(2)(x)
+
+		pt, err := dkpt.NewPoint("abc", nil, map[string]any{"message": strings.Repeat("x", 1000)}, dkpt.LOpt())
+		require.NoError(t, err)
+
+		d := &iodata{category: point.Logging, from: "abc", pts: []*dkpt.Point{pt}}
+		for i := 0; i < 3500; i++ { // about 3.5MB
+			require.NoError(t, x.fileOutput(d))
+		}
+
+		w := x.outputFileWriters[""]
+		require.NotNil(t, w)
+		require.NoError(t, w.Close())
+
+		arr, err := w.Rotated()
+		require.NoError(t, err)
+		assert.Len(t, arr, 2)
+
+		fi, err := os.Stat(filepath.Join(dir, "out.lp"))
+		require.NoError(t, err)
+		assert.True(t, fi.Size() <= 1024*1024)
+	})
+
+	t.Run("evict-and-close", func(t *T.T) {
+		defer func(du time.Duration) { outputFileIdleTimeout = du }(outputFileIdleTimeout)
+		outputFileIdleTimeout = 10 * time.Millisecond
+
+		dir := t.TempDir()
+
+		x := getIO()
+		WithOutputFile(filepath.Join(dir, "out.lp"))(x)
+		WithOutputFilePerInput(true)(x)
+
+		require.NoError(t, x.fileOutput(&iodata{category: point.Metric, from: "cpu", pts: dkpt.RandPoints(1)}))
+		time.Sleep(20 * time.Millisecond)
+
+		// writer of cpu idle, evicted
+		require.NoError(t, x.fileOutput(&iodata{category: point.Metric, from: "mem", pts: dkpt.RandPoints(1)}))
+		assert.Len(t, x.outputFileWriters, 1)
+		assert.NotNil(t, x.outputFileWriters["mem"])
+
+		// reopened on later write
+		require.NoError(t, x.fileOutput(&iodata{category: point.Metric, from: "cpu", pts: dkpt.RandPoints(1)}))
+		assert.Len(t, x.outputFileWriters, 2)
+
+		x.closeOutputFiles()
+		assert.Len(t, x.outputFileWriters, 0)
+
+		data, err := os.ReadFile(filepath.Join(dir, "out.cpu.lp"))
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 4) // 2 headers and 2 points
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
 	"strings"
@@ -661,6 +662,29 @@ func (e *Regex) String() string      { return fmt.Sprintf("re('%s')", e.Regex) }
 func (e *Regex) Pos() *PositionRange { return nil } // TODO
 func (e *Regex) DQLExpr()            {}             // not used
 
+// Wildcard is a glob pattern, `*' match any characters and `?' match
+// single character.
+type Wildcard struct {
+	Pattern string `json:"pattern,omitempty"`
+	Re      *regexp.Regexp
+}
+
+func (e *Wildcard) Type() ValueType     { return "" /* TODO */ }
+func (e *Wildcard) String() string      { return fmt.Sprintf("'%s'", e.Pattern) }
+func (e *Wildcard) Pos() *PositionRange { return nil } // TODO
+func (e *Wildcard) DQLExpr()            {}             // not used
+
+// CIDR is an IP network, such as 10.0.0.0/8.
+type CIDR struct {
+	Val string `json:"val,omitempty"`
+	Net *net.IPNet
+}
+
+func (e *CIDR) Type() ValueType     { return "" /* TODO */ }
+func (e *CIDR) String() string      { return fmt.Sprintf("'%s'", e.Val) }
+func (e *CIDR) Pos() *PositionRange { return nil } // TODO
+func (e *CIDR) DQLExpr()            {}             // not used
+
 type StringLiteral struct {
 	Val string `json:"val,omitempty"`
 }
@@ -702,6 +726,23 @@ func (e *BinaryExpr) String() string {
 
 func (e *BinaryExpr) DQLExpr() {} // not used
 
+// ExistsExpr test if the field exist(or not exist if Not set).
+type ExistsExpr struct {
+	Field Node `json:"field,omitempty"`
+	Not   bool `json:"not,omitempty"`
+}
+
+func (e *ExistsExpr) Type() ValueType     { return "" }  // TODO
+func (e *ExistsExpr) Pos() *PositionRange { return nil } // TODO
+func (e *ExistsExpr) String() string {
+	if e.Not {
+		return fmt.Sprintf("%s(%s)", ItemType(NOT_EXISTS).String(), e.Field.String())
+	}
+	return fmt.Sprintf("%s(%s)", ItemType(EXISTS).String(), e.Field.String())
+}
+
+func (e *ExistsExpr) DQLExpr() {} // not used
+
 type ParenExpr struct {
 	Param Node `json:"paren"`
 }
@@ -866,13 +907,13 @@ type WhereCondition struct {
 func (x *WhereCondition) Eval(data KVs) bool {
 	for _, c := range x.conditions {
 		switch expr := c.(type) {
-		case *BinaryExpr:
+		case Evaluable:
 			if !expr.Eval(data) {
 				return false
 			}
 
 		default:
-			log.Errorf("Eval only accept BinaryExpr")
+			log.Errorf("Eval only accept BinaryExpr, ParenExpr or ExistsExpr")
 			return false
 		}
 	}
@@ -7,6 +7,7 @@ package parser
 
 import (
 	"math"
+	"net"
 	"reflect"
 	"regexp"
 	"strings"
@@ -89,7 +90,10 @@ func (e *BinaryExpr) Eval(data KVs) bool {
 
 func (e *BinaryExpr) doEval(data KVs) bool {
 	switch e.Op {
-	case GTE, GT, LT, LTE, NEQ, EQ, IN, NOT_IN, MATCH, NOT_MATCH:
+	case GTE, GT, LT, LTE, NEQ, EQ, IN, NOT_IN, MATCH, NOT_MATCH,
+		IIN, NOT_IIN, IN_CIDR, NOT_IN_CIDR,
+		WILDCARD, NOT_WILDCARD, IWILDCARD, NOT_IWILDCARD,
+		BETWEEN, NOT_BETWEEN:
 	default:
 		log.Errorf("unsupported OP %s", e.Op.String())
 		return false
@@ -304,7 +308,7 @@ func (e *BinaryExpr) singleEval(data KVs) bool {
 				} else {
 					arr = append(arr, x.Float)
 				}
-			case *Regex:
+			case *Regex, *CIDR, *Wildcard:
 				arr = append(arr, x)
 			default:
 				log.Warnf("unsupported node list with type `%s'", reflect.TypeOf(elem).String())
@@ -361,6 +365,55 @@ func (e *BinaryExpr) singleEval(data KVs) bool {
 
 			return true
 
+		case IIN, NOT_IIN:
+			v, ok := data.Get(name)
+			if !ok {
+				return e.Op == NOT_IIN
+			}
+
+			for _, item := range arr {
+				if eqFold(v, item) {
+					return e.Op == IIN
+				}
+			}
+			return e.Op == NOT_IIN
+
+		case IN_CIDR, NOT_IN_CIDR:
+			v, ok := data.Get(name)
+			if !ok {
+				return e.Op == NOT_IN_CIDR
+			}
+
+			for _, item := range arr {
+				if inCIDR(v, item) {
+					return e.Op == IN_CIDR
+				}
+			}
+			return e.Op == NOT_IN_CIDR
+
+		case WILDCARD, NOT_WILDCARD, IWILDCARD, NOT_IWILDCARD:
+			matched := e.Op == WILDCARD || e.Op == IWILDCARD
+
+			v, ok := data.Get(name)
+			if !ok {
+				return !matched
+			}
+
+			for _, item := range arr {
+				if wildcardMatch(v, item) {
+					return matched
+				}
+			}
+			return !matched
+
+		case BETWEEN, NOT_BETWEEN:
+			v, ok := data.Get(name)
+			if !ok || len(arr) != 2 {
+				return e.Op == NOT_BETWEEN
+			}
+
+			return between(v, arr[0], arr[1]) == (e.Op == BETWEEN)
+
 		case GTE, GT, LT, LTE, NEQ, EQ:
 
 			if v, ok := data.Get(name); ok {
@@ -375,3 +428,94 @@ func (e *BinaryExpr) singleEval(data KVs) bool {
 	}
 	return false
 }
+
+// eqFold test if lhs equal to rhs, strings compared case-insensitively.
+func eqFold(lhs, rhs any) bool {
+	if l, ok := lhs.(string); ok {
+		if r, ok := rhs.(string); ok {
+			return strings.EqualFold(l, r)
+		}
+	}
+
+	return binEval(EQ, lhs, rhs)
+}
+
+// inCIDR test if lhs is an IP within network rhs.
+func inCIDR(lhs, rhs any) bool {
+	s, ok := lhs.(string)
+	if !ok {
+		return false
+	}
+
+	cidr, ok := rhs.(*CIDR)
+	if !ok {
+		return false
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+
+	return cidr.Net.Contains(ip)
+}
+
+// wildcardMatch test if lhs match the wildcard rhs.
+func wildcardMatch(lhs, rhs any) bool {
+	s, ok := lhs.(string)
+	if !ok {
+		return false
+	}
+
+	w, ok := rhs.(*Wildcard)
+	if !ok {
+		return false
+	}
+
+	return w.Re.MatchString(s)
+}
+
+// toNumber convert any int/uint/float into float64.
+func toNumber(x any) (float64, bool) {
+	switch v := x.(type) {
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32:
+		return float64(toInt64(v)), true
+	case uint64:
+		return float64(v), true
+	case float32, float64:
+		return toFloat64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// between test if min <= x <= max. Numbers of different types are
+// compared as float, and strings are compared lexically.
+func between(x, min, max any) bool {
+	if s, ok := x.(string); ok {
+		smin, ok1 := min.(string)
+		smax, ok2 := max.(string)
+		return ok1 && ok2 && s >= smin && s <= smax
+	}
+
+	f, ok := toNumber(x)
+	if !ok {
+		return false
+	}
+
+	fmin, ok1 := toNumber(min)
+	fmax, ok2 := toNumber(max)
+	return ok1 && ok2 && f >= fmin && f <= fmax
+}
+
+func (e *ExistsExpr) Eval(data KVs) bool {
+	switch x := e.Field.(type) {
+	case *Identifier:
+		_, ok := data.Get(x.Name)
+		return ok != e.Not
+	default:
+		log.Errorf("unknown field type, expect Identifier, got `%s'", reflect.TypeOf(e.Field).String())
+		return false
+	}
+}
@@ -111,6 +111,114 @@ func TestExprConditions(t *testing.T) {
 			pass:   false,
 		},
 
+		{
+			in:   "{ source = 'nginx' and client_ip IN_CIDR ['10.0.0.0/8', '192.168.1.1'] }",
+			tags: map[string]string{"source": "nginx", "client_ip": "10.1.2.3"},
+			pass: true,
+		},
+
+		{
+			in:   "{ client_ip in_cidr ['10.0.0.0/8', '192.168.1.1'] }",
+			tags: map[string]string{"client_ip": "192.168.1.2"},
+			pass: false,
+		},
+
+		{
+			in:   "{ client_ip in_cidr ['10.0.0.0/8', 'fd00::/8'] }",
+			tags: map[string]string{"client_ip": "fd00::1"},
+			pass: true,
+		},
+
+		{
+			in:   "{ client_ip in_cidr ['10.0.0.0/8'] }",
+			tags: map[string]string{"client_ip": "not-ip"},
+			pass: false,
+		},
+
+		{
+			in:   "{ client_ip notin_cidr ['10.0.0.0/8'] }",
+			tags: map[string]string{"client_ip": "11.0.0.1"},
+			pass: true,
+		},
+
+		{
+			in:   "{ host wildcard ['web-*', 'db-??'] }",
+			tags: map[string]string{"host": "db-01"},
+			pass: true,
+		},
+
+		{
+			in:   "{ host wildcard ['web-*', 'db-??'] }",
+			tags: map[string]string{"host": "DB-01"},
+			pass: false,
+		},
+
+		{
+			in:   "{ host iwildcard ['web-*', 'db-??'] }",
+			tags: map[string]string{"host": "DB-01"},
+			pass: true,
+		},
+
+		{
+			in:   "{ host notwildcard ['web-*'] }",
+			tags: map[string]string{"host": "web.abc"},
+			pass: true,
+		},
+
+		{
+			in:   "{ host notiwildcard ['web-*'] }",
+			tags: map[string]string{"host": "WEB-abc"},
+			pass: false,
+		},
+
+		{
+			in:   "{ source iin ['nginx', 'mysql'] }",
+			tags: map[string]string{"source": "NGINX"},
+			pass: true,
+		},
+
+		{
+			in:   "{ source notiin ['nginx', 'mysql'] }",
+			tags: map[string]string{"source": "MySQL"},
+			pass: false,
+		},
+
+		{
+			in:     "{ status between [200, 299] }",
+			fields: map[string]interface{}{"status": int64(299)},
+			pass:   true,
+		},
+
+		{
+			in:     "{ latency between [0.5, 1] }", // compare float with int
+			fields: map[string]interface{}{"latency": int64(1)},
+			pass:   true,
+		},
+
+		{
+			in:     "{ status notbetween [200, 299] }",
+			fields: map[string]interface{}{"status": int64(500)},
+			pass:   true,
+		},
+
+		{
+			in:   "{ version between ['1.0', '1.9'] }",
+			tags: map[string]string{"version": "1.2.3"},
+			pass: true,
+		},
+
+		{
+			in:     "{ exists(status) and notexists(error) }",
+			fields: map[string]interface{}{"status": int64(200)},
+			pass:   true,
+		},
+
+		{
+			in:     "{ exists(status), exists(error) }",
+			fields: map[string]interface{}{"status": int64(200)},
+			pass:   false,
+		},
+
 		// {
 		// 	in:     "{host in [re(`mongo_.*`), re(`nginx_.*`), reg(`mysql_.*`)]}",
 		// 	fields: map[string]interface{}{"host": "123abc"},
@@ -37,6 +37,10 @@ import (
 %token <item>
 AS ASC AUTO BY
 MATCH NOT_MATCH
+IN_CIDR NOT_IN_CIDR
+WILDCARD NOT_WILDCARD IWILDCARD NOT_IWILDCARD
+IIN NOT_IIN BETWEEN NOT_BETWEEN
+EXISTS NOT_EXISTS
 DESC TRUE FALSE FILTER
 IDENTIFIER IN NOT_IN AND LINK LIMIT SLIMIT
 OR NIL NULL OFFSET SOFFSET
@@ -73,6 +77,7 @@ ORDER RE INT FLOAT POINT TIMEZONE WITH
 	naming_arg
 	paren_expr
 	filter_elem
+	exists_expr
 	regex
 	columnref
 	bool_literal
@@ -126,7 +131,7 @@ stmts: where_conditions
 		 ;
 
 /* expression */
-expr: array_elem | regex | paren_expr | function_expr | binary_expr | cascade_functions
+expr: array_elem | regex | paren_expr | function_expr | binary_expr | cascade_functions | exists_expr
 		;
 
 columnref: identifier
@@ -299,9 +304,19 @@ filter_list: filter_elem
 					 { $$ = nil }
 					 ;
 
-filter_elem: binary_expr | paren_expr
+filter_elem: binary_expr | paren_expr | exists_expr
 					;
 
+exists_expr: EXISTS LEFT_PAREN columnref RIGHT_PAREN
+					 {
+						 $$ = &ExistsExpr{Field: $3}
+					 }
+					 | NOT_EXISTS LEFT_PAREN columnref RIGHT_PAREN
+					 {
+						 $$ = &ExistsExpr{Field: $3, Not: true}
+					 }
+					 ;
+
 binary_expr: expr ADD expr
 					 {
 					   $$ = yylex.(*parser).newBinExpr($1, $3, $2)
@@ -402,6 +417,66 @@ binary_expr: expr ADD expr
 						 bexpr.ReturnBool = true
 						 $$ = bexpr
 					 }
+					 | columnref IN_CIDR LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref NOT_IN_CIDR LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref WILDCARD LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref NOT_WILDCARD LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref IWILDCARD LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref NOT_IWILDCARD LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref IIN LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref NOT_IIN LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref BETWEEN LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
+					 | columnref NOT_BETWEEN LEFT_BRACKET array_list RIGHT_BRACKET
+					 {
+						 bexpr := yylex.(*parser).newBinExpr($1, $4, $2)
+						 bexpr.ReturnBool = true
+						 $$ = bexpr
+					 }
 					 ;
 
 /* function names */
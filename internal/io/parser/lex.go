@@ -77,8 +77,24 @@ var (
 		"filter":     FILTER,
 		"identifier": IDENTIFIER,
 
-		"in":    IN,
-		"notin": NOT_IN,
+		"in":     IN,
+		"notin":  NOT_IN,
+		"iin":    IIN,
+		"notiin": NOT_IIN,
+
+		"in_cidr":    IN_CIDR,
+		"notin_cidr": NOT_IN_CIDR,
+
+		"wildcard":     WILDCARD,
+		"notwildcard":  NOT_WILDCARD,
+		"iwildcard":    IWILDCARD,
+		"notiwildcard": NOT_IWILDCARD,
+
+		"between":    BETWEEN,
+		"notbetween": NOT_BETWEEN,
+
+		"exists":    EXISTS,
+		"notexists": NOT_EXISTS,
 
 		"limit":   LIMIT,
 		"link":    LINK,
@@ -8,6 +8,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"regexp"
@@ -44,9 +45,19 @@ type parser struct {
 }
 
 func GetConds(input string) WhereConditions {
+	conds, err := ParseConds(input)
+	if err != nil {
+		log.Error(err.Error())
+		return nil
+	}
+
+	return conds
+}
+
+// ParseConds parse input into where conditions, any parse error returned.
+func ParseConds(input string) (res WhereConditions, err error) {
 	log.Debugf("parse %s", input)
 
-	var err error
 	p := newParser(input)
 	defer parserPool.Put(p)
 	defer p.recover(&err)
@@ -54,12 +65,15 @@ func GetConds(input string) WhereConditions {
 	p.doParse()
 
 	if len(p.errs) > 0 {
-		log.Error(p.errs.Error())
+		return nil, p.errs
+	}
 
-		return nil
+	conds, ok := p.parseResult.(WhereConditions)
+	if !ok {
+		return nil, fmt.Errorf("invalid conditions: %q", input)
 	}
 
-	return p.parseResult.(WhereConditions)
+	return conds, nil
 }
 
 func newParser(input string) *parser {
@@ -283,11 +297,139 @@ func (p *parser) newBinExpr(l, r Node, op Item) *BinaryExpr {
 			p.addParseErrf(p.yyParser.lval.item.PositionRange(),
 				"invalid type in CONTAIN/NOT_CONTAIN list: %s(%s)", reflect.TypeOf(l).String(), l.String())
 		}
+
+	case IN_CIDR, NOT_IN_CIDR: // convert rhs into CIDR list
+		var cidrArr NodeList
+		for _, elem := range p.stringList(r, op) {
+			if x := p.newCIDR(elem); x != nil {
+				cidrArr = append(cidrArr, x)
+			}
+		}
+		return &BinaryExpr{LHS: l, RHS: cidrArr, Op: op.Typ}
+
+	case WILDCARD, NOT_WILDCARD, IWILDCARD, NOT_IWILDCARD: // convert rhs into wildcard list
+		var wildcardArr NodeList
+		for _, elem := range p.stringList(r, op) {
+			if x := p.newWildcard(elem, op.Typ == IWILDCARD || op.Typ == NOT_IWILDCARD); x != nil {
+				wildcardArr = append(wildcardArr, x)
+			}
+		}
+		return &BinaryExpr{LHS: l, RHS: wildcardArr, Op: op.Typ}
+
+	case BETWEEN, NOT_BETWEEN: // rhs should be [min, max]
+		nl, ok := r.(NodeList)
+		if !ok || len(nl) != 2 {
+			p.addParseErrf(p.yyParser.lval.item.PositionRange(),
+				"%s expect list of 2 elements: [min, max]", op.Typ.String())
+			return &BinaryExpr{LHS: l, RHS: NodeList{}, Op: op.Typ}
+		}
+
+		_, minNum := nl[0].(*NumberLiteral)
+		_, maxNum := nl[1].(*NumberLiteral)
+		_, minStr := nl[0].(*StringLiteral)
+		_, maxStr := nl[1].(*StringLiteral)
+		if !(minNum && maxNum) && !(minStr && maxStr) {
+			p.addParseErrf(p.yyParser.lval.item.PositionRange(),
+				"%s expect both numbers or both strings on min/max, got %s", op.Typ.String(), nl.String())
+		}
 	}
 
 	return &BinaryExpr{RHS: r, LHS: l, Op: op.Typ}
 }
 
+// stringList get all strings within node list n, other element type ignored.
+func (p *parser) stringList(n Node, op Item) (res []string) {
+	nl, ok := n.(NodeList)
+	if !ok {
+		p.addParseErrf(p.yyParser.lval.item.PositionRange(),
+			"invalid type in %s list: %s(%s)", op.Typ.String(), reflect.TypeOf(n).String(), n.String())
+		return nil
+	}
+
+	for _, elem := range nl {
+		switch x := elem.(type) {
+		case *StringLiteral:
+			res = append(res, x.Val)
+		default:
+			p.addParseErrf(p.yyParser.lval.item.PositionRange(),
+				"invalid element type in %s list: %s", op.Typ.String(), reflect.TypeOf(elem).String())
+		}
+	}
+
+	return res
+}
+
+func doNewCIDR(s string) (*CIDR, error) {
+	if !strings.Contains(s, "/") { // single IP
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP")
+		}
+
+		if ip.To4() != nil {
+			s += "/32"
+		} else {
+			s += "/128"
+		}
+	}
+
+	_, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CIDR{Val: s, Net: ipnet}, nil
+}
+
+func (p *parser) newCIDR(s string) *CIDR {
+	if x, err := doNewCIDR(s); err != nil {
+		p.addParseWarnf(p.yyParser.lval.item.PositionRange(),
+			"invalid CIDR: %s: %s, ignored", err.Error(), s)
+		return nil
+	} else {
+		return x
+	}
+}
+
+// doNewWildcard convert the glob pattern into regexp.
+func doNewWildcard(s string, ignoreCase bool) (*Wildcard, error) {
+	var sb strings.Builder
+
+	if ignoreCase {
+		sb.WriteString("(?i)")
+	}
+
+	sb.WriteString("^")
+	for _, c := range s {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Wildcard{Pattern: s, Re: re}, nil
+}
+
+func (p *parser) newWildcard(s string, ignoreCase bool) *Wildcard {
+	if x, err := doNewWildcard(s, ignoreCase); err != nil {
+		p.addParseWarnf(p.yyParser.lval.item.PositionRange(),
+			"invalid wildcard: %s: %s, ignored", err.Error(), s)
+		return nil
+	} else {
+		return x
+	}
+}
+
 func (p *parser) newFunc(fname string, args []Node) *FuncExpr {
 	agg := &FuncExpr{
 		Name:  strings.ToLower(fname),
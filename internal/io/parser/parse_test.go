@@ -281,6 +281,95 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			in: `{ source = 'nginx' and client_ip IN_CIDR ['10.0.0.0/8', '192.168.1.1', 'not-ip'] }`,
+			expected: WhereConditions{
+				&WhereCondition{
+					conditions: []Node{
+						&BinaryExpr{
+							Op: AND,
+							LHS: &BinaryExpr{
+								Op:  EQ,
+								LHS: &Identifier{Name: "source"},
+								RHS: &StringLiteral{Val: "nginx"},
+							},
+							RHS: &BinaryExpr{
+								Op:  IN_CIDR,
+								LHS: &Identifier{Name: "client_ip"},
+								RHS: NodeList{
+									&CIDR{Val: "10.0.0.0/8"},
+									&CIDR{Val: "192.168.1.1/32"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			in: `{ host iwildcard ['web-*', 'db-??'], exists(status), notexists(error) }`,
+			expected: WhereConditions{
+				&WhereCondition{
+					conditions: []Node{
+						&BinaryExpr{
+							Op:  IWILDCARD,
+							LHS: &Identifier{Name: "host"},
+							RHS: NodeList{
+								&Wildcard{Pattern: "web-*"},
+								&Wildcard{Pattern: "db-??"},
+							},
+						},
+						&ExistsExpr{Field: &Identifier{Name: "status"}},
+						&ExistsExpr{Field: &Identifier{Name: "error"}, Not: true},
+					},
+				},
+			},
+		},
+
+		{
+			in: `{ status between [200, 299] or source iin ['Nginx'] }`,
+			expected: WhereConditions{
+				&WhereCondition{
+					conditions: []Node{
+						&BinaryExpr{
+							Op: OR,
+							LHS: &BinaryExpr{
+								Op:  BETWEEN,
+								LHS: &Identifier{Name: "status"},
+								RHS: NodeList{
+									&NumberLiteral{IsInt: true, Int: 200},
+									&NumberLiteral{IsInt: true, Int: 299},
+								},
+							},
+							RHS: &BinaryExpr{
+								Op:  IIN,
+								LHS: &Identifier{Name: "source"},
+								RHS: NodeList{
+									&StringLiteral{Val: "Nginx"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			in:   `{ status between [200] }`,
+			fail: true,
+		},
+
+		{
+			in:   `{ status between [200, 'abc'] }`,
+			fail: true,
+		},
+
+		{
+			in:   `{ ip in_cidr [123] }`,
+			fail: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -342,3 +431,22 @@ func TestNewRegex(t *testing.T) {
 		t.Log(err)
 	}
 }
+
+func TestNewCIDR(t *testing.T) {
+	x, err := doNewCIDR("2001:db8::1")
+	tu.Ok(t, err)
+	tu.Equals(t, "2001:db8::1/128", x.Val)
+
+	_, err = doNewCIDR("10.0.0.0/33")
+	tu.Assert(t, err != nil, "expect error")
+}
+
+func TestNewWildcard(t *testing.T) {
+	x, err := doNewWildcard("a.b*c?", false)
+	tu.Ok(t, err)
+	tu.Equals(t, `^a\.b.*c.$`, x.Re.String())
+
+	x, err = doNewWildcard("abc", true)
+	tu.Ok(t, err)
+	tu.Equals(t, `(?i)^abc$`, x.Re.String())
+}
@@ -27,18 +27,23 @@ func (x *dkIO) cacheData(c *consumer, d *iodata, tryClean bool) {
 
 	log.Debugf("get iodata(%d points) from %s|%s", len(d.pts), d.category, d.from)
 
-	if x.fd != nil && x.matchOutputFileInput(d.from) {
-		log.Debugf("write %d(%s) points to %s", len(d.pts), d.from, x.outputFile)
+	if x.outputFile != "" && x.matchOutputFileInput(d.from) {
+		log.Debugf("write %d(%s) points to %s", len(d.pts), d.from, x.outputFilePath(d.from))
 
 		if err := x.fileOutput(d); err != nil {
 			log.Errorf("fileOutput: %s", err)
 		}
 
+		x.walAck(d.category, d.walPos)
+
 		// do not send data to remote.
 		return
 	}
 
 	c.pts = append(c.pts, d.pts...)
+	if d.walPos.Segment > 0 {
+		c.walAcks = append(c.walAcks, d.walPos)
+	}
 
 	if tryClean &&
 		x.maxCacheCount > 0 &&
@@ -58,9 +63,15 @@ func (x *dkIO) flush(c *consumer) {
 	}()
 
 	if err := x.doFlush(c.pts, c.category, c.fc); err != nil {
+		// On error, the points are neither sent nor cached in failcache,
+		// keep them not-acked in WAL and they will be replayed on next start.
 		log.Warnf("post %d points to %s failed: %s, ignored", len(c.pts), c.category, err)
+	} else {
+		x.walAck(c.category, c.walAcks...)
 	}
 
+	c.walAcks = c.walAcks[:0]
+
 	c.pts = c.pts[:0] // clear
 }
 
@@ -78,14 +89,25 @@ func (x *dkIO) flushFailCache(c *consumer) {
 }
 
 func (x *dkIO) doFlush(pts []*dkpt.Point, cat point.Category, fc failcache.Cache, dynamicURL ...string) error {
-	if x.dw == nil {
-		return fmt.Errorf("dataway not set")
-	}
-
 	if len(pts) == 0 {
 		return nil
 	}
 
+	if len(dynamicURL) == 0 {
+		skipDataway, err := x.writeOutputs(cat, pts)
+		if skipDataway {
+			return err
+		}
+
+		if err != nil {
+			log.Warnf("%s, ignored", err)
+		}
+	}
+
+	if x.dw == nil {
+		return fmt.Errorf("dataway not set")
+	}
+
 	opts := []dataway.WriteOption{
 		dataway.WithPoints(pts),
 		dataway.WithCategory(cat),
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"golang.org/x/time/rate"
+)
+
+const (
+	limitReasonRate = "rate_limit"
+	limitReasonShed = "shed"
+
+	defaultShedWatermark = 0.8
+)
+
+// queueUsager is a feeder output that can report its queue usage.
+type queueUsager interface {
+	// QueueUsage return the usage(0.0~1.0) of the queue of category cat.
+	QueueUsage(cat point.Category) float64
+}
+
+// newPtsLimiter create token bucket limiter on n points per second.
+func newPtsLimiter(n float64) *rate.Limiter {
+	burst := int(n)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(n), burst)
+}
+
+// allowPts get the number of points allowed by all the limiters, the
+// leading n points kept and the rest dropped. Tokens are consumed only if
+// all of the limiters allowed.
+func allowPts(now time.Time, n int, limiters ...*rate.Limiter) int {
+	for _, l := range limiters {
+		if tokens := int(l.TokensAt(now)); tokens < n {
+			n = tokens
+		}
+	}
+
+	if n <= 0 {
+		return 0
+	}
+
+	rs := make([]*rate.Reservation, 0, len(limiters))
+	for _, l := range limiters {
+		r := l.ReserveN(now, n)
+		if r.DelayFrom(now) > 0 { // tokens taken by others meanwhile
+			r.CancelAt(now)
+			for _, x := range rs {
+				x.CancelAt(now)
+			}
+			return 0
+		}
+
+		rs = append(rs, r)
+	}
+
+	return n
+}
+
+// shedThreshold get the queue usage on which points of cat shed.
+//
+// Categories not in priority list shed first, on usage reached the
+// watermark. Then listed categories shed one by one from the tail of
+// the list as usage increasing, the head of the list never shed.
+func (x *dkIO) shedThreshold(cat point.Category) float64 {
+	n := len(x.feedPriority)
+
+	rank := n
+	for i, c := range x.feedPriority {
+		if c == cat {
+			rank = i
+			break
+		}
+	}
+
+	return x.shedWatermark + (1.0-x.shedWatermark)*float64(n-rank)/float64(n)
+}
+
+// limitPts apply priority shedding and rate limits on pts fed from input
+// `from', the remaining points returned.
+func (x *dkIO) limitPts(cat point.Category, from string, pts []*dkpt.Point) []*dkpt.Point {
+	if len(pts) == 0 {
+		return pts
+	}
+
+	if len(x.feedPriority) > 0 {
+		if qu, ok := x.fo.(queueUsager); ok {
+			if th := x.shedThreshold(cat); th < 1.0 && qu.QueueUsage(cat) >= th {
+				feedLimitedPtsVec.WithLabelValues(from, cat.String(), limitReasonShed).Add(float64(len(pts)))
+				log.Debugf("queue usage over %.2f, %d points(%s/%s) shed", th, len(pts), from, cat)
+				return nil
+			}
+		}
+	}
+
+	var limiters []*rate.Limiter
+	if l, ok := x.inputLimiters[from]; ok {
+		limiters = append(limiters, l)
+	}
+
+	if l, ok := x.categoryLimiters[cat]; ok {
+		limiters = append(limiters, l)
+	}
+
+	n := len(pts)
+	if len(limiters) > 0 {
+		n = allowPts(time.Now(), n, limiters...)
+	}
+
+	if n < len(pts) {
+		feedLimitedPtsVec.WithLabelValues(from, cat.String(), limitReasonRate).Add(float64(len(pts) - n))
+		log.Debugf("%d points(%s/%s) dropped on rate limit", len(pts)-n, from, cat)
+		return pts[:n]
+	}
+
+	return pts
+}
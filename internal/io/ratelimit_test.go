@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	T "testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+type usageOutput struct {
+	FeederOutputer
+	usage float64
+}
+
+func (o *usageOutput) QueueUsage(point.Category) float64 {
+	return o.usage
+}
+
+func TestLimitPts(t *T.T) {
+	t.Run("category-limit", func(t *T.T) {
+		x := getIO()
+		WithCategoryRateLimits(map[string]float64{"L": 10, "no-such-category": 1})(x)
+		require.Len(t, x.categoryLimiters, 1)
+
+		pts := x.limitPts(point.Logging, "abc", dkpt.RandPoints(15))
+		assert.Len(t, pts, 10)
+
+		// no token left
+		pts = x.limitPts(point.Logging, "abc", dkpt.RandPoints(15))
+		assert.Len(t, pts, 0)
+
+		// other categories not limited
+		pts = x.limitPts(point.Metric, "abc", dkpt.RandPoints(15))
+		assert.Len(t, pts, 15)
+	})
+
+	t.Run("input-limit", func(t *T.T) {
+		x := getIO()
+		WithInputRateLimits(map[string]float64{"abc": 5})(x)
+		WithCategoryRateLimits(map[string]float64{"logging": 8})(x)
+
+		pts := x.limitPts(point.Logging, "abc", dkpt.RandPoints(10))
+		assert.Len(t, pts, 5)
+
+		pts = x.limitPts(point.Logging, "def", dkpt.RandPoints(10))
+		assert.Len(t, pts, 3) // 5 tokens used by abc
+	})
+
+	t.Run("input-tokens-kept-on-category-denied", func(t *T.T) {
+		x := getIO()
+		WithInputRateLimits(map[string]float64{"abc": 5})(x)
+		WithCategoryRateLimits(map[string]float64{"logging": 3})(x)
+
+		assert.Len(t, x.limitPts(point.Logging, "abc", dkpt.RandPoints(10)), 3)
+
+		// denied by category limiter, 2 tokens of abc not consumed
+		assert.Len(t, x.limitPts(point.Logging, "abc", dkpt.RandPoints(10)), 0)
+		assert.Len(t, x.limitPts(point.Metric, "abc", dkpt.RandPoints(10)), 2)
+	})
+
+	t.Run("shed", func(t *T.T) {
+		x := getIO()
+		WithFeedPriority([]string{"metric", "keyevent", "object", "tracing", "logging"}, 0.8)(x)
+
+		o := &usageOutput{}
+		x.fo = o
+
+		assert.InDelta(t, 1.0, x.shedThreshold(point.Metric), 0.001)
+		assert.InDelta(t, 0.84, x.shedThreshold(point.Logging), 0.001)
+		assert.InDelta(t, 0.8, x.shedThreshold(point.RUM), 0.001)
+
+		o.usage = 0.5
+		assert.Len(t, x.limitPts(point.RUM, "abc", dkpt.RandPoints(10)), 10)
+
+		o.usage = 0.82
+		assert.Len(t, x.limitPts(point.RUM, "abc", dkpt.RandPoints(10)), 0)
+		assert.Len(t, x.limitPts(point.Logging, "abc", dkpt.RandPoints(10)), 10)
+
+		o.usage = 0.9
+		assert.Len(t, x.limitPts(point.Logging, "abc", dkpt.RandPoints(10)), 0)
+		assert.Len(t, x.limitPts(point.Tracing, "abc", dkpt.RandPoints(10)), 0)
+		assert.Len(t, x.limitPts(point.Object, "abc", dkpt.RandPoints(10)), 10)
+
+		// highest priority never shed
+		o.usage = 1.0
+		assert.Len(t, x.limitPts(point.KeyEvent, "abc", dkpt.RandPoints(10)), 0)
+		assert.Len(t, x.limitPts(point.Metric, "abc", dkpt.RandPoints(10)), 10)
+	})
+
+	t.Run("queue-usage", func(t *T.T) {
+		fo := NewDatawayOutput(10)
+		qu, ok := fo.(queueUsager)
+		require.True(t, ok)
+		assert.Equal(t, 0.0, qu.QueueUsage(point.Logging))
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, fo.Write(&iodata{category: point.Logging}))
+		}
+
+		require.NoError(t, fo.Write(&iodata{category: point.Metric}))
+		assert.Equal(t, 0.5, qu.QueueUsage(point.Logging))
+		assert.Equal(t, 0.1, qu.QueueUsage(point.Metric))
+		assert.Equal(t, 0.0, qu.QueueUsage(point.Object))
+	})
+}
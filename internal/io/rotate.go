@@ -0,0 +1,247 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rotateTimeFormat = "20060102T150405.000000000"
+
+// rotateWriter is a file writer that rotate the file on size or time
+// exceeded. Rotated files are renamed with a timestamp suffix, gzipped
+// optionally, and only the newest maxFiles of them are kept.
+type rotateWriter struct {
+	path     string
+	maxSize  int64         // rotate if file size exceeded, 0 means no limit
+	interval time.Duration // rotate if file opened longer than interval, 0 means never
+	maxFiles int           // max rotated files kept, 0 means keep all
+	compress bool          // gzip rotated files
+
+	mtx       sync.Mutex
+	fd        *os.File
+	size      int64
+	openTime  time.Time
+	lastWrite time.Time
+
+	// wait background compressing
+	wg sync.WaitGroup
+}
+
+func (w *rotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o750); err != nil {
+		return err
+	}
+
+	fd, err := os.OpenFile(filepath.Clean(w.path), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+
+	fi, err := fd.Stat()
+	if err != nil {
+		fd.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	w.fd = fd
+	w.size = fi.Size()
+	w.openTime = time.Now()
+	return nil
+}
+
+func (w *rotateWriter) shouldRotate(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+
+	if w.maxSize > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+
+	if w.interval > 0 && time.Since(w.openTime) >= w.interval {
+		return true
+	}
+
+	return false
+}
+
+// Write write p to current file, rotate the file if needed.
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.fd == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.fd.Write(p)
+	w.size += int64(n)
+	w.lastWrite = time.Now()
+	return n, err
+}
+
+// WriteString write s to current file.
+func (w *rotateWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Rotate force rotate current file.
+func (w *rotateWriter) Rotate() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.fd == nil {
+		return nil
+	}
+
+	return w.rotate()
+}
+
+func (w *rotateWriter) rotate() error {
+	if err := w.fd.Close(); err != nil {
+		return err
+	}
+
+	w.fd = nil
+
+	rotated := w.path + "." + time.Now().Format(rotateTimeFormat)
+	if err := os.Rename(w.path, rotated); err != nil {
+		return err
+	}
+
+	if w.compress {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			if err := gzipFile(rotated); err != nil {
+				log.Warnf("gzip %s: %s, ignored", rotated, err)
+			}
+
+			w.clean()
+		}()
+	} else {
+		w.clean()
+	}
+
+	return w.open()
+}
+
+// Rotated list rotated files, ordered from oldest to newest.
+func (w *rotateWriter) Rotated() ([]string, error) {
+	arr, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, f := range arr {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(f, w.path+"."), ".gz")
+		if _, err := time.Parse(rotateTimeFormat, suffix); err != nil {
+			continue // not rotated by us
+		}
+
+		if w.compress && !strings.HasSuffix(f, ".gz") {
+			if _, err := os.Stat(f + ".gz"); err == nil {
+				continue // compressing
+			}
+		}
+
+		res = append(res, f)
+	}
+
+	sort.Strings(res) // timestamp suffix are sortable
+	return res, nil
+}
+
+// clean remove old rotated files.
+func (w *rotateWriter) clean() {
+	if w.maxFiles <= 0 {
+		return
+	}
+
+	arr, err := w.Rotated()
+	if err != nil {
+		log.Warnf("list rotated files of %s: %s, ignored", w.path, err)
+		return
+	}
+
+	for len(arr) > w.maxFiles {
+		if err := os.Remove(arr[0]); err != nil {
+			log.Warnf("remove %s: %s, ignored", arr[0], err)
+		}
+		arr = arr[1:]
+	}
+}
+
+// idle test if no write on the writer for du.
+func (w *rotateWriter) idle(du time.Duration) bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	return time.Since(w.lastWrite) >= du
+}
+
+// Close close current file, it will be reopened on next write.
+func (w *rotateWriter) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	defer w.wg.Wait()
+
+	if w.fd == nil {
+		return nil
+	}
+
+	err := w.fd.Close()
+	w.fd = nil
+	return err
+}
+
+func gzipFile(path string) error {
+	src, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck,gosec
+
+	dst, err := os.OpenFile(filepath.Clean(path+".gz"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		dst.Close() //nolint:errcheck,gosec
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
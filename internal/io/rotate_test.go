@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateWriter(t *T.T) {
+	t.Run("rotate-on-size", func(t *T.T) {
+		f := filepath.Join(t.TempDir(), "out.lp")
+
+		w := &rotateWriter{path: f, maxSize: 100, maxFiles: 3}
+		for i := 0; i < 20; i++ {
+			_, err := w.WriteString(strings.Repeat("x", 49) + "\n")
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, w.Close())
+
+		arr, err := w.Rotated()
+		require.NoError(t, err)
+		assert.Len(t, arr, 3)
+
+		fi, err := os.Stat(f)
+		require.NoError(t, err)
+		assert.True(t, fi.Size() <= 100)
+	})
+
+	t.Run("rotate-on-time", func(t *T.T) {
+		f := filepath.Join(t.TempDir(), "out.lp")
+
+		w := &rotateWriter{path: f, interval: time.Millisecond}
+		_, err := w.WriteString("abc\n")
+		require.NoError(t, err)
+
+		time.Sleep(time.Millisecond * 10)
+
+		_, err = w.WriteString("def\n")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		arr, err := w.Rotated()
+		require.NoError(t, err)
+		require.Len(t, arr, 1)
+
+		data, err := os.ReadFile(arr[0])
+		require.NoError(t, err)
+		assert.Equal(t, "abc\n", string(data))
+	})
+
+	t.Run("compress", func(t *T.T) {
+		f := filepath.Join(t.TempDir(), "out.lp")
+
+		w := &rotateWriter{path: f, compress: true}
+		_, err := w.WriteString("abc\n")
+		require.NoError(t, err)
+		require.NoError(t, w.Rotate())
+		require.NoError(t, w.Close()) // wait compressing done
+
+		arr, err := w.Rotated()
+		require.NoError(t, err)
+		require.Len(t, arr, 1)
+		require.True(t, strings.HasSuffix(arr[0], ".gz"))
+
+		fd, err := os.Open(arr[0])
+		require.NoError(t, err)
+		defer fd.Close() //nolint:errcheck
+
+		zr, err := gzip.NewReader(fd)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, "abc\n", string(data))
+	})
+}
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	lp "github.com/GuanceCloud/cliutils/lineproto"
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/wal"
+)
+
+// openWALs open WAL for each category under datakit data dir.
+func (x *dkIO) openWALs() {
+	for _, c := range point.AllCategories() {
+		if c == point.DynamicDWCategory { // dial-testing data not logged
+			continue
+		}
+
+		p := filepath.Join(datakit.DataDir, "wal", c.String())
+		catStr := c.String()
+
+		w, err := wal.Open(
+			wal.WithPath(p),
+			wal.WithCapacity(int64(x.walCapacityMB)*1024*1024),
+			wal.WithNoSync(x.walNoSync),
+			wal.WithDropCallback(func(n int64) {
+				walDroppedBytesVec.WithLabelValues(catStr).Add(float64(n))
+			}),
+		)
+		if err != nil {
+			log.Warnf("open WAL on %s: %s, ignored", p, err)
+			continue
+		}
+
+		x.wals[catStr] = w
+	}
+}
+
+// encodeWALRecord encode iodata into WAL record: the first line is the
+// feed name, the remaining lines are points in line-protocol.
+func encodeWALRecord(d *iodata) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(d.from)
+	for _, pt := range d.pts {
+		buf.WriteByte('\n')
+		buf.WriteString(pt.String())
+	}
+
+	return buf.Bytes()
+}
+
+func decodeWALRecord(cat point.Category, data []byte) (*iodata, error) {
+	d := &iodata{category: cat}
+
+	idx := bytes.IndexByte(data, '\n')
+	if idx < 0 { // no points
+		d.from = string(data)
+		return d, nil
+	}
+
+	d.from = string(data[:idx])
+
+	pts, err := lp.ParsePoints(data[idx+1:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WAL record from %q: %w", d.from, err)
+	}
+
+	d.pts = dkpt.WrapPoint(pts)
+	return d, nil
+}
+
+// walAppend log d into WAL of its category before d queued.
+func (x *dkIO) walAppend(d *iodata) {
+	w, ok := x.wals[d.category.String()]
+	if !ok || len(d.pts) == 0 {
+		return
+	}
+
+	pos, err := w.Append(encodeWALRecord(d))
+	if err != nil {
+		log.Warnf("WAL append %d points from %s: %s, ignored", len(d.pts), d.from, err)
+		return
+	}
+
+	d.walPos = pos
+	walRecordsVec.WithLabelValues(d.category.String()).Inc()
+}
+
+// walAck ack WAL records at pos.
+func (x *dkIO) walAck(cat point.Category, pos ...wal.Position) {
+	if len(pos) == 0 {
+		return
+	}
+
+	if w, ok := x.wals[cat.String()]; ok {
+		w.Ack(pos...)
+		walSizeVec.WithLabelValues(cat.String()).Set(float64(w.Size()))
+	}
+}
+
+// closeWALs seal and close all WALs, not-acked records kept for replay on
+// next start.
+func (x *dkIO) closeWALs() {
+	for cat, w := range x.wals {
+		if err := w.Close(); err != nil {
+			log.Warnf("close WAL on %q: %s, ignored", cat, err)
+		}
+	}
+}
+
+// writeIOData log d into WAL and queue it to feed output.
+func (x *dkIO) writeIOData(d *iodata) error {
+	x.walAppend(d)
+
+	err := x.fo.Write(d)
+	if errors.Is(err, ErrIOBusy) { // points dropped
+		x.walAck(d.category, d.walPos)
+	}
+
+	return err
+}
+
+// replayWALs feed data left in WAL by last run into io.
+func (x *dkIO) replayWALs() {
+	for cat, w := range x.wals {
+		c := point.CatString(cat)
+		w := w
+
+		g.Go(func(_ context.Context) error {
+			n := 0
+			if err := w.Replay(func(data []byte) error {
+				d, err := decodeWALRecord(c, data)
+				if err != nil {
+					log.Warnf("%s, ignored", err)
+					return nil
+				}
+
+				d.opt = &Option{Blocking: true}
+				if err := x.writeIOData(d); err != nil {
+					return err
+				}
+
+				n += len(d.pts)
+				return nil
+			}); err != nil {
+				log.Warnf("WAL replay on %q: %s", c, err)
+			}
+
+			if n > 0 {
+				log.Infof("WAL replayed %d points on %q", n, c)
+				walReplayPtsVec.WithLabelValues(c.String()).Add(float64(n))
+			}
+
+			return nil
+		})
+	}
+}
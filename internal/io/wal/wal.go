@@ -0,0 +1,597 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package wal implements a segmented write-ahead log for io. Feeding data
+// are appended to the log before they are queued in memory, and removed
+// after they have been flushed, so data buffered in memory survive a crash.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentSuffix = ".wal"
+	ackSuffix     = ".ack"
+	headerSize    = 8 // 4 bytes length + 4 bytes crc32
+
+	defaultSegmentSize = 32 * 1024 * 1024
+	defaultCapacity    = 1024 * 1024 * 1024
+	maxRecordSize      = 64 * 1024 * 1024
+)
+
+var (
+	ErrClosed         = errors.New("wal closed")
+	ErrRecordTooLarge = errors.New("wal record too large")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// Position locate a record within WAL.
+type Position struct {
+	Segment uint64 // segment ID, 0 means the record not logged
+	Offset  int64  // offset of the record within the segment
+}
+
+type segment struct {
+	id   uint64
+	path string
+	size int64
+
+	written,
+	acked int
+
+	sealed bool
+
+	// offsets of acked records are appended to the ack file, so that they
+	// are skipped on replay.
+	ackfd *os.File
+}
+
+func (s *segment) done() bool {
+	return s.sealed && s.acked >= s.written
+}
+
+func (s *segment) ackPath() string {
+	return strings.TrimSuffix(s.path, segmentSuffix) + ackSuffix
+}
+
+func (s *segment) closeAck() {
+	if s.ackfd != nil {
+		_ = s.ackfd.Close()
+		s.ackfd = nil
+	}
+}
+
+// WAL is a write-ahead log made of segment files under a single directory.
+//
+// Each Append returns the position of the record, the caller should Ack
+// the position once the record is no longer needed. Acked records are not
+// replayed. A segment file is removed when all of its records acked.
+type WAL struct {
+	path        string
+	segmentSize int64
+	capacity    int64
+	noSync      bool
+
+	mtx sync.Mutex
+
+	fd  *os.File
+	cur *segment
+
+	segs      map[uint64]*segment // sealed but not fully acked segments
+	recovered []*segment          // segments left by last run, waiting for replay
+
+	size   int64
+	nextID uint64
+	closed bool
+
+	onDrop func(bytes int64)
+}
+
+// Option used to configure WAL.
+type Option func(w *WAL)
+
+// WithPath set the directory of WAL segment files.
+func WithPath(p string) Option {
+	return func(w *WAL) {
+		w.path = p
+	}
+}
+
+// WithSegmentSize set max size of a single segment file.
+func WithSegmentSize(n int64) Option {
+	return func(w *WAL) {
+		if n > 0 {
+			w.segmentSize = n
+		}
+	}
+}
+
+// WithCapacity set max disk usage of the WAL. If reached, the oldest
+// segments are dropped even if some of their records not acked.
+func WithCapacity(n int64) Option {
+	return func(w *WAL) {
+		if n > 0 {
+			w.capacity = n
+		}
+	}
+}
+
+// WithNoSync disable fsync after each Append.
+func WithNoSync(on bool) Option {
+	return func(w *WAL) {
+		w.noSync = on
+	}
+}
+
+// WithDropCallback set callback on segment dropped for capacity exceeded.
+func WithDropCallback(fn func(bytes int64)) Option {
+	return func(w *WAL) {
+		w.onDrop = fn
+	}
+}
+
+// Open open(or create) WAL, segments left by last run are kept for Replay.
+func Open(opts ...Option) (*WAL, error) {
+	w := &WAL{
+		segmentSize: defaultSegmentSize,
+		capacity:    defaultCapacity,
+		segs:        map[uint64]*segment{},
+		nextID:      1,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(w)
+		}
+	}
+
+	if w.path == "" {
+		return nil, fmt.Errorf("wal path not set")
+	}
+
+	if err := os.MkdirAll(w.path, 0o750); err != nil {
+		return nil, err
+	}
+
+	if err := w.loadSegments(); err != nil {
+		return nil, err
+	}
+
+	if err := w.openSegment(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *WAL) segmentPath(id uint64) string {
+	return filepath.Join(w.path, fmt.Sprintf("%020d%s", id, segmentSuffix))
+}
+
+func (w *WAL) loadSegments() error {
+	entries, err := os.ReadDir(w.path)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), segmentSuffix) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), segmentSuffix), 10, 64)
+		if err != nil {
+			continue // not our file
+		}
+
+		fi, err := e.Info()
+		if err != nil {
+			return err
+		}
+
+		w.recovered = append(w.recovered, &segment{
+			id:     id,
+			path:   filepath.Join(w.path, e.Name()),
+			size:   fi.Size(),
+			sealed: true,
+		})
+
+		w.size += fi.Size()
+
+		if id >= w.nextID {
+			w.nextID = id + 1
+		}
+	}
+
+	sort.Slice(w.recovered, func(i, j int) bool {
+		return w.recovered[i].id < w.recovered[j].id
+	})
+
+	return nil
+}
+
+func (w *WAL) openSegment() error {
+	s := &segment{id: w.nextID}
+	s.path = w.segmentPath(s.id)
+
+	fd, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	// ack file left by a removed segment with the same ID
+	if err := os.Remove(s.ackPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		_ = fd.Close()
+		return err
+	}
+
+	w.nextID++
+	w.fd = fd
+	w.cur = s
+	return nil
+}
+
+// rotate seal current segment and open a new one.
+func (w *WAL) rotate() error {
+	if err := w.fd.Close(); err != nil {
+		return err
+	}
+
+	w.cur.sealed = true
+	if w.cur.done() {
+		w.removeSegment(w.cur)
+	} else {
+		w.segs[w.cur.id] = w.cur
+	}
+
+	return w.openSegment()
+}
+
+func (w *WAL) removeSegment(s *segment) {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+
+	s.closeAck()
+	_ = os.Remove(s.ackPath())
+
+	delete(w.segs, s.id)
+	w.size -= s.size
+}
+
+// dropOldest drop the oldest sealed segments until size under capacity.
+func (w *WAL) dropOldest() {
+	for w.size > w.capacity {
+		if len(w.recovered) > 0 {
+			s := w.recovered[0]
+			w.recovered = w.recovered[1:]
+			w.removeSegment(s)
+			if w.onDrop != nil {
+				w.onDrop(s.size)
+			}
+			continue
+		}
+
+		var oldest *segment
+		for _, s := range w.segs {
+			if oldest == nil || s.id < oldest.id {
+				oldest = s
+			}
+		}
+
+		if oldest == nil {
+			return
+		}
+
+		w.removeSegment(oldest)
+		if w.onDrop != nil {
+			w.onDrop(oldest.size)
+		}
+	}
+}
+
+// Append write data as a record into WAL, and return the position of the record.
+func (w *WAL) Append(data []byte) (Position, error) {
+	if len(data) > maxRecordSize {
+		return Position{}, ErrRecordTooLarge
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return Position{}, ErrClosed
+	}
+
+	if w.cur.size > 0 && w.cur.size+int64(len(data)+headerSize) > w.segmentSize {
+		if err := w.rotate(); err != nil {
+			return Position{}, err
+		}
+	}
+
+	pos := Position{Segment: w.cur.id, Offset: w.cur.size}
+
+	buf := make([]byte, headerSize+len(data))
+	binary.LittleEndian.PutUint32(buf, uint32(len(data)))
+	binary.LittleEndian.PutUint32(buf[4:], crc32.Checksum(data, crcTable))
+	copy(buf[headerSize:], data)
+
+	n, err := w.fd.Write(buf)
+	w.cur.size += int64(n)
+	w.size += int64(n)
+	if err != nil {
+		return Position{}, err
+	}
+
+	if !w.noSync {
+		if err := w.fd.Sync(); err != nil {
+			return Position{}, err
+		}
+	}
+
+	w.cur.written++
+
+	if w.size > w.capacity {
+		w.dropOldest()
+	}
+
+	return pos, nil
+}
+
+// Ack mark records at pos as done. Positions not logged(Segment is 0) are
+// ignored.
+func (w *WAL) Ack(pos ...Position) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed { // not-acked records replayed on next run
+		return
+	}
+
+	offsets := map[uint64][]byte{}
+	for _, p := range pos {
+		if p.Segment == 0 {
+			continue
+		}
+
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(p.Offset))
+		offsets[p.Segment] = append(offsets[p.Segment], b...)
+	}
+
+	for id, b := range offsets {
+		var s *segment
+		if w.cur.id == id {
+			s = w.cur
+		} else if x, ok := w.segs[id]; ok {
+			s = x
+		} else {
+			continue // segment dropped
+		}
+
+		s.acked += len(b) / 8
+
+		switch {
+		case s == w.cur && s.acked >= s.written:
+			// current segment fully acked, remove it and write a new one.
+			// On failure, following Append failed too.
+			_ = w.rotate()
+
+		case s.done():
+			w.removeSegment(s)
+
+		default:
+			// on failure, the records are replayed on next run
+			_ = w.writeAck(s, b)
+		}
+	}
+}
+
+func (w *WAL) writeAck(s *segment, b []byte) error {
+	if s.ackfd == nil {
+		fd, err := os.OpenFile(s.ackPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		s.ackfd = fd
+	}
+
+	if _, err := s.ackfd.Write(b); err != nil {
+		return err
+	}
+
+	if !w.noSync {
+		return s.ackfd.Sync()
+	}
+
+	return nil
+}
+
+// Pending return number of not-acked records.
+func (w *WAL) Pending() (n int) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	for _, s := range w.segs {
+		n += s.written - s.acked
+	}
+
+	if w.cur != nil {
+		n += w.cur.written - w.cur.acked
+	}
+
+	return n
+}
+
+// Size return disk usage of WAL in bytes.
+func (w *WAL) Size() int64 {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.size
+}
+
+// Replay read all records left by last run and pass them to fn. Each record
+// acked once fn returned ok, and the replayed segments are removed. If fn
+// failed, the replay stopped and records not replayed kept for next Replay.
+//
+// A partial record at the tail of a segment(for example, crashed during
+// writing) is ignored.
+func (w *WAL) Replay(fn func(data []byte) error) error {
+	for {
+		w.mtx.Lock()
+		if w.closed {
+			w.mtx.Unlock()
+			return ErrClosed
+		}
+
+		if len(w.recovered) == 0 {
+			w.mtx.Unlock()
+			return nil
+		}
+
+		s := w.recovered[0]
+		w.mtx.Unlock()
+
+		if err := replaySegment(s.path, ackedOffsets(s.ackPath()), func(offset int64, data []byte) error {
+			if err := fn(data); err != nil {
+				return err
+			}
+
+			// fn may have appended the record into current segment, ack it in
+			// the recovered one, or it's replayed twice if crashed before the
+			// recovered segment removed.
+			w.ackReplayed(s, offset)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		w.mtx.Lock()
+		if len(w.recovered) > 0 && w.recovered[0] == s { // may dropped during replay
+			w.recovered = w.recovered[1:]
+			w.removeSegment(s)
+		}
+		w.mtx.Unlock()
+	}
+}
+
+// ackReplayed mark record at offset of recovered segment s as done.
+func (w *WAL) ackReplayed(s *segment, offset int64) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed || len(w.recovered) == 0 || w.recovered[0] != s { // s dropped
+		return
+	}
+
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(offset))
+
+	// on failure, the record replayed again on next run
+	_ = w.writeAck(s, b)
+}
+
+// ackedOffsets read offsets of acked records from ack file.
+func ackedOffsets(path string) map[int64]bool {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil
+	}
+
+	acked := map[int64]bool{}
+	for ; len(b) >= 8; b = b[8:] {
+		acked[int64(binary.LittleEndian.Uint64(b))] = true
+	}
+
+	return acked
+}
+
+func replaySegment(path string, acked map[int64]bool, fn func(int64, []byte) error) error {
+	fd, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	defer fd.Close() //nolint:errcheck,gosec
+
+	r := bufio.NewReader(fd)
+	hdr := make([]byte, headerSize)
+
+	for offset := int64(0); ; {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return nil // EOF or partial header
+		}
+
+		size := binary.LittleEndian.Uint32(hdr)
+		if size > maxRecordSize {
+			return nil // broken segment
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil // partial record
+		}
+
+		if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(hdr[4:]) {
+			return nil // broken record, drop the remaining
+		}
+
+		if !acked[offset] {
+			if err := fn(offset, data); err != nil {
+				return err
+			}
+		}
+
+		offset += int64(headerSize) + int64(size)
+	}
+}
+
+// Close seal current segment and close the WAL. Not-acked records are kept
+// on disk for replay.
+func (w *WAL) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	err := w.fd.Close()
+
+	w.cur.sealed = true
+	if w.cur.done() {
+		w.removeSegment(w.cur)
+	} else {
+		w.segs[w.cur.id] = w.cur
+	}
+
+	for _, s := range w.segs {
+		s.closeAck()
+	}
+
+	for _, s := range w.recovered {
+		s.closeAck()
+	}
+
+	return err
+}
@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package wal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func segmentFiles(t *T.T, dir string) []string {
+	t.Helper()
+
+	arr, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	return arr
+}
+
+func TestAppendAck(t *T.T) {
+	t.Run("ack-remove-sealed-segment", func(t *T.T) {
+		dir := t.TempDir()
+		w, err := Open(WithPath(dir), WithSegmentSize(64), WithNoSync(true))
+		require.NoError(t, err)
+
+		var arr []Position
+		for i := 0; i < 10; i++ {
+			pos, err := w.Append([]byte(fmt.Sprintf("record-%d", i)))
+			require.NoError(t, err)
+			arr = append(arr, pos)
+		}
+
+		assert.Equal(t, 10, w.Pending())
+		assert.True(t, len(segmentFiles(t, dir)) > 1)
+
+		w.Ack(arr...)
+		assert.Equal(t, 0, w.Pending())
+
+		// current segment fully acked and removed, a new empty one opened
+		assert.Len(t, segmentFiles(t, dir), 1)
+
+		require.NoError(t, w.Close())
+		assert.Len(t, segmentFiles(t, dir), 0)
+	})
+
+	t.Run("ack-remove-current-segment", func(t *T.T) {
+		dir := t.TempDir()
+		w, err := Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		pos, err := w.Append([]byte("record-0"))
+		require.NoError(t, err)
+
+		w.Ack(pos)
+		assert.Equal(t, 0, w.Pending())
+		assert.Equal(t, int64(0), w.Size())
+
+		// removed, nothing replayed on crash
+		w2, err := Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+		require.NoError(t, w2.Replay(func(data []byte) error {
+			assert.Fail(t, "should not replay", string(data))
+			return nil
+		}))
+
+		require.NoError(t, w2.Close())
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("capacity", func(t *T.T) {
+		dir := t.TempDir()
+
+		dropped := int64(0)
+		w, err := Open(WithPath(dir),
+			WithSegmentSize(64),
+			WithCapacity(256),
+			WithNoSync(true),
+			WithDropCallback(func(n int64) { dropped += n }))
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			_, err := w.Append([]byte(fmt.Sprintf("record-%d", i)))
+			require.NoError(t, err)
+		}
+
+		assert.True(t, w.Size() <= 256)
+		assert.True(t, dropped > 0)
+		require.NoError(t, w.Close())
+	})
+}
+
+func TestReplay(t *T.T) {
+	t.Run("replay-not-acked", func(t *T.T) {
+		dir := t.TempDir()
+
+		w, err := Open(WithPath(dir), WithSegmentSize(64), WithNoSync(true))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			pos, err := w.Append([]byte(fmt.Sprintf("record-%d", i)))
+			require.NoError(t, err)
+
+			if i%2 == 0 {
+				w.Ack(pos)
+			}
+		}
+
+		require.NoError(t, w.Close()) // not acked segments kept
+
+		w, err = Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		var records []string
+		require.NoError(t, w.Replay(func(data []byte) error {
+			records = append(records, string(data))
+			return nil
+		}))
+
+		// acked records within not-fully-acked segment are skipped
+		assert.Equal(t, []string{"record-1", "record-3", "record-5", "record-7", "record-9"}, records)
+
+		// all replayed segments removed
+		assert.Len(t, segmentFiles(t, dir), 1)
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("crash", func(t *T.T) {
+		dir := t.TempDir()
+
+		w, err := Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		var arr []Position
+		for i := 0; i < 3; i++ {
+			pos, err := w.Append([]byte(fmt.Sprintf("record-%d", i)))
+			require.NoError(t, err)
+			arr = append(arr, pos)
+		}
+
+		w.Ack(arr[0], arr[2])
+
+		// w not closed
+		w2, err := Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		var records []string
+		require.NoError(t, w2.Replay(func(data []byte) error {
+			records = append(records, string(data))
+			return nil
+		}))
+
+		assert.Equal(t, []string{"record-1"}, records)
+		require.NoError(t, w2.Close())
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("replay-interrupted", func(t *T.T) {
+		dir := t.TempDir()
+
+		w, err := Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := w.Append([]byte(fmt.Sprintf("record-%d", i)))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+
+		// replayed records appended again, and the replay failed on record-1
+		w, err = Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		assert.Error(t, w.Replay(func(data []byte) error {
+			if string(data) == "record-1" {
+				return fmt.Errorf("mocked error")
+			}
+
+			_, err := w.Append(data)
+			return err
+		}))
+		require.NoError(t, w.Close())
+
+		w, err = Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		var records []string
+		require.NoError(t, w.Replay(func(data []byte) error {
+			records = append(records, string(data))
+			return nil
+		}))
+
+		// record-0 acked in the recovered segment, only its copy replayed
+		assert.Equal(t, []string{"record-1", "record-2", "record-0"}, records)
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("partial-tail", func(t *T.T) {
+		dir := t.TempDir()
+
+		w, err := Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		_, err = w.Append([]byte("record-0"))
+		require.NoError(t, err)
+		_, err = w.Append([]byte("record-1"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		// truncate the last record to mock a crash on writing
+		arr := segmentFiles(t, dir)
+		require.Len(t, arr, 1)
+		fi, err := os.Stat(arr[0])
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(arr[0], fi.Size()-3))
+
+		w, err = Open(WithPath(dir), WithNoSync(true))
+		require.NoError(t, err)
+
+		var records []string
+		require.NoError(t, w.Replay(func(data []byte) error {
+			records = append(records, string(data))
+			return nil
+		}))
+
+		assert.Equal(t, []string{"record-0"}, records)
+		require.NoError(t, w.Close())
+	})
+}
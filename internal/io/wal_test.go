@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package io
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/dataway"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/wal"
+)
+
+type walTestDataway struct {
+	writes int
+}
+
+func (dw *walTestDataway) Write(...dataway.WriteOption) error {
+	dw.writes++
+	return nil
+}
+
+func (dw *walTestDataway) Pull(string) ([]byte, error) {
+	return nil, nil
+}
+
+func TestWALRecord(t *T.T) {
+	t.Run("encode-decode", func(t *T.T) {
+		d := &iodata{
+			category: point.Logging,
+			from:     "logging/some.log",
+			pts:      dkpt.RandPoints(10),
+		}
+
+		x, err := decodeWALRecord(point.Logging, encodeWALRecord(d))
+		require.NoError(t, err)
+
+		assert.Equal(t, d.from, x.from)
+		require.Len(t, x.pts, 10)
+
+		for i := range d.pts {
+			assert.Equal(t, d.pts[i].String(), x.pts[i].String())
+		}
+	})
+
+	t.Run("no-points", func(t *T.T) {
+		x, err := decodeWALRecord(point.Logging, encodeWALRecord(&iodata{from: "abc"}))
+		require.NoError(t, err)
+		assert.Equal(t, "abc", x.from)
+		assert.Len(t, x.pts, 0)
+	})
+}
+
+func TestWALAck(t *T.T) {
+	dir := t.TempDir()
+	w, err := wal.Open(wal.WithPath(dir), wal.WithNoSync(true))
+	require.NoError(t, err)
+
+	x := getIO()
+	x.wals[point.Logging.String()] = w
+
+	c := &consumer{
+		category:   point.Logging,
+		flushTiker: time.NewTicker(time.Second),
+	}
+	defer c.flushTiker.Stop()
+
+	for i := 0; i < 3; i++ {
+		d := &iodata{
+			category: point.Logging,
+			from:     t.Name(),
+			pts:      dkpt.RandPoints(10),
+		}
+
+		x.walAppend(d)
+		assert.NotZero(t, d.walPos.Segment)
+		x.cacheData(c, d, false)
+	}
+
+	assert.Equal(t, 3, w.Pending())
+
+	// dataway not set, flush failed and WAL not acked
+	x.flush(c)
+	assert.Equal(t, 3, w.Pending())
+	assert.Len(t, c.walAcks, 0)
+
+	d := &iodata{
+		category: point.Logging,
+		from:     t.Name(),
+		pts:      dkpt.RandPoints(10),
+	}
+	x.walAppend(d)
+	x.cacheData(c, d, false)
+
+	x.dw = &walTestDataway{}
+	x.flush(c)
+	assert.Equal(t, 3, w.Pending())
+	assert.Len(t, c.walAcks, 0)
+
+	require.NoError(t, w.Close())
+
+	// the 3 not acked replayed
+	w, err = wal.Open(wal.WithPath(dir), wal.WithNoSync(true))
+	require.NoError(t, err)
+
+	n := 0
+	require.NoError(t, w.Replay(func([]byte) error {
+		n++
+		return nil
+	}))
+	assert.Equal(t, 3, n)
+	require.NoError(t, w.Close())
+}
+
+func TestWALAckOnDatawayFailure(t *T.T) {
+	var status int64 = http.StatusBadRequest
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt64(&status)))
+	}))
+	defer ts.Close()
+
+	dw := &dataway.Dataway{URLs: []string{fmt.Sprintf("%s?token=tkn_for_test", ts.URL)}}
+	require.NoError(t, dw.Init())
+
+	w, err := wal.Open(wal.WithPath(t.TempDir()), wal.WithNoSync(true))
+	require.NoError(t, err)
+	defer w.Close() //nolint:errcheck
+
+	x := getIO()
+	x.dw = dw
+	x.wals[point.Logging.String()] = w
+
+	c := &consumer{
+		category:   point.Logging,
+		flushTiker: time.NewTicker(time.Second),
+	}
+	defer c.flushTiker.Stop()
+
+	feed := func() {
+		d := &iodata{
+			category: point.Logging,
+			from:     t.Name(),
+			pts:      dkpt.RandPoints(10),
+		}
+
+		x.walAppend(d)
+		x.cacheData(c, d, false)
+	}
+
+	// 4xx and no failcache: points neither sent nor cached, not acked
+	feed()
+	x.flush(c)
+	assert.Equal(t, 1, w.Pending())
+
+	// sent, acked
+	atomic.StoreInt64(&status, http.StatusOK)
+	feed()
+	x.flush(c)
+	assert.Equal(t, 1, w.Pending())
+}
@@ -51,9 +51,16 @@ func (s *scoredPattern) String() string {
 	return fmt.Sprintf("score:%d, regexp:%s", s.score, s.regexp)
 }
 
+// learnThreshold is the score of the pattern learned, if no other patterns
+// matched.
+const learnThreshold = 100
+
 type Matcher struct {
 	patterns  []*scoredPattern
 	noPattern bool
+
+	// 学习到的规则，之后只使用此规则匹配
+	learned *scoredPattern
 }
 
 func NewMatcher(additionalPatterns []string) (*Matcher, error) {
@@ -87,6 +94,10 @@ func (m *Matcher) Match(content []byte) bool {
 		return !prefixIsSpace(content)
 	}
 
+	if m.learned != nil {
+		return m.learned.regexp.Match(content)
+	}
+
 	for idx, scoredPattern := range m.patterns {
 		match := scoredPattern.regexp.Match(content)
 		if match {
@@ -96,6 +107,7 @@ func (m *Matcher) Match(content []byte) bool {
 					return m.patterns[i].score > m.patterns[j].score
 				})
 			}
+			m.learn()
 			return true
 		}
 	}
@@ -107,6 +119,39 @@ func (m *Matcher) Match(content []byte) bool {
 	return false
 }
 
+// learn takes the pattern matched learnThreshold times as learned, if it's
+// the only one matched in all of the patterns.
+func (m *Matcher) learn() {
+	if len(m.patterns) < 2 || m.patterns[0].score < learnThreshold || m.patterns[1].score > 0 {
+		return
+	}
+	m.learned = m.patterns[0]
+}
+
+// Learn takes the pattern as learned, such as learned before restarted.
+// False returned if the pattern is not in the patterns.
+func (m *Matcher) Learn(pattern string) bool {
+	for idx, p := range m.patterns {
+		if p.regexp.String() != pattern {
+			continue
+		}
+
+		p.score = learnThreshold
+		m.patterns[0], m.patterns[idx] = p, m.patterns[0]
+		m.learned = p
+		return true
+	}
+	return false
+}
+
+// Learned returns the pattern learned, empty if not learned yet.
+func (m *Matcher) Learned() string {
+	if m.learned == nil {
+		return ""
+	}
+	return m.learned.regexp.String()
+}
+
 func prefixIsSpace(text []byte) bool {
 	if len(text) == 0 {
 		return true
@@ -161,6 +161,47 @@ func TestMatchNoPattern(t *testing.T) {
 	}
 }
 
+func TestMatchLearn(t *testing.T) {
+	patterns := []string{`^\d{4}-\d{2}-\d{2}`, `^[A-Z]+ `}
+
+	t.Run("learned", func(t *testing.T) {
+		m, err := NewMatcher(patterns)
+		assert.NoError(t, err)
+
+		for i := 0; i < learnThreshold-1; i++ {
+			assert.True(t, m.Match([]byte("2023-01-01 INFO")))
+		}
+		assert.Equal(t, "", m.Learned())
+
+		assert.True(t, m.Match([]byte("2023-01-01 INFO")))
+		assert.Equal(t, patterns[0], m.Learned())
+
+		// only the pattern learned used
+		assert.False(t, m.Match([]byte("INFO not a start")))
+	})
+
+	t.Run("not-learned-if-others-matched", func(t *testing.T) {
+		m, err := NewMatcher(patterns)
+		assert.NoError(t, err)
+
+		assert.True(t, m.Match([]byte("INFO start")))
+		for i := 0; i < learnThreshold; i++ {
+			assert.True(t, m.Match([]byte("2023-01-01 INFO")))
+		}
+		assert.Equal(t, "", m.Learned())
+	})
+
+	t.Run("learn", func(t *testing.T) {
+		m, err := NewMatcher(patterns)
+		assert.NoError(t, err)
+
+		assert.False(t, m.Learn(`^\S`))
+		assert.True(t, m.Learn(patterns[1]))
+		assert.Equal(t, patterns[1], m.Learned())
+		assert.False(t, m.Match([]byte("2023-01-01 INFO")))
+	})
+}
+
 func TestNewMatcher(t *testing.T) {
 	t.Run("ok-patterns", func(t *testing.T) {
 		patterns := []string{
@@ -8,6 +8,8 @@ package multiline
 
 import (
 	"bytes"
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 	"unicode"
@@ -26,6 +28,23 @@ type Option struct {
 	// 限制一段多行数据的最大存在时长，即从第一条匹配成功开始到现在，超出限制会执行 flush
 	// 避免出现一条匹配成功，N 条匹配失败然后追加写入到 buff，导致数据全部堆积的情况
 	MaxLifeDuration time.Duration
+
+	// 限制一段多行数据的最大行数，超出限制会执行 flush，为 0 则不限制
+	MaxLines int
+
+	// 续行正则，符合此正则的行追加到上一条数据的末尾，否则作为新数据的开始
+	// 设置后不再使用 patterns 判断新数据的开始
+	ContinuePattern string
+
+	// 结束正则，符合此正则的行是一条数据的最后一行，之后的行作为新数据的开始
+	// 只设置此项时，只以结束行划分数据
+	EndPattern string
+
+	// 一段多行数据在此时长内没有追加新的行，调用 FlushExpired 会执行 flush，为 0 则不限制
+	FlushTimeout time.Duration
+
+	// 自动多行模式已学习到的规则，如果在 patterns 中，只使用此规则匹配
+	Learned string
 }
 
 func initOption(opt *Option) *Option {
@@ -48,11 +67,19 @@ func initOption(opt *Option) *Option {
 
 type Multiline struct {
 	*Matcher
-	buff bytes.Buffer
-	opt  *Option
+	buff  bytes.Buffer
+	lines int
+	opt   *Option
+
+	continueRegexp *regexp.Regexp
+	endRegexp      *regexp.Regexp
+	// buff 中的数据已经读到结束行，下一行作为新数据的开始
+	ended bool
 
 	// 记录最后一次匹配成功并写入到 buff 的时间
 	lastWriteTime time.Time
+	// 记录最后一次写入到 buff 的时间
+	lastAppendTime time.Time
 }
 
 func New(patterns []string, opt *Option) (*Multiline, error) {
@@ -63,10 +90,28 @@ func New(patterns []string, opt *Option) (*Multiline, error) {
 
 	opt = initOption(opt)
 
-	return &Multiline{
+	m := &Multiline{
 		Matcher: match,
 		opt:     opt,
-	}, err
+	}
+
+	if opt.ContinuePattern != "" {
+		if m.continueRegexp, err = regexp.Compile(opt.ContinuePattern); err != nil {
+			return nil, fmt.Errorf("invalid continue pattern '%s', error %w", opt.ContinuePattern, err)
+		}
+	}
+
+	if opt.EndPattern != "" {
+		if m.endRegexp, err = regexp.Compile(opt.EndPattern); err != nil {
+			return nil, fmt.Errorf("invalid end pattern '%s', error %w", opt.EndPattern, err)
+		}
+	}
+
+	if opt.Learned != "" {
+		match.Learn(opt.Learned)
+	}
+
+	return m, nil
 }
 
 func (m *Multiline) ProcessLineString(text string) string {
@@ -79,25 +124,42 @@ var newLine = []byte{'\n'}
 func (m *Multiline) ProcessLine(text []byte) []byte {
 	// --匹配成功--
 	// 清空 buff 并写入新的文本，符合多行行为。记录当前时间。
-	if m.Match(text) {
+	if m.isStart(text) {
 		previousText := m.Flush()
-		m.buff.Write(text)
-		m.lastWriteTime = time.Now()
-		return previousText
+		m.write(text)
+		m.lastWriteTime = m.lastAppendTime
+		return m.checkEnd(text, previousText)
 	}
 
 	// --匹配失败--
 
 	// 这一条文本匹配失败，原应该追加写入到 buff 中，但是此时 buff 为空，说明这条文本没有头，是一条“僵尸多行文本”
 	// 为了避免匹配失败的文本堆积在 buff 中，需要在此处直接 return
+	// 只以结束行划分数据时，任何一行都可能是新数据的开始
 	if m.buff.Len() == 0 {
-		return text
+		if m.endRegexp == nil {
+			return text
+		}
+		m.write(text)
+		m.lastWriteTime = m.lastAppendTime
+		return m.checkEnd(text, nil)
+	}
+
+	// flush 规则零：行数超过限制，本行作为新数据的开始
+	if m.opt.MaxLines > 0 && m.lines >= m.opt.MaxLines {
+		previousText := m.Flush()
+		m.write(text)
+		m.lastWriteTime = m.lastAppendTime
+		return m.checkEnd(text, previousText)
 	}
 
 	// buff 不为空，说明 buff 中存在匹配成功的文本
 	// 将本次匹配失败的文本写入到 buff，追加到末尾，符合多行行为
-	m.buff.Write(newLine)
-	m.buff.Write(text)
+	m.write(text)
+
+	if res := m.checkEnd(text, nil); res != nil {
+		return res
+	}
 
 	// flush 规则一：单次多行采集时长超出限制
 	if time.Since(m.lastWriteTime) > m.opt.MaxLifeDuration {
@@ -114,6 +176,43 @@ func (m *Multiline) ProcessLine(text []byte) []byte {
 	return nil
 }
 
+func (m *Multiline) isStart(text []byte) bool {
+	switch {
+	case m.ended:
+		return true
+	case m.continueRegexp != nil:
+		return !m.continueRegexp.Match(text)
+	case m.endRegexp != nil && m.noPattern:
+		return false
+	default:
+		return m.Match(text)
+	}
+}
+
+func (m *Multiline) write(text []byte) {
+	if m.buff.Len() != 0 {
+		m.buff.Write(newLine)
+	}
+	m.buff.Write(text)
+	m.lines++
+	m.lastAppendTime = time.Now()
+}
+
+// checkEnd flushes the buff if the text is the end line. The previous text
+// flushed is returned first, and the buff is flushed by the next line or
+// timeout.
+func (m *Multiline) checkEnd(text, previousText []byte) []byte {
+	if m.endRegexp == nil || !m.endRegexp.Match(text) {
+		return previousText
+	}
+
+	if previousText != nil {
+		m.ended = true
+		return previousText
+	}
+	return m.Flush()
+}
+
 func (m *Multiline) BuffLength() int {
 	return m.buff.Len()
 }
@@ -126,7 +225,7 @@ func (m *Multiline) Flush() []byte {
 	text := make([]byte, m.buff.Len())
 	copy(text, m.buff.Bytes())
 
-	m.buff.Reset()
+	m.reset()
 	return text
 }
 
@@ -135,10 +234,24 @@ func (m *Multiline) FlushString() string {
 		return ""
 	}
 	text := m.buff.String()
-	m.buff.Reset()
+	m.reset()
 	return text
 }
 
+// FlushExpired flushes the buff if no line appended within FlushTimeout.
+func (m *Multiline) FlushExpired() string {
+	if m.opt.FlushTimeout <= 0 || m.buff.Len() == 0 || time.Since(m.lastAppendTime) < m.opt.FlushTimeout {
+		return ""
+	}
+	return m.FlushString()
+}
+
+func (m *Multiline) reset() {
+	m.buff.Reset()
+	m.lines = 0
+	m.ended = false
+}
+
 var asciiSpace = [256]uint8{'\t': 1, '\n': 1, '\v': 1, '\f': 1, '\r': 1, ' ': 1}
 
 func TrimRightSpace(s string) string {
@@ -104,6 +104,127 @@ func TestMultilineMatchLimit(t *testing.T) {
 	})
 }
 
+func TestMultilineModes(t *testing.T) {
+	process := func(m *Multiline, in []string) []string {
+		var out []string
+		for _, line := range in {
+			if res := m.ProcessLineString(line); res != "" {
+				out = append(out, res)
+			}
+		}
+		if res := m.FlushString(); res != "" {
+			out = append(out, res)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name     string
+		patterns []string
+		opt      *Option
+		in, out  []string
+	}{
+		{
+			name: "java-caused-by",
+			opt:  &Option{ContinuePattern: `^(\s+at |\s*\.\.\. \d+ more|Caused by:)`},
+			in: []string{
+				"java.lang.IllegalStateException: outer",
+				"\tat com.example.A.run(A.java:10)",
+				"Caused by: java.io.IOException: inner",
+				"\tat com.example.B.read(B.java:20)",
+				"\t... 3 more",
+				"next record",
+			},
+			out: []string{
+				"java.lang.IllegalStateException: outer\n\tat com.example.A.run(A.java:10)\nCaused by: java.io.IOException: inner\n\tat com.example.B.read(B.java:20)\n\t... 3 more",
+				"next record",
+			},
+		},
+		{
+			name: "python-traceback",
+			opt:  &Option{EndPattern: `^\w+(Error|Exception): `},
+			in: []string{
+				"Traceback (most recent call last):",
+				`  File "main.py", line 1, in <module>`,
+				"    raise ValueError('bad')",
+				"ValueError: bad",
+				"Traceback (most recent call last):",
+				`  File "main.py", line 2, in <module>`,
+				"KeyError: 'x'",
+			},
+			out: []string{
+				"Traceback (most recent call last):\n  File \"main.py\", line 1, in <module>\n    raise ValueError('bad')\nValueError: bad",
+				"Traceback (most recent call last):\n  File \"main.py\", line 2, in <module>\nKeyError: 'x'",
+			},
+		},
+		{
+			name:     "start-and-end",
+			patterns: []string{`^\d{4}-\d{2}-\d{2}`},
+			opt:      &Option{EndPattern: `^END$`},
+			in: []string{
+				"2023-01-01 first",
+				"body",
+				"2023-01-01 single END",
+				"END",
+				"orphan",
+				"2023-01-01 second",
+			},
+			out: []string{
+				"2023-01-01 first\nbody",
+				"2023-01-01 single END\nEND",
+				"orphan",
+				"2023-01-01 second",
+			},
+		},
+		{
+			name:     "max-lines",
+			patterns: []string{`^\S`},
+			opt:      &Option{MaxLines: 2},
+			in:       []string{"head", " 1", " 2", " 3", "next"},
+			out:      []string{"head\n 1", " 2\n 3", "next"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(tc.patterns, tc.opt)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.out, process(m, tc.in))
+		})
+	}
+
+	t.Run("end-line-as-start", func(t *testing.T) {
+		m, err := New(nil, &Option{ContinuePattern: `^\s`, EndPattern: `;$`})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "", m.ProcessLineString("a"))
+		assert.Equal(t, "a", m.ProcessLineString("b;"))
+		// the record ended is flushed by the next line
+		assert.Equal(t, "b;", m.ProcessLineString(" c"))
+		assert.Equal(t, " c", m.FlushString())
+	})
+
+	t.Run("flush-timeout", func(t *testing.T) {
+		m, err := New([]string{`^\S`}, &Option{FlushTimeout: time.Millisecond * 50})
+		assert.NoError(t, err)
+
+		_ = m.ProcessLineString("head")
+		_ = m.ProcessLineString(" body")
+		assert.Equal(t, "", m.FlushExpired())
+
+		time.Sleep(time.Millisecond * 100)
+		assert.Equal(t, "head\n body", m.FlushExpired())
+		assert.Equal(t, 0, m.BuffLength())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := New(nil, &Option{ContinuePattern: "(?!"})
+		assert.Error(t, err)
+		_, err = New(nil, &Option{EndPattern: "(?!"})
+		assert.Error(t, err)
+	})
+}
+
 func TestNewMultiline(t *testing.T) {
 	t.Run("ok-1", func(t *testing.T) {
 		_, err := New(nil, nil)
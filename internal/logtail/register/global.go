@@ -49,6 +49,13 @@ func Get(key string) *MetaData {
 	return globalRegister.Get(key)
 }
 
+func GetByFingerprint(fingerprint string) map[string]*MetaData {
+	if assertTesting || globalRegister == nil || fingerprint == "" {
+		return nil
+	}
+	return globalRegister.GetByFingerprint(fingerprint)
+}
+
 func SetAndFlush(key string, value *MetaData) error {
 	if assertTesting {
 		return nil
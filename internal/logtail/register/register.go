@@ -21,15 +21,27 @@ const defaultFlushFactor = 32
 type MetaData struct {
 	Source string `json:"source"`
 	Offset int64  `json:"offset"`
+	// Done is true if the archive has been read to the end
+	Done bool `json:"done,omitempty"`
+	// Fingerprint is the hash of the first FingerprintSize bytes of the file,
+	// to identify the file regardless of the path and inode
+	Fingerprint     string `json:"fingerprint,omitempty"`
+	FingerprintSize int64  `json:"fingerprint_size,omitempty"`
+	// MultilinePattern is the pattern learned by auto multiline of the source
+	MultilinePattern string `json:"multiline_pattern,omitempty"`
+	// Cursor is the cursor of the last journal entry read
+	Cursor string `json:"cursor,omitempty"`
 }
 
 func (m *MetaData) String() string {
-	return fmt.Sprintf("source: %s, offset: %d", m.Source, m.Offset)
+	return fmt.Sprintf("source: %s, offset: %d, fingerprint: %s", m.Source, m.Offset, m.Fingerprint)
 }
 
 type Register interface {
 	Set(string, *MetaData) error
 	Get(string) *MetaData
+	// GetByFingerprint returns the data of the fingerprint by the keys
+	GetByFingerprint(string) map[string]*MetaData
 	Flush() error
 }
 
@@ -40,6 +52,9 @@ type register struct {
 	count       int // set count
 	flushFactor int
 
+	// keys by the fingerprint, built on the first lookup
+	fingerprints map[string]map[string]struct{}
+
 	mu sync.Mutex
 }
 
@@ -121,6 +136,13 @@ func (r *register) Set(key string, value *MetaData) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.fingerprints != nil {
+		if old := r.Data[key]; old != nil {
+			r.unindex(old.Fingerprint, key)
+		}
+		r.index(value.Fingerprint, key)
+	}
+
 	r.Data[key] = value
 	r.count++
 	if r.count%r.flushFactor == 0 {
@@ -138,6 +160,51 @@ func (r *register) Get(key string) *MetaData {
 	return v
 }
 
+func (r *register) GetByFingerprint(fingerprint string) map[string]*MetaData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fingerprints == nil {
+		r.fingerprints = make(map[string]map[string]struct{})
+		for k, v := range r.Data {
+			r.index(v.Fingerprint, k)
+		}
+	}
+
+	var res map[string]*MetaData
+	for k := range r.fingerprints[fingerprint] {
+		if v := r.Data[k]; v != nil && v.Fingerprint == fingerprint {
+			if res == nil {
+				res = make(map[string]*MetaData)
+			}
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func (r *register) index(fingerprint, key string) {
+	if fingerprint == "" {
+		return
+	}
+
+	keys, ok := r.fingerprints[fingerprint]
+	if !ok {
+		keys = make(map[string]struct{})
+		r.fingerprints[fingerprint] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func (r *register) unindex(fingerprint, key string) {
+	if keys, ok := r.fingerprints[fingerprint]; ok {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(r.fingerprints, fingerprint)
+		}
+	}
+}
+
 func parse(b []byte) (*register, error) {
 	r := register{}
 	if len(b) != 0 {
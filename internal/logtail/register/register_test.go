@@ -188,3 +188,30 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestGetByFingerprint(t *testing.T) {
+	r := &register{
+		Data: map[string]*MetaData{
+			"a::1": {Source: "source", Offset: 100, Fingerprint: "fp1", FingerprintSize: 4096},
+			"b::2": {Source: "source", Offset: 200, Fingerprint: "fp1", FingerprintSize: 4096},
+			"c::3": {Source: "source", Offset: 300, Fingerprint: "fp2", FingerprintSize: 4096},
+			"d::4": {Source: "source", Offset: 400},
+		},
+		flushFactor: defaultFlushFactor,
+	}
+
+	assert.Equal(t, map[string]*MetaData{"a::1": r.Data["a::1"], "b::2": r.Data["b::2"]}, r.GetByFingerprint("fp1"))
+	assert.Equal(t, map[string]*MetaData{"c::3": r.Data["c::3"]}, r.GetByFingerprint("fp2"))
+	assert.Nil(t, r.GetByFingerprint("fp3"))
+
+	// index updated
+	assert.NoError(t, r.Set("b::2", &MetaData{Source: "source", Offset: 250, Fingerprint: "fp2"}))
+	assert.NoError(t, r.Set("e::5", &MetaData{Source: "source", Offset: 500, Fingerprint: "fp3"}))
+	assert.Equal(t, map[string]*MetaData{"a::1": r.Data["a::1"]}, r.GetByFingerprint("fp1"))
+	assert.Len(t, r.GetByFingerprint("fp2"), 2)
+	assert.Equal(t, int64(500), r.GetByFingerprint("fp3")["e::5"].Offset)
+
+	globalRegister = r
+	defer func() { globalRegister = nil }()
+	assert.Nil(t, GetByFingerprint(""))
+}
@@ -59,6 +59,15 @@ ulimit = 64000
   # IP database type, support iploc and geolite2
   ipdb_type = "iploc"
 
+  # User-provided CIDR database(CSV or JSON) which map subnets to fields like
+  # datacenter/team, geoip() searches it first. Relative path is under the
+  # ipdb directory(<DataKit-Install-Dir>/data/ipdb).
+  ipdb_cidr_file = ""
+
+  # How often to check IP database files and reload the changed ones, empty
+  # to disable reloading.
+  ipdb_reload_interval = "1m"
+
   # How often to sync remote pipeline
   remote_pull_interval = "1m"
 
@@ -76,13 +85,71 @@ ulimit = 64000
   # or use pure memory to cache the reftab data
   sqlite_mem_mode = false
 
+  # reftab loaded from local CSV/JSON files or SQL queries(sqlite/mysql/postgres),
+  # rows are updated incrementally by the primary key.
+  #[[pipeline.refer_table_sources]]
+  #  table_name = "cmdb_host"
+  #  primary_key = "host"
+  #  path = "/usr/local/datakit/data/cmdb_host.csv"
+  #  interval = "1m"
+
+  # How many compiled versions kept for each pipeline script
+  script_versions = 5
+
+  # Rollback script automatically if error rate of its new version spiked:
+  # at least rollback_min_points processed and error rate not less than
+  # rollback_error_rate.
+  auto_rollback = false
+  rollback_error_rate = 0.5
+  rollback_min_points = 100
+
+  # If the script failed, upload data processed so far with fields
+  # pl_error/pl_error_func/pl_error_pos added, instead of the original data.
+  annotate_error = false
+
+  # Time budget of a script running on a point(disabled if empty), the script
+  # exits before the next statement once exceeded, and the point is kept(skip)
+  # or dropped(drop) according to script_timeout_action.
+  script_timeout = ""
+  script_timeout_action = "skip"
+
+  # Record the time spent by each function of the scripts, always recorded
+  # if script_timeout set.
+  func_cost = false
+
+  # Canary of remote pipeline scripts: new version of a remote script runs on
+  # a sample fraction of points in parallel with the script in use, and only
+  # promoted when its error/drop rate and field count are within thresholds.
+  [pipeline.remote_canary]
+    enable = false
+    sample_rate = 0.1
+    min_points = 100
+    max_error_rate_delta = 0.05
+    max_drop_rate_delta = 0.1
+    min_field_ratio = 0.8
+    max_wait = "10m"
+
   # Offload data processing tasks to post-level data processors.
   [pipeline.offload]
-    receiver = "datakit-http"
+    receiver = "datakit-http" # datakit-http/http/kafka
     addresses = [
       # "http://<ip>:<port>"
     ]
 
+    # max number of points sent to receiver at once
+    # batch_size = 128
+
+    # points with the same value of the tag always sent to the same address
+    # hash_tag = "host"
+
+    # body format of receiver http and kafka: lineprotocol/json/protobuf
+    # format = "lineprotocol"
+
+    # [pipeline.offload.kafka]
+    #   topic = "datakit_offload_{category}"
+    #   kafka_version = ""
+    #   compression = "none"
+
 ################################################
 # HTTP server(9529)
 ################################################
@@ -122,11 +189,21 @@ ulimit = 64000
   flush_workers   = 0 # default to (cpu_core * 2 + 1)
   flush_interval  = "10s"
 
-  # We can write these data points into file in line-proto format(truncated at 32MB).
+  # We can write these data points into file in line-proto format.
   output_file = ""
   # only these input data points write to file. If list empy and output_file set,
   # all points are write to the file.
   output_file_inputs = []
+  # Rotate the file if its size exceeded(in MB) or it opened longer
+  # than the interval, 0 or empty to disable.
+  output_file_max_size_mb = 32
+  output_file_rotate_interval = ""
+  # Max rotated files kept(0 to keep all), and gzip rotated files or not.
+  output_file_max_files = 3
+  output_file_compress = false
+  # Write each input's points into its own file, i.e., for input cpu,
+  # /path/to/out.lp => /path/to/out.cpu.lp
+  output_file_per_input = false
 
   # Disk cache on datakit upload failed
   enable_cache = false
@@ -139,6 +216,26 @@ ulimit = 64000
   # failed-data-point at specified interval.
   cache_clean_interval = "5s"
 
+  # WAL(write-ahead log) on feeding data: all feeding points are logged on disk
+  # before queued in memory, and replayed on next start if not flushed.
+  enable_wal = false
+  # Max WAL disk size(in MB) of each category, if reached, old data dropped.
+  wal_max_size_mb = 1024
+  # Do not fsync on each WAL write. Data may lost on host crash(not datakit crash).
+  wal_no_sync = false
+
+  # Priority of categories(higher in front). If io queue usage reached
+  # shed_watermark(0.0~1.0), points of lower priority categories(and these
+  # not listed) are dropped before queued, the first one is never dropped.
+  feed_priority = [] # i.e., ["metric", "keyevent", "object", "tracing", "logging"]
+  shed_watermark = 0.8
+
+  # Max points per second of each category/input, points exceeded are dropped.
+  #[io.category_rate_limits]
+  #  logging = 10000.0
+  #[io.input_rate_limits]
+  #  "logging/nginx" = 1000.0
+
   # Data point filter configures.
   # NOTE: Most of the time, you should use web-side filter, it's a debug helper for developers.
   #[io.filters]
@@ -156,6 +253,27 @@ ulimit = 64000
   #    "{ service = re("abc.*") AND some_tag CONTAIN ['def_.*'] }",
   #  ]
 
+  # Extra outputs besides Dataway, selected by categories.
+  #[[io.outputs]]
+  #  type = "kafka" # kafka/otlp/file
+  #  categories = ["logging"]
+  #  skip_dataway = false # if true, these categories not sent to Dataway
+  #  [io.outputs.kafka]
+  #    addrs = ["localhost:9092"]
+  #    topic = "datakit_{category}"
+  #
+  #[[io.outputs]]
+  #  type = "otlp" # only metric supported
+  #  categories = ["metric"]
+  #  [io.outputs.otlp]
+  #    endpoint = "localhost:4317"
+  #
+  #[[io.outputs]]
+  #  type = "file"
+  #  categories = ["object"]
+  #  [io.outputs.file]
+  #    path = "/var/log/datakit/object.lp"
+
 ################################################
 # Dataway configure
 ################################################
@@ -175,7 +293,31 @@ ulimit = 64000
   enable_httptrace = false   # enable trace HTTP metrics(connection/NDS/TLS and so on)
   idle_timeout     = "90s"   # not-set, default 90s
 
+  # Load balance among multiple URLs(they should be the same workspace, such as
+  # Dataway nodes behind HA): round_robin/least_latency. Points are written to
+  # one of the healthy URLs and failover to others on error. If not set, points
+  # are written to all URLs.
+  #load_balance             = "round_robin"
+  #health_check_interval    = "10s" # ping URLs interval, default 10s under load balance
+  #circuit_breaker_failures = 3     # skip URL after these consecutive failures, default 3 under load balance
+  #circuit_breaker_timeout  = "30s" # how long the URL skipped before next try
+
+  # Compression of upload body: gzip(default)/zstd/snappy. zstd got smaller body,
+  # and snappy cost less CPU. If Dataway not accept the encoding, fallback to gzip.
+  #content_encoding = "gzip"
+
+  # Max body size(before compression) of each upload, default 10MB. With adaptive
+  # batching, body size adjusted within [min_body_bytes, max_body_bytes] to keep
+  # upload latency around batch_target_latency.
+  #max_body_bytes       = 10000000
+  #adaptive_batch       = false
+  #min_body_bytes       = 65536
+  #batch_target_latency = "1s"
+
   # Sinkers: DataKit are able to upload data point to multiple workspace
+  #sinker_fan_out        = false # copy point to every matched sinker, default only the first matched one
+  #sinker_drop_unmatched = false # drop points not sinked, default they send to urls above
+  #
   #[[dataway.sinkers]]
   #  categories = [ "L/M/O/..." ]
   #  filters = [
@@ -183,6 +325,8 @@ ulimit = 64000
   #    "{ source = 'some-logging-source'}",
   #  ]
   #  url = "https//openway.guance.com?token=<YOUR-TOKEN>"
+  #  percent = 0     # percent(0~100) of matched points sinked, 0 for all
+  #  mirror  = false # copy matched points to the sinker, and these points still routed to other sinkers or urls above
   #
   #[[dataway.sinkers]]
   #  another sinker...
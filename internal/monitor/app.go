@@ -15,17 +15,19 @@ import (
 	"github.com/dustin/go-humanize"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/rivo/tview"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 )
 
 var (
 	l = logger.DefaultSLogger("monitor")
 
 	inputsFeedCols   = strings.Split(`Input|Cat|Feeds|TotalPts|Filtered|LastFeed|AvgCost|Errors`, "|")
-	plStatsCols      = strings.Split("Script|Cat|Namespace|TotalPts|DropPts|ErrPts|PLUpdate|AvgCost", "|")
+	plStatsCols      = strings.Split("Script|Cat|Namespace|TotalPts|DropPts|ErrPts|PLUpdate|AvgCost|Timeout|TopFunc", "|")
 	enabledInputCols = strings.Split(`Input|Count|Crashed`, "|")
 	goroutineCols    = strings.Split(`Name|Running|Done|TotalCost`, "|")
 	httpAPIStatCols  = strings.Split(`API|Status|Total|Latency|BodySize`, "|")
 	filterRuleCols   = strings.Split("Cat|Total|Filtered(%)|Cost", "|")
+	filterHitCols    = strings.Split("Cat|Hash|Hits|LastHit|Rule|LastMatched", "|")
 	ioStatCols       = strings.Split(`Cat|ChanUsage|Points(ok/total)|Bytes(ok/total/gz)`, "|")
 	dwCols           = strings.Split(`API|Status|Count|Latency|Retry`, "|")
 
@@ -56,6 +58,7 @@ type monitorAPP struct {
 
 	filterStatsTable      *tview.Table
 	filterRulesStatsTable *tview.Table
+	filterRuleHitsTable   *tview.Table
 
 	exitPrompt     *tview.TextView
 	anyErrorPrompt *tview.TextView
@@ -64,6 +67,8 @@ type monitorAPP struct {
 
 	mfs map[string]*dto.MetricFamily
 
+	filterStats *filter.Stats
+
 	inputsStats map[string]string
 
 	anyError error
@@ -76,6 +81,7 @@ type monitorAPP struct {
 	maxRun                  int
 	refresh                 time.Duration
 	isURL                   string
+	filterURL               string
 	url                     string
 	onlyInputs, onlyModules []string
 }
@@ -123,6 +129,11 @@ func (app *monitorAPP) refreshData() {
 				app.anyError = fmt.Errorf("request stats failed: %w", err)
 			}
 
+			if app.verbose || exitsStr(app.onlyModules, moduleFilter) {
+				// filter stats API may not available on older datakit, ignore the error
+				app.filterStats, _ = requestFilterStats(app.filterURL)
+			}
+
 			// app.inputsStats, err = requestInputInfo(app.isURL)
 			// if err != nil {
 			//	app.anyError = fmt.Errorf("request input stats failed: %w", err)
@@ -28,6 +28,7 @@ func (app *monitorAPP) setupFlex() {
 										AddItem(app.filterStatsTable, 0, 2, false).      // filter stats
 										AddItem(app.filterRulesStatsTable, 0, 8, false), // filter rules stats
 				0, 10, false).
+			AddItem(app.filterRuleHitsTable, 0, 10, false).
 			AddItem(app.plStatTable, 0, 15, false).
 			AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).
 				AddItem(app.ioStatTable, 0, 10, false).
@@ -50,6 +51,7 @@ func (app *monitorAPP) setupFlex() {
 		if exitsStr(app.onlyModules, moduleFilter) {
 			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(app.filterStatsTable, 0, 10, false), 0, 10, false)
 			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(app.filterRulesStatsTable, 0, 10, false), 0, 10, false)
+			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(app.filterRuleHitsTable, 0, 10, false), 0, 10, false)
 		}
 
 		if exitsStr(app.onlyModules, moduleGoroutine) {
@@ -33,6 +33,7 @@ func WithHost(ipaddr string) APPOption {
 	return func(app *monitorAPP) {
 		app.url = fmt.Sprintf("http://%s/metrics", ipaddr)
 		app.isURL = fmt.Sprintf("http://%s/stats/input", ipaddr)
+		app.filterURL = fmt.Sprintf("http://%s/v1/filter/stats", ipaddr)
 	}
 }
 
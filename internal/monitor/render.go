@@ -22,6 +22,7 @@ func (app *monitorAPP) render() {
 	app.dwTable.Clear()
 	app.filterStatsTable.Clear()
 	app.filterRulesStatsTable.Clear()
+	app.filterRuleHitsTable.Clear()
 
 	app.renderBasicInfoTable(app.mfs)
 	app.renderGolangRuntimeTable(app.mfs)
@@ -33,6 +34,7 @@ func (app *monitorAPP) render() {
 
 	app.renderFilterStatsTable(app.mfs)
 	app.renderFilterRulesStatsTable(app.mfs, filterRuleCols)
+	app.renderFilterRuleHitsTable(app.filterStats, filterHitCols)
 	app.renderPLStatTable(app.mfs, plStatsCols)
 	app.renderIOTable(app.mfs, ioStatCols)
 	app.renderDatawayTable(app.mfs, dwCols)
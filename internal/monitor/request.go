@@ -6,10 +6,14 @@
 package monitor
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 )
 
 func requestMetrics(url string) (map[string]*dto.MetricFamily, error) {
@@ -74,3 +78,31 @@ func requestInputInfo(url string) (map[string]string, error) {
 
 	return inputs, nil
 } */
+
+func requestFilterStats(url string) (*filter.Stats, error) {
+	resp, err := http.Get(url) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", string(body))
+	}
+
+	var x struct {
+		Content *filter.Stats `json:"content"`
+	}
+
+	if err := json.Unmarshal(body, &x); err != nil {
+		return nil, err
+	}
+
+	return x.Content, nil
+}
@@ -54,6 +54,9 @@ func (app *monitorAPP) setup() {
 	app.filterRulesStatsTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false).SetSeparator(tview.Borders.Vertical)
 	app.filterRulesStatsTable.SetBorder(true).SetTitle("[red]F[white]ilter Rules").SetTitleAlign(tview.AlignLeft)
 
+	app.filterRuleHitsTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false).SetSeparator(tview.Borders.Vertical)
+	app.filterRuleHitsTable.SetBorder(true).SetTitle("[red]F[white]ilter Rule Hits").SetTitleAlign(tview.AlignLeft)
+
 	// bottom prompt
 	app.exitPrompt = tview.NewTextView().SetDynamicColors(true)
 	// error prompt
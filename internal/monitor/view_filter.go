@@ -6,6 +6,7 @@
 package monitor
 
 import (
+	"sort"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -13,6 +14,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/rivo/tview"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/filter"
 )
 
 func (app *monitorAPP) renderFilterRulesStatsTable(mfs map[string]*dto.MetricFamily, colArr []string) {
@@ -132,3 +134,56 @@ func (app *monitorAPP) renderFilterStatsTable(mfs map[string]*dto.MetricFamily)
 			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignLeft))
 	}
 }
+
+func (app *monitorAPP) renderFilterRuleHitsTable(stats *filter.Stats, colArr []string) {
+	table := app.filterRuleHitsTable
+
+	if app.anyError != nil {
+		return
+	}
+
+	// set table header
+	for idx := range colArr {
+		table.SetCell(0, idx, tview.NewTableCell(colArr[idx]).
+			SetMaxWidth(app.maxTableWidth).
+			SetTextColor(tcell.ColorGreen).
+			SetAlign(tview.AlignRight))
+	}
+
+	if stats == nil {
+		return
+	}
+
+	// rules with more hits first
+	rules := make([]*filter.RuleStats, len(stats.Rules))
+	copy(rules, stats.Rules)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Hits > rules[j].Hits
+	})
+
+	now := time.Now()
+
+	// Cat|Hash|Hits|LastHit|Rule|LastMatched
+	for i, r := range rules {
+		row := i + 1
+
+		table.SetCell(row, 0, tview.NewTableCell(point.CatString(r.Category).Alias()).
+			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignCenter))
+		table.SetCell(row, 1, tview.NewTableCell(r.Hash).
+			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignCenter))
+		table.SetCell(row, 2, tview.NewTableCell(number(r.Hits)).
+			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignRight))
+
+		lastHit := "-"
+		if r.Hits > 0 {
+			lastHit = humanize.RelTime(r.LastHit, now, "ago", "")
+		}
+		table.SetCell(row, 3, tview.NewTableCell(lastHit).
+			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignRight))
+
+		table.SetCell(row, 4, tview.NewTableCell(r.Rule).
+			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignLeft))
+		table.SetCell(row, 5, tview.NewTableCell(r.LastMatched).
+			SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignLeft))
+	}
+}
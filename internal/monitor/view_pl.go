@@ -28,6 +28,8 @@ func (app *monitorAPP) renderPLStatTable(mfs map[string]*dto.MetricFamily, colAr
 	totalDropPts := mfs["datakit_pipeline_drop_point_total"]
 	lastUpdate := mfs["datakit_pipeline_last_update_timestamp_seconds"]
 	cost := mfs["datakit_pipeline_cost_seconds"]
+	timeoutPts := mfs["datakit_pipeline_timeout_point_total"]
+	funcCost := mfs["datakit_pipeline_function_cost_seconds_total"]
 
 	if totalPts == nil {
 		table.SetTitle("[red]P[white]ipeline Info(no data collected)")
@@ -135,7 +137,81 @@ func (app *monitorAPP) renderPLStatTable(mfs map[string]*dto.MetricFamily, colAr
 			table.SetCell(row, col, tview.NewTableCell("-").
 				SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignCenter))
 		}
+		col++
+
+		if timeoutPts != nil {
+			x := metricWithLabel(timeoutPts, cat, name, ns)
+			if x == nil {
+				table.SetCell(row, col, tview.NewTableCell("-").
+					SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignCenter))
+			} else {
+				table.SetCell(row, col, tview.NewTableCell(number(x.GetCounter().GetValue())).
+					SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignRight))
+			}
+		} else {
+			table.SetCell(row, col, tview.NewTableCell("-").
+				SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignCenter))
+		}
+		col++
+
+		if fn, pct := topFunc(funcCost, cat, name, ns); fn != "" {
+			table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("%s(%.0f%%)", fn, pct)).
+				SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignRight))
+		} else {
+			table.SetCell(row, col, tview.NewTableCell("-").
+				SetMaxWidth(app.maxTableWidth).SetAlign(tview.AlignCenter))
+		}
 
 		row++
 	}
 }
+
+// topFunc returns the function spent most of the time within the script,
+// and its percentage of time spent by all functions.
+func topFunc(mf *dto.MetricFamily, cat, name, ns string) (string, float64) {
+	if mf == nil {
+		return "", 0
+	}
+
+	var top string
+	var max, total float64
+
+	for _, m := range mf.Metric {
+		var fn string
+		matched := 0
+		for _, lp := range m.GetLabel() {
+			val := lp.GetValue()
+			switch lp.GetName() {
+			case "category":
+				if val == cat {
+					matched++
+				}
+			case "name":
+				if val == name {
+					matched++
+				}
+			case "namespace":
+				if val == ns {
+					matched++
+				}
+			case "function":
+				fn = val
+			}
+		}
+
+		if matched != 3 {
+			continue
+		}
+
+		v := m.GetCounter().GetValue()
+		total += v
+		if v > max {
+			top, max = fn, v
+		}
+	}
+
+	if total == 0 {
+		return "", 0
+	}
+	return top, max / total * 100
+}
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ipdb
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const cidrColumn = "cidr"
+
+type cidrEntry struct {
+	ipnet  *net.IPNet
+	ones   int
+	fields map[string]string
+}
+
+// CIDRDB is the user-provided database which map subnets(such as internal
+// subnets) to fields like datacenter, team, city or isp. The file is CSV
+// with a header line or JSON array of objects, both require the column
+// `cidr`, other columns are the fields.
+type CIDRDB struct {
+	mu      sync.RWMutex
+	file    string
+	entries []*cidrEntry // sorted by prefix length, the longest first
+}
+
+func NewCIDRDB(file string) (*CIDRDB, error) {
+	db := &CIDRDB{file: file}
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *CIDRDB) Files() []string {
+	return []string{db.file}
+}
+
+func (db *CIDRDB) Reload() error {
+	data, err := os.ReadFile(filepath.Clean(db.file))
+	if err != nil {
+		return err
+	}
+
+	var rows []map[string]string
+	if strings.EqualFold(filepath.Ext(db.file), ".json") {
+		rows, err = parseCIDRJSON(data)
+	} else {
+		rows, err = parseCIDRCSV(data)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", db.file, err)
+	}
+
+	entries := make([]*cidrEntry, 0, len(rows))
+	for i, row := range rows {
+		ipnet, err := parseCIDR(row[cidrColumn])
+		if err != nil {
+			return fmt.Errorf("parse %s: row %d: %w", db.file, i+1, err)
+		}
+		delete(row, cidrColumn)
+
+		ones, _ := ipnet.Mask.Size()
+		entries = append(entries, &cidrEntry{ipnet: ipnet, ones: ones, fields: row})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ones > entries[j].ones
+	})
+
+	db.mu.Lock()
+	db.entries = entries
+	db.mu.Unlock()
+
+	return nil
+}
+
+// Lookup returns fields of the longest matched subnet, nil if not matched.
+// Subnets checked one by one, which is enough for a few thousands of them.
+func (db *CIDRDB) Lookup(ip string) map[string]string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, e := range db.entries {
+		if e.ipnet.Contains(addr) {
+			res := make(map[string]string, len(e.fields))
+			for k, v := range e.fields {
+				res[k] = v
+			}
+			return res
+		}
+	}
+
+	return nil
+}
+
+// parseCIDR accepts subnet like `10.0.0.0/8` or single IP.
+func parseCIDR(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid cidr %q", s)
+		}
+
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+	return ipnet, nil
+}
+
+func parseCIDRCSV(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		if header[i] == cidrColumn {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("column %q not found in header", cidrColumn)
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF { //nolint:errorlint
+				break
+			}
+			return nil, err
+		}
+
+		row := make(map[string]string, len(header))
+		for i, v := range record {
+			if v = strings.TrimSpace(v); v != "" {
+				row[header[i]] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseCIDRJSON(data []byte) ([]map[string]string, error) {
+	var arr []map[string]any
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]string, 0, len(arr))
+	for _, obj := range arr {
+		row := make(map[string]string, len(obj))
+		for k, v := range obj {
+			switch x := v.(type) {
+			case nil:
+			case string:
+				row[k] = x
+			default:
+				row[k] = fmt.Sprint(x)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ipdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIDRDB(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "internal.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte(`# internal subnets
+cidr,datacenter,team,city
+10.0.0.0/8,dc-sh,infra,Shanghai
+10.1.0.0/16,dc-bj,,Beijing
+10.1.2.3,dc-bj,db,Beijing
+fd00::/8,dc-v6,net,
+`), os.ModePerm))
+
+	jsonFile := filepath.Join(dir, "internal.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[
+		{"cidr": "10.0.0.0/8", "datacenter": "dc-sh", "team": "infra", "city": "Shanghai"},
+		{"cidr": "10.1.0.0/16", "datacenter": "dc-bj", "city": "Beijing"},
+		{"cidr": "10.1.2.3", "datacenter": "dc-bj", "team": "db", "city": "Beijing"},
+		{"cidr": "fd00::/8", "datacenter": "dc-v6", "team": "net", "rack": 12}
+	]`), os.ModePerm))
+
+	for _, f := range []string{csvFile, jsonFile} {
+		t.Run(filepath.Ext(f), func(t *testing.T) {
+			db, err := NewCIDRDB(f)
+			require.NoError(t, err)
+
+			assert.Equal(t, map[string]string{"datacenter": "dc-sh", "team": "infra", "city": "Shanghai"},
+				db.Lookup("10.2.0.1"))
+			assert.Equal(t, map[string]string{"datacenter": "dc-bj", "city": "Beijing"},
+				db.Lookup("10.1.0.1"))
+			assert.Equal(t, map[string]string{"datacenter": "dc-bj", "team": "db", "city": "Beijing"},
+				db.Lookup("10.1.2.3"))
+			assert.Equal(t, "dc-v6", db.Lookup("fd00::1")["datacenter"])
+
+			assert.Nil(t, db.Lookup("192.168.0.1"))
+			assert.Nil(t, db.Lookup("invalid-ip"))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		f := filepath.Join(dir, "invalid.csv")
+
+		require.NoError(t, os.WriteFile(f, []byte("cidr,team\n10.0.0.0/33,infra\n"), os.ModePerm))
+		_, err := NewCIDRDB(f)
+		assert.Error(t, err)
+
+		require.NoError(t, os.WriteFile(f, []byte("subnet,team\n10.0.0.0/8,infra\n"), os.ModePerm))
+		_, err = NewCIDRDB(f)
+		assert.Error(t, err)
+
+		_, err = NewCIDRDB(filepath.Join(dir, "not-exist.csv"))
+		assert.Error(t, err)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		f := filepath.Join(dir, "reload.csv")
+		require.NoError(t, os.WriteFile(f, []byte("cidr,team\n10.0.0.0/8,infra\n"), os.ModePerm))
+
+		db, err := NewCIDRDB(f)
+		require.NoError(t, err)
+
+		w := NewFileWatcher(db.Files())
+		assert.False(t, w.Changed())
+
+		require.NoError(t, os.WriteFile(f, []byte("cidr,team\n10.0.0.0/8,sre\n"), os.ModePerm))
+		require.NoError(t, os.Chtimes(f, time.Now(), time.Now().Add(time.Second)))
+		assert.True(t, w.Changed())
+		assert.False(t, w.Changed())
+
+		require.NoError(t, db.Reload())
+		assert.Equal(t, "sre", db.Lookup("10.0.0.1")["team"])
+
+		// invalid content keeps the database loaded before
+		require.NoError(t, os.WriteFile(f, []byte("cidr,team\nxxx,sre\n"), os.ModePerm))
+		assert.Error(t, db.Reload())
+		assert.Equal(t, "sre", db.Lookup("10.0.0.1")["team"])
+
+		// removed file ignored
+		require.NoError(t, os.Remove(f))
+		assert.False(t, w.Changed())
+	})
+}
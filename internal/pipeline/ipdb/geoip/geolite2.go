@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"sync"
 
 	"github.com/GuanceCloud/cliutils/logger"
 	"github.com/oschwald/geoip2-golang"
@@ -27,23 +28,21 @@ var openDB = func(f string) (*geoip2.Reader, error) {
 }
 
 type Geoip struct {
-	db *geoip2.Reader
+	mu    sync.RWMutex
+	db    *geoip2.Reader
+	asnDB *geoip2.Reader
+
+	cityFile string
+	asnFile  string
 }
 
-func (g *Geoip) loadIPLib(f string) error {
+func (g *Geoip) loadIPLib(f string) (*geoip2.Reader, error) {
 	if !datakit.FileExist(f) {
 		l.Warnf("%v not found", f)
-		return nil
-	}
-
-	db, err := openDB(f)
-	if err != nil {
-		return err
-	} else {
-		g.db = db
+		return nil, nil
 	}
 
-	return nil
+	return openDB(f)
 }
 
 func (g *Geoip) Init(dataDir string, config map[string]string) {
@@ -51,6 +50,7 @@ func (g *Geoip) Init(dataDir string, config map[string]string) {
 	l.Debug("use geolite2 db")
 	ipdbDir := filepath.Join(dataDir, "ipdb", "geolite2", "GeoLite2-City_20220617")
 	ipdbFile := "GeoLite2-City.mmdb"
+	asnFile := "GeoLite2-ASN.mmdb"
 
 	if file, ok := config["geoip_file"]; ok {
 		if len(file) > 0 {
@@ -58,13 +58,68 @@ func (g *Geoip) Init(dataDir string, config map[string]string) {
 		}
 	}
 
-	if err := g.loadIPLib(filepath.Join(ipdbDir, ipdbFile)); err != nil {
+	if file, ok := config["asn_file"]; ok {
+		if len(file) > 0 {
+			asnFile = file
+		}
+	}
+
+	g.cityFile = filepath.Join(ipdbDir, ipdbFile)
+	g.asnFile = filepath.Join(ipdbDir, asnFile)
+
+	if err := g.Reload(); err != nil {
 		l.Warnf("geolite2 load ip lib error: %s", err.Error())
 	}
 }
 
+func (g *Geoip) Files() []string {
+	return []string{g.cityFile, g.asnFile}
+}
+
+// Reload open the City and ASN databases again, the database not found on
+// disk is kept in use.
+func (g *Geoip) Reload() error {
+	db, err := g.loadIPLib(g.cityFile)
+	if err != nil {
+		return err
+	}
+
+	asnDB, err := g.loadIPLib(g.asnFile)
+	if err != nil {
+		if db != nil {
+			db.Close() //nolint:errcheck,gosec
+		}
+		return err
+	}
+
+	g.mu.Lock()
+	var olds []*geoip2.Reader
+	if db != nil {
+		olds = append(olds, g.db)
+		g.db = db
+	}
+	if asnDB != nil {
+		olds = append(olds, g.asnDB)
+		g.asnDB = asnDB
+	}
+	g.mu.Unlock()
+
+	// no lookup on the old databases, since they are replaced under lock
+	for _, old := range olds {
+		if old != nil {
+			old.Close() //nolint:errcheck,gosec
+		}
+	}
+
+	return nil
+}
+
 func (g *Geoip) Geo(ip string) (*ipdb.IPdbRecord, error) {
 	record := &ipdb.IPdbRecord{}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
 	if g.db == nil {
 		return record, nil
 	}
@@ -112,3 +167,27 @@ func (g *Geoip) get(ip string) (*geoip2.City, error) {
 func (g *Geoip) SearchIsp(ip string) string {
 	return ""
 }
+
+func (g *Geoip) ASN(ip string) (*ipdb.ASNRecord, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.asnDB == nil {
+		return nil, fmt.Errorf("ASN DB not set")
+	}
+
+	ipParse := net.ParseIP(ip)
+	if ipParse == nil {
+		return nil, fmt.Errorf("invalid ip %q", ip)
+	}
+
+	r, err := g.asnDB.ASN(ipParse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ipdb.ASNRecord{
+		Number:       r.AutonomousSystemNumber,
+		Organization: r.AutonomousSystemOrganization,
+	}, nil
+}
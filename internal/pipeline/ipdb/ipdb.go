@@ -18,6 +18,24 @@ type IPdb interface {
 	SearchIsp(ip string) string
 }
 
+// ASNSearcher is implemented by IPdb which able to find the autonomous
+// system of IP.
+type ASNSearcher interface {
+	ASN(ip string) (*ASNRecord, error)
+}
+
+// Reloader is implemented by IPdb which able to reload its database files
+// after they changed on disk.
+type Reloader interface {
+	Files() []string
+	Reload() error
+}
+
+type ASNRecord struct {
+	Number       uint
+	Organization string
+}
+
 type IPdbRecord struct {
 	Country   string
 	Region    string
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/GuanceCloud/cliutils/logger"
 	"github.com/ip2location/ip2location-go"
@@ -40,8 +41,12 @@ const (
 )
 
 type IPloc struct {
+	mu    sync.RWMutex
 	db    DB
 	ispDB map[string]string
+
+	iplocFile string
+	ispFile   string
 }
 
 func (iploc *IPloc) Init(dataDir string, config map[string]string) {
@@ -62,44 +67,77 @@ func (iploc *IPloc) Init(dataDir string, config map[string]string) {
 		}
 	}
 
-	if err := iploc.loadIPLib(filepath.Join(ipdbDir, iplocFile)); err != nil {
-		l.Warnf("iploc load ip lib error: %s", err.Error())
-	}
+	iploc.iplocFile = filepath.Join(ipdbDir, iplocFile)
+	iploc.ispFile = filepath.Join(ipdbDir, ispFile)
 
 	iploc.ispDB = map[string]string{}
 
-	if err := iploc.loadISP(filepath.Join(ipdbDir, ispFile)); err != nil {
-		l.Warnf("isp file load error: %s", err.Error())
+	if err := iploc.Reload(); err != nil {
+		l.Warnf("iploc reload error: %s", err.Error())
 	}
 }
 
-func (iploc *IPloc) loadIPLib(f string) error {
-	if !datakit.FileExist(f) {
-		l.Warnf("%v not found", f)
-		return nil
+func (iploc *IPloc) Files() []string {
+	return []string{iploc.iplocFile, iploc.ispFile}
+}
+
+// Reload load the ip location and isp files again, the file not found on
+// disk or failed to load is kept in use.
+func (iploc *IPloc) Reload() error {
+	var errs []string
+
+	db, err := iploc.loadIPLib(iploc.iplocFile)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("iploc load ip lib error: %s", err.Error()))
 	}
 
-	db, err := openDB(f)
+	ispDB, err := iploc.loadISP(iploc.ispFile)
 	if err != nil {
-		return err
-	} else {
+		errs = append(errs, fmt.Sprintf("isp file load error: %s", err.Error()))
+	}
+
+	iploc.mu.Lock()
+	old := iploc.db
+	if db != nil {
 		iploc.db = db
 	}
+	if ispDB != nil {
+		iploc.ispDB = ispDB
+	}
+	iploc.mu.Unlock()
+
+	if db != nil && old != nil {
+		if c, ok := old.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
 
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
 	return nil
 }
 
-func (iploc *IPloc) loadISP(f string) error {
+func (iploc *IPloc) loadIPLib(f string) (DB, error) {
+	if !datakit.FileExist(f) {
+		l.Warnf("%v not found", f)
+		return nil, nil
+	}
+
+	return openDB(f)
+}
+
+func (iploc *IPloc) loadISP(f string) (map[string]string, error) {
 	m := make(map[string]string)
 
 	if !datakit.FileExist(f) {
 		l.Warnf("%v not found", f)
-		return nil
+		return nil, nil
 	}
 
 	fd, err := os.Open(filepath.Clean(f))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer fd.Close() //nolint:errcheck,gosec
@@ -119,16 +157,18 @@ func (iploc *IPloc) loadISP(f string) error {
 	}
 
 	if len(m) != 0 {
-		iploc.ispDB = m
 		l.Infof("found new %d rules", len(m))
-	} else {
-		l.Infof("no rules founded")
+		return m, nil
 	}
 
-	return nil
+	l.Infof("no rules founded")
+	return nil, nil
 }
 
 func (iploc *IPloc) SearchIsp(ip string) string {
+	iploc.mu.RLock()
+	defer iploc.mu.RUnlock()
+
 	if len(iploc.ispDB) == 0 {
 		return "unknown"
 	}
@@ -145,6 +185,10 @@ func (iploc *IPloc) SearchIsp(ip string) string {
 
 func (iploc *IPloc) Geo(ip string) (*ipdb.IPdbRecord, error) {
 	record := &ipdb.IPdbRecord{}
+
+	iploc.mu.RLock()
+	defer iploc.mu.RUnlock()
+
 	if iploc.db == nil {
 		return record, nil
 	}
@@ -77,4 +77,20 @@ func TestIPloc(t *testing.T) {
 
 		iplocInstance.db = backDB
 	})
+
+	t.Run("Reload", func(t *testing.T) {
+		assert.Equal(t, []string{filepath.Join(iplocDir, iplocFile), filepath.Join(iplocDir, ispFile)},
+			iplocInstance.Files())
+
+		err := ioutil.WriteFile(filepath.Join(iplocDir, ispFile), []byte("221.0.0.0/13 cmcc"), fs.ModePerm)
+		assert.NoError(t, err)
+
+		assert.NoError(t, iplocInstance.Reload())
+		assert.Equal(t, "cmcc", iplocInstance.SearchIsp("221.0.0.0"))
+
+		// removed isp file keeps the rules loaded before
+		assert.NoError(t, os.Remove(filepath.Join(iplocDir, ispFile)))
+		assert.NoError(t, iplocInstance.Reload())
+		assert.Equal(t, "cmcc", iplocInstance.SearchIsp("221.0.0.0"))
+	})
 }
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ipdb
+
+import (
+	"os"
+	"time"
+)
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// FileWatcher detect changes of database files by polling their modify time
+// and size. Polling instead of inotify, because files replaced by rename or
+// by the symlink swap of Kubernetes ConfigMap are easily missed by inotify.
+type FileWatcher struct {
+	files []string
+	stats map[string]fileStat
+}
+
+func NewFileWatcher(files []string) *FileWatcher {
+	w := &FileWatcher{
+		files: files,
+		stats: map[string]fileStat{},
+	}
+	w.Changed()
+	return w
+}
+
+// Changed report whether any file changed since the last call. Removed
+// files are ignored, the database loaded before kept in use.
+func (w *FileWatcher) Changed() bool {
+	changed := false
+	for _, f := range w.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+
+		st := fileStat{modTime: fi.ModTime(), size: fi.Size()}
+		if old, ok := w.stats[f]; !ok || old != st {
+			w.stats[f] = st
+			changed = true
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package offload
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// virtual nodes of each address on the ring, more virtual nodes
+// make keys distributed more evenly.
+const ringReplicas = 64
+
+// hashRing select address for the key by consistent hashing, so keys
+// moved as few as possible when addresses added or removed.
+type hashRing struct {
+	hashes []uint64       // sorted hashes of virtual nodes
+	nodes  map[uint64]int // virtual node hash -> address index
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	// fnv hashes of similar keys are close to each other, mixed by the
+	// finalizer of murmur3 to spread them over the ring.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func newHashRing(addresses []string) *hashRing {
+	r := &hashRing{
+		nodes: make(map[uint64]int, len(addresses)*ringReplicas),
+	}
+
+	for i, addr := range addresses {
+		for j := 0; j < ringReplicas; j++ {
+			h := hashKey(addr + "#" + strconv.Itoa(j))
+			if _, ok := r.nodes[h]; ok { // hash conflict, keep the first one
+				continue
+			}
+			r.nodes[h] = i
+			r.hashes = append(r.hashes, h)
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+
+	return r
+}
+
+// get returns index of the address that the key belongs to.
+func (r *hashRing) get(key string) int {
+	if len(r.hashes) == 0 {
+		return 0
+	}
+
+	h := hashKey(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.nodes[r.hashes[i]]
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package offload
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+func TestHashRing(t *testing.T) {
+	addrs := []string{"http://1.1.1.1:9529", "http://2.2.2.2:9529", "http://3.3.3.3:9529"}
+
+	t.Run("stable", func(t *testing.T) {
+		r1 := newHashRing(addrs)
+		r2 := newHashRing(addrs)
+
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("host-%d", i)
+			assert.Equal(t, r1.get(key), r2.get(key))
+		}
+	})
+
+	t.Run("distribution", func(t *testing.T) {
+		r := newHashRing(addrs)
+
+		cnt := map[int]int{}
+		for i := 0; i < 3000; i++ {
+			cnt[r.get(fmt.Sprintf("host-%d", i))]++
+		}
+
+		require.Len(t, cnt, len(addrs))
+		for _, n := range cnt {
+			assert.Greater(t, n, 500, "cnt: %+#v", cnt)
+		}
+	})
+
+	t.Run("add-address", func(t *testing.T) {
+		r1 := newHashRing(addrs)
+		r2 := newHashRing(append(addrs, "http://4.4.4.4:9529"))
+
+		moved := 0
+		for i := 0; i < 3000; i++ {
+			key := fmt.Sprintf("host-%d", i)
+			if i1, i2 := r1.get(key), r2.get(key); i1 != i2 {
+				assert.Equal(t, 3, i2, "keys only moved to the new address")
+				moved++
+			}
+		}
+
+		assert.Less(t, moved, 1500)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		r := newHashRing(nil)
+		assert.Equal(t, 0, r.get("abc"))
+	})
+}
+
+type hashSender4test struct {
+	sync.Mutex
+	d map[uint64][]*dkpt.Point
+}
+
+func (sender *hashSender4test) Send(s uint64, cat point.Category, pts []*dkpt.Point) error {
+	sender.Lock()
+	defer sender.Unlock()
+	sender.d[s] = append(sender.d[s], pts...)
+	return nil
+}
+
+func TestHashWkr(t *testing.T) {
+	addrs := []string{"a", "b", "c", "d"}
+
+	var pts []*dkpt.Point
+	for i := 0; i < 500; i++ {
+		pts = append(pts, dkpt.MustNewPoint("log", map[string]string{
+			"host": fmt.Sprintf("host-%d", i%20),
+		}, map[string]interface{}{"n": i}, nil))
+	}
+
+	s := &hashSender4test{d: map[uint64][]*dkpt.Point{}}
+	wkr := OffloadWorker{
+		ch:        newDataChan(),
+		stopChan:  make(chan struct{}),
+		sender:    s,
+		batchSize: 7,
+		hashTag:   "host",
+		ring:      newHashRing(addrs),
+		lanes:     newLanes(len(addrs)),
+	}
+
+	// one customer for each address
+	var wg sync.WaitGroup
+	for i := range wkr.lanes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, wkr.laneCustomer(context.Background(), point.Logging, i))
+		}(i)
+	}
+
+	for i := 0; i < len(pts); i += 50 {
+		assert.NoError(t, wkr.Send(point.Logging, pts[i:i+50]))
+	}
+	time.Sleep(time.Millisecond * 50)
+	wkr.Stop()
+	wg.Wait()
+
+	total := 0
+	hostAddr := map[string]uint64{}
+	for i, arr := range s.d {
+		assert.Less(t, i, uint64(len(addrs)))
+		total += len(arr)
+
+		last := -1
+		for _, pt := range arr {
+			host := pt.Tags()["host"]
+			if x, ok := hostAddr[host]; ok {
+				assert.Equal(t, x, i, "host %s sent to multiple addresses", host)
+			}
+			hostAddr[host] = i
+
+			// order kept within each address
+			fs, err := pt.Fields()
+			require.NoError(t, err)
+			n := int(fs["n"].(int64))
+			assert.Greater(t, n, last)
+			last = n
+		}
+	}
+
+	assert.Equal(t, len(pts), total)
+	assert.Len(t, hostAddr, 20)
+}
@@ -23,7 +23,7 @@ import (
 const (
 	maxCustomer   = 16
 	chanSize      = maxCustomer
-	ptsBuf        = 128
+	ptsBuf        = 128 // default batch size
 	flushInterval = time.Second * 15
 )
 
@@ -40,10 +40,21 @@ func InitOffloaWorker(cfg *OffloadConfig) error {
 		return err
 	} else {
 		_offloadWkr = wkr
-		for i := 0; i < int(math.Ceil(float64(runtime.NumCPU())*1.5)); i++ {
-			if i >= maxCustomer {
-				break
-			}
+
+		// points of the same hash tag value sent in order by the customer
+		// of their lane.
+		for i := range wkr.lanes {
+			i := i
+			_offloadWkr.g.Go(func(ctx context.Context) error {
+				return _offloadWkr.laneCustomer(ctx, point.Logging, i)
+			})
+		}
+
+		if len(wkr.lanes) > 0 {
+			return nil
+		}
+
+		for i := 0; i < wkr.customers(); i++ {
 			// logging only
 			_offloadWkr.g.Go(func(ctx context.Context) error {
 				return _offloadWkr.Customer(ctx, point.Logging)
@@ -67,6 +78,20 @@ func OffloadSend(cat point.Category, pt []*dkpt.Point) error {
 type OffloadConfig struct {
 	Receiver  string   `toml:"receiver"`
 	Addresses []string `toml:"addresses"`
+
+	// BatchSize is the max number of points sent to receiver at once.
+	BatchSize int `toml:"batch_size"`
+
+	// HashTag is the tag to select address by consistent hashing, points
+	// with the same tag value always sent to the same address. Round robin
+	// used if not set.
+	HashTag string `toml:"hash_tag"`
+
+	// Format is the body format of receiver http and kafka:
+	// lineprotocol(default)/json/protobuf.
+	Format string `toml:"format"`
+
+	Kafka *KafkaConfig `toml:"kafka"`
 }
 
 type Receiver interface {
@@ -118,6 +143,15 @@ type OffloadWorker struct {
 
 	sender Receiver
 
+	batchSize int
+	hashTag   string
+	ring      *hashRing // nil if no consistent hashing
+
+	// if hash tag set, points go to lane of their tag value, each lane
+	// has its own customer. With consistent hashing, each address has
+	// its own lane.
+	lanes []chan []*dkpt.Point
+
 	g *goroutine.Group
 }
 
@@ -127,8 +161,10 @@ func newOffloader(cfg *OffloadConfig) (*OffloadWorker, error) {
 	}
 
 	wrk := &OffloadWorker{
-		ch:       newDataChan(),
-		stopChan: make(chan struct{}),
+		ch:        newDataChan(),
+		stopChan:  make(chan struct{}),
+		batchSize: cfg.BatchSize,
+		hashTag:   cfg.HashTag,
 		g: goroutine.NewGroup(goroutine.Option{
 			Name: "pipeline-offload",
 		}),
@@ -141,13 +177,62 @@ func newOffloader(cfg *OffloadConfig) (*OffloadWorker, error) {
 		} else {
 			wrk.sender = s
 		}
+	case HTTPRcv:
+		if s, err := NewHTTPRecver(cfg.Addresses, cfg.Format); err != nil {
+			return nil, err
+		} else {
+			wrk.sender = s
+		}
+	case KafkaRcv:
+		// partitioned by hash tag within kafka, no address selection.
+		if s, err := NewKafkaRecver(cfg); err != nil {
+			return nil, err
+		} else {
+			wrk.sender = s
+		}
+
+		if cfg.HashTag != "" {
+			wrk.lanes = newLanes(wrk.customers())
+		}
+		return wrk, nil
 	default:
 		return nil, fmt.Errorf("unsupported receiver")
 	}
 
+	if cfg.HashTag != "" {
+		wrk.ring = newHashRing(cfg.Addresses)
+		wrk.lanes = newLanes(len(cfg.Addresses))
+	}
+
 	return wrk, nil
 }
 
+func newLanes(n int) []chan []*dkpt.Point {
+	lanes := make([]chan []*dkpt.Point, n)
+	for i := range lanes {
+		lanes[i] = make(chan []*dkpt.Point, chanSize)
+	}
+	return lanes
+}
+
+// laneOf returns index of the lane that the tag value belongs to.
+func (offload *OffloadWorker) laneOf(tagValue string) int {
+	if offload.ring != nil {
+		return offload.ring.get(tagValue)
+	}
+
+	return int(hashKey(tagValue) % uint64(len(offload.lanes)))
+}
+
+// customers return number of customers to run if no hash tag set.
+func (offload *OffloadWorker) customers() int {
+	n := int(math.Ceil(float64(runtime.NumCPU()) * 1.5))
+	if n > maxCustomer {
+		n = maxCustomer
+	}
+	return n
+}
+
 func (offload *OffloadWorker) Customer(ctx context.Context, cat point.Category) error {
 	flushTicker := time.NewTicker(flushInterval)
 	var ch chan []*dkpt.Point
@@ -159,7 +244,7 @@ func (offload *OffloadWorker) Customer(ctx context.Context, cat point.Category)
 		return fmt.Errorf("unsupported category")
 	}
 
-	ptsCache := make([]*dkpt.Point, 0, ptsBuf)
+	ptsCache := make([]*dkpt.Point, 0, offload.getBatchSize())
 
 	var lbID uint64 = 0 // taking modulus to achieve load balancing
 
@@ -173,7 +258,7 @@ func (offload *OffloadWorker) Customer(ctx context.Context, cat point.Category)
 				if err := offload.sender.Send(lbID, cat, ptsCache); err != nil {
 					l.Errorf("offload send failed: %w", err)
 				}
-				ptsCache = make([]*dkpt.Point, 0, ptsBuf)
+				ptsCache = make([]*dkpt.Point, 0, offload.getBatchSize())
 				lbID++
 			}
 		case <-offload.stopChan:
@@ -191,8 +276,53 @@ func (offload *OffloadWorker) Customer(ctx context.Context, cat point.Category)
 	}
 }
 
+// laneCustomer send points of lane i. With consistent hashing, points of the
+// lane all sent to the i-th address.
+func (offload *OffloadWorker) laneCustomer(ctx context.Context, cat point.Category, i int) error {
+	flushTicker := time.NewTicker(flushInterval)
+	defer flushTicker.Stop()
+
+	ch := offload.lanes[i]
+	cache := make([]*dkpt.Point, 0, offload.getBatchSize())
+
+	flush := func() {
+		if len(cache) > 0 {
+			if err := offload.sender.Send(uint64(i), cat, cache); err != nil {
+				l.Errorf("offload send failed: %w", err)
+			}
+			cache = make([]*dkpt.Point, 0, offload.getBatchSize())
+		}
+	}
+
+	for {
+		select {
+		case pts := <-ch:
+			cache, _ = offload.sendOrCache(uint64(i), cat, cache, pts)
+
+		case <-flushTicker.C:
+			flush()
+
+		case <-offload.stopChan:
+			flush()
+			return nil
+
+		case <-datakit.Exit.Wait():
+			flush()
+			return nil
+		}
+	}
+}
+
+func (offload *OffloadWorker) getBatchSize() int {
+	if offload.batchSize > 0 {
+		return offload.batchSize
+	}
+	return ptsBuf
+}
+
 func (offload *OffloadWorker) sendOrCache(s uint64, cat point.Category, cache []*dkpt.Point, ptsInput []*dkpt.Point) ([]*dkpt.Point, uint64) {
-	diff := ptsBuf - len(cache)
+	batchSize := offload.getBatchSize()
+	diff := batchSize - len(cache)
 	switch {
 	case diff > len(ptsInput):
 		// append
@@ -205,7 +335,7 @@ func (offload *OffloadWorker) sendOrCache(s uint64, cat point.Category, cache []
 			l.Errorf("offload send failed: %w", err)
 		}
 		// new slice
-		cache = make([]*dkpt.Point, 0, ptsBuf)
+		cache = make([]*dkpt.Point, 0, batchSize)
 		s++
 
 	case diff < len(ptsInput):
@@ -213,18 +343,18 @@ func (offload *OffloadWorker) sendOrCache(s uint64, cat point.Category, cache []
 		if err := offload.sender.Send(s, cat, cache); err != nil {
 			l.Errorf("offload send failed: %w", err)
 		}
-		cache = make([]*dkpt.Point, 0, ptsBuf)
+		cache = make([]*dkpt.Point, 0, batchSize)
 
 		ptsInput = ptsInput[diff:]
-		for i := 0; i < len(ptsInput)/ptsBuf; i++ {
-			cache = append(cache, ptsInput[i*ptsBuf:(i+1)*ptsBuf]...)
+		for i := 0; i < len(ptsInput)/batchSize; i++ {
+			cache = append(cache, ptsInput[i*batchSize:(i+1)*batchSize]...)
 			if err := offload.sender.Send(s, cat, cache); err != nil {
 				l.Errorf("offload send failed: %w", err)
 			}
-			cache = make([]*dkpt.Point, 0, ptsBuf)
+			cache = make([]*dkpt.Point, 0, batchSize)
 		}
 
-		if i := len(ptsInput) % ptsBuf; i > 0 {
+		if i := len(ptsInput) % batchSize; i > 0 {
 			cache = append(cache, ptsInput[len(ptsInput)-i:]...)
 		}
 		s++
@@ -237,6 +367,27 @@ func (offload *OffloadWorker) Send(cat point.Category, pts []*dkpt.Point) error
 		return fmt.Errorf("unsupported category")
 	}
 
+	if len(offload.lanes) > 0 {
+		// split points by lane of their tag value, order within lane kept.
+		arr := make([][]*dkpt.Point, len(offload.lanes))
+		for _, pt := range pts {
+			if pt == nil {
+				continue
+			}
+
+			i := offload.laneOf(pt.Tags()[offload.hashTag])
+			arr[i] = append(arr[i], pt)
+		}
+
+		for i, x := range arr {
+			if len(x) > 0 {
+				offload.lanes[i] <- x
+			}
+		}
+
+		return nil
+	}
+
 	if offload.ch == nil || offload.ch.logging == nil {
 		return fmt.Errorf("logging data chan not ready")
 	}
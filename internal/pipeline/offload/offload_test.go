@@ -95,6 +95,34 @@ func TestWkr(t *testing.T) {
 	}
 }
 
+func TestCustomers(t *testing.T) {
+	wkr := &OffloadWorker{}
+	if n := wkr.customers(); n < 1 || n > maxCustomer {
+		t.Errorf("unexpected customers: %d", n)
+	}
+
+	// one lane(and customer) for each address if hash tag set
+	wkr, err := newOffloader(&OffloadConfig{
+		Receiver:  DKRcv,
+		Addresses: []string{"http://1.1.1.1:9529", "http://2.2.2.2:9529", "http://3.3.3.3:9529"},
+		HashTag:   "host",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(wkr.lanes) != 3 {
+		t.Errorf("expect 3 lanes with hash tag, got %d", len(wkr.lanes))
+	}
+
+	for i := 0; i < 100; i++ {
+		tag := strconv.Itoa(i)
+		if wkr.laneOf(tag) != wkr.ring.get(tag) {
+			t.Errorf("lane of %q not the address selected by ring", tag)
+		}
+	}
+}
+
 func TestDataKitHTTP(t *testing.T) {
 	_, err := newOffloader(&OffloadConfig{
 		Receiver:  DKRcv,
@@ -25,6 +25,13 @@ const DKRcv = "datakit-http"
 func sendReq(req *http.Request, cli *http.Client) (resp *http.Response, err error) {
 	if err := retry.Do(
 		func() error {
+			// body consumed by the previous attempt
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					return err
+				}
+			}
+
 			resp, err = cli.Do(req)
 			if err != nil {
 				return err
@@ -86,13 +93,13 @@ func (recevier *DKRecver) Send(s uint64, cat point.Category, data []*dkpt.Point)
 		return nil
 	}
 
-	i := s % (uint64)(len(recevier.AddrMap))
-	addr := recevier.AddrMap[i][cat]
-
 	if len(recevier.AddrMap) == 0 {
 		return fmt.Errorf("no server address")
 	}
 
+	i := s % (uint64)(len(recevier.AddrMap))
+	addr := recevier.AddrMap[i][cat]
+
 	dataStr := []string{}
 	for _, pt := range data {
 		if pt != nil {
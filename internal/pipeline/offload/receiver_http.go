@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package offload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+	ihttp "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/httpcli"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+const (
+	HTTPRcv = "http"
+
+	FormatLineProtocol = "lineprotocol"
+	FormatJSON         = "json"
+	FormatProtobuf     = "protobuf"
+)
+
+// encodeBody encode points in format, the content type of the body returned.
+func encodeBody(format string, data []*dkpt.Point) ([]byte, string, error) {
+	switch format {
+	case FormatLineProtocol, "":
+		arr := make([]string, 0, len(data))
+		for _, pt := range data {
+			if pt != nil {
+				arr = append(arr, pt.String())
+			}
+		}
+		return []byte(strings.Join(arr, "\n")), "text/plain", nil
+
+	case FormatJSON:
+		arr := make([]*point.JSONPoint, 0, len(data))
+		for _, pt := range data {
+			if pt == nil {
+				continue
+			}
+
+			fs, err := pt.Fields()
+			if err != nil {
+				return nil, "", err
+			}
+
+			arr = append(arr, &point.JSONPoint{
+				Measurement: pt.Name(),
+				Tags:        pt.Tags(),
+				Fields:      fs,
+				Time:        pt.Time().UnixNano(),
+			})
+		}
+
+		body, err := json.Marshal(arr)
+		if err != nil {
+			return nil, "", err
+		}
+		return body, "application/json", nil
+
+	case FormatProtobuf:
+		pts := make([]*point.Point, 0, len(data))
+		for _, pt := range data {
+			if pt == nil {
+				continue
+			}
+
+			fs, err := pt.Fields()
+			if err != nil {
+				return nil, "", err
+			}
+
+			pts = append(pts, point.NewPointV2([]byte(pt.Name()),
+				append(point.NewTags(pt.Tags()), point.NewKVs(fs)...),
+				point.WithTime(pt.Time())))
+		}
+
+		enc := point.GetEncoder(point.WithEncEncoding(point.Protobuf))
+		defer point.PutEncoder(enc)
+
+		arr, err := enc.Encode(pts)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(arr) == 0 {
+			return nil, point.PBContentType, nil
+		}
+		return arr[0], point.PBContentType, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case FormatLineProtocol, FormatJSON, FormatProtobuf, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// HTTPRecver post points to any HTTP server, the placeholder `{category}`
+// within addresses replaced with the category name(logging/metric/...).
+type HTTPRecver struct {
+	httpCli *http.Client
+
+	Addresses []string
+	Format    string
+}
+
+func NewHTTPRecver(addresses []string, format string) (*HTTPRecver, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no server address")
+	}
+
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+
+	return &HTTPRecver{
+		httpCli:   ihttp.Cli(nil),
+		Addresses: append([]string{}, addresses...),
+		Format:    format,
+	}, nil
+}
+
+func (recevier *HTTPRecver) Send(s uint64, cat point.Category, data []*dkpt.Point) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	if len(recevier.Addresses) == 0 {
+		return fmt.Errorf("no server address")
+	}
+
+	addr := strings.ReplaceAll(recevier.Addresses[s%uint64(len(recevier.Addresses))],
+		"{category}", cat.String())
+
+	body, contentType, err := encodeBody(recevier.Format, data)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", addr, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := sendReq(req, recevier.httpCli)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode/100 != 2 {
+		r := make([]byte, 256)
+		n, _ := resp.Body.Read(r)
+		return fmt.Errorf("post %s failed, http status code: %d, body: %s", addr, resp.StatusCode, string(r[:n]))
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package offload
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/Shopify/sarama"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/output"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+const (
+	KafkaRcv = "kafka"
+
+	defaultKafkaTopic = "datakit_offload_{category}"
+)
+
+// KafkaConfig is the config of Kafka receiver, addresses of the offload
+// config are Kafka brokers.
+type KafkaConfig struct {
+	// Topic of the messages, the placeholder `{category}` replaced with
+	// the category name(logging/metric/...).
+	Topic string `toml:"topic"`
+
+	output.KafkaProducerConfig
+}
+
+func (kc *KafkaConfig) saramaConfig() (*sarama.Config, error) {
+	cfg, err := kc.SaramaConfig("datakit-offload")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Producer.Partitioner = sarama.NewHashPartitioner
+	return cfg, nil
+}
+
+// KafkaRecver write each point as a message into Kafka topic. The value of
+// hash tag used as message key, so points with the same tag value go to the
+// same partition and consumed in order by the same consumer.
+type KafkaRecver struct {
+	producer sarama.SyncProducer
+
+	topic   string
+	format  string
+	hashTag string
+}
+
+func NewKafkaRecver(cfg *OffloadConfig) (*KafkaRecver, error) {
+	if len(cfg.Addresses) == 0 {
+		return nil, fmt.Errorf("no kafka broker address")
+	}
+
+	if err := checkFormat(cfg.Format); err != nil {
+		return nil, err
+	}
+
+	kc := cfg.Kafka
+	if kc == nil {
+		kc = &KafkaConfig{}
+	}
+
+	scfg, err := kc.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := sarama.NewSyncProducer(cfg.Addresses, scfg)
+	if err != nil {
+		return nil, fmt.Errorf("NewSyncProducer: %w", err)
+	}
+
+	return newKafkaRecver(p, kc.Topic, cfg.Format, cfg.HashTag), nil
+}
+
+func newKafkaRecver(p sarama.SyncProducer, topic, format, hashTag string) *KafkaRecver {
+	if topic == "" {
+		topic = defaultKafkaTopic
+	}
+
+	return &KafkaRecver{
+		producer: p,
+		topic:    topic,
+		format:   format,
+		hashTag:  hashTag,
+	}
+}
+
+func (recevier *KafkaRecver) Send(s uint64, cat point.Category, data []*dkpt.Point) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	topic := strings.ReplaceAll(recevier.topic, "{category}", cat.String())
+
+	msgs := make([]*sarama.ProducerMessage, 0, len(data))
+	for _, pt := range data {
+		if pt == nil {
+			continue
+		}
+
+		body, _, err := encodeBody(recevier.format, []*dkpt.Point{pt})
+		if err != nil {
+			return err
+		}
+
+		msg := &sarama.ProducerMessage{
+			Topic: topic,
+			Value: sarama.ByteEncoder(body),
+		}
+
+		if recevier.hashTag != "" {
+			msg.Key = sarama.StringEncoder(pt.Tags()[recevier.hashTag])
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return recevier.producer.SendMessages(msgs)
+}
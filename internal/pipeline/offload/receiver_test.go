@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package offload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bstoml "github.com/BurntSushi/toml"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+)
+
+func testPoints(t *testing.T) []*dkpt.Point {
+	t.Helper()
+
+	ts := time.Unix(1700000000, 123)
+	var pts []*dkpt.Point
+	for _, host := range []string{"h1", "h2", "h1"} {
+		pt, err := dkpt.NewPoint("nginx",
+			map[string]string{"host": host},
+			map[string]interface{}{"message": "some log", "n": 1},
+			&dkpt.PointOption{Time: ts, Category: point.Logging.URL()})
+		require.NoError(t, err)
+		pts = append(pts, pt)
+	}
+
+	return pts
+}
+
+func TestHTTPRecver(t *testing.T) {
+	pts := testPoints(t)
+
+	cases := []struct {
+		format, contentType string
+		enc                 point.Encoding
+	}{
+		{FormatLineProtocol, "text/plain", point.LineProtocol},
+		{"", "text/plain", point.LineProtocol},
+		{FormatJSON, "application/json", point.JSON},
+		{FormatProtobuf, point.PBContentType, point.Protobuf},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			var (
+				path, contentType string
+				got               []*point.Point
+			)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				contentType = r.Header.Get("Content-Type")
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				dec := point.GetDecoder(point.WithDecEncoding(tc.enc))
+				defer point.PutDecoder(dec)
+
+				got, err = dec.Decode(body)
+				require.NoError(t, err)
+			}))
+			defer ts.Close()
+
+			rcv, err := NewHTTPRecver([]string{ts.URL + "/v1/write/{category}"}, tc.format)
+			require.NoError(t, err)
+
+			require.NoError(t, rcv.Send(0, point.Logging, pts))
+
+			assert.Equal(t, "/v1/write/logging", path)
+			assert.Equal(t, tc.contentType, contentType)
+			require.Len(t, got, len(pts))
+
+			for i, pt := range got {
+				assert.Equal(t, "nginx", string(pt.Name()))
+				assert.Equal(t, pts[i].Tags()["host"], string(pt.GetTag([]byte("host"))))
+				assert.Equal(t, pts[i].Time().UnixNano(), pt.Time().UnixNano())
+			}
+		})
+	}
+
+	t.Run("server-error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer ts.Close()
+
+		rcv, err := NewHTTPRecver([]string{ts.URL}, FormatJSON)
+		require.NoError(t, err)
+		assert.Error(t, rcv.Send(0, point.Logging, pts))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewHTTPRecver([]string{"http://localhost"}, "xml")
+		assert.Error(t, err)
+
+		_, err = NewHTTPRecver(nil, FormatJSON)
+		assert.Error(t, err)
+	})
+}
+
+func TestKafkaRecver(t *testing.T) {
+	pts := testPoints(t)
+
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Successes = true
+
+	t.Run("hash-tag", func(t *testing.T) {
+		p := mocks.NewSyncProducer(t, cfg)
+		defer p.Close() //nolint:errcheck
+
+		var keys []string
+		for range pts {
+			p.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				assert.Equal(t, "offload_logging", msg.Topic)
+
+				k, err := msg.Key.Encode()
+				require.NoError(t, err)
+				keys = append(keys, string(k))
+
+				v, err := msg.Value.Encode()
+				require.NoError(t, err)
+
+				dec := point.GetDecoder(point.WithDecEncoding(point.JSON))
+				defer point.PutDecoder(dec)
+
+				arr, err := dec.Decode(v)
+				require.NoError(t, err)
+				assert.Len(t, arr, 1)
+				return nil
+			})
+		}
+
+		rcv := newKafkaRecver(p, "offload_{category}", FormatJSON, "host")
+		assert.NoError(t, rcv.Send(0, point.Logging, pts))
+		assert.Equal(t, []string{"h1", "h2", "h1"}, keys)
+	})
+
+	t.Run("no-hash-tag", func(t *testing.T) {
+		p := mocks.NewSyncProducer(t, cfg)
+		defer p.Close() //nolint:errcheck
+
+		for range pts {
+			p.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				assert.Equal(t, "datakit_offload_logging", msg.Topic)
+				assert.Nil(t, msg.Key)
+				return nil
+			})
+		}
+
+		rcv := newKafkaRecver(p, "", "", "")
+		assert.NoError(t, rcv.Send(0, point.Logging, pts))
+	})
+}
+
+func TestKafkaConfig(t *testing.T) {
+	var cfg OffloadConfig
+	_, err := bstoml.Decode(`
+receiver = "kafka"
+addresses = ["localhost:9092"]
+[kafka]
+  topic = "abc"
+  kafka_version = "2.8.0"
+  compression = "zstd"
+  timeout = "5s"
+  sasl_user = "user"
+  sasl_password = "pass"
+`, &cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Kafka)
+
+	scfg, err := cfg.Kafka.saramaConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "datakit-offload", scfg.ClientID)
+	assert.Equal(t, sarama.V2_8_0_0, scfg.Version)
+	assert.Equal(t, sarama.CompressionZSTD, scfg.Producer.Compression)
+	assert.Equal(t, 5*time.Second, scfg.Producer.Timeout)
+	assert.True(t, scfg.Net.SASL.Enable)
+	assert.Equal(t, "pass", scfg.Net.SASL.Password)
+
+	cfg.Kafka.KafkaVersion = "no-such-version"
+	_, err = cfg.Kafka.saramaConfig()
+	assert.Error(t, err)
+}
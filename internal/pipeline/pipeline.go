@@ -8,8 +8,10 @@ package pipeline
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -38,7 +40,10 @@ import (
 	"golang.org/x/text/transform"
 )
 
-var ipdbInstance ipdb.IPdb // get ip location and isp
+var (
+	ipdbInstance ipdb.IPdb    // get ip location and isp
+	cidrDB       *ipdb.CIDRDB // user-provided subnets
+)
 
 var (
 	l                               = logger.DefaultSLogger("pipeline")
@@ -62,12 +67,38 @@ func GetIPdb() ipdb.IPdb {
 type PipelineCfg struct {
 	IPdbAttr               map[string]string      `toml:"ipdb_attr"`
 	IPdbType               string                 `toml:"ipdb_type"`
+	IPdbCIDRFile           string                 `toml:"ipdb_cidr_file"`
+	IPdbReloadInterval     string                 `toml:"ipdb_reload_interval"`
 	RemotePullInterval     string                 `toml:"remote_pull_interval"`
 	ReferTableURL          string                 `toml:"refer_table_url"`
 	ReferTablePullInterval string                 `toml:"refer_table_pull_interval"`
 	UseSQLite              bool                   `toml:"use_sqlite"`
 	SQLiteMemMode          bool                   `toml:"sqlite_mem_mode"`
 	Offload                *offload.OffloadConfig `toml:"offload"`
+
+	// tables loaded from local files or SQL queries
+	ReferTableSources []*plrefertable.SourceConfig `toml:"refer_table_sources"`
+
+	// script versioning and rollback
+	ScriptVersions    int     `toml:"script_versions"`
+	AutoRollback      bool    `toml:"auto_rollback"`
+	RollbackErrorRate float64 `toml:"rollback_error_rate"`
+	RollbackMinPoints uint64  `toml:"rollback_min_points"`
+
+	RemoteCanary *plscript.CanaryConfig `toml:"remote_canary"`
+
+	// keep the point processed by the failed script with fields
+	// pl_error/pl_error_func/pl_error_pos added
+	AnnotateError bool `toml:"annotate_error"`
+
+	// time budget of a script running on a point, the point kept(skip)
+	// or dropped(drop) if exceeded
+	ScriptTimeout       string `toml:"script_timeout"`
+	ScriptTimeoutAction string `toml:"script_timeout_action"`
+
+	// record the time spent by each function, always recorded if the time
+	// budget set
+	FuncCost bool `toml:"func_cost"`
 }
 
 func NewPipelineFromFile(category point.Category, path string) (*Pipeline, error) {
@@ -161,8 +192,9 @@ func Init(pipelineCfg *PipelineCfg) error {
 	if _, err := InitIPdb(pipelineCfg); err != nil {
 		l.Warnf("init ipdb error: %s", err.Error())
 	}
+	startIPdbReload(pipelineCfg.IPdbReloadInterval)
 
-	if pipelineCfg.ReferTableURL != "" {
+	if pipelineCfg.ReferTableURL != "" || len(pipelineCfg.ReferTableSources) != 0 {
 		dur, err := time.ParseDuration(pipelineCfg.ReferTablePullInterval)
 		if err != nil {
 			l.Warnf("refer table pull interval %s, err: %v", dur, err)
@@ -171,6 +203,8 @@ func Init(pipelineCfg *PipelineCfg) error {
 		if err := plrefertable.InitReferTableRunner(
 			pipelineCfg.ReferTableURL, dur, pipelineCfg.UseSQLite, pipelineCfg.SQLiteMemMode); err != nil {
 			l.Error("init refer table, error: %v", err)
+		} else if err := plrefertable.InitReferTableSources(pipelineCfg.ReferTableSources); err != nil {
+			l.Errorf("init refer table sources, error: %v", err)
 		}
 	}
 
@@ -181,6 +215,18 @@ func Init(pipelineCfg *PipelineCfg) error {
 		}
 	}
 
+	plscript.SetMaxVersions(pipelineCfg.ScriptVersions)
+	plscript.SetCanaryConfig(pipelineCfg.RemoteCanary)
+	plscript.SetAnnotateError(pipelineCfg.AnnotateError)
+	plscript.SetFuncCost(pipelineCfg.FuncCost)
+	initTimeBudget(pipelineCfg.ScriptTimeout, pipelineCfg.ScriptTimeoutAction)
+	if pipelineCfg.AutoRollback {
+		plscript.StartAutoRollback(&plscript.RollbackPolicy{
+			ErrorRate: pipelineCfg.RollbackErrorRate,
+			MinPoints: pipelineCfg.RollbackMinPoints,
+		})
+	}
+
 	if err := loadPatterns(); err != nil {
 		return err
 	}
@@ -188,11 +234,43 @@ func Init(pipelineCfg *PipelineCfg) error {
 	return nil
 }
 
+func initTimeBudget(timeout, action string) {
+	var du time.Duration
+	if timeout != "" {
+		var err error
+		if du, err = time.ParseDuration(timeout); err != nil {
+			l.Warnf("invalid script timeout %q: %s, time budget disabled", timeout, err)
+			du = 0
+		}
+	}
+
+	if err := plscript.SetTimeBudget(du, action); err != nil {
+		l.Warnf("%s, time budget disabled", err)
+	}
+}
+
 // InitIPdb init ipdb instance.
 func InitIPdb(pipelineCfg *PipelineCfg) (ipdb.IPdb, error) {
 	if pipelineCfg == nil {
 		pipelineCfg = pipelineDefaultCfg
 	}
+
+	// the custom CIDR database works without ipdb installed
+	cidrDB = nil
+	if pipelineCfg.IPdbCIDRFile != "" {
+		f := pipelineCfg.IPdbCIDRFile
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(datakit.DataDir, "ipdb", f)
+		}
+
+		if db, err := ipdb.NewCIDRDB(f); err != nil {
+			l.Warnf("load ipdb cidr file %s: %s", f, err)
+		} else {
+			cidrDB = db
+		}
+	}
+	funcs.InitCIDRDB(cidrDB)
+
 	if instance, ok := pipelineIPDbmap[pipelineCfg.IPdbType]; ok {
 		ipdbInstance = instance
 		ipdbInstance.Init(datakit.DataDir, pipelineCfg.IPdbAttr)
@@ -208,6 +286,71 @@ func InitIPdb(pipelineCfg *PipelineCfg) (ipdb.IPdb, error) {
 	return ipdbInstance, nil
 }
 
+// startIPdbReload reload the ipdb and the custom CIDR database if any of
+// their files changed on disk, check them every interval.
+func startIPdbReload(interval string) {
+	if interval == "" {
+		return
+	}
+
+	du, err := time.ParseDuration(interval)
+	if err != nil {
+		l.Warnf("invalid ipdb reload interval %q: %s, ipdb reload disabled", interval, err)
+		return
+	}
+	if du <= 0 {
+		return
+	}
+
+	var rs []ipdb.Reloader
+	if r, ok := ipdbInstance.(ipdb.Reloader); ok {
+		rs = append(rs, r)
+	}
+	if cidrDB != nil {
+		rs = append(rs, cidrDB)
+	}
+
+	if len(rs) == 0 {
+		return
+	}
+
+	ws := make([]*ipdb.FileWatcher, 0, len(rs))
+	for _, r := range rs {
+		ws = append(ws, ipdb.NewFileWatcher(r.Files()))
+	}
+
+	g := datakit.G("pipeline_ipdb")
+	g.Go(func(ctx context.Context) error {
+		tick := time.NewTicker(du)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				reloadIPdb(rs, ws)
+
+			case <-datakit.Exit.Wait():
+				l.Info("ipdb reload exits")
+				return nil
+			}
+		}
+	})
+}
+
+func reloadIPdb(rs []ipdb.Reloader, ws []*ipdb.FileWatcher) {
+	for i, r := range rs {
+		if !ws[i].Changed() {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			l.Warnf("reload ipdb %v: %s", r.Files(), err)
+		} else {
+			l.Infof("ipdb %v reloaded", r.Files())
+		}
+	}
+}
+
 func loadPatterns() error {
 	// 从文件加载 pattern
 	loadedPatterns, err := grok.LoadPatternsFromPath(datakit.PipelinePatternDir)
@@ -6,6 +6,7 @@
 package pipeline
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -54,9 +55,35 @@ func RunPl(category point.Category, pts []*dkpt.Point, plOpt *plscript.Option,
 			continue
 		}
 
+		// new version of the remote script under canary also run on
+		// a copy of the sampled point.
+		var canaryData ptinput.PlInputPt
+		canary := plscript.QueryCanary(category, script.Name())
+		if canary != nil && canary.Sample() {
+			if x, err := ptinput.WrapDeprecatedPoint(category, pt); err == nil {
+				canaryData = x
+			}
+		}
+
 		err := script.Run(inputData, nil, plOpt)
+
+		if canaryData != nil {
+			canary.Observe(inputData, err)
+			canary.Run(canaryData, plOpt)
+		}
+
 		if err != nil {
 			l.Warn(err)
+			if errors.Is(err, plscript.ErrScriptTimeout) && inputData.Dropped() {
+				continue
+			}
+			// keep the point processed with error fields added
+			if plscript.AnnotateError() && !inputData.Dropped() {
+				if dkpt, err := inputData.DkPoint(); err == nil {
+					ret = append(ret, dkpt)
+					continue
+				}
+			}
 			ret = append(ret, pt)
 			continue
 		}
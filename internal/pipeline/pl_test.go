@@ -7,6 +7,7 @@ package pipeline
 
 import (
 	"testing"
+	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
 	"github.com/GuanceCloud/platypus/pkg/engine"
@@ -319,3 +320,102 @@ func BenchmarkScript(b *testing.B) {
 		}
 	})
 }
+
+func TestRunPlCanary(t *testing.T) {
+	script.SetCanaryConfig(&script.CanaryConfig{Enable: true, SampleRate: 1, MinPoints: 5, MaxWait: time.Hour})
+	defer script.SetCanaryConfig(nil)
+
+	script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, map[string]string{"canary_svc.p": `add_key(a, 1)`})
+	defer script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, nil)
+
+	// the new version drops all points
+	script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, map[string]string{"canary_svc.p": `drop()`})
+	assert.NotNil(t, script.QueryCanary(point.Tracing, "canary_svc.p"))
+
+	var pts []*dkpt.Point
+	for i := 0; i < 5; i++ {
+		pt, err := dkpt.NewPoint("m_name",
+			map[string]string{"service": "canary_svc"},
+			map[string]interface{}{"f1": int64(i)},
+			&dkpt.PointOption{Category: datakit.Tracing, Time: time.Now()})
+		assert.NoError(t, err)
+		pts = append(pts, pt)
+	}
+
+	out, _, err := RunPl(point.Tracing, pts, nil, nil)
+	assert.NoError(t, err)
+
+	// points processed by the script in use, and the canary rejected
+	assert.Len(t, out, 5)
+	for _, pt := range out {
+		fields, err := pt.Fields()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), fields["a"])
+	}
+
+	assert.Nil(t, script.QueryCanary(point.Tracing, "canary_svc.p"))
+}
+
+func TestRunPlAnnotateError(t *testing.T) {
+	script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, map[string]string{
+		"annotate_svc.p": "add_key(a, 1)\narr = []\nadd_key(b, arr[1])",
+	})
+	defer script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, nil)
+
+	newPts := func() []*dkpt.Point {
+		pt, err := dkpt.NewPoint("m_name",
+			map[string]string{"service": "annotate_svc"},
+			map[string]interface{}{"f1": int64(1)},
+			&dkpt.PointOption{Category: datakit.Tracing, Time: time.Now()})
+		assert.NoError(t, err)
+		return []*dkpt.Point{pt}
+	}
+
+	// no error fields by default
+	out, _, err := RunPl(point.Tracing, newPts(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+	fields, err := out[0].Fields()
+	assert.NoError(t, err)
+	assert.NotContains(t, fields, script.FieldPlError)
+
+	script.SetAnnotateError(true)
+	defer script.SetAnnotateError(false)
+
+	out, _, err = RunPl(point.Tracing, newPts(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+	fields, err = out[0].Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), fields["a"])
+	assert.Equal(t, "add_key", fields[script.FieldPlErrorFunc])
+	assert.Equal(t, "annotate_svc.p:3:16", fields[script.FieldPlErrorPos])
+	assert.Equal(t, "list index out of range", fields[script.FieldPlError])
+}
+
+func TestRunPlTimeBudget(t *testing.T) {
+	script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, map[string]string{
+		"timeout_svc.p": "for i = 0; i < 100000000; i = i + 1 {\n\tadd_key(a, i)\n}",
+	})
+	defer script.ReloadAllRemoteDotPScript2StoreFromMap(point.Tracing, nil)
+	defer initTimeBudget("", "")
+
+	newPts := func() []*dkpt.Point {
+		pt, err := dkpt.NewPoint("m_name",
+			map[string]string{"service": "timeout_svc"},
+			map[string]interface{}{"f1": int64(1)},
+			&dkpt.PointOption{Category: datakit.Tracing, Time: time.Now()})
+		assert.NoError(t, err)
+		return []*dkpt.Point{pt}
+	}
+
+	initTimeBudget("10ms", script.TimeoutActionSkip)
+	out, _, err := RunPl(point.Tracing, newPts(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+
+	initTimeBudget("10ms", script.TimeoutActionDrop)
+	out, _, err = RunPl(point.Tracing, newPts(), nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, out, 0)
+}
@@ -6,10 +6,13 @@
 package plmap
 
 import (
+	"math"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/spf13/cast"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/hash"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
 )
@@ -31,6 +34,15 @@ func NewAggBuks(upFn UploadFunc) *AggBuckets {
 
 func (a *AggBuckets) CreateBucket(name string, interval time.Duration, count int,
 	keepValue bool, constTags map[string]string,
+) {
+	a.CreateWindowBucket(name, interval, 0, count, keepValue, constTags)
+}
+
+// CreateWindowBucket creates a bucket with sliding window, the points
+// uploaded every interval cover data of the last window. Window less than
+// or equal to interval means no sliding window.
+func (a *AggBuckets) CreateWindowBucket(name string, interval, window time.Duration, count int,
+	keepValue bool, constTags map[string]string,
 ) {
 	a.Lock()
 	defer a.Unlock()
@@ -42,6 +54,7 @@ func (a *AggBuckets) CreateBucket(name string, interval time.Duration, count int
 	buk, ok := a.data[name]
 	if !ok {
 		buk = newBucket(name, interval, count, keepValue, a.uploadDataFn)
+		buk.setWindow(window)
 		a.data[name] = buk
 		buk.startScan()
 	}
@@ -77,8 +90,9 @@ func (a *AggBuckets) GetBucket(name string) (*bucket, bool) {
 }
 
 type aggFields struct {
-	tags   []string
-	fields map[string]aggMetric
+	tags    []string
+	fields  map[string]aggMetric
+	actions map[string]string
 }
 
 type ptsGroup struct {
@@ -97,8 +111,9 @@ func (g *ptsGroup) addMetric(tagsValue []string, name, action string, value any)
 	agg, ok := g.timeline[tagsHash]
 	if !ok {
 		agg = &aggFields{
-			tags:   tagsValue,
-			fields: map[string]aggMetric{},
+			tags:    tagsValue,
+			fields:  map[string]aggMetric{},
+			actions: map[string]string{},
 		}
 		g.timeline[tagsHash] = agg
 	}
@@ -110,6 +125,7 @@ func (g *ptsGroup) addMetric(tagsValue []string, name, action string, value any)
 			return false
 		}
 		agg.fields[name] = m
+		agg.actions[name] = action
 	}
 	m.Append(value)
 
@@ -131,6 +147,12 @@ type bucket struct {
 
 	extraTags map[string]string
 
+	// sliding window made of the groups of the last windowSlots intervals,
+	// history holds the groups of previous intervals, oldest first.
+	window      time.Duration
+	windowSlots int
+	history     []map[uint64]*ptsGroup
+
 	stop chan struct{}
 
 	uploadFn UploadFunc
@@ -188,6 +210,19 @@ func (buk *bucket) setExtraTag(extra map[string]string) {
 	buk.extraTags = extra
 }
 
+func (buk *bucket) setWindow(window time.Duration) {
+	buk.Lock()
+	defer buk.Unlock()
+
+	if buk.interval <= 0 || window <= buk.interval {
+		return
+	}
+
+	buk.window = window
+	buk.countLimit = 0
+	buk.windowSlots = int((window + buk.interval - 1) / buk.interval)
+}
+
 func (buk *bucket) AddMetric(fieldName, action string, tagsName,
 	tagsValue []string, aggField any,
 ) bool {
@@ -250,7 +285,7 @@ func newBucket(name string, interval time.Duration, count int,
 }
 
 func conv2Pt(name string, tagsName []string, aggTF *aggFields,
-	extraTags map[string]string,
+	extraTags map[string]string, ts time.Time,
 ) (*point.Point, bool) {
 	if len(tagsName) != len(aggTF.tags) {
 		return nil, false
@@ -267,11 +302,32 @@ func conv2Pt(name string, tagsName []string, aggTF *aggFields,
 
 	fields := map[string]any{}
 	for k, v := range aggTF.fields {
-		if v != nil {
+		if v == nil {
+			continue
+		}
+
+		if m, ok := v.(aggMultiMetric); ok {
+			for suffix, val := range m.Values() {
+				fields[k+"_"+suffix] = val
+			}
+		} else {
 			fields[k] = v.Value()
 		}
 	}
-	if pt, err := point.NewPoint(name, tags, fields, point.MOpt()); err != nil {
+
+	for k, v := range fields {
+		// NaN and Inf not allowed in line protocol
+		if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			delete(fields, k)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	if pt, err := point.NewPoint(name, tags, fields,
+		&point.PointOption{Category: datakit.Metric, Time: ts}); err != nil {
 		return nil, false
 	} else {
 		return pt, true
@@ -281,8 +337,14 @@ func conv2Pt(name string, tagsName []string, aggTF *aggFields,
 // 结束聚合.
 func endAgg(b *bucket) []*point.Point {
 	pts := []*point.Point{}
+	ts := time.Now()
 
-	for tagNameHash, group := range b.group {
+	groups := b.group
+	if b.windowSlots > 1 {
+		groups = mergeGroups(append(b.history, b.group))
+	}
+
+	for tagNameHash, group := range groups {
 		if group == nil {
 			continue
 		}
@@ -291,13 +353,22 @@ func endAgg(b *bucket) []*point.Point {
 			continue
 		}
 		for _, tl := range group.timeline {
-			if pt, ok := conv2Pt(b.bukName, tagsName, tl, b.extraTags); ok {
+			if pt, ok := conv2Pt(b.bukName, tagsName, tl, b.extraTags, ts); ok {
 				pts = append(pts, pt)
 			}
 		}
 	}
 
-	if !b.keepValue {
+	switch {
+	case b.windowSlots > 1:
+		// keep the groups of the last windowSlots-1 intervals, the tag
+		// names kept for them.
+		b.history = append(b.history, b.group)
+		if n := len(b.history) - (b.windowSlots - 1); n > 0 {
+			b.history = append([]map[uint64]*ptsGroup{}, b.history[n:]...)
+		}
+		b.group = map[uint64]*ptsGroup{}
+	case !b.keepValue:
 		b.by = map[uint64][]string{}
 		b.group = map[uint64]*ptsGroup{}
 	}
@@ -305,11 +376,72 @@ func endAgg(b *bucket) []*point.Point {
 	return pts
 }
 
+// mergeGroups merges the groups of intervals, oldest first, into new groups.
+func mergeGroups(slots []map[uint64]*ptsGroup) map[uint64]*ptsGroup {
+	merged := map[uint64]*ptsGroup{}
+
+	for _, slot := range slots {
+		for tagNameHash, group := range slot {
+			if group == nil {
+				continue
+			}
+
+			mg, ok := merged[tagNameHash]
+			if !ok {
+				mg = &ptsGroup{
+					timeline:   map[uint64]*aggFields{},
+					countLimit: group.countLimit,
+				}
+				merged[tagNameHash] = mg
+			}
+
+			for tagsHash, tl := range group.timeline {
+				agg, ok := mg.timeline[tagsHash]
+				if !ok {
+					agg = &aggFields{
+						tags:    tl.tags,
+						fields:  map[string]aggMetric{},
+						actions: map[string]string{},
+					}
+					mg.timeline[tagsHash] = agg
+				}
+
+				for name, m := range tl.fields {
+					action := tl.actions[name]
+					dst, ok := agg.fields[name]
+					if !ok {
+						if dst, ok = NewAggMetric(name, action); !ok {
+							continue
+						}
+						agg.fields[name] = dst
+						agg.actions[name] = action
+					}
+					dst.Merge(m)
+				}
+			}
+		}
+	}
+
+	return merged
+}
+
 type aggMetric interface {
 	Append(any)
 	Value() any
+
+	// Merge the other metric created by the same action into this one.
+	Merge(aggMetric)
 }
 
+// aggMultiMetric outputs multiple fields, the key of values is appended
+// to the field name as suffix.
+type aggMultiMetric interface {
+	Values() map[string]any
+}
+
+// histogramQuantiles are the quantiles output by action histogram.
+var histogramQuantiles = []float64{50, 75, 90, 95, 99}
+
 func NewAggMetric(name, action string) (aggMetric, bool) {
 	switch action {
 	case "avg":
@@ -322,11 +454,40 @@ func NewAggMetric(name, action string) (aggMetric, bool) {
 		return &maxMetric{}, true
 	case "set":
 		return &setMetric{}, true
+	case "count":
+		return &countMetric{}, true
+	case "distinct":
+		return &distinctMetric{hll: newHyperLogLog()}, true
+	case "histogram":
+		return &histogramMetric{td: newTDigest(defaultCompression)}, true
 	default:
+		if q, ok := parsePercentile(action); ok {
+			return &percentileMetric{q: q, td: newTDigest(defaultCompression)}, true
+		}
 		return nil, false
 	}
 }
 
+// ValidAggAction checks whether the action supported by agg_metric.
+func ValidAggAction(action string) bool {
+	_, ok := NewAggMetric("", action)
+	return ok
+}
+
+// parsePercentile parses action such as p50, p99 and p99.9.
+func parsePercentile(action string) (float64, bool) {
+	if len(action) < 2 || action[0] != 'p' {
+		return 0, false
+	}
+
+	q, err := strconv.ParseFloat(action[1:], 64)
+	if err != nil || q <= 0 || q > 100 {
+		return 0, false
+	}
+
+	return q / 100, true
+}
+
 type avgMetric struct {
 	sum   float64
 	count float64
@@ -341,6 +502,13 @@ func (f *avgMetric) Value() any {
 	return f.sum / f.count
 }
 
+func (f *avgMetric) Merge(m aggMetric) {
+	if m, ok := m.(*avgMetric); ok {
+		f.sum += m.sum
+		f.count += m.count
+	}
+}
+
 type sumMetric struct {
 	sum float64
 }
@@ -353,21 +521,25 @@ func (f *sumMetric) Value() any {
 	return f.sum
 }
 
+func (f *sumMetric) Merge(m aggMetric) {
+	if m, ok := m.(*sumMetric); ok {
+		f.sum += m.sum
+	}
+}
+
 type minMetric struct {
 	inserted bool
 	min      float64
 }
 
 func (f *minMetric) Append(v any) {
-	min := cast.ToFloat64(v)
+	f.add(cast.ToFloat64(v))
+}
 
-	if f.inserted {
-		if f.min > min {
-			f.min = min
-		}
-	} else {
+func (f *minMetric) add(min float64) {
+	if !f.inserted || f.min > min {
 		f.min = min
-		f.inserted = false
+		f.inserted = true
 	}
 }
 
@@ -375,13 +547,25 @@ func (f *minMetric) Value() any {
 	return f.min
 }
 
+func (f *minMetric) Merge(m aggMetric) {
+	if m, ok := m.(*minMetric); ok && m.inserted {
+		f.add(m.min)
+	}
+}
+
 type maxMetric struct {
-	max float64
+	inserted bool
+	max      float64
 }
 
 func (f *maxMetric) Append(v any) {
-	if max := cast.ToFloat64(v); f.max < max {
+	f.add(cast.ToFloat64(v))
+}
+
+func (f *maxMetric) add(max float64) {
+	if !f.inserted || f.max < max {
 		f.max = max
+		f.inserted = true
 	}
 }
 
@@ -389,6 +573,12 @@ func (f *maxMetric) Value() any {
 	return f.max
 }
 
+func (f *maxMetric) Merge(m aggMetric) {
+	if m, ok := m.(*maxMetric); ok && m.inserted {
+		f.add(m.max)
+	}
+}
+
 type setMetric struct {
 	set float64
 }
@@ -400,3 +590,108 @@ func (f *setMetric) Append(v any) {
 func (f *setMetric) Value() any {
 	return f.set
 }
+
+// Merge keeps the value of the later one.
+func (f *setMetric) Merge(m aggMetric) {
+	if m, ok := m.(*setMetric); ok {
+		f.set = m.set
+	}
+}
+
+type countMetric struct {
+	count int64
+}
+
+func (f *countMetric) Append(v any) {
+	f.count++
+}
+
+func (f *countMetric) Value() any {
+	return f.count
+}
+
+func (f *countMetric) Merge(m aggMetric) {
+	if m, ok := m.(*countMetric); ok {
+		f.count += m.count
+	}
+}
+
+type distinctMetric struct {
+	hll *hyperLogLog
+}
+
+func (f *distinctMetric) Append(v any) {
+	f.hll.add(cast.ToString(v))
+}
+
+func (f *distinctMetric) Value() any {
+	return f.hll.count()
+}
+
+func (f *distinctMetric) Merge(m aggMetric) {
+	if m, ok := m.(*distinctMetric); ok {
+		f.hll.merge(m.hll)
+	}
+}
+
+type percentileMetric struct {
+	q  float64
+	td *tdigest
+}
+
+func (f *percentileMetric) Append(v any) {
+	f.td.add(cast.ToFloat64(v), 1)
+}
+
+func (f *percentileMetric) Value() any {
+	return f.td.quantile(f.q)
+}
+
+func (f *percentileMetric) Merge(m aggMetric) {
+	if m, ok := m.(*percentileMetric); ok {
+		f.td.merge(m.td)
+	}
+}
+
+type histogramMetric struct {
+	td  *tdigest
+	sum float64
+}
+
+func (f *histogramMetric) Append(v any) {
+	x := cast.ToFloat64(v)
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return
+	}
+	f.td.add(x, 1)
+	f.sum += x
+}
+
+func (f *histogramMetric) Value() any {
+	return int64(f.td.count)
+}
+
+func (f *histogramMetric) Values() map[string]any {
+	values := map[string]any{
+		"count": int64(f.td.count),
+		"min":   f.td.min,
+		"max":   f.td.max,
+	}
+
+	if f.td.count > 0 {
+		values["avg"] = f.sum / f.td.count
+	}
+
+	for _, q := range histogramQuantiles {
+		values["p"+strconv.FormatFloat(q, 'f', -1, 64)] = f.td.quantile(q / 100)
+	}
+
+	return values
+}
+
+func (f *histogramMetric) Merge(m aggMetric) {
+	if m, ok := m.(*histogramMetric); ok {
+		f.td.merge(m.td)
+		f.sum += m.sum
+	}
+}
@@ -108,18 +108,161 @@ func TestAggMetric(t *testing.T) {
 			d:      []any{1, 2, 1, 2, -1},
 			o:      -1.0,
 		},
+		{
+			action: "min",
+			d:      []any{3, 2, 5},
+			o:      2.0,
+		},
+		{
+			action: "max",
+			d:      []any{-3, -2, -5},
+			o:      -2.0,
+		},
+		{
+			action: "count",
+			d:      []any{1, "a", 1.5},
+			o:      int64(3),
+		},
+		{
+			action: "distinct",
+			d:      []any{"a", "b", "a", 1, "1"},
+			o:      int64(3),
+		},
+		{
+			action: "p50",
+			d:      []any{1, 2, 3, 4, 5},
+			o:      3.0,
+		},
+		{
+			action: "p100",
+			d:      []any{1, 2, 3, 4, "5"},
+			o:      5.0,
+		},
+		{
+			action: "p0",
+			failed: true,
+		},
+		{
+			action: "median",
+			failed: true,
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.action, func(t *testing.T) {
 			v, ok := NewAggMetric("", tc.action)
 			assert.Equal(t, !tc.failed, ok)
-			if v != nil {
-				for _, d := range tc.d {
-					v.Append(d)
-				}
+			if v == nil {
+				return
+			}
+			for _, d := range tc.d {
+				v.Append(d)
 			}
 			assert.Equal(t, tc.o, v.Value())
 		})
 	}
 }
+
+func TestAggMetricMerge(t *testing.T) {
+	for _, action := range []string{"avg", "sum", "min", "max", "set", "count", "distinct", "p90", "histogram"} {
+		t.Run(action, func(t *testing.T) {
+			all, _ := NewAggMetric("", action)
+			a, _ := NewAggMetric("", action)
+			b, _ := NewAggMetric("", action)
+
+			for i := 0; i < 100; i++ {
+				all.Append(i)
+				if i < 30 {
+					a.Append(i)
+				} else {
+					b.Append(i)
+				}
+			}
+
+			merged, _ := NewAggMetric("", action)
+			merged.Merge(a)
+			merged.Merge(b)
+
+			if m, ok := all.(aggMultiMetric); ok {
+				assert.Equal(t, m.Values(), merged.(aggMultiMetric).Values())
+			} else {
+				assert.Equal(t, all.Value(), merged.Value())
+			}
+		})
+	}
+}
+
+func TestHistogramMetric(t *testing.T) {
+	m, ok := NewAggMetric("latency", "histogram")
+	assert.True(t, ok)
+
+	for i := 1; i <= 100; i++ {
+		m.Append(i)
+	}
+
+	pt, ok := conv2Pt("http", []string{"host"}, &aggFields{
+		tags:   []string{"h1"},
+		fields: map[string]aggMetric{"latency": m},
+	}, map[string]string{"service": "web"}, time.Unix(100, 0))
+	assert.True(t, ok)
+
+	fields, err := pt.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), fields["latency_count"])
+	assert.Equal(t, 1.0, fields["latency_min"])
+	assert.Equal(t, 100.0, fields["latency_max"])
+	assert.Equal(t, 50.5, fields["latency_avg"])
+	assert.InDelta(t, 95.5, fields["latency_p95"], 1)
+	assert.InDelta(t, 99.5, fields["latency_p99"], 1)
+	assert.Equal(t, map[string]string{"host": "h1", "service": "web"}, pt.Tags())
+	assert.Equal(t, time.Unix(100, 0), pt.Time())
+}
+
+func TestWindowBucket(t *testing.T) {
+	buk := newBucket("http", time.Minute, 0, false, nil)
+	buk.setWindow(time.Minute * 3)
+	assert.Equal(t, 3, buk.windowSlots)
+
+	sumOf := func(pts []*point.Point) map[string]any {
+		res := map[string]any{}
+		for _, pt := range pts {
+			fields, _ := pt.Fields()
+			res[pt.Tags()["host"]] = fields["req"]
+		}
+		return res
+	}
+
+	// interval 1
+	buk.AddMetric("req", "sum", []string{"host"}, []string{"h1"}, 1)
+	buk.AddMetric("req", "sum", []string{"host"}, []string{"h2"}, 10)
+	assert.Equal(t, map[string]any{"h1": 1.0, "h2": 10.0}, sumOf(endAgg(buk)))
+
+	// interval 2
+	buk.AddMetric("req", "sum", []string{"host"}, []string{"h1"}, 2)
+	assert.Equal(t, map[string]any{"h1": 3.0, "h2": 10.0}, sumOf(endAgg(buk)))
+
+	// interval 3
+	buk.AddMetric("req", "sum", []string{"host"}, []string{"h1"}, 4)
+	assert.Equal(t, map[string]any{"h1": 7.0, "h2": 10.0}, sumOf(endAgg(buk)))
+
+	// interval 4, interval 1 out of window
+	buk.AddMetric("req", "sum", []string{"host"}, []string{"h1"}, 8)
+	assert.Equal(t, map[string]any{"h1": 14.0}, sumOf(endAgg(buk)))
+	assert.Len(t, buk.history, 2)
+
+	// nothing within the window
+	endAgg(buk)
+	endAgg(buk)
+	assert.Empty(t, endAgg(buk))
+
+	t.Run("no-window", func(t *testing.T) {
+		buk := newBucket("http", time.Minute, 0, false, nil)
+		buk.setWindow(time.Minute)
+		assert.Equal(t, 0, buk.windowSlots)
+
+		buk = newBucket("http", 0, 10, false, nil)
+		buk.setWindow(time.Minute)
+		assert.Equal(t, 0, buk.windowSlots)
+		assert.Equal(t, 10, buk.countLimit)
+	})
+}
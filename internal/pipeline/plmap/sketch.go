@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plmap
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/hash"
+)
+
+const (
+	defaultCompression = 100
+
+	// 2^14 registers, 16KiB memory and about 0.8% standard error.
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+)
+
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// tdigest is a merging t-digest used to estimate quantiles, see
+// https://github.com/tdunning/t-digest.
+type tdigest struct {
+	compression float64
+
+	// sorted by mean
+	centroids []centroid
+	buf       []centroid
+
+	count    float64
+	min, max float64
+}
+
+func newTDigest(compression float64) *tdigest {
+	if compression <= 0 {
+		compression = defaultCompression
+	}
+	return &tdigest{compression: compression}
+}
+
+func (td *tdigest) add(v, weight float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || weight <= 0 {
+		return
+	}
+
+	if td.count == 0 {
+		td.min, td.max = v, v
+	} else {
+		td.min = math.Min(td.min, v)
+		td.max = math.Max(td.max, v)
+	}
+
+	td.count += weight
+	td.buf = append(td.buf, centroid{mean: v, count: weight})
+	if len(td.buf) >= int(td.compression)*5 {
+		td.compress()
+	}
+}
+
+func (td *tdigest) merge(other *tdigest) {
+	if other.count == 0 {
+		return
+	}
+
+	min, max := other.min, other.max
+	if td.count > 0 {
+		min = math.Min(min, td.min)
+		max = math.Max(max, td.max)
+	}
+
+	for _, c := range other.centroids {
+		td.add(c.mean, c.count)
+	}
+	for _, c := range other.buf {
+		td.add(c.mean, c.count)
+	}
+
+	td.min, td.max = min, max
+}
+
+// scale function k1 of the t-digest paper.
+func (td *tdigest) k(q float64) float64 {
+	return td.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (td *tdigest) kInv(k float64) float64 {
+	if k >= td.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/td.compression) + 1) / 2
+}
+
+func (td *tdigest) compress() {
+	if len(td.buf) == 0 {
+		return
+	}
+
+	all := append(td.centroids, td.buf...) //nolint:gocritic
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	out := make([]centroid, 0, len(td.centroids)+1)
+	out = append(out, all[0])
+
+	soFar := 0.0
+	qLimit := td.kInv(td.k(0) + 1)
+
+	for _, c := range all[1:] {
+		cur := &out[len(out)-1]
+		if (soFar+cur.count+c.count)/td.count <= qLimit {
+			cur.mean += (c.mean - cur.mean) * c.count / (cur.count + c.count)
+			cur.count += c.count
+			continue
+		}
+
+		soFar += cur.count
+		qLimit = td.kInv(td.k(soFar/td.count) + 1)
+		out = append(out, c)
+	}
+
+	td.centroids = out
+	td.buf = td.buf[:0]
+}
+
+// quantile returns the estimated value at q(0~1).
+func (td *tdigest) quantile(q float64) float64 {
+	td.compress()
+
+	switch {
+	case td.count == 0:
+		return 0
+	case q <= 0:
+		return td.min
+	case q >= 1:
+		return td.max
+	case len(td.centroids) == 1:
+		return td.centroids[0].mean
+	}
+
+	index := q * td.count
+
+	first := td.centroids[0]
+	if index < first.count/2 {
+		return td.min + (first.mean-td.min)*index/(first.count/2)
+	}
+
+	// weight before the center of the current centroid
+	soFar := first.count / 2
+	for i := 0; i < len(td.centroids)-1; i++ {
+		a, b := td.centroids[i], td.centroids[i+1]
+		dw := (a.count + b.count) / 2
+		if soFar+dw > index {
+			return a.mean + (b.mean-a.mean)*(index-soFar)/dw
+		}
+		soFar += dw
+	}
+
+	last := td.centroids[len(td.centroids)-1]
+	if rest := last.count / 2; rest > 0 {
+		return last.mean + (td.max-last.mean)*math.Min(1, (index-soFar)/rest)
+	}
+	return td.max
+}
+
+// hyperLogLog estimates count of distinct values.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, hllRegisters)}
+}
+
+func (h *hyperLogLog) add(s string) {
+	x := fmix64(hash.Fnv1aStrHash(s))
+
+	idx := x >> (64 - hllPrecision)
+	rho := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+
+	if rho > h.registers[idx] {
+		h.registers[idx] = rho
+	}
+}
+
+func (h *hyperLogLog) merge(other *hyperLogLog) {
+	for i, v := range other.registers {
+		if v > h.registers[i] {
+			h.registers[i] = v
+		}
+	}
+}
+
+func (h *hyperLogLog) count() int64 {
+	m := float64(hllRegisters)
+
+	sum, zeros := 0.0, 0
+	for _, v := range h.registers {
+		sum += math.Ldexp(1, -int(v))
+		if v == 0 {
+			zeros++
+		}
+	}
+
+	est := 0.7213 / (1 + 1.079/m) * m * m / sum
+
+	// linear counting for small cardinalities
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+
+	return int64(est + 0.5)
+}
+
+// fmix64 is the finalizer of MurmurHash3, FNV-1a alone not random enough
+// in high bits for short strings.
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
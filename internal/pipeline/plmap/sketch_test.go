@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plmap
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTDigest(t *testing.T) {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec
+
+	dists := map[string]func() float64{
+		"uniform":     func() float64 { return r.Float64() * 1000 },
+		"exponential": func() float64 { return r.ExpFloat64() * 100 },
+		"normal":      func() float64 { return r.NormFloat64()*50 + 500 },
+	}
+
+	for name, gen := range dists {
+		t.Run(name, func(t *testing.T) {
+			td := newTDigest(0)
+
+			values := make([]float64, 100000)
+			for i := range values {
+				values[i] = gen()
+				td.add(values[i], 1)
+			}
+			sort.Float64s(values)
+
+			assert.Equal(t, values[0], td.quantile(0))
+			assert.Equal(t, values[len(values)-1], td.quantile(1))
+
+			for _, q := range []float64{0.01, 0.5, 0.9, 0.95, 0.99, 0.999} {
+				// compare the rank of the estimated value
+				v := td.quantile(q)
+				rank := float64(sort.SearchFloat64s(values, v)) / float64(len(values))
+				assert.InDelta(t, q, rank, 0.005, "q: %v, value: %v", q, v)
+			}
+
+			assert.Less(t, len(td.centroids), 200)
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		td := newTDigest(0)
+		assert.Equal(t, 0.0, td.quantile(0.5))
+
+		td.add(math.NaN(), 1)
+		td.add(3, 1)
+		assert.Equal(t, 3.0, td.quantile(0.5))
+		assert.Equal(t, 1.0, td.count)
+	})
+}
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000, 1000000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			a, b := newHyperLogLog(), newHyperLogLog()
+			for i := 0; i < n; i++ {
+				if i%2 == 0 {
+					a.add(fmt.Sprintf("user-%d", i))
+				} else {
+					b.add(fmt.Sprintf("user-%d", i))
+				}
+				// duplicated
+				a.add(fmt.Sprintf("user-%d", i/2))
+			}
+
+			a.merge(b)
+			assert.InDelta(t, float64(n), float64(a.count()), float64(n)*0.02+1)
+		})
+	}
+}
@@ -0,0 +1,543 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package pltest run regression tests of pipeline scripts.
+//
+// Test cases of script xxx.p are within xxx.p.test(in YAML) under the same
+// directory, each case has an input sample and the expected result:
+//
+//	cases:
+//	  - name: access-log
+//	    input: '127.0.0.1 GET /index.html 200'
+//	    expect:
+//	      measurement: nginx
+//	      drop: false
+//	      tags:
+//	        method: GET
+//	      fields:
+//	        status: 200
+//	      absent: [some_key]
+//
+// Only tags and fields listed in expect are checked, and keys within absent
+// should not exist in the result. On golden update, only the checked keys are
+// rewritten with the actual result, comments and absent keys are kept.
+package pltest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	lp "github.com/GuanceCloud/cliutils/lineproto"
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	dkpt "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/script"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// TestFileSuffix is the suffix of test file appended to the script file name.
+const TestFileSuffix = ".test"
+
+// TestFile is the content of a test file.
+type TestFile struct {
+	Cases []*Case `yaml:"cases"`
+}
+
+// Case is a single test case of a script.
+type Case struct {
+	Name string `yaml:"name"`
+
+	// Input is the message of logging, or line-protocol of other categories.
+	Input string `yaml:"input"`
+
+	Expect *Expect `yaml:"expect"`
+}
+
+// Expect is the expected result of the case.
+type Expect struct {
+	Measurement string                 `yaml:"measurement,omitempty"`
+	Drop        bool                   `yaml:"drop"`
+	Tags        map[string]string      `yaml:"tags,omitempty"`
+	Fields      map[string]interface{} `yaml:"fields,omitempty"`
+	Absent      []string               `yaml:"absent,omitempty"`
+}
+
+// CaseResult is the test result of a case.
+type CaseResult struct {
+	Category point.Category
+	Script   string
+	Case     string
+
+	// Diffs between expected and actual result.
+	Diffs []string
+
+	// Error is set if the case can not run.
+	Error error
+}
+
+func (r *CaseResult) Passed() bool {
+	return r.Error == nil && len(r.Diffs) == 0
+}
+
+// Result is the test result of all scripts.
+type Result struct {
+	Cases []*CaseResult
+
+	// Scripts without test file.
+	NoTest []string
+
+	// Test files updated on golden update.
+	Updated []string
+}
+
+func (r *Result) Failed() int {
+	n := 0
+	for _, c := range r.Cases {
+		if !c.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// NewInputPoint build input point of category from txt.
+func NewInputPoint(category point.Category, txt string) (*dkpt.Point, error) {
+	opt := &dkpt.PointOption{
+		Category: category.URL(),
+		Time:     time.Now(),
+	}
+
+	var (
+		pts []*dkpt.Point
+		err error
+	)
+
+	//nolint:exhaustive
+	switch category {
+	case point.Logging:
+		return dkpt.NewPoint("default", nil, map[string]interface{}{pipeline.FieldMessage: txt}, opt)
+
+	case point.Metric, point.MetricDeprecated:
+		x, e := lp.ParsePoints([]byte(txt), &lp.Option{EnablePointInKey: true})
+		pts, err = dkpt.WrapPoint(x), e
+
+	default:
+		x, e := lp.ParsePoints([]byte(txt), nil)
+		pts, err = dkpt.WrapPoint(x), e
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pts) == 0 {
+		return nil, fmt.Errorf("no point in input")
+	}
+
+	return pts[0], nil
+}
+
+// Run run test files of all scripts under dir. The dir is in the same
+// structure as the pipeline dir of datakit: logging scripts at the root,
+// scripts of other categories within sub-dirs(metric/object/...).
+// On update, expected values of each case rewritten with the actual result.
+func Run(dir string, update bool) (*Result, error) {
+	if fi, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	scripts, scriptsPath := script.ReadPlScriptFromPlStructPath(dir)
+
+	res := &Result{}
+
+	cats := make([]point.Category, 0, len(scriptsPath))
+	for cat := range scriptsPath {
+		cats = append(cats, cat)
+	}
+	sort.Slice(cats, func(i, j int) bool { return cats[i] < cats[j] })
+
+	for _, cat := range cats {
+		paths := scriptsPath[cat]
+		if len(paths) == 0 {
+			continue
+		}
+
+		// scripts within the same category can import each other, so
+		// they are loaded into the same store.
+		store := script.NewScriptStore(cat)
+		errs := store.UpdateScriptsWithNS(script.DefaultScriptNS, scripts[cat], paths)
+
+		names := make([]string, 0, len(paths))
+		for name := range paths {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			testFile := paths[name] + TestFileSuffix
+			if _, err := os.Stat(testFile); err != nil {
+				res.NoTest = append(res.NoTest, paths[name])
+				continue
+			}
+
+			tf, err := loadTestFile(testFile)
+			if err != nil {
+				res.Cases = append(res.Cases, &CaseResult{Category: cat, Script: paths[name], Error: err})
+				continue
+			}
+
+			if err, ok := errs[name]; ok {
+				res.Cases = append(res.Cases, &CaseResult{Category: cat, Script: paths[name], Error: err})
+				continue
+			}
+
+			sc, ok := store.GetWithNs(name, script.DefaultScriptNS)
+			if !ok {
+				res.Cases = append(res.Cases, &CaseResult{
+					Category: cat, Script: paths[name], Error: fmt.Errorf("script %s not loaded", name),
+				})
+				continue
+			}
+
+			actuals := make([]*Expect, 0, len(tf.Cases))
+			for _, c := range tf.Cases {
+				cr, actual := runCase(cat, sc, c)
+				cr.Script = paths[name]
+				res.Cases = append(res.Cases, cr)
+				actuals = append(actuals, actual)
+			}
+
+			if update {
+				if err := updateTestFile(testFile, actuals); err != nil {
+					return nil, err
+				}
+				res.Updated = append(res.Updated, testFile)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func loadTestFile(f string) (*TestFile, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(f))
+	if err != nil {
+		return nil, err
+	}
+
+	var tf TestFile
+	if err := yaml.Unmarshal(data, &tf); err != nil {
+		return nil, fmt.Errorf("invalid test file %s: %w", f, err)
+	}
+
+	return &tf, nil
+}
+
+// updateTestFile rewrite expect of cases within test file f with the actual
+// results(nil if the case failed to run). Tags and fields not found in the
+// actual result are removed.
+func updateTestFile(f string, actuals []*Expect) error {
+	data, err := ioutil.ReadFile(filepath.Clean(f))
+	if err != nil {
+		return err
+	}
+
+	// edit on YAML nodes to keep comments
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid test file %s: %w", f, err)
+	}
+
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	if cases := mappingValue(doc.Content[0], "cases"); cases != nil {
+		for i, c := range cases.Content {
+			if i >= len(actuals) || actuals[i] == nil {
+				continue
+			}
+
+			if err := updateExpect(c, actuals[i]); err != nil {
+				return fmt.Errorf("update test file %s: %w", f, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f, buf.Bytes(), datakit.ConfPerm)
+}
+
+func updateExpect(c *yaml.Node, actual *Expect) error {
+	expect := mappingValue(c, "expect")
+	if expect == nil || expect.Kind != yaml.MappingNode {
+		// expect not set, fill it with the actual result, except the
+		// message of input.
+		fields := map[string]interface{}{}
+		for k, v := range actual.Fields {
+			if k != pipeline.FieldMessage {
+				fields[k] = v
+			}
+		}
+
+		x := *actual
+		x.Fields = fields
+
+		node := &yaml.Node{}
+		if err := node.Encode(&x); err != nil {
+			return err
+		}
+
+		if expect != nil {
+			return setNodeValue(expect, node)
+		}
+
+		c.Content = append(c.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "expect"}, node)
+		return nil
+	}
+
+	if v := mappingValue(expect, "measurement"); v != nil {
+		if err := setValue(v, actual.Measurement); err != nil {
+			return err
+		}
+	}
+
+	if v := mappingValue(expect, "drop"); v != nil {
+		if err := setValue(v, actual.Drop); err != nil {
+			return err
+		}
+	} else if actual.Drop { // drop always checked
+		expect.Content = append(expect.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "drop"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+
+	if err := updateMapping(mappingValue(expect, "tags"), func(k string) (interface{}, bool) {
+		v, ok := actual.Tags[k]
+		return v, ok
+	}); err != nil {
+		return err
+	}
+
+	return updateMapping(mappingValue(expect, "fields"), func(k string) (interface{}, bool) {
+		v, ok := actual.Fields[k]
+		return v, ok
+	})
+}
+
+// updateMapping set values of keys within mapping node m, the key removed if
+// not found.
+func updateMapping(m *yaml.Node, get func(k string) (interface{}, bool)) error {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	content := m.Content[:0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+
+		x, ok := get(k.Value)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(v, x); err != nil {
+			return err
+		}
+
+		content = append(content, k, v)
+	}
+
+	m.Content = content
+	return nil
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func setValue(node *yaml.Node, v interface{}) error {
+	var x yaml.Node
+	if err := x.Encode(v); err != nil {
+		return err
+	}
+
+	return setNodeValue(node, &x)
+}
+
+// setNodeValue replace node with x, comments of node kept.
+func setNodeValue(node, x *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && x.Kind == yaml.ScalarNode && node.Tag == x.Tag {
+		x.Style = node.Style // keep quoting
+	}
+
+	x.HeadComment, x.LineComment, x.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	*node = *x
+	return nil
+}
+
+// runCase run case c on script sc, and the actual result returned.
+func runCase(cat point.Category, sc *script.PlScript, c *Case) (*CaseResult, *Expect) {
+	cr := &CaseResult{Category: cat, Case: c.Name}
+
+	pt, err := NewInputPoint(cat, c.Input)
+	if err != nil {
+		cr.Error = fmt.Errorf("invalid input: %w", err)
+		return cr, nil
+	}
+
+	out, drop, err := (&pipeline.Pipeline{Script: sc}).Run(cat, pt, nil,
+		&dkpt.PointOption{Category: cat.URL(), Time: time.Now()}, nil)
+	if err != nil {
+		cr.Error = err
+		return cr, nil
+	}
+
+	fields, err := out.Fields()
+	if err != nil {
+		cr.Error = err
+		return cr, nil
+	}
+
+	actual := &Expect{
+		Measurement: out.Name(),
+		Drop:        drop,
+		Tags:        out.Tags(),
+		Fields:      fields,
+	}
+
+	if c.Expect == nil {
+		cr.Diffs = append(cr.Diffs, "expect not set")
+	} else {
+		cr.Diffs = diff(c.Expect, actual)
+	}
+
+	return cr, actual
+}
+
+func diff(expect, actual *Expect) []string {
+	var diffs []string
+
+	if expect.Measurement != "" && expect.Measurement != actual.Measurement {
+		diffs = append(diffs, fmt.Sprintf("measurement: expect %q, got %q", expect.Measurement, actual.Measurement))
+	}
+
+	if expect.Drop != actual.Drop {
+		diffs = append(diffs, fmt.Sprintf("drop: expect %v, got %v", expect.Drop, actual.Drop))
+	}
+
+	for _, k := range sortedKeys(expect.Tags) {
+		if v, ok := actual.Tags[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("tag %s: expect %q, not found", k, expect.Tags[k]))
+		} else if v != expect.Tags[k] {
+			diffs = append(diffs, fmt.Sprintf("tag %s: expect %q, got %q", k, expect.Tags[k], v))
+		}
+	}
+
+	for _, k := range sortedKeys(expect.Fields) {
+		ev := expect.Fields[k]
+		if v, ok := actual.Fields[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("field %s: expect %#v, not found", k, ev))
+		} else if !valueEqual(ev, v) {
+			diffs = append(diffs, fmt.Sprintf("field %s: expect %#v(%T), got %#v(%T)", k, ev, ev, v, v))
+		}
+	}
+
+	for _, k := range expect.Absent {
+		if v, ok := actual.Tags[k]; ok {
+			diffs = append(diffs, fmt.Sprintf("tag %s: expect absent, got %q", k, v))
+		}
+
+		if v, ok := actual.Fields[k]; ok {
+			diffs = append(diffs, fmt.Sprintf("field %s: expect absent, got %#v", k, v))
+		}
+	}
+
+	return diffs
+}
+
+// valueEqual compare expected value in YAML and the actual field value,
+// integers(int/int64/uint64...) are compared as int64.
+func valueEqual(expect, actual interface{}) bool {
+	if e, ok := toInt64(expect); ok {
+		a, ok := toInt64(actual)
+		return ok && a == e
+	}
+
+	switch e := expect.(type) {
+	case float64:
+		a, ok := actual.(float64)
+		return ok && a == e
+	case float32:
+		a, ok := actual.(float64)
+		return ok && a == float64(e)
+	default:
+		return expect == actual
+	}
+}
+
+func toInt64(x interface{}) (int64, bool) {
+	switch v := x.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package pltest
+
+import (
+	"os"
+	"path/filepath"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	loggingScript = `
+json(_, method)
+json(_, code)
+set_tag(method)
+cast(code, "int")
+if code == 500 {
+	drop()
+}
+`
+
+	loggingTest = `
+cases:
+  - name: ok
+    input: '{"method": "GET", "code": "200"}'
+    expect:
+      drop: false
+      tags:
+        method: GET
+      fields:
+        code: 200
+      absent: [no_such_key]
+  - name: drop
+    input: '{"method": "POST", "code": "500"}'
+    expect:
+      drop: true
+  - name: mismatch
+    input: '{"method": "PUT", "code": "404"}'
+    expect:
+      tags:
+        method: GET # the method
+      fields:
+        # wrong code
+        code: 404
+        missing: "x"
+      absent: [code]
+`
+
+	metricScript = `
+add_key(cores, 8)
+set_tag(host, "xyz")
+`
+
+	metricTest = `
+cases:
+  - input: 'cpu,host=abc usage=1.5 1677830000000000000'
+    expect:
+      measurement: cpu
+      fields:
+        usage: 1.5
+        cores: 8
+      tags:
+        host: xyz
+`
+)
+
+func writeFile(t *T.T, f, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(f), os.ModePerm))
+	require.NoError(t, os.WriteFile(f, []byte(content), os.ModePerm))
+}
+
+func TestRun(t *T.T) {
+	t.Run("basic", func(t *T.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "test.p"), loggingScript)
+		writeFile(t, filepath.Join(dir, "test.p"+TestFileSuffix), loggingTest)
+		writeFile(t, filepath.Join(dir, "no-test.p"), `add_key(a, 1)`)
+		writeFile(t, filepath.Join(dir, "metric", "cpu.p"), metricScript)
+		writeFile(t, filepath.Join(dir, "metric", "cpu.p"+TestFileSuffix), metricTest)
+
+		res, err := Run(dir, false)
+		require.NoError(t, err)
+
+		require.Len(t, res.Cases, 4)
+		assert.Equal(t, 1, res.Failed())
+		assert.Equal(t, []string{filepath.Join(dir, "no-test.p")}, res.NoTest)
+
+		for _, c := range res.Cases {
+			t.Logf("%s/%s: %v", c.Script, c.Case, c.Diffs)
+			assert.NoError(t, c.Error)
+
+			if c.Case == "mismatch" {
+				assert.Equal(t, []string{
+					`tag method: expect "GET", got "PUT"`,
+					`field missing: expect "x", not found`,
+					`field code: expect absent, got 404`,
+				}, c.Diffs)
+			} else {
+				assert.True(t, c.Passed())
+			}
+		}
+	})
+
+	t.Run("update", func(t *T.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "test.p"), loggingScript)
+		writeFile(t, filepath.Join(dir, "test.p"+TestFileSuffix), loggingTest)
+
+		res, err := Run(dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "test.p"+TestFileSuffix)}, res.Updated)
+
+		// only absent of the mismatch case still failed after golden update
+		res, err = Run(dir, false)
+		require.NoError(t, err)
+		require.Len(t, res.Cases, 3)
+		assert.Equal(t, 1, res.Failed())
+		assert.Equal(t, []string{`field code: expect absent, got 404`}, res.Cases[2].Diffs)
+
+		data, err := os.ReadFile(filepath.Join(dir, "test.p"+TestFileSuffix))
+		require.NoError(t, err)
+
+		tf, err := loadTestFile(filepath.Join(dir, "test.p"+TestFileSuffix))
+		require.NoError(t, err)
+		require.Len(t, tf.Cases, 3)
+
+		// only checked keys updated
+		assert.Equal(t, &Expect{
+			Tags:   map[string]string{"method": "PUT"},
+			Fields: map[string]interface{}{"code": 404},
+			Absent: []string{"code"},
+		}, tf.Cases[2].Expect)
+
+		assert.Equal(t, &Expect{Drop: true}, tf.Cases[1].Expect)
+
+		// comments kept
+		assert.Contains(t, string(data), "# the method")
+		assert.Contains(t, string(data), "# wrong code")
+	})
+
+	t.Run("update-no-expect", func(t *T.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "test.p"), loggingScript)
+		writeFile(t, filepath.Join(dir, "test.p"+TestFileSuffix), `
+cases:
+  - name: new
+    input: '{"method": "GET", "code": "200"}'
+`)
+
+		_, err := Run(dir, true)
+		require.NoError(t, err)
+
+		tf, err := loadTestFile(filepath.Join(dir, "test.p"+TestFileSuffix))
+		require.NoError(t, err)
+		require.Len(t, tf.Cases, 1)
+
+		// message of input not included
+		assert.Equal(t, &Expect{
+			Measurement: "default",
+			Tags:        map[string]string{"method": "GET"},
+			Fields:      map[string]interface{}{"code": 200, "status": "unknown"},
+		}, tf.Cases[0].Expect)
+	})
+
+	t.Run("invalid", func(t *T.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "bad-script.p"), `add_key(`)
+		writeFile(t, filepath.Join(dir, "bad-script.p"+TestFileSuffix), metricTest)
+		writeFile(t, filepath.Join(dir, "bad-test.p"), metricScript)
+		writeFile(t, filepath.Join(dir, "bad-test.p"+TestFileSuffix), `cases: {`)
+
+		res, err := Run(dir, false)
+		require.NoError(t, err)
+		require.Len(t, res.Cases, 2)
+		for _, c := range res.Cases {
+			assert.Error(t, c.Error)
+		}
+
+		_, err = Run(filepath.Join(dir, "no-such-dir"), false)
+		assert.Error(t, err)
+	})
+}
+
+func TestValueEqual(t *T.T) {
+	assert.True(t, valueEqual(200, int64(200)))
+	assert.True(t, valueEqual(200, uint64(200)))
+	assert.False(t, valueEqual(200, 200.0))
+	assert.True(t, valueEqual(1.5, 1.5))
+	assert.True(t, valueEqual("x", "x"))
+	assert.True(t, valueEqual(true, true))
+	assert.False(t, valueEqual("1", 1))
+}
@@ -39,6 +39,7 @@ var FuncsMap = map[string]runtime.FuncCall{
 	"drop_origin_data":       DropOriginData,
 	"exit":                   Exit,
 	"geoip":                  GeoIP,
+	"geoip_asn":              GeoIPASN,
 	"get_key":                Getkey,
 	"group_between":          Group,
 	"group_in":               GroupIn,
@@ -73,6 +74,14 @@ var FuncsMap = map[string]runtime.FuncCall{
 	"value_type":             ValueType,
 	"vaild_json":             VaildJSON,
 	"conv_traceid_w3c_to_dd": ConvTraceIDW3C2DD,
+	"json_set":               JSONSet,
+	"json_delete":            JSONDelete,
+	"dump_json":              DumpJSON,
+	"map_keys":               MapKeys,
+	"map_flatten":            MapFlatten,
+	"list_pluck":             ListPluck,
+	"list_join":              ListJoin,
+	"add_keys":               AddKeys,
 	// disable
 	"json_all": JSONAll,
 }
@@ -99,6 +108,7 @@ var FuncsCheckMap = map[string]runtime.FuncCheck{
 	"drop_origin_data":       DropOriginDataChecking,
 	"exit":                   ExitChecking,
 	"geoip":                  GeoIPChecking,
+	"geoip_asn":              GeoIPASNChecking,
 	"get_key":                GetkeyChecking,
 	"group_between":          GroupChecking,
 	"group_in":               GroupInChecking,
@@ -133,6 +143,14 @@ var FuncsCheckMap = map[string]runtime.FuncCheck{
 	"value_type":             ValueTypeChecking,
 	"vaild_json":             VaildJSONChecking,
 	"conv_traceid_w3c_to_dd": ConvTraceIDW3C2DDChecking,
+	"json_set":               JSONSetChecking,
+	"json_delete":            JSONDeleteChecking,
+	"dump_json":              DumpJSONChecking,
+	"map_keys":               MapKeysChecking,
+	"map_flatten":            MapFlattenChecking,
+	"list_pluck":             ListPluckChecking,
+	"list_join":              ListJoinChecking,
+	"add_keys":               AddKeysChecking,
 
 	// disable
 	"json_all": JSONAllChecking,
@@ -40,6 +40,7 @@ var PipelineFunctionDocs = map[string]*PLDoc{
 	"duration_precision()":     &durationPrecisionMarkdown,
 	"exit()":                   &exitMarkdown,
 	"geoip()":                  &geoIPMarkdown,
+	"geoip_asn()":              &geoIPASNMarkdown,
 	"get_key()":                &getKeyMarkdown,
 	"grok()":                   &grokMarkdown,
 	"group_between()":          &groupBetweenMarkdown,
@@ -73,6 +74,14 @@ var PipelineFunctionDocs = map[string]*PLDoc{
 	"value_type()":             &valueTypeMarkdown,
 	"vaild_json()":             &vaildJSONMarkdown,
 	"conv_traceid_w3c_to_dd()": &convTraceID128MD,
+	"json_set()":               &jsonSetMarkdown,
+	"json_delete()":            &jsonDeleteMarkdown,
+	"dump_json()":              &dumpJSONMarkdown,
+	"map_keys()":               &mapKeysMarkdown,
+	"map_flatten()":            &mapFlattenMarkdown,
+	"list_pluck()":             &listPluckMarkdown,
+	"list_join()":              &listJoinMarkdown,
+	"add_keys()":               &addKeysMarkdown,
 }
 
 var PipelineFunctionDocsEN = map[string]*PLDoc{
@@ -97,6 +106,7 @@ var PipelineFunctionDocsEN = map[string]*PLDoc{
 	"duration_precision()":     &durationPrecisionMarkdownEN,
 	"exit()":                   &exitMarkdownEN,
 	"geoip()":                  &geoIPMarkdownEN,
+	"geoip_asn()":              &geoIPASNMarkdownEN,
 	"get_key()":                &getKeyMarkdownEN,
 	"grok()":                   &grokMarkdownEN,
 	"group_between()":          &groupBetweenMarkdownEN,
@@ -130,6 +140,14 @@ var PipelineFunctionDocsEN = map[string]*PLDoc{
 	"value_type()":             &valueTypeMarkdownEN,
 	"vaild_json()":             &vaildJSONMarkdownEN,
 	"conv_traceid_w3c_to_dd()": &convTraceID128MDEN,
+	"json_set()":               &jsonSetMarkdownEN,
+	"json_delete()":            &jsonDeleteMarkdownEN,
+	"dump_json()":              &dumpJSONMarkdownEN,
+	"map_keys()":               &mapKeysMarkdownEN,
+	"map_flatten()":            &mapFlattenMarkdownEN,
+	"list_pluck()":             &listPluckMarkdownEN,
+	"list_join()":              &listJoinMarkdownEN,
+	"add_keys()":               &addKeysMarkdownEN,
 }
 
 // embed docs.
@@ -180,6 +198,9 @@ var (
 	//go:embed md/geoip.md
 	docGeoIP string
 
+	//go:embed md/geoip_asn.md
+	docGeoIPASN string
+
 	//go:embed md/datetime.md
 	docDatetime string
 
@@ -296,6 +317,30 @@ var (
 
 	//go:embed md/conv_traceid_w3c_to_dd.md
 	docConvTraceID string
+
+	//go:embed md/json_set.md
+	docJSONSet string
+
+	//go:embed md/json_delete.md
+	docJSONDelete string
+
+	//go:embed md/dump_json.md
+	docDumpJSON string
+
+	//go:embed md/map_keys.md
+	docMapKeys string
+
+	//go:embed md/map_flatten.md
+	docMapFlatten string
+
+	//go:embed md/list_pluck.md
+	docListPluck string
+
+	//go:embed md/list_join.md
+	docListJoin string
+
+	//go:embed md/add_keys.md
+	docAddKeys string
 )
 
 const (
@@ -459,6 +504,12 @@ var (
 			langTagZhCN: {cNetwork},
 		},
 	}
+	geoIPASNMarkdown = PLDoc{
+		Doc: docGeoIPASN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cNetwork},
+		},
+	}
 	grokMarkdown = PLDoc{
 		Doc: docGrok, Deprecated: false,
 		FnCategory: map[string][]string{
@@ -650,4 +701,60 @@ var (
 			langTagEnUS: {cStringOp},
 		},
 	}
+
+	jsonSetMarkdown = PLDoc{
+		Doc: docJSONSet,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cJSON},
+		},
+	}
+
+	jsonDeleteMarkdown = PLDoc{
+		Doc: docJSONDelete,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cJSON},
+		},
+	}
+
+	dumpJSONMarkdown = PLDoc{
+		Doc: docDumpJSON,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cJSON},
+		},
+	}
+
+	mapKeysMarkdown = PLDoc{
+		Doc: docMapKeys,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cOther},
+		},
+	}
+
+	mapFlattenMarkdown = PLDoc{
+		Doc: docMapFlatten,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cJSON, cOther},
+		},
+	}
+
+	listPluckMarkdown = PLDoc{
+		Doc: docListPluck,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cJSON, cOther},
+		},
+	}
+
+	listJoinMarkdown = PLDoc{
+		Doc: docListJoin,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cOther},
+		},
+	}
+
+	addKeysMarkdown = PLDoc{
+		Doc: docAddKeys,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cMeasurementOp},
+		},
+	}
 )
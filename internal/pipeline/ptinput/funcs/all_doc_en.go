@@ -57,6 +57,9 @@ var (
 	//go:embed md/geoip.en.md
 	docGeoIPEN string
 
+	//go:embed md/geoip_asn.en.md
+	docGeoIPASNEN string
+
 	//go:embed md/datetime.en.md
 	docDatetimeEN string
 
@@ -173,6 +176,30 @@ var (
 
 	//go:embed md/conv_traceid_w3c_to_dd.en.md
 	docConvTraceIDEN string
+
+	//go:embed md/json_set.en.md
+	docJSONSetEN string
+
+	//go:embed md/json_delete.en.md
+	docJSONDeleteEN string
+
+	//go:embed md/dump_json.en.md
+	docDumpJSONEN string
+
+	//go:embed md/map_keys.en.md
+	docMapKeysEN string
+
+	//go:embed md/map_flatten.en.md
+	docMapFlattenEN string
+
+	//go:embed md/list_pluck.en.md
+	docListPluckEN string
+
+	//go:embed md/list_join.en.md
+	docListJoinEN string
+
+	//go:embed md/add_keys.en.md
+	docAddKeysEN string
 )
 
 const (
@@ -338,6 +365,12 @@ var (
 			langTagEnUS: {eNetwork},
 		},
 	}
+	geoIPASNMarkdownEN = PLDoc{
+		Doc: docGeoIPASNEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eNetwork},
+		},
+	}
 	grokMarkdownEN = PLDoc{
 		Doc: docGrokEN, Deprecated: false,
 		FnCategory: map[string][]string{
@@ -529,4 +562,60 @@ var (
 			langTagEnUS: {eStringOp},
 		},
 	}
+
+	jsonSetMarkdownEN = PLDoc{
+		Doc: docJSONSetEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eJSON},
+		},
+	}
+
+	jsonDeleteMarkdownEN = PLDoc{
+		Doc: docJSONDeleteEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eJSON},
+		},
+	}
+
+	dumpJSONMarkdownEN = PLDoc{
+		Doc: docDumpJSONEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eJSON},
+		},
+	}
+
+	mapKeysMarkdownEN = PLDoc{
+		Doc: docMapKeysEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eOther},
+		},
+	}
+
+	mapFlattenMarkdownEN = PLDoc{
+		Doc: docMapFlattenEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eJSON, eOther},
+		},
+	}
+
+	listPluckMarkdownEN = PLDoc{
+		Doc: docListPluckEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eJSON, eOther},
+		},
+	}
+
+	listJoinMarkdownEN = PLDoc{
+		Doc: docListJoinEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eOther},
+		},
+	}
+
+	addKeysMarkdownEN = PLDoc{
+		Doc: docAddKeysEN,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eMeasurementOp},
+		},
+	}
 )
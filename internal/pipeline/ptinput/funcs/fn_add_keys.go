@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func AddKeysChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"m", "prefix",
+	}, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	return nil
+}
+
+func AddKeys(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, dtype, errR := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if errR != nil {
+		return errR
+	}
+
+	m, ok := val.(map[string]any)
+	if !ok || dtype != ast.Map {
+		return nil
+	}
+
+	prefix := ""
+	if funcExpr.Param[1] != nil {
+		v, dtype, errR := runtime.RunStmt(ctx, funcExpr.Param[1])
+		if errR != nil {
+			return errR
+		}
+
+		if dtype != ast.String {
+			return runtime.NewRunError(ctx, fmt.Sprintf("param prefix expect str, got %s", dtype),
+				funcExpr.Param[1].StartPos())
+		}
+		prefix = v.(string)
+	}
+
+	for k, v := range m {
+		v, dtype := ast.DectDataType(v)
+		if err := addKey2PtWithVal(ctx.InData(), prefix+k, v, dtype, ptinput.KindPtDefault); err != nil {
+			l.Debug(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	tu "github.com/GuanceCloud/cliutils/testutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestAddKeys(t *testing.T) {
+	cases := []struct {
+		name, pl, in string
+		expected     interface{}
+		fail         bool
+		outkey       string
+	}{
+		{
+			name: "prefix",
+			pl: `d = load_json(_)
+			add_keys(d["objectRef"], "object_")`,
+			in:       `{"objectRef": {"resource": "pods", "namespace": "default"}}`,
+			expected: `pods`,
+			outkey:   "object_resource",
+		},
+		{
+			name: "nested",
+			pl: `d = load_json(_)
+			add_keys(d)`,
+			in:       `{"objectRef": {"resource": "pods"}}`,
+			expected: `{"resource":"pods"}`,
+			outkey:   "objectRef",
+		},
+		{
+			name: "in-loop",
+			pl: `d = load_json(_)
+			for x in d["annotations"] {
+				add_keys({"value": x["value"]}, x["key"] + "_")
+			}`,
+			in:       `{"annotations": [{"key": "a", "value": "x"}, {"key": "b", "value": "y"}]}`,
+			expected: `y`,
+			outkey:   "b_value",
+		},
+	}
+
+	for idx, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if err != nil {
+				if tc.fail {
+					t.Logf("[%d]expect error: %s", idx, err)
+				} else {
+					t.Errorf("[%d] failed: %s", idx, err)
+				}
+				return
+			}
+
+			if tc.fail {
+				t.Fatalf("[%d] expect error", idx)
+			}
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			errR := runScript(runner, pt)
+			if errR != nil {
+				t.Fatal(errR.Error())
+			}
+
+			v, _, _ := pt.Get(tc.outkey)
+			tu.Equals(t, tc.expected, v)
+			t.Logf("[%d] PASS", idx)
+		})
+	}
+}
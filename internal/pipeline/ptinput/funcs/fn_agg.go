@@ -12,12 +12,14 @@ import (
 	"github.com/GuanceCloud/platypus/pkg/ast"
 	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
 	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"github.com/spf13/cast"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plmap"
 )
 
 func AggCreateChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
 	if err := reindexFuncArgs(funcExpr, []string{
 		"bucket", "on_interval", "on_count",
-		"keep_value", "const_tags",
+		"keep_value", "const_tags", "window",
 	}, 1); err != nil {
 		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
 	}
@@ -72,6 +74,25 @@ func AggCreateChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.P
 		}
 	}
 
+	if arg := funcExpr.Param[5]; arg != nil {
+		switch arg.NodeType { //nolint:exhaustive
+		case ast.TypeStringLiteral:
+			window, err := time.ParseDuration(arg.StringLiteral.Val)
+			if err != nil {
+				return runtime.NewRunError(ctx, fmt.Sprintf("parse window: %s", err.Error()),
+					arg.StartPos())
+			}
+			if window < interval || interval <= 0 || count > 0 {
+				return runtime.NewRunError(ctx,
+					"param `window` requires `on_interval` greater than 0 and not greater than `window`, "+
+						"and cannot be used with `on_count`", arg.StartPos())
+			}
+		default:
+			return runtime.NewRunError(ctx, fmt.Sprintf("param `window` expect StringLiteral, got %s",
+				arg.NodeType), arg.StartPos())
+		}
+	}
+
 	return nil
 }
 
@@ -120,7 +141,12 @@ func AggCreate(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
 		}
 	}
 
-	buks.CreateBucket(bukName, interval, count, keepValue, constTags)
+	var window time.Duration
+	if arg := funcExpr.Param[5]; arg != nil {
+		window, _ = time.ParseDuration(arg.StringLiteral.Val)
+	}
+
+	buks.CreateWindowBucket(bukName, interval, window, count, keepValue, constTags)
 
 	return nil
 }
@@ -152,6 +178,10 @@ func AggAddMetricChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchai
 	arg3 := funcExpr.Param[2]
 	switch arg3.NodeType { //nolint:exhaustive
 	case ast.TypeStringLiteral:
+		if !plmap.ValidAggAction(arg3.StringLiteral.Val) {
+			return runtime.NewRunError(ctx, fmt.Sprintf("unsupported agg_fn `%s'",
+				arg3.StringLiteral.Val), arg3.StartPos())
+		}
 	default:
 		return runtime.NewRunError(ctx, fmt.Sprintf("param `agg_fn` expect StringLiteral, got %s",
 			arg3.NodeType), arg3.StartPos())
@@ -218,9 +248,13 @@ func AggAddMetric(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlErro
 		if v, err := ctx.GetKey(by); err != nil {
 			return nil
 		} else {
-			if v, ok := v.Value.(string); ok {
+			switch v := v.Value.(type) {
+			case string:
 				byValue = append(byValue, v)
-			} else {
+			case int64, float64, bool:
+				// such as status code of access logs
+				byValue = append(byValue, cast.ToString(v))
+			default:
 				return nil
 			}
 		}
@@ -50,6 +50,51 @@ func TestAgg(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "latency",
+			pl: `
+				grok(_, "%{WORD:user} %{INT:status:int} %{NUMBER:latency:float}")
+				agg_create("http_latency", on_interval="1m", window="5m", const_tags={"service":"web"})
+
+				agg_metric("http_latency", "latency", "histogram", ["status"], "latency")
+				agg_metric("http_latency", "latency_p99", "p99", ["status"], "latency")
+				agg_metric("http_latency", "users", "distinct", ["status"], "user")
+				agg_metric("http_latency", "req", "count", ["status"], "latency")
+				`,
+			in: []string{`u1 200 10`, `u2 200 20`, `u1 200 30`, `u3 200 40`},
+			out: map[string]map[string]any{
+				"http_latency": {
+					"status":        "200",
+					"service":       "web",
+					"latency_count": int64(4),
+					"latency_min":   10.0,
+					"latency_max":   40.0,
+					"latency_avg":   25.0,
+					"latency_p50":   25.0,
+					"latency_p99":   40.0,
+					"users":         int64(3),
+					"req":           int64(4),
+				},
+			},
+		},
+		{
+			name: "invalid-agg-fn",
+			pl: `
+				agg_create("abc")
+				agg_metric("abc", "f1", "p101", ["t1"], "f0")
+			`,
+			fail: true,
+		},
+		{
+			name: "invalid-window",
+			pl:   `agg_create("abc", on_interval="1m", window="30s")`,
+			fail: true,
+		},
+		{
+			name: "window-with-count",
+			pl:   `agg_create("abc", on_count=10, window="5m")`,
+			fail: true,
+		},
 	}
 
 	for idx, tc := range cases {
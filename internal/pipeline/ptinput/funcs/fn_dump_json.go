@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+func DumpJSONChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"val", "indent",
+	}, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if funcExpr.Param[1] != nil {
+		switch funcExpr.Param[1].NodeType { //nolint:exhaustive
+		case ast.TypeBoolLiteral:
+		default:
+			return runtime.NewRunError(ctx, fmt.Sprintf("param indent expect BoolLiteral, got %s",
+				funcExpr.Param[1].NodeType), funcExpr.Param[1].StartPos())
+		}
+	}
+
+	return nil
+}
+
+func DumpJSON(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, _, errR := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if errR != nil {
+		return errR
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+
+	if funcExpr.Param[1] != nil && funcExpr.Param[1].BoolLiteral.Val {
+		b, err = json.MarshalIndent(val, "", "  ")
+	} else {
+		b, err = json.Marshal(val)
+	}
+
+	if err != nil {
+		l.Debug(err)
+		ctx.Regs.ReturnAppend("", ast.String)
+		return nil
+	}
+
+	ctx.Regs.ReturnAppend(string(b), ast.String)
+	return nil
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	tu "github.com/GuanceCloud/cliutils/testutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestDumpJSON(t *testing.T) {
+	cases := []struct {
+		name, pl, in string
+		expected     interface{}
+		fail         bool
+		outkey       string
+	}{
+		{
+			name: "map",
+			pl: `d = load_json(_)
+			d["a"]["b"] = 2
+			add_key(a, dump_json(d["a"]))`,
+			in:       `{"a": {"b": 1}, "c": [1, 2]}`,
+			expected: `{"b":2}`,
+			outkey:   "a",
+		},
+		{
+			name:     "indent",
+			pl:       `add_key(a, dump_json([1, "2"], true))`,
+			expected: "[\n  1,\n  \"2\"\n]",
+			outkey:   "a",
+		},
+		{
+			name:     "nil",
+			pl:       `add_key(a, dump_json(nil))`,
+			expected: `null`,
+			outkey:   "a",
+		},
+		{
+			name: "invalid-indent",
+			pl:   `add_key(a, dump_json(nil, 1))`,
+			fail: true,
+		},
+	}
+
+	for idx, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if err != nil {
+				if tc.fail {
+					t.Logf("[%d]expect error: %s", idx, err)
+				} else {
+					t.Errorf("[%d] failed: %s", idx, err)
+				}
+				return
+			}
+
+			if tc.fail {
+				t.Fatalf("[%d] expect error", idx)
+			}
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			errR := runScript(runner, pt)
+			if errR != nil {
+				t.Fatal(errR.Error())
+			}
+
+			v, _, _ := pt.Get(tc.outkey)
+			tu.Equals(t, tc.expected, v)
+			t.Logf("[%d] PASS", idx)
+		})
+	}
+}
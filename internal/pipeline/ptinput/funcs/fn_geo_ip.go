@@ -15,7 +15,10 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
 )
 
-var ipdbInstance ipdb.IPdb
+var (
+	ipdbInstance ipdb.IPdb
+	cidrDB       *ipdb.CIDRDB // user-provided subnets, consulted before ipdbInstance
+)
 
 var geoDefaultVal = "unknown"
 
@@ -31,6 +34,17 @@ func InitIPdb(instance ipdb.IPdb) {
 	ipdbInstance = instance
 }
 
+func InitCIDRDB(db *ipdb.CIDRDB) {
+	cidrDB = db
+}
+
+func ASN(ip string) (*ipdb.ASNRecord, error) {
+	if s, ok := ipdbInstance.(ipdb.ASNSearcher); ok {
+		return s.ASN(ip)
+	}
+	return nil, fmt.Errorf("ipdb not support ASN")
+}
+
 func GeoIPChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
 	if len(funcExpr.Param) != 1 {
 		return runtime.NewRunError(ctx, fmt.Sprintf(
@@ -6,11 +6,15 @@
 package funcs
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ipdb"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
 )
@@ -20,6 +24,13 @@ type mockGEO struct{}
 func (m *mockGEO) Init(dataDir string, config map[string]string) {}
 func (m *mockGEO) SearchIsp(ip string) string                    { return "" }
 
+func (m *mockGEO) ASN(ip string) (*ipdb.ASNRecord, error) {
+	if ip == "8.8.8.8" {
+		return &ipdb.ASNRecord{Number: 15169, Organization: "GOOGLE"}, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
 func (m *mockGEO) Geo(ip string) (*ipdb.IPdbRecord, error) {
 	return &ipdb.IPdbRecord{
 		City: func() string {
@@ -147,3 +158,105 @@ func TestGeoIpFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestGeoIpCIDRDB(t *testing.T) {
+	ipdbInstance = &mockGEO{}
+
+	f := filepath.Join(t.TempDir(), "internal.csv")
+	require.NoError(t, os.WriteFile(f, []byte("cidr,datacenter,team,city,country,province,isp\n"+
+		"10.0.0.0/8,dc-sh,infra,,,,\n"+
+		"10.1.0.0/16,dc-bj,db,Beijing,CN,Beijing,internal\n"), os.ModePerm))
+
+	db, err := ipdb.NewCIDRDB(f)
+	require.NoError(t, err)
+
+	InitCIDRDB(db)
+	defer InitCIDRDB(nil)
+
+	cases := []struct {
+		ip       string
+		expected map[string]string
+		absent   []string
+	}{
+		{
+			ip: "10.2.0.1",
+			expected: map[string]string{
+				"datacenter": "dc-sh",
+				"team":       "infra",
+				"city":       "Shanghai", // from ipdb
+				"country":    "CN",
+				"province":   "Shanghai",
+			},
+		},
+		{
+			ip: "10.1.0.1",
+			expected: map[string]string{
+				"datacenter": "dc-bj",
+				"team":       "db",
+				"city":       "Beijing",
+				"country":    "CN",
+				"province":   "Beijing",
+				"isp":        "internal",
+			},
+		},
+		{
+			ip: "1.2.3.4",
+			expected: map[string]string{
+				"city": "Shanghai",
+			},
+			absent: []string{"datacenter", "team"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ip, func(t *testing.T) {
+			runner, err := NewTestingRunner(`geoip(ip)`)
+			require.NoError(t, err)
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"ip": tc.ip}, time.Now())
+			require.Nil(t, runScript(runner, pt))
+
+			for k, v := range tc.expected {
+				r, _, ok := pt.GetWithIsTag(k)
+				assert.True(t, ok, k)
+				assert.Equal(t, v, r, k)
+			}
+
+			for _, k := range tc.absent {
+				_, _, ok := pt.GetWithIsTag(k)
+				assert.False(t, ok, k)
+			}
+		})
+	}
+}
+
+func TestGeoIpASN(t *testing.T) {
+	ipdbInstance = &mockGEO{}
+
+	runner, err := NewTestingRunner(`geoip_asn(ip)`)
+	require.NoError(t, err)
+
+	pt := ptinput.NewPlPoint(
+		point.Logging, "test", nil, map[string]any{"ip": "8.8.8.8"}, time.Now())
+	require.Nil(t, runScript(runner, pt))
+
+	r, _, ok := pt.GetWithIsTag("asn")
+	assert.True(t, ok)
+	assert.Equal(t, int64(15169), r)
+
+	r, _, ok = pt.GetWithIsTag("asn_org")
+	assert.True(t, ok)
+	assert.Equal(t, "GOOGLE", r)
+
+	// not found
+	pt = ptinput.NewPlPoint(
+		point.Logging, "test", nil, map[string]any{"ip": "1.2.3.4"}, time.Now())
+	require.Nil(t, runScript(runner, pt))
+
+	_, _, ok = pt.GetWithIsTag("asn")
+	assert.False(t, ok)
+
+	_, err = NewTestingRunner(`geoip_asn()`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"encoding/json"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func JSONDeleteChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"input", "json_path",
+	}, 2); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if _, err := getKeyName(funcExpr.Param[0]); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	if isASTJSONPath(funcExpr.Param[1]) {
+		if _, err := astJSONPath(funcExpr.Param[1]); err != nil {
+			return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[1].StartPos())
+		}
+	}
+
+	return nil
+}
+
+func JSONDelete(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	srcKey, err := getKeyName(funcExpr.Param[0])
+	if err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	path, errR := getJSONPathArg(ctx, funcExpr.Param[1])
+	if errR != nil {
+		return errR
+	}
+
+	cont, err := ctx.GetKeyConv2Str(srcKey)
+	if err != nil {
+		l.Debug(err)
+		return nil
+	}
+
+	var root any
+	if err := json.Unmarshal([]byte(cont), &root); err != nil {
+		l.Debug(err)
+		return nil
+	}
+
+	root, ok := jsonPathDelete(root, path)
+	if !ok {
+		return nil
+	}
+
+	dst, err := json.Marshal(root)
+	if err != nil {
+		l.Debug(err)
+		return nil
+	}
+
+	if err := addKey2PtWithVal(ctx.InData(), srcKey, string(dst), ast.String,
+		ptinput.KindPtDefault); err != nil {
+		l.Debug(err)
+	}
+
+	return nil
+}
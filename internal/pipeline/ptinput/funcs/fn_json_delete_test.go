@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	tu "github.com/GuanceCloud/cliutils/testutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestJSONDelete(t *testing.T) {
+	cases := []struct {
+		name, pl, in string
+		expected     interface{}
+		fail         bool
+		outkey       string
+	}{
+		{
+			name: "delete",
+			pl: `json_delete(_, a.b[-1])
+			json_delete(_, "a.c")`,
+			in:       `{"a": {"b": [1, 2, 3], "c": "d"}, "e": "f"}`,
+			expected: `{"a":{"b":[1,2]},"e":"f"}`,
+			outkey:   "message",
+		},
+		{
+			name:     "delete-from-root-list",
+			pl:       `json_delete(_, "[0]")`,
+			in:       `[1, 2]`,
+			expected: `[2]`,
+			outkey:   "message",
+		},
+		{
+			name:     "not-found",
+			pl:       `json_delete(_, a.x.y)`,
+			in:       `{"a": {"b": 1}}`,
+			expected: `{"a": {"b": 1}}`,
+			outkey:   "message",
+		},
+		{
+			name: "invalid-index",
+			pl:   `json_delete(_, a[b])`,
+			fail: true,
+		},
+	}
+
+	for idx, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if err != nil {
+				if tc.fail {
+					t.Logf("[%d]expect error: %s", idx, err)
+				} else {
+					t.Errorf("[%d] failed: %s", idx, err)
+				}
+				return
+			}
+
+			if tc.fail {
+				t.Fatalf("[%d] expect error", idx)
+			}
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			errR := runScript(runner, pt)
+			if errR != nil {
+				t.Fatal(errR.Error())
+			}
+
+			v, _, _ := pt.Get(tc.outkey)
+			tu.Equals(t, tc.expected, v)
+			t.Logf("[%d] PASS", idx)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"encoding/json"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func JSONSetChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"input", "json_path", "value",
+	}, 3); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if _, err := getKeyName(funcExpr.Param[0]); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	if isASTJSONPath(funcExpr.Param[1]) {
+		if _, err := astJSONPath(funcExpr.Param[1]); err != nil {
+			return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[1].StartPos())
+		}
+	}
+
+	return nil
+}
+
+func JSONSet(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	srcKey, err := getKeyName(funcExpr.Param[0])
+	if err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	path, errR := getJSONPathArg(ctx, funcExpr.Param[1])
+	if errR != nil {
+		return errR
+	}
+
+	val, _, errR := runtime.RunStmt(ctx, funcExpr.Param[2])
+	if errR != nil {
+		return errR
+	}
+
+	// the key not exist, set on an empty object
+	var root any
+	if cont, err := ctx.GetKeyConv2Str(srcKey); err == nil && cont != "" {
+		if err := json.Unmarshal([]byte(cont), &root); err != nil {
+			l.Debug(err)
+			return nil
+		}
+	}
+
+	root, err = jsonPathSet(root, path, val)
+	if err != nil {
+		l.Debug(err)
+		return nil
+	}
+
+	dst, err := json.Marshal(root)
+	if err != nil {
+		l.Debug(err)
+		return nil
+	}
+
+	if err := addKey2PtWithVal(ctx.InData(), srcKey, string(dst), ast.String,
+		ptinput.KindPtDefault); err != nil {
+		l.Debug(err)
+	}
+
+	return nil
+}
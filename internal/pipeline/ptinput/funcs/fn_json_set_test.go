@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	tu "github.com/GuanceCloud/cliutils/testutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestJSONSet(t *testing.T) {
+	cases := []struct {
+		name, pl, in string
+		expected     interface{}
+		fail         bool
+		outkey       string
+	}{
+		{
+			name:     "attr-path",
+			pl:       `json_set(_, a.b[0].c, 2)`,
+			in:       `{"a": {"b": [{"c": 1}]}}`,
+			expected: `{"a":{"b":[{"c":2}]}}`,
+			outkey:   "message",
+		},
+		{
+			name:     "str-path-create",
+			pl:       `json_set(_, "a.d", {"e": "f"})`,
+			in:       `{"a": {"b": 1}}`,
+			expected: `{"a":{"b":1,"d":{"e":"f"}}}`,
+			outkey:   "message",
+		},
+		{
+			name: "append-and-negative-index",
+			pl: `json_set(_, a.b[1], "x")
+			json_set(_, "a.b[-2]", 0)`,
+			in:       `{"a": {"b": [1]}}`,
+			expected: `{"a":{"b":[0,"x"]}}`,
+			outkey:   "message",
+		},
+		{
+			name: "dynamic-path-in-loop",
+			pl: `d = load_json(_)
+			for x in d["items"] {
+				json_set(_, "names." + x["name"], x["id"])
+			}`,
+			in:       `{"items": [{"name": "a", "id": 1}, {"name": "b", "id": 2}]}`,
+			expected: `{"items":[{"id":1,"name":"a"},{"id":2,"name":"b"}],"names":{"a":1,"b":2}}`,
+			outkey:   "message",
+		},
+		{
+			name:     "key-not-exist",
+			pl:       `json_set(abc, a.b, true)`,
+			in:       `{}`,
+			expected: `{"a":{"b":true}}`,
+			outkey:   "abc",
+		},
+		{
+			name:     "out-of-range",
+			pl:       `json_set(_, a[3], 1)`,
+			in:       `{"a": [1]}`,
+			expected: `{"a": [1]}`,
+			outkey:   "message",
+		},
+		{
+			name:     "invalid-json",
+			pl:       `json_set(_, a, 1)`,
+			in:       `{"a": `,
+			expected: `{"a": `,
+			outkey:   "message",
+		},
+		{
+			name: "missing-value",
+			pl:   `json_set(_, a)`,
+			fail: true,
+		},
+	}
+
+	for idx, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if err != nil {
+				if tc.fail {
+					t.Logf("[%d]expect error: %s", idx, err)
+				} else {
+					t.Errorf("[%d] failed: %s", idx, err)
+				}
+				return
+			}
+
+			if tc.fail {
+				t.Fatalf("[%d] expect error", idx)
+			}
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			errR := runScript(runner, pt)
+			if errR != nil {
+				t.Fatal(errR.Error())
+			}
+
+			v, _, _ := pt.Get(tc.outkey)
+			tu.Equals(t, tc.expected, v)
+			t.Logf("[%d] PASS", idx)
+		})
+	}
+}
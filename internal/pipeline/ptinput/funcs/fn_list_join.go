@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"strings"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+func ListJoinChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"arr", "sep",
+	}, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	return nil
+}
+
+func ListJoin(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, _, errR := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if errR != nil {
+		return errR
+	}
+
+	sep := ","
+	if funcExpr.Param[1] != nil {
+		v, dtype, errR := runtime.RunStmt(ctx, funcExpr.Param[1])
+		if errR != nil {
+			return errR
+		}
+		if dtype == ast.String {
+			sep = v.(string)
+		}
+	}
+
+	var parts []string
+	if arr, ok := val.([]any); ok {
+		for _, elem := range arr {
+			elem, dtype := ast.DectDataType(elem)
+			if s, err := runtime.Conv2String(elem, dtype); err == nil {
+				parts = append(parts, s)
+			}
+		}
+	}
+
+	ctx.Regs.ReturnAppend(strings.Join(parts, sep), ast.String)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+func ListPluckChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"arr", "json_path",
+	}, 2); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if isASTJSONPath(funcExpr.Param[1]) {
+		if _, err := astJSONPath(funcExpr.Param[1]); err != nil {
+			return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[1].StartPos())
+		}
+	}
+
+	return nil
+}
+
+func ListPluck(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, _, errR := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if errR != nil {
+		return errR
+	}
+
+	path, errR := getJSONPathArg(ctx, funcExpr.Param[1])
+	if errR != nil {
+		return errR
+	}
+
+	res := []any{}
+	if arr, ok := val.([]any); ok {
+		for _, elem := range arr {
+			// nil if the element does not have the path
+			v, _ := jsonPathGet(elem, path)
+			res = append(res, v)
+		}
+	}
+
+	ctx.Regs.ReturnAppend(res, ast.List)
+	return nil
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	tu "github.com/GuanceCloud/cliutils/testutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestListPluck(t *testing.T) {
+	cases := []struct {
+		name, pl, in string
+		expected     interface{}
+		fail         bool
+		outkey       string
+	}{
+		{
+			name: "ident-path",
+			pl: `d = load_json(_)
+			add_key(names, list_join(list_pluck(d["items"], name)))`,
+			in:       `{"items": [{"name": "a", "meta": {"ns": "default"}}, {"name": "b", "meta": {"ns": "kube-system"}}]}`,
+			expected: `a,b`,
+			outkey:   "names",
+		},
+		{
+			name: "str-path",
+			pl: `d = load_json(_)
+			add_key(namespaces, list_join(list_pluck(d["items"], "meta.ns"), ";"))`,
+			in:       `{"items": [{"name": "a", "meta": {"ns": "default"}}, {"name": "b", "meta": {"ns": "kube-system"}}]}`,
+			expected: `default;kube-system`,
+			outkey:   "namespaces",
+		},
+		{
+			name: "missing",
+			pl: `d = load_json(_)
+			add_key(x, dump_json(list_pluck(d, a[0])))`,
+			in:       `[{"a": [1]}, {"b": 2}, 3]`,
+			expected: `[1,null,null]`,
+			outkey:   "x",
+		},
+		{
+			name: "for-in",
+			pl: `d = load_json(_)
+			n = 0
+			for item in d["items"] {
+				if item["meta"]["ns"] == "kube-system" {
+					n = n + 1
+				}
+			}
+			add_key(system_items, n)`,
+			in:       `{"items": [{"name": "a", "meta": {"ns": "default"}}, {"name": "b", "meta": {"ns": "kube-system"}}]}`,
+			expected: int64(1),
+			outkey:   "system_items",
+		},
+	}
+
+	for idx, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if err != nil {
+				if tc.fail {
+					t.Logf("[%d]expect error: %s", idx, err)
+				} else {
+					t.Errorf("[%d] failed: %s", idx, err)
+				}
+				return
+			}
+
+			if tc.fail {
+				t.Fatalf("[%d] expect error", idx)
+			}
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			errR := runScript(runner, pt)
+			if errR != nil {
+				t.Fatal(errR.Error())
+			}
+
+			v, _, _ := pt.Get(tc.outkey)
+			tu.Equals(t, tc.expected, v)
+			t.Logf("[%d] PASS", idx)
+		})
+	}
+}
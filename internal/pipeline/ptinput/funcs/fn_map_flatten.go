@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+func MapFlattenChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"val", "sep",
+	}, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	return nil
+}
+
+func MapFlatten(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, _, errR := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if errR != nil {
+		return errR
+	}
+
+	sep := "."
+	if funcExpr.Param[1] != nil {
+		v, dtype, errR := runtime.RunStmt(ctx, funcExpr.Param[1])
+		if errR != nil {
+			return errR
+		}
+
+		if dtype != ast.String {
+			return runtime.NewRunError(ctx, fmt.Sprintf("param sep expect str, got %s", dtype),
+				funcExpr.Param[1].StartPos())
+		}
+		sep = v.(string)
+	}
+
+	res := map[string]any{}
+	flatten(res, "", sep, val)
+
+	ctx.Regs.ReturnAppend(res, ast.Map)
+	return nil
+}
+
+// flatten nested maps and lists into res, keys joined with sep, and
+// list elements keyed by their index.
+func flatten(res map[string]any, prefix, sep string, val any) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + sep + k
+	}
+
+	switch v := val.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flatten(res, join(k), sep, child)
+		}
+	case []any:
+		for i, child := range v {
+			flatten(res, join(strconv.Itoa(i)), sep, child)
+		}
+	default:
+		if prefix != "" {
+			res[prefix] = v
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	tu "github.com/GuanceCloud/cliutils/testutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestMapFlatten(t *testing.T) {
+	cases := []struct {
+		name, pl, in string
+		expected     interface{}
+		fail         bool
+		outkey       string
+	}{
+		{
+			name: "flatten",
+			pl: `d = load_json(_)
+			add_keys(map_flatten(d["user"], "_"), "user_")`,
+			in:       `{"user": {"name": "admin", "groups": ["system:masters", "system:authenticated"]}}`,
+			expected: `system:authenticated`,
+			outkey:   "user_groups_1",
+		},
+		{
+			name: "default-sep",
+			pl: `d = map_flatten(load_json(_))
+			add_key(x, d["a.b.0.c"])`,
+			in:       `{"a": {"b": [{"c": 1.5}]}}`,
+			expected: float64(1.5),
+			outkey:   "x",
+		},
+		{
+			name:     "not-map-or-list",
+			pl:       `add_key(n, len(map_flatten("abc")))`,
+			expected: int64(0),
+			outkey:   "n",
+		},
+	}
+
+	for idx, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if err != nil {
+				if tc.fail {
+					t.Logf("[%d]expect error: %s", idx, err)
+				} else {
+					t.Errorf("[%d] failed: %s", idx, err)
+				}
+				return
+			}
+
+			if tc.fail {
+				t.Fatalf("[%d] expect error", idx)
+			}
+
+			pt := ptinput.NewPlPoint(
+				point.Logging, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			errR := runScript(runner, pt)
+			if errR != nil {
+				t.Fatal(errR.Error())
+			}
+
+			v, _, _ := pt.Get(tc.outkey)
+			tu.Equals(t, tc.expected, v)
+			t.Logf("[%d] PASS", idx)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"sort"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+func MapKeysChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, []string{
+		"m",
+	}, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	return nil
+}
+
+func MapKeys(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, dtype, errR := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if errR != nil {
+		return errR
+	}
+
+	keys := []any{}
+
+	if m, ok := val.(map[string]any); ok && dtype == ast.Map {
+		arr := make([]string, 0, len(m))
+		for k := range m {
+			arr = append(arr, k)
+		}
+		sort.Strings(arr)
+
+		for _, k := range arr {
+			keys = append(keys, k)
+		}
+	}
+
+	ctx.Regs.ReturnAppend(keys, ast.List)
+	return nil
+}
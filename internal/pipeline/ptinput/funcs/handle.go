@@ -139,18 +139,44 @@ func UserAgentHandle(str string) (map[string]interface{}, map[string]ast.DType)
 	return res, retType
 }
 
+// GeoIPHandle find fields of IP within the user-provided CIDR database first,
+// fields missing there are taken from the ipdb.
 func GeoIPHandle(ip string) (map[string]string, error) {
+	res := make(map[string]string)
+	if cidrDB != nil {
+		for k, v := range cidrDB.Lookup(ip) {
+			res[k] = v
+		}
+	}
+
+	_, hasCity := res["city"]
+	_, hasProvince := res["province"]
+	_, hasCountry := res["country"]
+	_, hasISP := res["isp"]
+	if hasCity && hasProvince && hasCountry && hasISP {
+		return res, nil
+	}
+
 	record, err := Geo(ip)
 	if err != nil {
+		if len(res) != 0 {
+			return res, nil //nolint:nilerr
+		}
 		return nil, err
 	}
 
-	res := make(map[string]string)
-
-	res["city"] = record.City
-	res["province"] = record.Region
-	res["country"] = record.Country
-	res["isp"] = ip2isp.SearchISP(ip)
+	if !hasCity {
+		res["city"] = record.City
+	}
+	if !hasProvince {
+		res["province"] = record.Region
+	}
+	if !hasCountry {
+		res["country"] = record.Country
+	}
+	if !hasISP {
+		res["isp"] = ip2isp.SearchISP(ip)
+	}
 
 	return res, nil
 }
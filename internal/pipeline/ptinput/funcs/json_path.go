@@ -0,0 +1,293 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+// jsonPathSeg is a map key or list index within JSON path.
+type jsonPathSeg struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseJSONPath parse path like `a.b[0].c`, the leading `$.` is optional.
+func parseJSONPath(s string) ([]jsonPathSeg, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "$"), ".")
+	if s == "" {
+		return nil, fmt.Errorf("empty json path")
+	}
+
+	var path []jsonPathSeg
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid json path %q", s)
+		}
+
+		key := part
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			key = part[:i]
+			part = part[i:]
+		} else {
+			part = ""
+		}
+
+		if key != "" {
+			path = append(path, jsonPathSeg{key: key})
+		}
+
+		for part != "" {
+			end := strings.IndexByte(part, ']')
+			if part[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid json path %q", s)
+			}
+
+			idx, err := strconv.Atoi(part[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index within json path %q: %w", s, err)
+			}
+			path = append(path, jsonPathSeg{index: idx, isIdx: true})
+			part = part[end+1:]
+		}
+	}
+
+	return path, nil
+}
+
+// astJSONPath convert path like `a.b[0].c` within script to segments.
+func astJSONPath(node *ast.Node) ([]jsonPathSeg, error) {
+	switch node.NodeType { //nolint:exhaustive
+	case ast.TypeIdentifier:
+		return []jsonPathSeg{{key: node.Identifier.Name}}, nil
+
+	case ast.TypeAttrExpr:
+		path, err := astJSONPath(node.AttrExpr.Obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if node.AttrExpr.Attr != nil {
+			attr, err := astJSONPath(node.AttrExpr.Attr)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, attr...)
+		}
+		return path, nil
+
+	case ast.TypeIndexExpr:
+		var path []jsonPathSeg
+		if node.IndexExpr.Obj != nil {
+			path = append(path, jsonPathSeg{key: node.IndexExpr.Obj.Name})
+		}
+
+		for _, idx := range node.IndexExpr.Index {
+			switch idx.NodeType { //nolint:exhaustive
+			case ast.TypeIntegerLiteral:
+				path = append(path, jsonPathSeg{index: int(idx.IntegerLiteral.Val), isIdx: true})
+			default:
+				return nil, fmt.Errorf("index value is not int")
+			}
+		}
+		return path, nil
+
+	default:
+		return nil, fmt.Errorf("expect AttrExpr, IndexExpr or Identifier, got %s", node.NodeType)
+	}
+}
+
+func isASTJSONPath(node *ast.Node) bool {
+	switch node.NodeType { //nolint:exhaustive
+	case ast.TypeIdentifier, ast.TypeAttrExpr, ast.TypeIndexExpr:
+		return true
+	default:
+		return false
+	}
+}
+
+// getJSONPathArg get JSON path from function argument, the path is written
+// directly(`a.b[0]`) or is an expression of string(`"a.b[0]"`).
+func getJSONPathArg(ctx *runtime.Context, node *ast.Node) ([]jsonPathSeg, *errchain.PlError) {
+	if isASTJSONPath(node) {
+		path, err := astJSONPath(node)
+		if err != nil {
+			return nil, runtime.NewRunError(ctx, err.Error(), node.StartPos())
+		}
+		return path, nil
+	}
+
+	val, dtype, errR := runtime.RunStmt(ctx, node)
+	if errR != nil {
+		return nil, errR
+	}
+
+	if dtype != ast.String {
+		return nil, runtime.NewRunError(ctx, fmt.Sprintf("json path expect str, got %s", dtype), node.StartPos())
+	}
+
+	path, err := parseJSONPath(val.(string))
+	if err != nil {
+		return nil, runtime.NewRunError(ctx, err.Error(), node.StartPos())
+	}
+	return path, nil
+}
+
+func listIndex(arr []any, i int) int {
+	if i < 0 {
+		i += len(arr)
+	}
+	return i
+}
+
+// jsonPathGet returns value on the path, false if not found.
+func jsonPathGet(cur any, path []jsonPathSeg) (any, bool) {
+	for _, seg := range path {
+		switch v := cur.(type) {
+		case map[string]any:
+			if seg.isIdx {
+				return nil, false
+			}
+
+			child, ok := v[seg.key]
+			if !ok {
+				return nil, false
+			}
+			cur = child
+
+		case []any:
+			if !seg.isIdx {
+				return nil, false
+			}
+
+			i := listIndex(v, seg.index)
+			if i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// jsonPathSet set value on the path, missing objects on the path created,
+// index equal to length of the list appends the value. The root may be
+// replaced, so the new root returned.
+func jsonPathSet(cur any, path []jsonPathSeg, val any) (any, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+
+	seg := path[0]
+
+	if seg.isIdx {
+		var arr []any
+		switch v := cur.(type) {
+		case nil:
+		case []any:
+			arr = v
+		default:
+			return nil, fmt.Errorf("index %d on non-list value", seg.index)
+		}
+
+		i := listIndex(arr, seg.index)
+		if i == len(arr) {
+			arr = append(arr, nil)
+		}
+
+		if i < 0 || i >= len(arr) {
+			return nil, fmt.Errorf("index %d out of range", seg.index)
+		}
+
+		child, err := jsonPathSet(arr[i], path[1:], val)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = child
+		return arr, nil
+	}
+
+	var m map[string]any
+	switch v := cur.(type) {
+	case nil:
+		m = map[string]any{}
+	case map[string]any:
+		m = v
+	default:
+		return nil, fmt.Errorf("key %s on non-map value", seg.key)
+	}
+
+	child, err := jsonPathSet(m[seg.key], path[1:], val)
+	if err != nil {
+		return nil, err
+	}
+	m[seg.key] = child
+	return m, nil
+}
+
+// jsonPathDelete delete the map key or list element on the path, false
+// returned if the path not found.
+func jsonPathDelete(cur any, path []jsonPathSeg) (any, bool) {
+	if len(path) == 0 {
+		return cur, false
+	}
+
+	parent, ok := jsonPathGet(cur, path[:len(path)-1])
+	if !ok {
+		return cur, false
+	}
+
+	last := path[len(path)-1]
+
+	switch v := parent.(type) {
+	case map[string]any:
+		if last.isIdx {
+			return cur, false
+		}
+
+		if _, ok := v[last.key]; !ok {
+			return cur, false
+		}
+		delete(v, last.key)
+		return cur, true
+
+	case []any:
+		if !last.isIdx {
+			return cur, false
+		}
+
+		i := listIndex(v, last.index)
+		if i < 0 || i >= len(v) {
+			return cur, false
+		}
+
+		// the list shrinks, set it back to its parent.
+		arr := append(v[:i:i], v[i+1:]...)
+		if len(path) == 1 {
+			return arr, true
+		}
+
+		root, err := jsonPathSet(cur, path[:len(path)-1], arr)
+		if err != nil {
+			return cur, false
+		}
+		return root, true
+
+	default:
+		return cur, false
+	}
+}
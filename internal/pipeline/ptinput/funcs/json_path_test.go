@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	cases := []struct {
+		path   string
+		expect []jsonPathSeg
+		fail   bool
+	}{
+		{
+			path:   "a",
+			expect: []jsonPathSeg{{key: "a"}},
+		},
+		{
+			path: "$.a.b[0][-1].c",
+			expect: []jsonPathSeg{
+				{key: "a"}, {key: "b"},
+				{index: 0, isIdx: true}, {index: -1, isIdx: true},
+				{key: "c"},
+			},
+		},
+		{
+			path:   "[1].a",
+			expect: []jsonPathSeg{{index: 1, isIdx: true}, {key: "a"}},
+		},
+		{path: "", fail: true},
+		{path: "a..b", fail: true},
+		{path: "a[x]", fail: true},
+		{path: "a[1", fail: true},
+		{path: "a[1]b", fail: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			path, err := parseJSONPath(tc.path)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, path)
+		})
+	}
+}
@@ -97,6 +97,9 @@ type PlPoint struct {
 
 	aggBuckets *plmap.AggBuckets
 
+	// time spent by each function running on the point
+	funcCost map[string]time.Duration
+
 	category point.Category
 	drop     bool
 }
@@ -267,6 +270,19 @@ func (pt *PlPoint) SetAggBuckets(buks *plmap.AggBuckets) {
 	pt.aggBuckets = buks
 }
 
+// AddFuncCost adds the time spent by the function running on the point.
+func (pt *PlPoint) AddFuncCost(name string, cost time.Duration) {
+	if pt.funcCost == nil {
+		pt.funcCost = map[string]time.Duration{}
+	}
+	pt.funcCost[name] += cost
+}
+
+// FuncCost returns the time spent by each function running on the point.
+func (pt *PlPoint) FuncCost() map[string]time.Duration {
+	return pt.funcCost
+}
+
 func (pt *PlPoint) KeyTime2Time() {
 	if v, _, err := pt.Get("time"); err == nil {
 		if nanots, ok := v.(int64); ok {
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/logger"
@@ -158,6 +159,99 @@ func initReferTableRunner(runner *Runner, plRefTables PlReferTables, tableURL st
 	return nil
 }
 
+// InitReferTableSources load tables from local files or SQL queries, must be
+// called after InitReferTableRunner.
+func InitReferTableSources(cfgs []*SourceConfig) error {
+	return initReferTableSources(_runner, _plReferTables, cfgs)
+}
+
+func initReferTableSources(runner *Runner, plRefTables PlReferTables, cfgs []*SourceConfig) error {
+	if len(cfgs) == 0 {
+		return nil
+	}
+	if runner == nil {
+		return fmt.Errorf("runner == nil")
+	}
+	if plRefTables == nil {
+		return fmt.Errorf("refer table not initialized")
+	}
+
+	var srcs []*source
+	for _, cfg := range cfgs {
+		src, err := newSource(cfg)
+		if err != nil {
+			l.Errorf("invalid refer table source: %s, ignored", err)
+			continue
+		}
+		srcs = append(srcs, src)
+	}
+
+	if len(srcs) == 0 {
+		return fmt.Errorf("no valid refer table source")
+	}
+
+	g := goroutine.NewGroup(goroutine.Option{Name: "refer-table-source"})
+
+	var wg sync.WaitGroup
+	wg.Add(len(srcs))
+	for _, src := range srcs {
+		src := src
+		g.Go(func(ctx context.Context) error {
+			return sourceWkr(plRefTables, src, wg.Done, datakit.Exit.Wait())
+		})
+	}
+
+	// without refer_table_url, init finished after all sources loaded
+	if runner.inConfig.URL == "" && runner.initFinished != nil {
+		g.Go(func(ctx context.Context) error {
+			wg.Wait()
+			close(runner.initFinished)
+			return nil
+		})
+	}
+
+	return nil
+}
+
+func sourceWkr(plRefTables PlReferTables, src *source, loaded func(), ch <-chan any) error {
+	defer src.close()
+
+	ticker := time.NewTicker(src.interval)
+	defer ticker.Stop()
+
+	syncSource(plRefTables, src, time.Now())
+	loaded()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			syncSource(plRefTables, src, now)
+		case <-ch:
+			return nil
+		}
+	}
+}
+
+func syncSource(plRefTables PlReferTables, src *source, now time.Time) {
+	table, full, err := src.load(now)
+	if err != nil {
+		l.Errorf("load table %s: %v", src.cfg.TableName, err)
+		return
+	}
+
+	if table == nil {
+		return
+	}
+
+	if err := plRefTables.updateTable(table, src.cfg.PrimaryKey, full); err != nil {
+		l.Errorf("failed to update table %s: %v", src.cfg.TableName, err)
+		src.reset()
+		return
+	}
+
+	l.Infof("table %s loaded, %d rows, full: %v", src.cfg.TableName, len(table.RowData), full)
+}
+
 func checkURL(tableURL string) (string, error) {
 	u, err := url.Parse(tableURL)
 	if err != nil {
@@ -0,0 +1,434 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	// SQL drivers of the SQL source, SQLite registered within table_sqlite_other.go.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/spf13/cast"
+)
+
+const defaultSourceInterval = time.Minute
+
+// SourceConfig is the config of a table loaded from local file or SQL
+// query instead of refer_table_url.
+type SourceConfig struct {
+	TableName  string `toml:"table_name" json:"table_name"`
+	PrimaryKey string `toml:"primary_key" json:"primary_key"`
+
+	// Type of columns, "string" by default for CSV, others inferred from
+	// values of JSON or SQL.
+	ColumnType map[string]string `toml:"column_type" json:"column_type"`
+
+	// How often to check the file or run the query.
+	Interval string `toml:"interval" json:"interval"`
+
+	// File source, CSV with header line or JSON array of objects(*.json).
+	Path string `toml:"path" json:"path"`
+
+	// SQL source, driver is one of sqlite/mysql/postgres.
+	Driver string `toml:"driver" json:"driver"`
+	DSN    string `toml:"dsn" json:"dsn"`
+	Query  string `toml:"query" json:"query"`
+
+	// IncrementalQuery run with the max value of CursorColumn seen before as
+	// the only argument, rows returned are inserted or updated. The full
+	// Query run again every FullInterval to remove deleted rows.
+	IncrementalQuery string `toml:"incremental_query" json:"incremental_query"`
+	CursorColumn     string `toml:"cursor_column" json:"cursor_column"`
+	FullInterval     string `toml:"full_interval" json:"full_interval"`
+}
+
+type source struct {
+	cfg *SourceConfig
+
+	interval     time.Duration
+	fullInterval time.Duration
+
+	// file source
+	modTime time.Time
+	size    int64
+
+	// SQL source
+	db       *sql.DB
+	cursor   any
+	lastFull time.Time
+}
+
+func newSource(cfg *SourceConfig) (*source, error) {
+	if cfg.TableName == "" {
+		return nil, fmt.Errorf("empty table name")
+	}
+
+	if cfg.PrimaryKey == "" {
+		return nil, fmt.Errorf("table: %s, empty primary key", cfg.TableName)
+	}
+
+	for col, dtype := range cfg.ColumnType {
+		switch dtype {
+		case columnTypeInt, columnTypeFloat, columnTypeBool, columnTypeStr:
+		default:
+			return nil, fmt.Errorf("table: %s, unsupported column type: %s -> %s",
+				cfg.TableName, col, dtype)
+		}
+	}
+
+	switch {
+	case cfg.Path != "" && cfg.DSN == "":
+	case cfg.Path == "" && cfg.DSN != "":
+		if _, err := sqlDriverName(cfg.Driver); err != nil {
+			return nil, fmt.Errorf("table: %s, %w", cfg.TableName, err)
+		}
+		if cfg.Query == "" {
+			return nil, fmt.Errorf("table: %s, empty query", cfg.TableName)
+		}
+		if cfg.IncrementalQuery != "" && cfg.CursorColumn == "" {
+			return nil, fmt.Errorf("table: %s, cursor column required by incremental query", cfg.TableName)
+		}
+	default:
+		return nil, fmt.Errorf("table: %s, either path or dsn required", cfg.TableName)
+	}
+
+	src := &source{cfg: cfg, interval: defaultSourceInterval}
+
+	if cfg.Interval != "" {
+		du, err := time.ParseDuration(cfg.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("table: %s, invalid interval %q: %w", cfg.TableName, cfg.Interval, err)
+		}
+		if du > 0 {
+			src.interval = du
+		}
+	}
+
+	if cfg.FullInterval != "" {
+		du, err := time.ParseDuration(cfg.FullInterval)
+		if err != nil {
+			return nil, fmt.Errorf("table: %s, invalid full interval %q: %w", cfg.TableName, cfg.FullInterval, err)
+		}
+		src.fullInterval = du
+	}
+
+	return src, nil
+}
+
+func sqlDriverName(driver string) (string, error) {
+	switch strings.ToLower(driver) {
+	case "sqlite", "sqlite3":
+		return "sqlite", nil
+	case "mysql":
+		return "mysql", nil
+	case "postgres", "postgresql":
+		return "postgres", nil
+	default:
+		return "", fmt.Errorf("unsupported SQL driver %q", driver)
+	}
+}
+
+// load returns the table and whether it's the whole table, nil table
+// returned if nothing changed.
+func (src *source) load(now time.Time) (*referTable, bool, error) {
+	if src.cfg.Path != "" {
+		return src.loadFile()
+	}
+	return src.loadSQL(now)
+}
+
+// reset make the next load a full one, used after the table failed to update.
+func (src *source) reset() {
+	src.modTime = time.Time{}
+	src.size = 0
+	src.cursor = nil
+}
+
+func (src *source) close() {
+	if src.db != nil {
+		src.db.Close() //nolint:errcheck,gosec
+	}
+}
+
+func (src *source) loadFile() (*referTable, bool, error) {
+	fi, err := os.Stat(src.cfg.Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if fi.ModTime().Equal(src.modTime) && fi.Size() == src.size {
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(src.cfg.Path))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var table *referTable
+	if strings.EqualFold(filepath.Ext(src.cfg.Path), ".json") {
+		table, err = src.parseJSON(data)
+	} else {
+		table, err = src.parseCSV(data)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("parse %s: %w", src.cfg.Path, err)
+	}
+
+	src.modTime = fi.ModTime()
+	src.size = fi.Size()
+
+	return table, true, nil
+}
+
+func (src *source) parseCSV(data []byte) (*referTable, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	table := &referTable{TableName: src.cfg.TableName}
+	for _, col := range header {
+		col = strings.TrimSpace(col)
+		table.ColumnName = append(table.ColumnName, col)
+		table.ColumnType = append(table.ColumnType, src.columnType(col, columnTypeStr))
+	}
+
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF { //nolint:errorlint
+				break
+			}
+			return nil, err
+		}
+
+		row := make([]any, len(record))
+		for i, v := range record {
+			row[i] = v
+		}
+		table.RowData = append(table.RowData, row)
+	}
+
+	return table, nil
+}
+
+func (src *source) parseJSON(data []byte) (*referTable, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var objs []map[string]any
+	if err := dec.Decode(&objs); err != nil {
+		return nil, err
+	}
+
+	// columns sorted by name, types inferred from the first non-null value
+	colType := map[string]string{}
+	for _, obj := range objs {
+		for k, v := range obj {
+			if _, ok := colType[k]; !ok || colType[k] == "" {
+				colType[k] = inferColumnType(v)
+			}
+		}
+	}
+
+	table := &referTable{TableName: src.cfg.TableName}
+	for col := range colType {
+		table.ColumnName = append(table.ColumnName, col)
+	}
+	sort.Strings(table.ColumnName)
+
+	for _, col := range table.ColumnName {
+		table.ColumnType = append(table.ColumnType, src.columnType(col, colType[col]))
+	}
+
+	for _, obj := range objs {
+		row := make([]any, len(table.ColumnName))
+		for i, col := range table.ColumnName {
+			row[i] = obj[col]
+		}
+		table.RowData = append(table.RowData, row)
+	}
+
+	return table, nil
+}
+
+func (src *source) loadSQL(now time.Time) (*referTable, bool, error) {
+	if src.db == nil {
+		driver, err := sqlDriverName(src.cfg.Driver)
+		if err != nil {
+			return nil, false, err
+		}
+
+		db, err := sql.Open(driver, src.cfg.DSN)
+		if err != nil {
+			return nil, false, fmt.Errorf("open %s: %w", driver, err)
+		}
+		src.db = db
+	}
+
+	full := src.cursor == nil || src.cfg.IncrementalQuery == "" ||
+		(src.fullInterval > 0 && now.Sub(src.lastFull) >= src.fullInterval)
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	if full {
+		rows, err = src.db.Query(src.cfg.Query)
+	} else {
+		rows, err = src.db.Query(src.cfg.IncrementalQuery, src.cursor)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, false, err
+	}
+
+	table := &referTable{TableName: src.cfg.TableName, ColumnName: cols}
+	cursorCol := -1
+	for i, col := range cols {
+		if col == src.cfg.CursorColumn {
+			cursorCol = i
+		}
+	}
+
+	cursor := src.cursor
+	for rows.Next() {
+		row := make([]any, len(cols))
+		addrs := make([]any, len(cols))
+		for i := range row {
+			addrs[i] = &row[i]
+		}
+
+		if err := rows.Scan(addrs...); err != nil {
+			return nil, false, err
+		}
+
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+
+		if cursorCol >= 0 && cursorGreater(row[cursorCol], cursor) {
+			cursor = row[cursorCol]
+		}
+
+		for i, v := range row {
+			if t, ok := v.(time.Time); ok {
+				row[i] = t.Format(time.RFC3339Nano)
+			}
+		}
+
+		table.RowData = append(table.RowData, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if !full && len(table.RowData) == 0 {
+		return nil, false, nil
+	}
+
+	for i, col := range cols {
+		dtype := ""
+		for _, row := range table.RowData {
+			if dtype = inferColumnType(row[i]); dtype != "" {
+				break
+			}
+		}
+		if dtype == "" {
+			dtype = columnTypeStr
+		}
+		table.ColumnType = append(table.ColumnType, src.columnType(col, dtype))
+	}
+
+	src.cursor = cursor
+	if full {
+		src.lastFull = now
+	}
+
+	return table, full, nil
+}
+
+func (src *source) columnType(col, inferred string) string {
+	if dtype, ok := src.cfg.ColumnType[col]; ok {
+		return dtype
+	}
+	if inferred == "" {
+		return columnTypeStr
+	}
+	return inferred
+}
+
+// inferColumnType returns empty string for null.
+func inferColumnType(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return columnTypeBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return columnTypeInt
+	case float32, float64:
+		return columnTypeFloat
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return columnTypeInt
+		}
+		return columnTypeFloat
+	default:
+		return columnTypeStr
+	}
+}
+
+func cursorGreater(v, cursor any) bool {
+	if v == nil {
+		return false
+	}
+	if cursor == nil {
+		return true
+	}
+
+	switch x := v.(type) {
+	case time.Time:
+		if c, ok := cursor.(time.Time); ok {
+			return x.After(c)
+		}
+	case string:
+		if c, ok := cursor.(string); ok {
+			return x > c
+		}
+	}
+
+	a, errA := cast.ToFloat64E(v)
+	b, errB := cast.ToFloat64E(cursor)
+	if errA == nil && errB == nil {
+		return a > b
+	}
+
+	return fmt.Sprint(v) > fmt.Sprint(cursor)
+}
@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  *SourceConfig
+		fail bool
+	}{
+		{
+			name: "file",
+			cfg:  &SourceConfig{TableName: "t", PrimaryKey: "k", Path: "/tmp/t.csv"},
+		},
+		{
+			name: "sql",
+			cfg: &SourceConfig{
+				TableName: "t", PrimaryKey: "k", Driver: "mysql", DSN: "user@/db", Query: "SELECT * FROM t",
+				IncrementalQuery: "SELECT * FROM t WHERE updated_at > ?", CursorColumn: "updated_at",
+			},
+		},
+		{
+			name: "no-primary-key",
+			cfg:  &SourceConfig{TableName: "t", Path: "/tmp/t.csv"},
+			fail: true,
+		},
+		{
+			name: "path-and-dsn",
+			cfg:  &SourceConfig{TableName: "t", PrimaryKey: "k", Path: "/tmp/t.csv", DSN: "user@/db"},
+			fail: true,
+		},
+		{
+			name: "unknown-driver",
+			cfg:  &SourceConfig{TableName: "t", PrimaryKey: "k", Driver: "oracle", DSN: "x", Query: "SELECT 1"},
+			fail: true,
+		},
+		{
+			name: "no-cursor",
+			cfg: &SourceConfig{
+				TableName: "t", PrimaryKey: "k", Driver: "postgres", DSN: "x", Query: "SELECT 1",
+				IncrementalQuery: "SELECT 1",
+			},
+			fail: true,
+		},
+		{
+			name: "invalid-column-type",
+			cfg:  &SourceConfig{TableName: "t", PrimaryKey: "k", Path: "/tmp/t.csv", ColumnType: map[string]string{"a": "date"}},
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newSource(tc.cfg)
+			if tc.fail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "host.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte("host,team,cpu\nh1,infra,4\nh2,db,8\n"), os.ModePerm))
+
+	jsonFile := filepath.Join(dir, "host.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[
+		{"host": "h1", "team": "infra", "cpu": 4, "load": 0.5},
+		{"host": "h2", "team": "db", "cpu": 8, "online": true}
+	]`), os.ModePerm))
+
+	for _, f := range []string{csvFile, jsonFile} {
+		t.Run(filepath.Ext(f), func(t *testing.T) {
+			src, err := newSource(&SourceConfig{
+				TableName:  "host",
+				PrimaryKey: "host",
+				Path:       f,
+				ColumnType: map[string]string{"cpu": "int"},
+			})
+			require.NoError(t, err)
+
+			plrefer := &PlReferTablesInMemory{}
+			syncSource(plrefer, src, time.Now())
+			v, ok := plrefer.query("host", []string{"host"}, []any{"h2"}, nil)
+			require.True(t, ok)
+			assert.Equal(t, int64(8), v["cpu"])
+			assert.Equal(t, "db", v["team"])
+
+			// not changed
+			table, _, err := src.load(time.Now())
+			assert.NoError(t, err)
+			assert.Nil(t, table)
+		})
+	}
+
+	t.Run("json-types", func(t *testing.T) {
+		src, err := newSource(&SourceConfig{TableName: "host", PrimaryKey: "host", Path: jsonFile})
+		require.NoError(t, err)
+
+		table, full, err := src.load(time.Now())
+		require.NoError(t, err)
+		assert.True(t, full)
+		assert.Equal(t, []string{"cpu", "host", "load", "online", "team"}, table.ColumnName)
+		assert.Equal(t, []string{"int", "string", "float", "bool", "string"}, table.ColumnType)
+	})
+
+	t.Run("file-changed", func(t *testing.T) {
+		f := filepath.Join(dir, "changed.csv")
+		require.NoError(t, os.WriteFile(f, []byte("host,team\nh1,infra\nh2,db\n"), os.ModePerm))
+
+		src, err := newSource(&SourceConfig{TableName: "host", PrimaryKey: "host", Path: f})
+		require.NoError(t, err)
+
+		plrefer := &PlReferTablesInMemory{}
+		syncSource(plrefer, src, time.Now())
+
+		require.NoError(t, os.WriteFile(f, []byte("host,team\nh1,sre\n"), os.ModePerm))
+		require.NoError(t, os.Chtimes(f, time.Now(), time.Now().Add(time.Second)))
+		syncSource(plrefer, src, time.Now())
+
+		v, ok := plrefer.query("host", []string{"host"}, []any{"h1"}, nil)
+		require.True(t, ok)
+		assert.Equal(t, "sre", v["team"])
+
+		_, ok = plrefer.query("host", []string{"host"}, []any{"h2"}, nil)
+		assert.False(t, ok)
+	})
+}
+
+func TestSourceRunner(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "host.csv")
+	require.NoError(t, os.WriteFile(f, []byte("host,team\nh1,infra\n"), os.ModePerm))
+
+	plrefer := &PlReferTablesInMemory{}
+	runner := &Runner{initFinished: make(chan struct{})}
+
+	require.NoError(t, initReferTableSources(runner, plrefer, []*SourceConfig{
+		{TableName: "host", PrimaryKey: "host", Path: f},
+		{TableName: "invalid"}, // ignored
+	}))
+
+	require.True(t, runner.InitFinished(time.Second*5))
+
+	_, ok := plrefer.query("host", []string{"team"}, []any{"infra"}, nil)
+	assert.True(t, ok)
+
+	assert.Error(t, initReferTableSources(runner, plrefer, []*SourceConfig{{TableName: "invalid"}}))
+}
+
+// fakeDriver returns rows set by test for any query, the args of the last
+// query recorded.
+type fakeDriver struct {
+	cols     []string
+	rows     [][]driver.Value
+	lastArgs []driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{d: c.d}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, io.EOF }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s *fakeStmt) Close() error                                    { return nil }
+func (s *fakeStmt) NumInput() int                                   { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, io.EOF }
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.lastArgs = args
+	return &fakeRows{cols: s.d.cols, rows: s.d.rows}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var testFakeDriver = &fakeDriver{}
+
+func init() { //nolint:gochecknoinits
+	sql.Register("refertable_fake", testFakeDriver)
+}
+
+func TestSQLSource(t *testing.T) {
+	src, err := newSource(&SourceConfig{
+		TableName:        "host",
+		PrimaryKey:       "host",
+		Driver:           "mysql",
+		DSN:              "x",
+		Query:            "SELECT host, team, cpu, updated_at FROM host",
+		IncrementalQuery: "SELECT host, team, cpu, updated_at FROM host WHERE updated_at > ?",
+		CursorColumn:     "updated_at",
+		FullInterval:     "1h",
+	})
+	require.NoError(t, err)
+
+	src.db, err = sql.Open("refertable_fake", "")
+	require.NoError(t, err)
+
+	plrefer := &PlReferTablesInMemory{}
+	now := time.Now()
+
+	testFakeDriver.cols = []string{"host", "team", "cpu", "updated_at"}
+	testFakeDriver.rows = [][]driver.Value{
+		{[]byte("h1"), []byte("infra"), int64(4), int64(100)},
+		{[]byte("h2"), []byte("db"), int64(8), int64(200)},
+	}
+	syncSource(plrefer, src, now)
+
+	v, ok := plrefer.query("host", []string{"host"}, []any{"h2"}, nil)
+	require.True(t, ok)
+	assert.Equal(t, int64(8), v["cpu"])
+	assert.Equal(t, int64(200), src.cursor)
+
+	// incremental
+	testFakeDriver.rows = [][]driver.Value{
+		{[]byte("h1"), []byte("sre"), int64(4), int64(300)},
+	}
+	syncSource(plrefer, src, now.Add(time.Minute))
+	assert.Equal(t, []driver.Value{int64(200)}, testFakeDriver.lastArgs)
+	assert.Equal(t, int64(300), src.cursor)
+
+	v, ok = plrefer.query("host", []string{"host"}, []any{"h1"}, nil)
+	require.True(t, ok)
+	assert.Equal(t, "sre", v["team"])
+
+	_, ok = plrefer.query("host", []string{"host"}, []any{"h2"}, nil)
+	assert.True(t, ok)
+
+	// nothing changed
+	testFakeDriver.rows = nil
+	table, _, err := src.load(now.Add(time.Minute * 2))
+	assert.NoError(t, err)
+	assert.Nil(t, table)
+
+	// full query deletes rows
+	testFakeDriver.rows = [][]driver.Value{
+		{[]byte("h1"), []byte("sre"), int64(4), int64(300)},
+	}
+	syncSource(plrefer, src, now.Add(time.Hour*2))
+	assert.Empty(t, testFakeDriver.lastArgs)
+
+	_, ok = plrefer.query("host", []string{"host"}, []any{"h2"}, nil)
+	assert.False(t, ok)
+}
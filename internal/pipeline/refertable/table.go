@@ -25,6 +25,11 @@ const (
 type PlReferTables interface {
 	query(tableName string, colName []string, colValue []any, kGet []string) (map[string]any, bool)
 	updateAll(tables []referTable) (retErr error)
+
+	// updateTable update single table from local source, rows matched by
+	// the primary column. If full, rows are the whole table and those not
+	// within them deleted, otherwise rows are inserted or updated only.
+	updateTable(table *referTable, pk string, full bool) (retErr error)
 	stats() *ReferTableStats
 }
 
@@ -33,6 +38,9 @@ type PlReferTablesInMemory struct {
 	tablesName   []string
 	updateMutex  sync.Mutex
 	queryRWmutex sync.RWMutex
+
+	// tables from local sources, kept while updating tables pulled from URL
+	localTables map[string]struct{}
 }
 
 type ReferTableStats struct {
@@ -97,6 +105,11 @@ func (plrefer *PlReferTablesInMemory) updateAll(tables []referTable) (retErr err
 	tablesName := []string{}
 	for idx := range tables {
 		table := tables[idx]
+		if _, ok := plrefer.localTables[table.TableName]; ok {
+			l.Warnf("table %s exists within local sources, ignored", table.TableName)
+			continue
+		}
+
 		if err := table.buildTableIndex(); err != nil {
 			return err
 		}
@@ -106,6 +119,13 @@ func (plrefer *PlReferTablesInMemory) updateAll(tables []referTable) (retErr err
 		}
 	}
 
+	for _, name := range plrefer.tablesName {
+		if _, ok := plrefer.localTables[name]; ok {
+			refTableMap[name] = plrefer.tables[name]
+			tablesName = append(tablesName, name)
+		}
+	}
+
 	plrefer.queryRWmutex.Lock()
 	defer plrefer.queryRWmutex.Unlock()
 	plrefer.tables = refTableMap
@@ -122,7 +142,7 @@ func (plrefer *PlReferTablesInMemory) stats() *ReferTableStats {
 		tableStats.Name = append(tableStats.Name, name)
 
 		tableStats.Row = append(tableStats.Row,
-			len(plrefer.tables[name].RowData))
+			plrefer.tables[name].rowCount())
 	}
 
 	return &tableStats
@@ -138,6 +158,16 @@ type referTable struct {
 	index map[string]map[any][]int
 
 	colIndex map[string]int
+
+	// tables from local sources are indexed by primary column, rows deleted
+	// are set to nil until the table rebuilt.
+	pkCol   int
+	pkIndex map[any]int
+	deleted int
+}
+
+func (table *referTable) rowCount() int {
+	return len(table.RowData) - table.deleted
 }
 
 func (table *referTable) check() error {
@@ -184,17 +214,19 @@ func (table *referTable) buildTableIndex() error {
 	table.index = map[string]map[any][]int{}
 	table.colIndex = map[string]int{}
 
+	for colIdx, colName := range table.ColumnName {
+		if _, ok := table.index[colName]; !ok {
+			table.colIndex[colName] = colIdx
+			table.index[colName] = map[any][]int{}
+		}
+	}
+
 	// 遍历行
 	for rowIdx, row := range table.RowData {
 		// 遍历列，建立索引: colName -> colValue -> []rowIndex
 		for colIdx := 0; colIdx < len(table.ColumnName); colIdx++ {
 			colName := table.ColumnName[colIdx]
 
-			if _, ok := table.index[colName]; !ok {
-				table.colIndex[colName] = colIdx
-				table.index[colName] = map[any][]int{}
-			}
-
 			// 列数据转换为指定类型
 			v, err := conv(row[colIdx], table.ColumnType[colIdx])
 			if err != nil {
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	_ "modernc.org/sqlite"
@@ -24,6 +25,10 @@ var dbPath = filepath.Join(datakit.DataDir, "reftable_sqlite")
 type PlReferTablesSqlite struct {
 	tableNames []string
 	db         *sql.DB
+
+	// tables from local sources, kept while updating tables pulled from URL
+	localTables []string
+	mu          sync.Mutex
 }
 
 func (p *PlReferTablesSqlite) query(tableName string, colName []string, colValue []any, kGet []string) (map[string]any, bool) {
@@ -72,15 +77,35 @@ func (p *PlReferTablesSqlite) query(tableName string, colName []string, colValue
 	return ret, true
 }
 
+func (p *PlReferTablesSqlite) isLocal(name string) bool {
+	for _, t := range p.localTables {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *PlReferTablesSqlite) updateAll(tables []referTable) (retErr error) {
 	if p.db == nil {
 		return errors.New("PlReferTablesSqlite is not initialized")
 	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var remoteTables []referTable
 	for _, table := range tables {
+		if p.isLocal(table.TableName) {
+			l.Warnf("table %s exists within local sources, ignored", table.TableName)
+			continue
+		}
 		if err := table.check(); err != nil {
 			return err
 		}
+		remoteTables = append(remoteTables, table)
 	}
+	tables = remoteTables
 
 	tx, err := p.db.Begin()
 	if err != nil {
@@ -139,19 +164,112 @@ func (p *PlReferTablesSqlite) updateAll(tables []referTable) (retErr error) {
 	return nil
 }
 
+func (p *PlReferTablesSqlite) updateTable(table *referTable, pk string, full bool) (retErr error) {
+	if p.db == nil {
+		return errors.New("PlReferTablesSqlite is not initialized")
+	}
+
+	if err := table.check(); err != nil {
+		return err
+	}
+
+	found := false
+	for _, name := range table.ColumnName {
+		if name == pk {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("table: %s, primary key %s not found", table.TableName, pk)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start a TX: %w", err)
+	}
+	defer func() {
+		if retErr != nil {
+			if err := tx.Rollback(); err != nil {
+				l.Errorf("failed to rollback TX: %v", err)
+			}
+		} else {
+			if err := tx.Commit(); err != nil {
+				l.Errorf("failed to commit TX: %v", err)
+			}
+		}
+	}()
+
+	isNew := !p.isLocal(table.TableName)
+
+	// the table pulled from URL with the same name is replaced
+	if full || isNew {
+		dropStmt := fmt.Sprintf("DROP TABLE IF EXISTS %s", table.TableName)
+		if _, err := tx.Exec(dropStmt); err != nil {
+			return fmt.Errorf("failed to execute '%s': %w", dropStmt, err)
+		}
+
+		createStmt := buildCreateTableStmt(table)
+		if _, err := tx.Exec(createStmt); err != nil {
+			return fmt.Errorf("failed to execute '%s': %w", createStmt, err)
+		}
+	}
+
+	deleteStmt := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table.TableName, pk)
+	insertStmt := buildInsertIntoStmts(table)
+	pkCol := 0
+	for i, name := range table.ColumnName {
+		if name == pk {
+			pkCol = i
+		}
+	}
+
+	for _, row := range table.RowData {
+		if !full && !isNew {
+			if _, err := tx.Exec(deleteStmt, row[pkCol]); err != nil {
+				return fmt.Errorf("failed to execute '%s' with params %v: %w", deleteStmt, row[pkCol], err)
+			}
+		}
+		if _, err := tx.Exec(insertStmt, row...); err != nil {
+			return fmt.Errorf("failed to execute '%s' with params %v: %w", insertStmt, row, err)
+		}
+	}
+
+	if isNew {
+		p.localTables = append(p.localTables, table.TableName)
+
+		tableNames := []string{}
+		for _, t := range p.tableNames {
+			if t != table.TableName {
+				tableNames = append(tableNames, t)
+			}
+		}
+		p.tableNames = tableNames
+	}
+
+	return nil
+}
+
 func (p *PlReferTablesSqlite) stats() *ReferTableStats {
 	if p.db == nil {
 		return nil
 	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	var (
 		res    ReferTableStats
 		numRow int
 	)
-	for _, tableName := range p.tableNames {
+	for _, tableName := range append(append([]string{}, p.tableNames...), p.localTables...) {
 		if err := p.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)).Scan(&numRow); err != nil {
 			l.Errorf("Query retuned: %v", err)
 			return nil
 		}
+		res.Name = append(res.Name, tableName)
 		res.Row = append(res.Row, numRow)
 	}
 	return &res
@@ -33,6 +33,11 @@ func (p *PlReferTablesSqlite) updateAll(tables []referTable) (retErr error) {
 	return nil
 }
 
+func (p *PlReferTablesSqlite) updateTable(table *referTable, pk string, full bool) (retErr error) {
+	l.Errorf("windows-386 does not support query using SQLite")
+	return nil
+}
+
 func (p *PlReferTablesSqlite) stats() *ReferTableStats {
 	l.Errorf("windows-386 does not support query using SQLite")
 	return nil
@@ -0,0 +1,275 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"fmt"
+	"sort"
+)
+
+type rowChange struct {
+	idx int
+	row []any
+}
+
+func (plrefer *PlReferTablesInMemory) updateTable(table *referTable, pk string, full bool) (retErr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			retErr = fmt.Errorf("update table: %s", err)
+		}
+	}()
+
+	plrefer.updateMutex.Lock()
+	defer plrefer.updateMutex.Unlock()
+
+	if err := table.convRows(); err != nil {
+		return err
+	}
+
+	pkCol := -1
+	for i, name := range table.ColumnName {
+		if name == pk {
+			pkCol = i
+		}
+	}
+	if pkCol < 0 {
+		return fmt.Errorf("table: %s, primary key %s not found", table.TableName, pk)
+	}
+
+	// only this goroutine modify tables, read them without query lock
+	old := plrefer.tables[table.TableName]
+	if old == nil || !old.sameSchema(table, pkCol) {
+		if old != nil && !full {
+			return fmt.Errorf("table: %s, schema changed on incremental update", table.TableName)
+		}
+		return plrefer.replaceTable(table, pkCol)
+	}
+
+	var (
+		updates []rowChange
+		appends [][]any
+		deletes []int
+		pending = map[any]int{} // new rows by primary key
+		seen    map[any]struct{}
+	)
+
+	if full {
+		seen = make(map[any]struct{}, len(table.RowData))
+	}
+
+	for _, row := range table.RowData {
+		k := row[pkCol]
+		if full {
+			seen[k] = struct{}{}
+		}
+
+		if idx, ok := old.pkIndex[k]; ok {
+			if !rowEqual(old.RowData[idx], row) {
+				updates = append(updates, rowChange{idx: idx, row: row})
+			}
+			continue
+		}
+
+		if i, ok := pending[k]; ok {
+			appends[i] = row
+		} else {
+			pending[k] = len(appends)
+			appends = append(appends, row)
+		}
+	}
+
+	if full {
+		for k, idx := range old.pkIndex {
+			if _, ok := seen[k]; !ok {
+				deletes = append(deletes, idx)
+			}
+		}
+
+		// too many deleted rows, rebuild the table to release them
+		if total := len(old.RowData) + len(appends); total > 0 &&
+			(old.deleted+len(deletes))*2 > total {
+			return plrefer.replaceTable(table, pkCol)
+		}
+	}
+
+	if len(updates) == 0 && len(appends) == 0 && len(deletes) == 0 {
+		return nil
+	}
+
+	plrefer.queryRWmutex.Lock()
+	defer plrefer.queryRWmutex.Unlock()
+
+	for _, c := range updates {
+		old.updateRow(c.idx, c.row)
+	}
+	for _, row := range appends {
+		old.appendRow(row)
+	}
+	for _, idx := range deletes {
+		old.deleteRow(idx)
+	}
+
+	l.Debugf("table %s updated, %d updated, %d inserted, %d deleted",
+		table.TableName, len(updates), len(appends), len(deletes))
+
+	return nil
+}
+
+// replaceTable build index of the whole table and replace the old one.
+func (plrefer *PlReferTablesInMemory) replaceTable(table *referTable, pkCol int) error {
+	// the last row wins if primary key duplicated
+	rows := make([][]any, 0, len(table.RowData))
+	pkIndex := make(map[any]int, len(table.RowData))
+	for _, row := range table.RowData {
+		if idx, ok := pkIndex[row[pkCol]]; ok {
+			rows[idx] = row
+		} else {
+			pkIndex[row[pkCol]] = len(rows)
+			rows = append(rows, row)
+		}
+	}
+
+	newTable := &referTable{
+		TableName:  table.TableName,
+		ColumnName: table.ColumnName,
+		ColumnType: table.ColumnType,
+		RowData:    rows,
+		pkCol:      pkCol,
+		pkIndex:    pkIndex,
+	}
+
+	if err := newTable.buildTableIndex(); err != nil {
+		return err
+	}
+
+	plrefer.queryRWmutex.Lock()
+	defer plrefer.queryRWmutex.Unlock()
+
+	if plrefer.tables == nil {
+		plrefer.tables = map[string]*referTable{}
+	}
+	if plrefer.localTables == nil {
+		plrefer.localTables = map[string]struct{}{}
+	}
+
+	if _, ok := plrefer.tables[table.TableName]; !ok {
+		plrefer.tablesName = append(plrefer.tablesName, table.TableName)
+	}
+	plrefer.tables[table.TableName] = newTable
+	plrefer.localTables[table.TableName] = struct{}{}
+
+	return nil
+}
+
+// convRows check the table and convert values of rows to the column type.
+func (table *referTable) convRows() error {
+	if err := table.check(); err != nil {
+		return err
+	}
+
+	for rowIdx, row := range table.RowData {
+		for colIdx := range table.ColumnName {
+			v, err := conv(row[colIdx], table.ColumnType[colIdx])
+			if err != nil {
+				return fmt.Errorf("table: %s, row: %d, col: %d, cast error: %w",
+					table.TableName, rowIdx, colIdx, err)
+			}
+			row[colIdx] = v
+		}
+	}
+
+	return nil
+}
+
+func (table *referTable) sameSchema(other *referTable, pkCol int) bool {
+	if table.pkIndex == nil || table.pkCol != pkCol ||
+		len(table.ColumnName) != len(other.ColumnName) {
+		return false
+	}
+
+	for i := range table.ColumnName {
+		if table.ColumnName[i] != other.ColumnName[i] ||
+			table.ColumnType[i] != other.ColumnType[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func rowEqual(a, b []any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (table *referTable) appendRow(row []any) {
+	idx := len(table.RowData)
+	table.RowData = append(table.RowData, row)
+
+	// the new row index is the largest, the index list kept increasing
+	for colIdx, colName := range table.ColumnName {
+		table.index[colName][row[colIdx]] = append(table.index[colName][row[colIdx]], idx)
+	}
+	table.pkIndex[row[table.pkCol]] = idx
+}
+
+func (table *referTable) updateRow(idx int, row []any) {
+	old := table.RowData[idx]
+	for colIdx, colName := range table.ColumnName {
+		if old[colIdx] == row[colIdx] {
+			continue
+		}
+		table.removeIndex(colName, old[colIdx], idx)
+		table.insertIndex(colName, row[colIdx], idx)
+	}
+	table.RowData[idx] = row
+}
+
+func (table *referTable) deleteRow(idx int) {
+	row := table.RowData[idx]
+	if row == nil {
+		return
+	}
+
+	for colIdx, colName := range table.ColumnName {
+		table.removeIndex(colName, row[colIdx], idx)
+	}
+	delete(table.pkIndex, row[table.pkCol])
+
+	table.RowData[idx] = nil
+	table.deleted++
+}
+
+func (table *referTable) insertIndex(colName string, v any, idx int) {
+	idxs := table.index[colName][v]
+	i := sort.SearchInts(idxs, idx)
+	idxs = append(idxs, 0)
+	copy(idxs[i+1:], idxs[i:])
+	idxs[i] = idx
+	table.index[colName][v] = idxs
+}
+
+func (table *referTable) removeIndex(colName string, v any, idx int) {
+	idxs := table.index[colName][v]
+	i := sort.SearchInts(idxs, idx)
+	if i >= len(idxs) || idxs[i] != idx {
+		return
+	}
+
+	if len(idxs) == 1 {
+		delete(table.index[colName], v)
+		return
+	}
+	table.index[colName][v] = append(idxs[:i], idxs[i+1:]...)
+}
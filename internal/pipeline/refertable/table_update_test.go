@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hostTable(rows ...[]any) *referTable {
+	return &referTable{
+		TableName:  "cmdb_host",
+		ColumnName: []string{"host", "team", "cpu"},
+		ColumnType: []string{"string", "string", "int"},
+		RowData:    rows,
+	}
+}
+
+func TestUpdateTable(t *testing.T) {
+	plrefer := &PlReferTablesInMemory{}
+
+	require.NoError(t, plrefer.updateTable(hostTable(
+		[]any{"h1", "infra", 4},
+		[]any{"h2", "infra", "8"},
+		[]any{"h3", "db", 16},
+		[]any{"h3", "db", 32}, // the last row wins
+	), "host", true))
+
+	v, ok := plrefer.query("cmdb_host", []string{"host"}, []any{"h3"}, nil)
+	require.True(t, ok)
+	assert.Equal(t, int64(32), v["cpu"])
+	assert.Equal(t, 3, plrefer.stats().Row[0])
+
+	t.Run("incremental", func(t *testing.T) {
+		require.NoError(t, plrefer.updateTable(hostTable(
+			[]any{"h2", "sre", 8},
+			[]any{"h4", "sre", 2},
+		), "host", false))
+
+		v, ok := plrefer.query("cmdb_host", []string{"host"}, []any{"h2"}, nil)
+		require.True(t, ok)
+		assert.Equal(t, "sre", v["team"])
+
+		// h1 not within incremental rows, kept
+		_, ok = plrefer.query("cmdb_host", []string{"host"}, []any{"h1"}, nil)
+		assert.True(t, ok)
+
+		v, ok = plrefer.query("cmdb_host", []string{"team", "cpu"}, []any{"sre", int64(2)}, nil)
+		require.True(t, ok)
+		assert.Equal(t, "h4", v["host"])
+
+		// index of the old value removed
+		v, ok = plrefer.query("cmdb_host", []string{"team"}, []any{"infra"}, nil)
+		require.True(t, ok)
+		assert.Equal(t, "h1", v["host"])
+
+		assert.Equal(t, 4, plrefer.stats().Row[0])
+	})
+
+	t.Run("full", func(t *testing.T) {
+		require.NoError(t, plrefer.updateTable(hostTable(
+			[]any{"h1", "infra", 4},
+			[]any{"h2", "infra", 8},
+			[]any{"h3", "db", 32},
+			[]any{"h5", "db", 1},
+		), "host", true))
+
+		_, ok := plrefer.query("cmdb_host", []string{"host"}, []any{"h4"}, nil)
+		assert.False(t, ok)
+
+		_, ok = plrefer.query("cmdb_host", []string{"team"}, []any{"sre"}, nil)
+		assert.False(t, ok)
+
+		v, ok := plrefer.query("cmdb_host", []string{"team", "cpu"}, []any{"infra", int64(8)}, nil)
+		require.True(t, ok)
+		assert.Equal(t, "h2", v["host"])
+
+		table := plrefer.tables["cmdb_host"]
+		assert.Equal(t, 1, table.deleted)
+		assert.Equal(t, 4, plrefer.stats().Row[0])
+	})
+
+	t.Run("rebuild", func(t *testing.T) {
+		old := plrefer.tables["cmdb_host"]
+
+		require.NoError(t, plrefer.updateTable(hostTable(
+			[]any{"h1", "infra", 4},
+		), "host", true))
+
+		table := plrefer.tables["cmdb_host"]
+		assert.NotSame(t, old, table)
+		assert.Equal(t, 0, table.deleted)
+		assert.Len(t, table.RowData, 1)
+	})
+
+	t.Run("schema-changed", func(t *testing.T) {
+		table := hostTable([]any{"h1", "infra"})
+		table.ColumnName = table.ColumnName[:2]
+		table.ColumnType = table.ColumnType[:2]
+		assert.Error(t, plrefer.updateTable(table, "host", false))
+
+		require.NoError(t, plrefer.updateTable(table, "host", true))
+		v, ok := plrefer.query("cmdb_host", []string{"host"}, []any{"h1"}, nil)
+		require.True(t, ok)
+		assert.Len(t, v, 2)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, plrefer.updateTable(hostTable([]any{"h1", "infra", 1}), "ip", true))
+		assert.Error(t, plrefer.updateTable(hostTable([]any{"h1", "infra", "x"}), "host", true))
+	})
+
+	t.Run("keep-local-tables", func(t *testing.T) {
+		tables, err := decodeJSONData([]byte(testTableData))
+		require.NoError(t, err)
+
+		tables = append(tables, *hostTable([]any{"h9", "remote", 1}))
+		require.NoError(t, plrefer.updateAll(tables))
+
+		_, ok := plrefer.query("table1", []string{"key1"}, []any{"a"}, nil)
+		assert.True(t, ok)
+
+		// table from URL with the same name ignored
+		_, ok = plrefer.query("cmdb_host", []string{"host"}, []any{"h1"}, nil)
+		assert.True(t, ok)
+		_, ok = plrefer.query("cmdb_host", []string{"host"}, []any{"h9"}, nil)
+		assert.False(t, ok)
+
+		assert.ElementsMatch(t, []string{"table1", "table2", "cmdb_host"}, plrefer.stats().Name)
+	})
+}
+
+func TestRowIndex(t *testing.T) {
+	table := hostTable()
+	plrefer := &PlReferTablesInMemory{}
+	require.NoError(t, plrefer.updateTable(table, "host", true))
+
+	var rows [][]any
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []any{fmt.Sprintf("h%d", i), fmt.Sprintf("team-%d", i%3), i})
+	}
+	require.NoError(t, plrefer.updateTable(hostTable(rows...), "host", false))
+
+	// move rows between teams, index lists must be kept increasing
+	var changed [][]any
+	for i := 0; i < 100; i += 7 {
+		changed = append(changed, []any{fmt.Sprintf("h%d", i), "team-x", i})
+	}
+	require.NoError(t, plrefer.updateTable(hostTable(changed...), "host", false))
+
+	tb := plrefer.tables["cmdb_host"]
+	for col, m := range tb.index {
+		for v, idxs := range m {
+			for i := 1; i < len(idxs); i++ {
+				assert.Less(t, idxs[i-1], idxs[i], "%s: %v", col, v)
+			}
+		}
+	}
+
+	assert.Len(t, tb.index["team"]["team-x"], len(changed))
+	for _, row := range changed {
+		v, ok := plrefer.query("cmdb_host", []string{"team", "host"}, []any{"team-x", row[0]}, nil)
+		require.True(t, ok)
+		assert.Equal(t, row[2].(int64), v["cpu"])
+	}
+}
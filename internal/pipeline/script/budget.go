@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package script
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	plruntime "github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput/funcs"
+)
+
+// Actions if the script run exceeded the time budget.
+const (
+	TimeoutActionSkip = "skip" // stop the script and keep the point
+	TimeoutActionDrop = "drop" // stop the script and drop the point
+)
+
+var ErrScriptTimeout = errors.New("script run exceeded time budget")
+
+type timeBudget struct {
+	timeout time.Duration
+	drop    bool
+}
+
+var _timeBudget atomic.Value // *timeBudget
+
+// SetTimeBudget limits the time of a script running on a point, disabled
+// if timeout not positive.
+//
+// The script exits before the next statement once the timeout exceeded,
+// a function running can not be interrupted, the point is then kept or
+// dropped according to the action.
+func SetTimeBudget(timeout time.Duration, action string) error {
+	if timeout <= 0 {
+		_timeBudget.Store((*timeBudget)(nil))
+		return nil
+	}
+
+	b := &timeBudget{timeout: timeout}
+	switch action {
+	case "", TimeoutActionSkip:
+	case TimeoutActionDrop:
+		b.drop = true
+	default:
+		_timeBudget.Store((*timeBudget)(nil))
+		return fmt.Errorf("unknown timeout action %q, expect %s or %s",
+			action, TimeoutActionSkip, TimeoutActionDrop)
+	}
+
+	_timeBudget.Store(b)
+	return nil
+}
+
+func getTimeBudget() *timeBudget {
+	b, _ := _timeBudget.Load().(*timeBudget)
+	return b
+}
+
+// budgetSignal stops the script once the deadline exceeded or the
+// signal wrapped exits.
+type budgetSignal struct {
+	signal   plruntime.Signal
+	deadline time.Time
+	exceeded bool
+}
+
+func (s *budgetSignal) ExitSignal() bool {
+	if s.signal != nil && s.signal.ExitSignal() {
+		return true
+	}
+
+	if !s.exceeded && time.Now().After(s.deadline) {
+		s.exceeded = true
+	}
+	return s.exceeded
+}
+
+var _funcCost int32
+
+// SetFuncCost set whether to record the time spent by each function,
+// always recorded if the time budget set.
+func SetFuncCost(enable bool) {
+	if enable {
+		atomic.StoreInt32(&_funcCost, 1)
+	} else {
+		atomic.StoreInt32(&_funcCost, 0)
+	}
+}
+
+func recordFuncCost() bool {
+	return atomic.LoadInt32(&_funcCost) == 1 || getTimeBudget() != nil
+}
+
+// funcCostRecorder is implemented by the input point to collect the time
+// spent by functions.
+type funcCostRecorder interface {
+	AddFuncCost(name string, cost time.Duration)
+	FuncCost() map[string]time.Duration
+}
+
+var (
+	_timedFuncs     map[string]plruntime.FuncCall
+	_timedFuncsOnce sync.Once
+)
+
+// timedFuncs returns the functions recording the time spent on the point.
+func timedFuncs() map[string]plruntime.FuncCall {
+	_timedFuncsOnce.Do(func() {
+		_timedFuncs = make(map[string]plruntime.FuncCall, len(funcs.FuncsMap))
+		for name, fn := range funcs.FuncsMap {
+			_timedFuncs[name] = timedFunc(name, fn)
+		}
+	})
+	return _timedFuncs
+}
+
+func timedFunc(name string, fn plruntime.FuncCall) plruntime.FuncCall {
+	return func(ctx *plruntime.Context, call *ast.CallExpr) *errchain.PlError {
+		if !recordFuncCost() {
+			return fn(ctx, call)
+		}
+
+		start := time.Now()
+		err := fn(ctx, call)
+
+		if r, ok := ctx.InData().(funcCostRecorder); ok {
+			r.AddFuncCost(name, time.Since(start))
+		}
+		return err
+	}
+}
+
+// slowestFunc returns the function spent most of the time.
+func slowestFunc(cost map[string]time.Duration) string {
+	var name string
+	var max time.Duration
+	for k, v := range cost {
+		if v > max || (v == max && k < name) {
+			name, max = k, v
+		}
+	}
+	return name
+}
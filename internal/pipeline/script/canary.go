@@ -0,0 +1,462 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package script
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	plengine "github.com/GuanceCloud/platypus/pkg/engine"
+	plruntime "github.com/GuanceCloud/platypus/pkg/engine/runtime"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/stats"
+)
+
+const (
+	CanaryPromoted = "promoted"
+	CanaryRejected = "rejected"
+)
+
+// CanaryConfig configures canary of remote pipeline scripts: new version
+// of a remote script runs on a sample fraction of points in parallel with
+// the script in use, and is only promoted when their results are within
+// thresholds.
+type CanaryConfig struct {
+	Enable bool `toml:"enable"`
+
+	// fraction of points also run on the new version
+	SampleRate float64 `toml:"sample_rate"`
+
+	// sampled points required before the comparison
+	MinPoints uint64 `toml:"min_points"`
+
+	// thresholds of the new version compared with the script in use:
+	// error/drop rate increased at most MaxErrorRateDelta/MaxDropRateDelta,
+	// and average field count at least MinFieldRatio of the script in use.
+	MaxErrorRateDelta float64 `toml:"max_error_rate_delta"`
+	MaxDropRateDelta  float64 `toml:"max_drop_rate_delta"`
+	MinFieldRatio     float64 `toml:"min_field_ratio"`
+
+	// the new version promoted if not enough points sampled within MaxWait
+	MaxWait time.Duration `toml:"max_wait"`
+}
+
+func DefaultCanaryConfig() *CanaryConfig {
+	return &CanaryConfig{
+		SampleRate:        0.1,
+		MinPoints:         100,
+		MaxErrorRateDelta: 0.05,
+		MaxDropRateDelta:  0.1,
+		MinFieldRatio:     0.8,
+		MaxWait:           10 * time.Minute,
+	}
+}
+
+var _canaryCfg atomic.Value // *CanaryConfig
+
+// SetCanaryConfig enable canary of remote scripts if c enabled.
+func SetCanaryConfig(c *CanaryConfig) {
+	if c == nil || !c.Enable {
+		_canaryCfg.Store((*CanaryConfig)(nil))
+		return
+	}
+
+	def := DefaultCanaryConfig()
+	x := *c
+
+	if x.SampleRate <= 0 || x.SampleRate > 1 {
+		x.SampleRate = def.SampleRate
+	}
+
+	if x.MinPoints == 0 {
+		x.MinPoints = def.MinPoints
+	}
+
+	if x.MaxErrorRateDelta <= 0 {
+		x.MaxErrorRateDelta = def.MaxErrorRateDelta
+	}
+
+	if x.MaxDropRateDelta <= 0 {
+		x.MaxDropRateDelta = def.MaxDropRateDelta
+	}
+
+	if x.MinFieldRatio <= 0 {
+		x.MinFieldRatio = def.MinFieldRatio
+	}
+
+	if x.MaxWait <= 0 {
+		x.MaxWait = def.MaxWait
+	}
+
+	_canaryCfg.Store(&x)
+}
+
+func canaryConfig() *CanaryConfig {
+	if c, ok := _canaryCfg.Load().(*CanaryConfig); ok {
+		return c
+	}
+	return nil
+}
+
+type canaryStats struct {
+	pt, ptErr, ptDrop, fields uint64
+}
+
+func (s *canaryStats) observe(plpt ptinput.PlInputPt, err error) {
+	atomic.AddUint64(&s.pt, 1)
+
+	switch {
+	case err != nil:
+		atomic.AddUint64(&s.ptErr, 1)
+	case plpt.Dropped():
+		atomic.AddUint64(&s.ptDrop, 1)
+	default:
+		atomic.AddUint64(&s.fields, uint64(len(plpt.Fields())+len(plpt.Tags())))
+	}
+}
+
+func (s *canaryStats) rates() (errRate, dropRate, avgFields float64) {
+	pt := atomic.LoadUint64(&s.pt)
+	if pt == 0 {
+		return 0, 0, 0
+	}
+
+	ptErr := atomic.LoadUint64(&s.ptErr)
+	ptDrop := atomic.LoadUint64(&s.ptDrop)
+
+	errRate = float64(ptErr) / float64(pt)
+	dropRate = float64(ptDrop) / float64(pt)
+
+	if ok := pt - ptErr - ptDrop; ok > 0 {
+		avgFields = float64(atomic.LoadUint64(&s.fields)) / float64(ok)
+	}
+
+	return errRate, dropRate, avgFields
+}
+
+// Canary is a new version of remote script under canary.
+type Canary struct {
+	// results of sampled points on the script in use and the new version
+	baseline, canary canaryStats
+
+	store  *ScriptStore
+	script *PlScript
+	cfg    *CanaryConfig
+	start  time.Time
+	timer  *time.Timer // decide the canary on MaxWait
+
+	decided int32
+}
+
+func newCanary(store *ScriptStore, s *PlScript, cfg *CanaryConfig) *Canary {
+	c := &Canary{store: store, script: s, cfg: cfg, start: time.Now()}
+	c.timer = time.AfterFunc(cfg.MaxWait, c.expire)
+	return c
+}
+
+// expire decide the canary if no enough points sampled within MaxWait.
+func (c *Canary) expire() {
+	if ok, reason := c.check(); ok {
+		c.store.decideCanary(c, reason == "", reason)
+	}
+}
+
+func (c *Canary) stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// Sample decides whether the point also run on the canary.
+func (c *Canary) Sample() bool {
+	return atomic.LoadInt32(&c.decided) == 0 && rand.Float64() < c.cfg.SampleRate //nolint:gosec
+}
+
+// Observe record result of the sampled point on the script in use.
+func (c *Canary) Observe(plpt ptinput.PlInputPt, err error) {
+	c.baseline.observe(plpt, err)
+}
+
+// Run run the sampled point on the canary, plpt should be a copy of the
+// point run on the script in use, its result not used. The canary has no
+// agg buckets, so agg functions within it are no-op.
+func (c *Canary) Run(plpt ptinput.PlInputPt, opt *Option) {
+	var err error
+	var signal plruntime.Signal
+	start := time.Now()
+
+	budget := getTimeBudget()
+	if budget != nil {
+		signal = &budgetSignal{deadline: start.Add(budget.timeout)}
+	}
+
+	if e := plengine.RunScriptWithRMapIn(c.script.proc, plpt, signal); e != nil {
+		err = e // *errchain.PlError
+	} else if budget != nil && time.Since(start) > budget.timeout {
+		err = ErrScriptTimeout
+	}
+
+	if err == nil && c.script.category == point.Logging {
+		var disable bool
+		var ignore []string
+		if opt != nil {
+			disable = opt.DisableAddStatusField
+			ignore = opt.IgnoreStatus
+		}
+		ProcLoggingStatus(plpt, disable, ignore)
+	}
+
+	c.canary.observe(plpt, err)
+
+	if ok, reason := c.check(); ok {
+		c.store.decideCanary(c, reason == "", reason)
+	}
+}
+
+// check if canary can be decided, and the reason of rejection returned.
+func (c *Canary) check() (bool, string) {
+	if atomic.LoadUint64(&c.canary.pt) < c.cfg.MinPoints {
+		// not enough points sampled within MaxWait, promote it.
+		return time.Since(c.start) >= c.cfg.MaxWait, ""
+	}
+
+	errBase, dropBase, fieldsBase := c.baseline.rates()
+	errNew, dropNew, fieldsNew := c.canary.rates()
+
+	switch {
+	case errNew-errBase > c.cfg.MaxErrorRateDelta:
+		return true, fmt.Sprintf("error rate %.3f, %.3f in use", errNew, errBase)
+	case dropNew-dropBase > c.cfg.MaxDropRateDelta:
+		return true, fmt.Sprintf("drop rate %.3f, %.3f in use", dropNew, dropBase)
+	case fieldsBase > 0 && fieldsNew/fieldsBase < c.cfg.MinFieldRatio:
+		return true, fmt.Sprintf("average fields %.1f, %.1f in use", fieldsNew, fieldsBase)
+	default:
+		return true, ""
+	}
+}
+
+// CanaryInfo is the state of a canary.
+type CanaryInfo struct {
+	Category string    `json:"category"`
+	Name     string    `json:"name"`
+	Hash     string    `json:"hash"`
+	Start    time.Time `json:"start"`
+
+	Points    uint64 `json:"points"`
+	ErrPoints uint64 `json:"error_points"`
+	DropPoint uint64 `json:"drop_points"`
+}
+
+// remoteCanary holds remote scripts under canary of the store.
+type remoteCanary struct {
+	sync.Mutex // serializes remote scripts update and canary decision
+
+	canaries sync.Map // name -> *Canary
+
+	// name -> hash of versions rejected, they are not canaried again
+	rejected map[string]string
+}
+
+// remove drop canary of script name and stop its timer.
+func (rc *remoteCanary) remove(name interface{}) {
+	if v, ok := rc.canaries.LoadAndDelete(name); ok {
+		if c, ok := v.(*Canary); ok {
+			c.stop()
+		}
+	}
+}
+
+// GetCanary returns canary of script name if any.
+func (store *ScriptStore) GetCanary(name string) *Canary {
+	if v, ok := store.remote.canaries.Load(name); ok {
+		if c, ok := v.(*Canary); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// Canaries returns states of all canaries within the store.
+func (store *ScriptStore) Canaries() []*CanaryInfo {
+	var arr []*CanaryInfo
+	store.remote.canaries.Range(func(k, v interface{}) bool {
+		if c, ok := v.(*Canary); ok {
+			arr = append(arr, &CanaryInfo{
+				Category:  store.category.String(),
+				Name:      c.script.name,
+				Hash:      c.script.hash,
+				Start:     c.start,
+				Points:    atomic.LoadUint64(&c.canary.pt),
+				ErrPoints: atomic.LoadUint64(&c.canary.ptErr),
+				DropPoint: atomic.LoadUint64(&c.canary.ptDrop),
+			})
+		}
+		return true
+	})
+	return arr
+}
+
+// UpdateRemoteScripts load scripts pushed by remote. If canary enabled, new
+// versions of scripts in use are put under canary, and the versions in use
+// are kept until these canaries promoted.
+func (store *ScriptStore) UpdateRemoteScripts(scripts map[string]string) map[string]error {
+	cfg := canaryConfig()
+
+	store.remote.Lock()
+	defer store.remote.Unlock()
+
+	if cfg == nil {
+		store.remote.canaries.Range(func(k, _ interface{}) bool {
+			store.remote.remove(k)
+			return true
+		})
+		return store.UpdateScriptsWithNS(RemoteScriptNS, scripts, nil)
+	}
+
+	if store.remote.rejected == nil {
+		store.remote.rejected = map[string]string{}
+	}
+
+	// compiled together, so that they can import each other. No agg
+	// buckets for canaries, or the aggregated metrics will be uploaded twice.
+	compiled, _ := NewScripts(scripts, nil, RemoteScriptNS, store.category)
+
+	active := map[string]string{}
+	for name, content := range scripts {
+		hash := scriptHash(content)
+
+		// the index may point to a script of lower namespace,
+		// compare with the version pushed before if any.
+		cur, inUse := store.IndexGet(name)
+		old, hasOld := store.GetWithNs(name, RemoteScriptNS)
+		if hasOld {
+			cur = old
+		}
+
+		if !inUse || cur.hash == hash {
+			store.remote.remove(name)
+			active[name] = content
+			continue
+		}
+
+		if hasOld {
+			active[name] = old.script // keep the version in use
+		}
+
+		if c := store.GetCanary(name); c != nil && c.script.hash == hash {
+			continue // already under canary
+		}
+
+		if store.remote.rejected[name] == hash {
+			continue // rejected before
+		}
+
+		s, ok := compiled[name]
+		if !ok {
+			l.Warnf("remote script %s(%s) compile failed, version in use kept", name, store.category)
+			store.remote.rejected[name] = hash
+			stats.WriteCanary(store.category, name, CanaryRejected)
+			continue
+		}
+
+		l.Infof("remote script %s(%s) under canary, sample rate: %f", name, store.category, cfg.SampleRate)
+
+		store.remote.remove(name) // canary of older version
+		store.remote.canaries.Store(name, newCanary(store, s, cfg))
+	}
+
+	// canaries of scripts removed by remote
+	store.remote.canaries.Range(func(k, _ interface{}) bool {
+		if _, ok := scripts[k.(string)]; !ok {
+			store.remote.remove(k)
+		}
+		return true
+	})
+
+	return store.UpdateScriptsWithNS(RemoteScriptNS, active, nil)
+}
+
+// decideCanary promote or reject the canary.
+func (store *ScriptStore) decideCanary(c *Canary, promote bool, reason string) {
+	if !atomic.CompareAndSwapInt32(&c.decided, 0, 1) {
+		return
+	}
+
+	store.remote.Lock()
+	defer store.remote.Unlock()
+
+	name := c.script.name
+
+	if cur := store.GetCanary(name); cur != c {
+		return // canary replaced
+	}
+	store.remote.remove(name)
+
+	if !promote {
+		l.Warnf("remote script %s(%s) rejected: %s", name, store.category, reason)
+
+		if store.remote.rejected == nil {
+			store.remote.rejected = map[string]string{}
+		}
+		store.remote.rejected[name] = c.script.hash
+
+		stats.WriteCanary(store.category, name, CanaryRejected)
+		stats.WriteEvent(&stats.ChangeEvent{
+			Name:     name,
+			Category: store.category,
+			NS:       RemoteScriptNS,
+			Script:   c.script.script,
+			Op:       stats.EventOpCanaryReject,
+			Time:     time.Now(),
+			Reason:   reason,
+		})
+		return
+	}
+
+	l.Infof("remote script %s(%s) promoted", name, store.category)
+
+	active := map[string]string{}
+	store.storage.RLock()
+	for k, s := range store.storage.scripts[RemoteScriptNS] {
+		active[k] = s.script
+	}
+	store.storage.RUnlock()
+
+	active[name] = c.script.script
+
+	stats.WriteCanary(store.category, name, CanaryPromoted)
+	stats.WriteEvent(&stats.ChangeEvent{
+		Name:     name,
+		Category: store.category,
+		NS:       RemoteScriptNS,
+		Script:   c.script.script,
+		Op:       stats.EventOpCanaryPromote,
+		Time:     time.Now(),
+	})
+
+	if errs := store.UpdateScriptsWithNS(RemoteScriptNS, active, nil); len(errs) > 0 {
+		l.Warnf("promote remote script %s(%s): %v", name, store.category, errs)
+	}
+}
+
+// QueryCanary returns canary of the script within category if any.
+func QueryCanary(category point.Category, name string) *Canary {
+	return whichStore(category).GetCanary(name)
+}
+
+// CanaryList returns states of canaries within all categories.
+func CanaryList() []*CanaryInfo {
+	var arr []*CanaryInfo
+	for _, store := range _allCategory {
+		arr = append(arr, store.Canaries()...)
+	}
+	return arr
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package script
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestRemoteCanary(t *testing.T) {
+	const (
+		v1     = `add_key(a, 1)`
+		v2Good = "add_key(a, 1)\nadd_key(b, 2)"
+		v2Drop = `drop()`
+		v2Bad  = `add_key(a, 1)` + "\n" + `add_key(b, 1)` + "\n" + `delete(a)` + "\n" + `delete(b)`
+	)
+
+	// run n points on script in use, and copies of these points on canary
+	feed := func(store *ScriptStore, name string, n int) {
+		for i := 0; i < n; i++ {
+			s, ok := store.IndexGet(name)
+			require.True(t, ok)
+
+			c := store.GetCanary(name)
+			if c == nil {
+				return
+			}
+
+			plpt := ptinput.NewPlPoint(point.Logging, "ng", nil, nil, time.Now())
+			err := s.Run(plpt, nil, &Option{DisableAddStatusField: true})
+			c.Observe(plpt, err)
+			c.Run(ptinput.NewPlPoint(point.Logging, "ng", nil, nil, time.Now()), &Option{DisableAddStatusField: true})
+		}
+	}
+
+	cfg := &CanaryConfig{Enable: true, SampleRate: 1, MinPoints: 10, MaxWait: time.Hour}
+
+	t.Run("disabled", func(t *testing.T) {
+		SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+		store.UpdateRemoteScripts(map[string]string{"a.p": v1})
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Drop})
+
+		s, ok := store.IndexGet("a.p")
+		require.True(t, ok)
+		assert.Equal(t, v2Drop, s.script)
+		assert.Nil(t, store.GetCanary("a.p"))
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		SetCanaryConfig(cfg)
+		defer SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+
+		// no script in use, loaded directly
+		store.UpdateRemoteScripts(map[string]string{"a.p": v1})
+		assert.Nil(t, store.GetCanary("a.p"))
+
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Good})
+		require.NotNil(t, store.GetCanary("a.p"))
+		assert.Len(t, store.Canaries(), 1)
+
+		// the version in use kept
+		s, _ := store.IndexGet("a.p")
+		assert.Equal(t, v1, s.script)
+
+		assert.True(t, store.GetCanary("a.p").Sample())
+
+		feed(store, "a.p", 10)
+
+		assert.Nil(t, store.GetCanary("a.p"))
+		s, _ = store.IndexGet("a.p")
+		assert.Equal(t, v2Good, s.script)
+		assert.Equal(t, RemoteScriptNS, s.ns)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		SetCanaryConfig(cfg)
+		defer SetCanaryConfig(nil)
+
+		for _, bad := range []string{v2Drop, v2Bad} {
+			store := NewScriptStore(point.Logging)
+			store.UpdateRemoteScripts(map[string]string{"a.p": v1, "b.p": v1})
+			store.UpdateRemoteScripts(map[string]string{"a.p": bad, "b.p": v1})
+
+			feed(store, "a.p", 10)
+
+			assert.Nil(t, store.GetCanary("a.p"))
+			s, _ := store.IndexGet("a.p")
+			assert.Equal(t, v1, s.script)
+
+			// the rejected version pushed again
+			store.UpdateRemoteScripts(map[string]string{"a.p": bad, "b.p": v2Good})
+			assert.Nil(t, store.GetCanary("a.p"))
+			assert.NotNil(t, store.GetCanary("b.p"))
+
+			s, _ = store.IndexGet("a.p")
+			assert.Equal(t, v1, s.script)
+		}
+	})
+
+	t.Run("compile-failed", func(t *testing.T) {
+		SetCanaryConfig(cfg)
+		defer SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+		store.UpdateRemoteScripts(map[string]string{"a.p": v1})
+		store.UpdateRemoteScripts(map[string]string{"a.p": `add_key(`})
+
+		assert.Nil(t, store.GetCanary("a.p"))
+		s, ok := store.IndexGet("a.p")
+		require.True(t, ok)
+		assert.Equal(t, v1, s.script)
+	})
+
+	t.Run("override-lower-ns", func(t *testing.T) {
+		SetCanaryConfig(cfg)
+		defer SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v1}, nil)
+
+		// script of default namespace in use until remote one promoted
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Good})
+		s, _ := store.IndexGet("a.p")
+		assert.Equal(t, DefaultScriptNS, s.ns)
+
+		feed(store, "a.p", 10)
+
+		s, _ = store.IndexGet("a.p")
+		assert.Equal(t, RemoteScriptNS, s.ns)
+	})
+
+	t.Run("max-wait", func(t *testing.T) {
+		SetCanaryConfig(&CanaryConfig{Enable: true, SampleRate: 1, MinPoints: 10, MaxWait: time.Millisecond})
+		defer SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+		store.UpdateRemoteScripts(map[string]string{"a.p": v1})
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Good})
+
+		// decided by timer, no point sampled
+		require.Eventually(t, func() bool {
+			s, _ := store.IndexGet("a.p")
+			return s.script == v2Good
+		}, time.Second, 5*time.Millisecond)
+		assert.Nil(t, store.GetCanary("a.p"))
+	})
+
+	t.Run("same-as-remote", func(t *testing.T) {
+		SetCanaryConfig(cfg)
+		defer SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v1}, nil)
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Good})
+		require.NotNil(t, store.GetCanary("a.p"))
+
+		feed(store, "a.p", 10)
+		s, _ := store.IndexGet("a.p")
+		require.Equal(t, v2Good, s.script)
+
+		// same as the remote version in use, no canary
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Good})
+		assert.Nil(t, store.GetCanary("a.p"))
+
+		s, _ = store.IndexGet("a.p")
+		assert.Equal(t, RemoteScriptNS, s.ns)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		SetCanaryConfig(cfg)
+		defer SetCanaryConfig(nil)
+
+		store := NewScriptStore(point.Logging)
+		store.UpdateRemoteScripts(map[string]string{"a.p": v1})
+		store.UpdateRemoteScripts(map[string]string{"a.p": v2Good})
+		require.NotNil(t, store.GetCanary("a.p"))
+
+		store.UpdateRemoteScripts(map[string]string{})
+		assert.Nil(t, store.GetCanary("a.p"))
+		_, ok := store.IndexGet("a.p")
+		assert.False(t, ok)
+	})
+}
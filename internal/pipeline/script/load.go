@@ -213,5 +213,5 @@ func ReloadAllGitReposDotPScript2Store(category point.Category, filePath []strin
 
 // ReloadAllRemoteDotPScript2StoreFromMap Deprecated.
 func ReloadAllRemoteDotPScript2StoreFromMap(category point.Category, m map[string]string) {
-	_ = whichStore(category).UpdateScriptsWithNS(RemoteScriptNS, m, nil)
+	_ = whichStore(category).UpdateRemoteScripts(m)
 }
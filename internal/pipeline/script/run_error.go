@@ -0,0 +1,225 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package script
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"github.com/GuanceCloud/platypus/pkg/token"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/stats"
+)
+
+// Fields added to the point if the script failed and annotate error enabled.
+const (
+	FieldPlError     = "pl_error"
+	FieldPlErrorFunc = "pl_error_func"
+	FieldPlErrorPos  = "pl_error_pos"
+)
+
+var _annotateError int32
+
+// SetAnnotateError set whether to keep the point processed by the failed
+// script with error fields added, instead of the original one.
+func SetAnnotateError(enable bool) {
+	if enable {
+		atomic.StoreInt32(&_annotateError, 1)
+	} else {
+		atomic.StoreInt32(&_annotateError, 0)
+	}
+}
+
+func AnnotateError() bool {
+	return atomic.LoadInt32(&_annotateError) == 1
+}
+
+// runError converts the error chain to the structured error, the function
+// is the innermost call of this script containing the error position.
+func (script *PlScript) runError(err *errchain.PlError) *stats.RunError {
+	e := &stats.RunError{Error: err.Err}
+
+	if len(err.PosChain) == 0 {
+		return e
+	}
+
+	pos := err.PosChain[0]
+	e.Pos = fmt.Sprintf("%s:%d:%d", pos.File, pos.Ln, pos.Col)
+
+	if script.proc == nil {
+		return e
+	}
+
+	// error raised within the script referenced, find the call of this script
+	for _, p := range err.PosChain {
+		if p.File == script.proc.Name {
+			if call := callAt(script.proc.Ast, token.Pos(p.Pos)); call != nil {
+				e.Func = call.Name
+			}
+			break
+		}
+	}
+
+	return e
+}
+
+func annotateError(plpt ptinput.PlInputPt, e *stats.RunError) {
+	for k, v := range map[string]string{
+		FieldPlError:     e.Error,
+		FieldPlErrorFunc: e.Func,
+		FieldPlErrorPos:  e.Pos,
+	} {
+		if err := plpt.Set(k, v, ast.String); err != nil {
+			l.Debugf("set %s: %s", k, err)
+		}
+	}
+}
+
+// errorInput returns a sample of the input, the message of logging or the
+// line protocol like text of others. The point is not serialized as a whole,
+// only the keys within the sample size are written.
+func errorInput(plpt ptinput.PlInputPt) string {
+	if v, _, err := plpt.Get(ptinput.Originkey); err == nil {
+		if s, ok := v.(string); ok {
+			return stats.TruncateSample(s)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(plpt.GetPtName())
+
+	tags := plpt.Tags()
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if b.Len() >= stats.MaxErrorSampleLen {
+			break
+		}
+		b.WriteString("," + k + "=" + tags[k])
+	}
+
+	fields := plpt.Fields()
+	keys = keys[:0]
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if b.Len() >= stats.MaxErrorSampleLen {
+			break
+		}
+
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+
+		if v, ok := fields[k].(string); ok {
+			b.WriteString(k + "=" + strconv.Quote(stats.TruncateSample(v)))
+		} else {
+			b.WriteString(k + "=" + fmt.Sprint(fields[k]))
+		}
+	}
+
+	return stats.TruncateSample(b.String())
+}
+
+// callAt returns the innermost call expression containing pos.
+func callAt(stmts ast.Stmts, pos token.Pos) *ast.CallExpr {
+	var found *ast.CallExpr
+
+	var walk func(node *ast.Node)
+	walkBlock := func(block *ast.BlockStmt) {
+		if block != nil {
+			for _, node := range block.Stmts {
+				walk(node)
+			}
+		}
+	}
+
+	walk = func(node *ast.Node) {
+		if node == nil {
+			return
+		}
+
+		switch node.NodeType { //nolint:exhaustive
+		case ast.TypeCallExpr:
+			call := node.CallExpr
+			if call.NamePos.Pos <= pos && pos <= call.RParen.Pos &&
+				(found == nil || call.NamePos.Pos >= found.NamePos.Pos) {
+				found = call
+			}
+			for _, p := range call.Param {
+				walk(p)
+			}
+		case ast.TypeListInitExpr:
+			for _, v := range node.ListInitExpr.List {
+				walk(v)
+			}
+		case ast.TypeMapInitExpr:
+			for _, kv := range node.MapInitExpr.KeyValeList {
+				walk(kv[0])
+				walk(kv[1])
+			}
+		case ast.TypeParenExpr:
+			walk(node.ParenExpr.Param)
+		case ast.TypeAttrExpr:
+			walk(node.AttrExpr.Obj)
+			walk(node.AttrExpr.Attr)
+		case ast.TypeIndexExpr:
+			for _, v := range node.IndexExpr.Index {
+				walk(v)
+			}
+		case ast.TypeInExpr:
+			walk(node.InExpr.LHS)
+			walk(node.InExpr.RHS)
+		case ast.TypeArithmeticExpr:
+			walk(node.ArithmeticExpr.LHS)
+			walk(node.ArithmeticExpr.RHS)
+		case ast.TypeConditionalExpr:
+			walk(node.ConditionalExpr.LHS)
+			walk(node.ConditionalExpr.RHS)
+		case ast.TypeAssignmentExpr:
+			walk(node.AssignmentExpr.LHS)
+			walk(node.AssignmentExpr.RHS)
+		case ast.TypeBlockStmt:
+			walkBlock(node.BlockStmt)
+		case ast.TypeIfelseStmt:
+			for _, elem := range node.IfelseStmt.IfList {
+				walk(elem.Condition)
+				walkBlock(elem.Block)
+			}
+			walkBlock(node.IfelseStmt.Else)
+		case ast.TypeForStmt:
+			walk(node.ForStmt.Init)
+			walk(node.ForStmt.Cond)
+			walk(node.ForStmt.Loop)
+			walkBlock(node.ForStmt.Body)
+		case ast.TypeForInStmt:
+			walk(node.ForInStmt.Varb)
+			walk(node.ForInStmt.Iter)
+			walkBlock(node.ForInStmt.Body)
+		}
+	}
+
+	for _, node := range stmts {
+		walk(node)
+	}
+
+	return found
+}
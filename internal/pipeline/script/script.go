@@ -8,6 +8,7 @@ package script
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
@@ -27,6 +28,10 @@ type Option struct {
 }
 
 type PlScript struct {
+	// points processed and failed by this version of script,
+	// used to check error rate of the version.
+	pt, ptErr uint64
+
 	name     string // script name
 	filePath string
 	script   string // script content
@@ -39,6 +44,9 @@ type PlScript struct {
 	plBuks *plmap.AggBuckets
 
 	updateTS int64
+
+	hash    string // hash of script content
+	version int    // version number within the store
 }
 
 func CategoryList() (map[point.Category]struct{}, map[point.Category]struct{}) {
@@ -86,7 +94,7 @@ func NewScripts(scripts map[string]string, scriptPath map[string]string, ns stri
 		}
 		return nil, retErr
 	}
-	ret, retErr := plengine.ParseScript(scripts, funcs.FuncsMap, funcs.FuncsCheckMap)
+	ret, retErr := plengine.ParseScript(scripts, timedFuncs(), funcs.FuncsCheckMap)
 
 	retScipt := map[string]*PlScript{}
 
@@ -105,6 +113,7 @@ func NewScripts(scripts map[string]string, scriptPath map[string]string, ns stri
 			proc:     ng,
 			updateTS: time.Now().UnixNano(),
 			plBuks:   plbuks,
+			hash:     scriptHash(scripts[name]),
 		}
 	}
 
@@ -132,9 +141,35 @@ func (script *PlScript) Run(plpt ptinput.PlInputPt, signal plruntime.Signal, opt
 
 	plpt.SetAggBuckets(script.plBuks)
 
+	atomic.AddUint64(&script.pt, 1)
+
+	budget := getTimeBudget()
+	if budget != nil {
+		signal = &budgetSignal{signal: signal, deadline: startTime.Add(budget.timeout)}
+	}
+
 	err := plengine.RunScriptWithRMapIn(script.proc, plpt, signal)
+
+	cost := time.Since(startTime)
+	var funcCost map[string]time.Duration
+	if r, ok := plpt.(funcCostRecorder); ok {
+		funcCost = r.FuncCost()
+	}
+	stats.WriteScriptCost(script.category, script.ns, script.name, cost, funcCost)
+
+	if err == nil && budget != nil && cost > budget.timeout {
+		return script.timeout(plpt, budget, cost, funcCost)
+	}
+
 	if err != nil {
-		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 0, 1, int64(time.Since(startTime)), err)
+		atomic.AddUint64(&script.ptErr, 1)
+		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 0, 1, int64(cost), err)
+
+		runErr := script.runError(err)
+		stats.WriteScriptError(script.category, script.ns, script.name, runErr, errorInput(plpt))
+		if AnnotateError() {
+			annotateError(plpt, runErr)
+		}
 		return err
 	}
 
@@ -152,9 +187,9 @@ func (script *PlScript) Run(plpt ptinput.PlInputPt, signal plruntime.Signal, opt
 	}
 
 	if plpt.Dropped() {
-		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 1, 0, int64(time.Since(startTime)), nil)
+		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 1, 0, int64(cost), nil)
 	} else {
-		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 0, 0, int64(time.Since(startTime)), nil)
+		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 0, 0, int64(cost), nil)
 	}
 
 	plpt.KeyTime2Time()
@@ -162,6 +197,32 @@ func (script *PlScript) Run(plpt ptinput.PlInputPt, signal plruntime.Signal, opt
 	return nil
 }
 
+// timeout handles the point on which the script exceeded the time budget,
+// the function spent most of the time recorded as the one failed.
+func (script *PlScript) timeout(plpt ptinput.PlInputPt, budget *timeBudget,
+	cost time.Duration, funcCost map[string]time.Duration,
+) error {
+	err := fmt.Errorf("%w: %s > %s", ErrScriptTimeout, cost, budget.timeout)
+
+	atomic.AddUint64(&script.ptErr, 1)
+	stats.WriteScriptTimeout(script.category, script.ns, script.name)
+
+	runErr := &stats.RunError{Func: slowestFunc(funcCost), Error: err.Error()}
+	stats.WriteScriptError(script.category, script.ns, script.name, runErr, errorInput(plpt))
+
+	if budget.drop {
+		plpt.MarkDrop(true)
+		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 1, 1, int64(cost), err)
+		return err
+	}
+
+	stats.WriteScriptStats(script.category, script.ns, script.name, 1, 0, 1, int64(cost), err)
+	if AnnotateError() {
+		annotateError(plpt, runErr)
+	}
+	return err
+}
+
 func (script *PlScript) Name() string {
 	return script.name
 }
@@ -177,3 +238,14 @@ func (script *PlScript) Category() point.Category {
 func (script *PlScript) NS() string {
 	return script.ns
 }
+
+// Hash returns hash of the script content.
+func (script *PlScript) Hash() string {
+	return script.hash
+}
+
+// Version returns version number of the script within the store, 0 if the
+// script not loaded into any store.
+func (script *PlScript) Version() int {
+	return script.version
+}
@@ -6,13 +6,20 @@
 package script
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/GuanceCloud/cliutils/point"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"github.com/GuanceCloud/platypus/pkg/parser"
+	"github.com/GuanceCloud/platypus/pkg/token"
 	"github.com/stretchr/testify/assert"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/stats"
 )
 
 func TestScript(t *testing.T) {
@@ -134,3 +141,202 @@ func TestDrop(t *testing.T) {
 		t.Error("drop != true")
 	}
 }
+
+func TestRunError(t *testing.T) {
+	defer SetAnnotateError(false)
+
+	ret, retErr := NewScripts(map[string]string{"err.p": `grok(_, "%{WORD:user} %{WORD:action}")
+arr = [1]
+if action == "login" {
+	add_key(uid, arr[2])
+}
+x = arr[3]
+`}, nil, DefaultScriptNS, point.Logging)
+	if len(retErr) > 0 {
+		t.Fatal(retErr)
+	}
+	s := ret["err.p"]
+
+	cases := []struct {
+		input, fn, pos string
+	}{
+		{input: "u1 login", fn: "add_key", pos: "err.p:4:19"},
+		{input: "u1 logout", fn: "", pos: "err.p:6:9"},
+	}
+
+	for _, annotate := range []bool{false, true} {
+		SetAnnotateError(annotate)
+
+		for _, tc := range cases {
+			plpt := ptinput.NewPlPoint(point.Logging, "ng", nil, map[string]any{"message": tc.input}, time.Now())
+			err := s.Run(plpt, nil, nil)
+			if err == nil {
+				t.Fatal("expect error")
+			}
+
+			plErr, ok := err.(*errchain.PlError)
+			if !ok {
+				t.Fatal(err)
+			}
+
+			e := s.runError(plErr)
+			assert.Equal(t, tc.fn, e.Func)
+			assert.Equal(t, tc.pos, e.Pos)
+			assert.Equal(t, "list index out of range", e.Error)
+
+			fields := plpt.Fields()
+			if annotate {
+				assert.Equal(t, tc.fn, fields[FieldPlErrorFunc])
+				assert.Equal(t, tc.pos, fields[FieldPlErrorPos])
+				assert.Equal(t, e.Error, fields[FieldPlError])
+				assert.Equal(t, "u1", fields["user"])
+			} else {
+				assert.NotContains(t, fields, FieldPlError)
+			}
+		}
+	}
+
+	st, ok := stats.ReadScriptStats(point.Logging, DefaultScriptNS, "err.p")
+	if !ok {
+		t.Fatal("stats not found")
+	}
+	assert.Equal(t, map[string]uint64{"add_key": 2, "": 2}, st.FuncErrors)
+	assert.Len(t, st.ErrorSamples, 4)
+	assert.Equal(t, "u1 logout", st.ErrorSamples[0].Input)
+}
+
+func TestErrorInput(t *testing.T) {
+	plpt := ptinput.NewPlPoint(point.Metric, "cpu", map[string]string{"host": "h1", "cpu": "0"},
+		map[string]any{"usage": 1.5, "name": "x"}, time.Now())
+	assert.Equal(t, `cpu,cpu=0,host=h1 name="x",usage=1.5`, errorInput(plpt))
+
+	// only keys within the sample size written
+	fields := map[string]any{}
+	for i := 0; i < 1000; i++ {
+		fields[fmt.Sprintf("f%04d", i)] = strings.Repeat("中", 100)
+	}
+	plpt = ptinput.NewPlPoint(point.Metric, "cpu", nil, fields, time.Now())
+	input := errorInput(plpt)
+	assert.LessOrEqual(t, len(input), stats.MaxErrorSampleLen)
+	assert.True(t, utf8.ValidString(input))
+	assert.True(t, strings.HasPrefix(input, `cpu f0000="中中`))
+
+	plpt = ptinput.NewPlPoint(point.Logging, "ng", nil, map[string]any{"message": strings.Repeat("中", 1000)}, time.Now())
+	input = errorInput(plpt)
+	assert.True(t, utf8.ValidString(input))
+	assert.Equal(t, stats.MaxErrorSampleLen/3*3, len(input))
+}
+
+func TestCallAt(t *testing.T) {
+	content := `a = f1(x, f2(y, [f3(z)]))
+if f4(a) {
+	for v in f5() {
+		b = {"k": f6(v)}
+	}
+}
+c = d
+`
+	stmts, err := parser.ParsePipeline("t.p", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"f1(":   "f1",
+		"x,":    "f1",
+		"y,":    "f2",
+		"z)":    "f3",
+		"a) {":  "f4",
+		"f5()":  "f5",
+		"v)}":   "f6",
+		"c = d": "",
+	}
+
+	for sub, fn := range cases {
+		pos := strings.Index(content, sub)
+		call := callAt(stmts, token.Pos(pos))
+		if fn == "" {
+			assert.Nil(t, call, sub)
+		} else if assert.NotNil(t, call, sub) {
+			assert.Equal(t, fn, call.Name, sub)
+		}
+	}
+}
+
+func TestTimeBudget(t *testing.T) {
+	defer func() {
+		_ = SetTimeBudget(0, "")
+	}()
+
+	ret, retErr := NewScripts(map[string]string{"loop.p": `add_key(a, 1)
+for i = 0; i < 100000000; i = i + 1 {
+	add_key(b, i)
+}
+`}, nil, DefaultScriptNS, point.Logging)
+	if len(retErr) > 0 {
+		t.Fatal(retErr)
+	}
+	s := ret["loop.p"]
+
+	assert.Error(t, SetTimeBudget(time.Millisecond, "abort"))
+	assert.Nil(t, getTimeBudget())
+
+	for _, action := range []string{TimeoutActionSkip, TimeoutActionDrop} {
+		if err := SetTimeBudget(10*time.Millisecond, action); err != nil {
+			t.Fatal(err)
+		}
+
+		plpt := ptinput.NewPlPoint(point.Logging, "ng", nil, map[string]any{"message": "x"}, time.Now())
+		start := time.Now()
+		err := s.Run(plpt, nil, nil)
+		assert.ErrorIs(t, err, ErrScriptTimeout)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, action == TimeoutActionDrop, plpt.Dropped())
+
+		assert.Contains(t, plpt.(funcCostRecorder).FuncCost(), "add_key")
+	}
+
+	st, ok := stats.ReadScriptStats(point.Logging, DefaultScriptNS, "loop.p")
+	if !ok {
+		t.Fatal("stats not found")
+	}
+	assert.Equal(t, uint64(2), st.PtTimeout)
+	assert.Equal(t, uint64(2), st.FuncErrors["add_key"])
+	assert.Contains(t, st.FuncCost, "add_key")
+	assert.GreaterOrEqual(t, st.MaxCost, int64(10*time.Millisecond))
+	assert.GreaterOrEqual(t, st.TotalCost, st.MaxCost)
+}
+
+func TestFuncCost(t *testing.T) {
+	defer SetFuncCost(false)
+
+	ret, retErr := NewScripts(map[string]string{"cost.p": `add_key(a, 1)`}, nil, DefaultScriptNS, point.Logging)
+	if len(retErr) > 0 {
+		t.Fatal(retErr)
+	}
+	s := ret["cost.p"]
+
+	// not recorded by default
+	plpt := ptinput.NewPlPoint(point.Logging, "ng", nil, map[string]any{"message": "x"}, time.Now())
+	assert.NoError(t, s.Run(plpt, nil, nil))
+	assert.Nil(t, plpt.(funcCostRecorder).FuncCost())
+
+	SetFuncCost(true)
+	plpt = ptinput.NewPlPoint(point.Logging, "ng", nil, map[string]any{"message": "x"}, time.Now())
+	assert.NoError(t, s.Run(plpt, nil, nil))
+	assert.Contains(t, plpt.(funcCostRecorder).FuncCost(), "add_key")
+
+	st, ok := stats.ReadScriptStats(point.Logging, DefaultScriptNS, "cost.p")
+	if !ok {
+		t.Fatal("stats not found")
+	}
+	assert.Contains(t, st.FuncCost, "add_key")
+}
+
+func TestSlowestFunc(t *testing.T) {
+	assert.Equal(t, "", slowestFunc(nil))
+	assert.Equal(t, "grok", slowestFunc(map[string]time.Duration{
+		"grok":    3 * time.Millisecond,
+		"add_key": time.Millisecond,
+	}))
+}
@@ -129,11 +129,16 @@ type ScriptStore struct {
 
 	index     map[string]*PlScript
 	indexLock sync.RWMutex
+
+	remote remoteCanary
 }
 
 type scriptStorage struct {
 	sync.RWMutex
 	scripts map[string](map[string]*PlScript)
+
+	// recent versions of scripts: ns -> name -> versions
+	history map[string](map[string]*scriptHistory)
 }
 
 func NewScriptStore(category point.Category) *ScriptStore {
@@ -306,8 +311,22 @@ func (store *ScriptStore) UpdateScriptsWithNS(ns string, namedScript map[string]
 		store.storage.scripts[ns] = map[string]*PlScript{}
 	}
 
-	retScripts, retErr := NewScripts(namedScript, scriptPath, ns, store.category,
-		plmap.NewAggBuks(_uploadFn))
+	retScripts, retErr := NewScripts(namedScript, scriptPath, ns, store.category)
+
+	for name, newScript := range retScripts {
+		if curScript, ok := store.storage.scripts[ns][name]; ok && store.pinned(ns, newScript) {
+			// the version rolled back from loaded again, keep the current one
+			l.Infof("script %s(%s/%s) is the version rolled back from, ignored", name, store.category, ns)
+			retScripts[name] = curScript
+			continue
+		}
+
+		// each script owns its agg buckets, so that stopping the buckets of
+		// a replaced script does not affect the others kept
+		newScript.SetAggBuks(plmap.NewAggBuks(_uploadFn))
+
+		store.addVersion(ns, newScript)
+	}
 
 	for name, err := range retErr {
 		var errStr string
@@ -340,9 +359,13 @@ func (store *ScriptStore) UpdateScriptsWithNS(ns string, namedScript map[string]
 	// 在 storage & index 执行删除以及更新操作
 	for name, curScript := range store.storage.scripts[ns] {
 		if newScript, ok := retScripts[name]; ok {
+			if newScript != curScript && curScript.plBuks != nil {
+				curScript.plBuks.StopAllBukScanner()
+			}
 			store.storage.scripts[ns][name] = newScript
 			stats.UpdateScriptStatsMeta(store.category, ns, name, newScript.script, false, false, "")
 			store.indexUpdate(newScript)
+			continue
 		}
 		needDelete[name] = curScript.script
 	}
@@ -272,6 +272,60 @@ func TestPlScriptStore(t *testing.T) {
 	}
 }
 
+func TestUpdateScriptsStopAggBuks(t *testing.T) {
+	store := NewScriptStore(point.Logging)
+
+	store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{
+		"a.p": "add_key(v, 1)",
+		"b.p": "add_key(v, 1)",
+	}, nil)
+
+	a1 := store.storage.scripts[DefaultScriptNS]["a.p"]
+	b1 := store.storage.scripts[DefaultScriptNS]["b.p"]
+	assert.NotSame(t, a1.plBuks, b1.plBuks)
+
+	a1.plBuks.CreateBucket("a", time.Minute, 0, false, nil)
+	b1.plBuks.CreateBucket("b", time.Minute, 0, false, nil)
+
+	// both replaced, even if b.p not changed
+	store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{
+		"a.p": "add_key(v, 2)",
+		"b.p": "add_key(v, 1)",
+	}, nil)
+
+	_, ok := a1.plBuks.GetBucket("a")
+	assert.False(t, ok)
+
+	_, ok = b1.plBuks.GetBucket("b")
+	assert.False(t, ok)
+
+	// rollback to the previous version
+	a2 := store.storage.scripts[DefaultScriptNS]["a.p"]
+	a2.plBuks.CreateBucket("a", time.Minute, 0, false, nil)
+	_, err := store.Rollback(DefaultScriptNS, "a.p", 0, "test")
+	assert.NoError(t, err)
+
+	_, ok = a2.plBuks.GetBucket("a")
+	assert.False(t, ok)
+
+	// the version rolled back from is ignored, the current one kept
+	a3 := store.storage.scripts[DefaultScriptNS]["a.p"]
+	a3.plBuks.CreateBucket("a", time.Minute, 0, false, nil)
+	store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{
+		"a.p": "add_key(v, 2)",
+		"b.p": "add_key(v, 1)",
+	}, nil)
+
+	assert.Same(t, a3, store.storage.scripts[DefaultScriptNS]["a.p"])
+	_, ok = a3.plBuks.GetBucket("a")
+	assert.True(t, ok)
+
+	// deleted
+	store.UpdateScriptsWithNS(DefaultScriptNS, nil, nil)
+	_, ok = a3.plBuks.GetBucket("a")
+	assert.False(t, ok)
+}
+
 func TestWhichStore(t *testing.T) {
 	r := whichStore(point.Metric)
 	if r == nil {
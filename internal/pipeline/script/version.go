@@ -0,0 +1,384 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package script
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/plmap"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/stats"
+)
+
+const (
+	DefaultMaxVersions       = 5
+	DefaultRollbackErrorRate = 0.5
+	DefaultRollbackMinPoints = 100
+
+	defaultRollbackCheckInterval = 10 * time.Second
+
+	RollbackReasonManual    = "manual"
+	RollbackReasonErrorRate = "error_rate"
+)
+
+var (
+	_maxVersions int64 = DefaultMaxVersions
+
+	_startAutoRollback int32
+)
+
+// SetMaxVersions set how many compiled versions kept for each script.
+func SetMaxVersions(n int) {
+	if n <= 0 {
+		n = DefaultMaxVersions
+	}
+	atomic.StoreInt64(&_maxVersions, int64(n))
+}
+
+func maxVersions() int {
+	return int(atomic.LoadInt64(&_maxVersions))
+}
+
+func scriptHash(content string) string {
+	h := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(h[:])
+}
+
+// ScriptVersion is a compiled version of script within the store.
+type ScriptVersion struct {
+	Version  int       `json:"version"`
+	Hash     string    `json:"hash"`
+	Script   string    `json:"script"`
+	UpdateAt time.Time `json:"update_at"`
+
+	// Current is the version in use.
+	Current bool `json:"current"`
+
+	// Points processed and failed by this version.
+	Points    uint64 `json:"points"`
+	ErrPoints uint64 `json:"error_points"`
+}
+
+// ScriptVersions is all versions of a script within a namespace.
+type ScriptVersions struct {
+	Category string           `json:"category"`
+	NS       string           `json:"ns"`
+	Name     string           `json:"name"`
+	Versions []*ScriptVersion `json:"versions"`
+}
+
+// scriptHistory is the recent compiled versions of a script.
+type scriptHistory struct {
+	versions []*PlScript // oldest first
+	seq      int         // latest version number
+
+	// hash of the version rolled back from, the same content
+	// will not be loaded again unless the rollback pinned off.
+	pinnedHash string
+}
+
+func (h *scriptHistory) find(version int) (int, *PlScript) {
+	for i, s := range h.versions {
+		if s.version == version {
+			return i, s
+		}
+	}
+	return -1, nil
+}
+
+// addVersion record the new compiled script as a version, the storage lock
+// should be held by caller.
+func (store *ScriptStore) addVersion(ns string, s *PlScript) {
+	if store.storage.history == nil {
+		store.storage.history = map[string]map[string]*scriptHistory{}
+	}
+
+	if store.storage.history[ns] == nil {
+		store.storage.history[ns] = map[string]*scriptHistory{}
+	}
+
+	h, ok := store.storage.history[ns][s.name]
+	if !ok {
+		h = &scriptHistory{}
+		store.storage.history[ns][s.name] = h
+	}
+
+	// reloaded with the same content, it's still the latest version.
+	if n := len(h.versions); n > 0 && h.versions[n-1].hash == s.hash {
+		s.version = h.versions[n-1].version
+		h.versions[n-1] = s
+		return
+	}
+
+	// content changed, the pinned version can be loaded again later.
+	h.pinnedHash = ""
+
+	h.seq++
+	s.version = h.seq
+	h.versions = append(h.versions, s)
+
+	if max := maxVersions(); len(h.versions) > max {
+		h.versions = h.versions[len(h.versions)-max:]
+	}
+}
+
+// pinned check if script is the version rolled back from, the storage lock
+// should be held by caller.
+func (store *ScriptStore) pinned(ns string, s *PlScript) bool {
+	if h, ok := store.storage.history[ns][s.name]; ok {
+		return h.pinnedHash != "" && h.pinnedHash == s.hash
+	}
+	return false
+}
+
+// Versions returns versions of script name, versions within all namespaces
+// returned if ns empty.
+func (store *ScriptStore) Versions(ns, name string) []*ScriptVersions {
+	store.storage.RLock()
+	defer store.storage.RUnlock()
+
+	var ret []*ScriptVersions
+
+	for _, x := range plScriptNSSearchOrder {
+		if ns != "" && ns != x {
+			continue
+		}
+
+		h, ok := store.storage.history[x][name]
+		if !ok {
+			continue
+		}
+
+		cur := store.storage.scripts[x][name]
+
+		sv := &ScriptVersions{Category: store.category.String(), NS: x, Name: name}
+		for _, s := range h.versions {
+			sv.Versions = append(sv.Versions, &ScriptVersion{
+				Version:   s.version,
+				Hash:      s.hash,
+				Script:    s.script,
+				UpdateAt:  time.Unix(0, s.updateTS),
+				Current:   s == cur,
+				Points:    atomic.LoadUint64(&s.pt),
+				ErrPoints: atomic.LoadUint64(&s.ptErr),
+			})
+		}
+
+		ret = append(ret, sv)
+	}
+
+	return ret
+}
+
+// Rollback atomically replace the script name within ns with one of its
+// previous version, version 0 means the version just before the current
+// one. The version rolled back from will not be loaded again until its
+// content changed.
+func (store *ScriptStore) Rollback(ns, name string, version int, reason string) (*PlScript, error) {
+	store.storage.Lock()
+	defer store.storage.Unlock()
+
+	return store.rollback(ns, name, version, reason)
+}
+
+func (store *ScriptStore) rollback(ns, name string, version int, reason string) (*PlScript, error) {
+	h, ok := store.storage.history[ns][name]
+	if !ok {
+		return nil, fmt.Errorf("script %s not found in namespace %s", name, ns)
+	}
+
+	cur := store.storage.scripts[ns][name]
+
+	if version == 0 {
+		idx := len(h.versions) // script deleted, rollback to the latest one
+		if cur != nil {
+			idx, _ = h.find(cur.version)
+		}
+
+		if idx <= 0 {
+			return nil, fmt.Errorf("no previous version of script %s", name)
+		}
+
+		version = h.versions[idx-1].version
+	}
+
+	idx, target := h.find(version)
+	if target == nil {
+		return nil, fmt.Errorf("version %d of script %s not found", version, name)
+	}
+
+	if target == cur {
+		return nil, fmt.Errorf("version %d of script %s already in use", version, name)
+	}
+
+	// the old version may have been stopped, so new agg buckets used.
+	restored := &PlScript{
+		name:     target.name,
+		filePath: target.filePath,
+		script:   target.script,
+		ns:       target.ns,
+		category: target.category,
+		proc:     target.proc,
+		plBuks:   plmap.NewAggBuks(_uploadFn),
+		updateTS: time.Now().UnixNano(),
+		hash:     target.hash,
+		version:  target.version,
+	}
+	h.versions[idx] = restored
+
+	var scriptOld string
+	if cur != nil {
+		h.pinnedHash = cur.hash
+		scriptOld = cur.script
+		if cur.plBuks != nil {
+			cur.plBuks.StopAllBukScanner()
+		}
+	}
+
+	store.storage.scripts[ns][name] = restored
+
+	stats.UpdateScriptStatsMeta(store.category, ns, name, restored.script, false, false, "")
+	store.indexUpdate(restored)
+
+	stats.WriteRollback(store.category, ns, name, reason)
+	stats.WriteEvent(&stats.ChangeEvent{
+		Name:      name,
+		Category:  store.category,
+		NS:        ns,
+		Script:    restored.script,
+		ScriptOld: scriptOld,
+		Op:        stats.EventOpRollback,
+		Reason:    reason,
+		Time:      time.Now(),
+	})
+
+	l.Warnf("script %s(%s/%s) rolled back to version %d, reason: %s",
+		name, store.category, ns, restored.version, reason)
+
+	return restored, nil
+}
+
+// RollbackPolicy decides when the current version of script rolled back to
+// its previous version: at least MinPoints processed and error rate not
+// less than ErrorRate, and the error rate higher than the previous version.
+type RollbackPolicy struct {
+	ErrorRate float64
+	MinPoints uint64
+}
+
+func errorRate(s *PlScript) (float64, uint64) {
+	pt := atomic.LoadUint64(&s.pt)
+	if pt == 0 {
+		return 0, 0
+	}
+
+	return float64(atomic.LoadUint64(&s.ptErr)) / float64(pt), pt
+}
+
+// CheckRollback rollback scripts that their error rate spiked, names of
+// these scripts returned.
+func (store *ScriptStore) CheckRollback(p *RollbackPolicy) []string {
+	store.storage.Lock()
+	defer store.storage.Unlock()
+
+	var rolled []string
+
+	for ns, scripts := range store.storage.scripts {
+		for name, cur := range scripts {
+			h, ok := store.storage.history[ns][name]
+			if !ok {
+				continue
+			}
+
+			idx, _ := h.find(cur.version)
+			if idx <= 0 {
+				continue
+			}
+
+			rate, pt := errorRate(cur)
+			if pt < p.MinPoints || rate < p.ErrorRate {
+				continue
+			}
+
+			if prevRate, _ := errorRate(h.versions[idx-1]); rate <= prevRate {
+				continue
+			}
+
+			if _, err := store.rollback(ns, name, 0, RollbackReasonErrorRate); err != nil {
+				l.Warnf("rollback %s(%s/%s): %s", name, store.category, ns, err.Error())
+				continue
+			}
+
+			rolled = append(rolled, name)
+		}
+	}
+
+	return rolled
+}
+
+// ScriptVersionsList returns versions of the script within category.
+func ScriptVersionsList(category point.Category, ns, name string) []*ScriptVersions {
+	return whichStore(category).Versions(ns, name)
+}
+
+// RollbackScript rollback the script within category, if ns empty, the
+// namespace of the script in use selected.
+func RollbackScript(category point.Category, ns, name string, version int) (*PlScript, error) {
+	store := whichStore(category)
+
+	if ns == "" {
+		s, ok := store.IndexGet(name)
+		if !ok {
+			return nil, fmt.Errorf("script %s not found", name)
+		}
+		ns = s.ns
+	}
+
+	return store.Rollback(ns, name, version, RollbackReasonManual)
+}
+
+// StartAutoRollback check scripts of all categories periodically, and
+// rollback scripts that their error rate spiked.
+func StartAutoRollback(p *RollbackPolicy) {
+	if !atomic.CompareAndSwapInt32(&_startAutoRollback, 0, 1) {
+		return
+	}
+
+	if p.ErrorRate <= 0 || p.ErrorRate > 1 {
+		p.ErrorRate = DefaultRollbackErrorRate
+	}
+
+	if p.MinPoints == 0 {
+		p.MinPoints = DefaultRollbackMinPoints
+	}
+
+	l.Infof("pipeline auto rollback enabled, error rate: %f, min points: %d", p.ErrorRate, p.MinPoints)
+
+	g := datakit.G("pipeline_rollback")
+	g.Go(func(ctx context.Context) error {
+		tick := time.NewTicker(defaultRollbackCheckInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				for _, store := range _allCategory {
+					store.CheckRollback(p)
+				}
+
+			case <-datakit.Exit.Wait():
+				l.Info("pipeline auto rollback exits")
+				return nil
+			}
+		}
+	})
+}
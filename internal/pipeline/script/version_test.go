@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package script
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline/ptinput"
+)
+
+func TestScriptVersions(t *testing.T) {
+	const (
+		v1 = `add_key(v, 1)`
+		v2 = `add_key(v, 2)`
+		v3 = `add_key(v, 3)`
+	)
+
+	current := func(store *ScriptStore, ns, name string) *ScriptVersion {
+		for _, sv := range store.Versions(ns, name) {
+			for _, v := range sv.Versions {
+				if v.Current {
+					return v
+				}
+			}
+		}
+		return nil
+	}
+
+	t.Run("versions", func(t *testing.T) {
+		store := NewScriptStore(point.Logging)
+
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v1}, nil)
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v1}, nil) // same content
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v2}, nil)
+
+		arr := store.Versions("", "a.p")
+		require.Len(t, arr, 1)
+		assert.Equal(t, DefaultScriptNS, arr[0].NS)
+		require.Len(t, arr[0].Versions, 2)
+
+		assert.Equal(t, 1, arr[0].Versions[0].Version)
+		assert.Equal(t, scriptHash(v1), arr[0].Versions[0].Hash)
+		assert.False(t, arr[0].Versions[0].Current)
+
+		assert.Equal(t, 2, arr[0].Versions[1].Version)
+		assert.Equal(t, v2, arr[0].Versions[1].Script)
+		assert.True(t, arr[0].Versions[1].Current)
+
+		s, ok := store.IndexGet("a.p")
+		require.True(t, ok)
+		assert.Equal(t, 2, s.Version())
+
+		// points counted on version
+		require.NoError(t, s.Run(ptinput.NewPlPoint(point.Logging, "ng", nil, nil, time.Now()), nil, nil))
+		assert.Equal(t, uint64(1), current(store, DefaultScriptNS, "a.p").Points)
+
+		assert.Empty(t, store.Versions(ConfdScriptNS, "a.p"))
+	})
+
+	t.Run("max-versions", func(t *testing.T) {
+		SetMaxVersions(2)
+		defer SetMaxVersions(0)
+
+		store := NewScriptStore(point.Logging)
+		for _, x := range []string{v1, v2, v3} {
+			store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": x}, nil)
+		}
+
+		arr := store.Versions(DefaultScriptNS, "a.p")
+		require.Len(t, arr, 1)
+		require.Len(t, arr[0].Versions, 2)
+		assert.Equal(t, 2, arr[0].Versions[0].Version)
+		assert.Equal(t, 3, arr[0].Versions[1].Version)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		store := NewScriptStore(point.Logging)
+		for _, x := range []string{v1, v2, v3} {
+			store.UpdateScriptsWithNS(RemoteScriptNS, map[string]string{"a.p": x}, nil)
+		}
+
+		s, err := store.Rollback(RemoteScriptNS, "a.p", 0, RollbackReasonManual)
+		require.NoError(t, err)
+		assert.Equal(t, 2, s.Version())
+
+		idx, ok := store.IndexGet("a.p")
+		require.True(t, ok)
+		assert.Equal(t, s, idx)
+
+		s, err = store.Rollback(RemoteScriptNS, "a.p", 1, RollbackReasonManual)
+		require.NoError(t, err)
+		assert.Equal(t, v1, s.script)
+
+		_, err = store.Rollback(RemoteScriptNS, "a.p", 1, RollbackReasonManual)
+		assert.Error(t, err, "already in use")
+
+		_, err = store.Rollback(RemoteScriptNS, "a.p", 0, RollbackReasonManual)
+		assert.Error(t, err, "no previous version")
+
+		_, err = store.Rollback(RemoteScriptNS, "a.p", 10, RollbackReasonManual)
+		assert.Error(t, err, "no such version")
+
+		_, err = store.Rollback(ConfdScriptNS, "a.p", 0, RollbackReasonManual)
+		assert.Error(t, err, "no such script")
+
+		// the version rolled back from(v2) pushed again, ignored
+		store.UpdateScriptsWithNS(RemoteScriptNS, map[string]string{"a.p": v2}, nil)
+		assert.Equal(t, 1, current(store, RemoteScriptNS, "a.p").Version)
+
+		// new content loaded
+		store.UpdateScriptsWithNS(RemoteScriptNS, map[string]string{"a.p": v3 + "\n"}, nil)
+		assert.Equal(t, 4, current(store, RemoteScriptNS, "a.p").Version)
+	})
+
+	t.Run("rollback-deleted", func(t *testing.T) {
+		store := NewScriptStore(point.Logging)
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v1}, nil)
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": `add_key(`}, nil) // compile failed
+
+		_, ok := store.IndexGet("a.p")
+		assert.False(t, ok)
+
+		s, err := store.Rollback(DefaultScriptNS, "a.p", 0, RollbackReasonManual)
+		require.NoError(t, err)
+		assert.Equal(t, 1, s.Version())
+
+		_, ok = store.IndexGet("a.p")
+		assert.True(t, ok)
+	})
+
+	t.Run("auto-rollback", func(t *testing.T) {
+		store := NewScriptStore(point.Logging)
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v1, "b.p": v1}, nil)
+
+		s1, _ := store.GetWithNs("a.p", DefaultScriptNS)
+		atomic.StoreUint64(&s1.pt, 100)
+		atomic.StoreUint64(&s1.ptErr, 1)
+
+		store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"a.p": v2, "b.p": v2}, nil)
+
+		p := &RollbackPolicy{ErrorRate: 0.5, MinPoints: 10}
+
+		a, _ := store.GetWithNs("a.p", DefaultScriptNS)
+		b, _ := store.GetWithNs("b.p", DefaultScriptNS)
+
+		// not enough points
+		atomic.StoreUint64(&a.pt, 5)
+		atomic.StoreUint64(&a.ptErr, 5)
+		assert.Empty(t, store.CheckRollback(p))
+
+		// error rate spiked on a.p, b.p is ok
+		atomic.StoreUint64(&a.pt, 20)
+		atomic.StoreUint64(&a.ptErr, 15)
+		atomic.StoreUint64(&b.pt, 20)
+		atomic.StoreUint64(&b.ptErr, 1)
+		assert.Equal(t, []string{"a.p"}, store.CheckRollback(p))
+
+		assert.Equal(t, 1, current(store, DefaultScriptNS, "a.p").Version)
+		assert.Equal(t, 2, current(store, DefaultScriptNS, "b.p").Version)
+
+		// no previous version
+		a, _ = store.GetWithNs("a.p", DefaultScriptNS)
+		atomic.StoreUint64(&a.pt, 20)
+		atomic.StoreUint64(&a.ptErr, 20)
+		assert.Empty(t, store.CheckRollback(p))
+	})
+}
@@ -6,6 +6,8 @@
 package stats
 
 import (
+	"time"
+
 	"github.com/GuanceCloud/cliutils/metrics"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -13,17 +15,69 @@ import (
 var (
 	plPtsVec,
 	plErrPtsVec,
-	plDropVec *prometheus.CounterVec
+	plFuncErrVec,
+	plTimeoutVec,
+	plDropVec,
+	plRollbackVec,
+	plCanaryVec *prometheus.CounterVec
 	plUpdateVec *prometheus.GaugeVec
 	plCostVec   *prometheus.SummaryVec
+
+	plFuncCost = &funcCostCollector{
+		desc: prometheus.NewDesc("datakit_pipeline_function_cost_seconds_total",
+			"Pipeline total running time by function",
+			[]string{"category", "name", "namespace", "function"}, nil),
+		stats: &_plstats,
+	}
 )
 
+// funcCostCollector exports the time spent by functions aggregated within
+// the script stats, instead of updating the metric on each point.
+type funcCostCollector struct {
+	desc  *prometheus.Desc
+	stats *Stats
+}
+
+func (c *funcCostCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *funcCostCollector) Collect(ch chan<- prometheus.Metric) {
+	c.stats.stats.Range(func(key, value interface{}) bool {
+		s, ok := value.(*ScriptStats)
+		if !ok || s == nil {
+			return true
+		}
+
+		_, _, funcCost := s.cost.read()
+		if len(funcCost) == 0 {
+			return true
+		}
+
+		s.meta.RLock()
+		category, name, ns := s.meta.category.String(), s.meta.name, s.meta.ns
+		s.meta.RUnlock()
+
+		for fn, cost := range funcCost {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue,
+				float64(cost)/float64(time.Second), category, name, ns, fn)
+		}
+		return true
+	})
+}
+
 func Metrics() []prometheus.Collector {
 	return []prometheus.Collector{
 		plPtsVec,
+		plErrPtsVec,
+		plFuncErrVec,
+		plFuncCost,
+		plTimeoutVec,
 		plDropVec,
 		plUpdateVec,
 		plCostVec,
+		plRollbackVec,
+		plCanaryVec,
 	}
 }
 
@@ -71,6 +125,35 @@ func init() {
 		},
 	)
 
+	plFuncErrVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "pipeline",
+			Name:      "function_error_total",
+			Help:      "Pipeline run errors by function",
+		},
+		[]string{
+			"category",
+			"name",
+			"namespace",
+			"function",
+		},
+	)
+
+	plTimeoutVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "pipeline",
+			Name:      "timeout_point_total",
+			Help:      "Pipeline total points exceeded the time budget",
+		},
+		[]string{
+			"category",
+			"name",
+			"namespace",
+		},
+	)
+
 	plCostVec = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace: "datakit",
@@ -99,5 +182,34 @@ func init() {
 		},
 	)
 
+	plRollbackVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "pipeline",
+			Name:      "rollback_total",
+			Help:      "Pipeline script rollback count",
+		},
+		[]string{
+			"category",
+			"name",
+			"namespace",
+			"reason",
+		},
+	)
+
+	plCanaryVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "datakit",
+			Subsystem: "pipeline",
+			Name:      "canary_total",
+			Help:      "Remote pipeline script canary result count",
+		},
+		[]string{
+			"category",
+			"name",
+			"result",
+		},
+	)
+
 	metrics.MustRegister(Metrics()...)
 }
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type scriptCost struct {
+	maxCost   int64
+	ptTimeout uint64
+
+	// function name: total time spent, ns
+	funcCost map[string]int64
+	sync.Mutex
+}
+
+func (c *scriptCost) write(cost time.Duration, funcCost map[string]time.Duration) {
+	for {
+		max := atomic.LoadInt64(&c.maxCost)
+		if int64(cost) <= max || atomic.CompareAndSwapInt64(&c.maxCost, max, int64(cost)) {
+			break
+		}
+	}
+
+	if len(funcCost) == 0 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.funcCost == nil {
+		c.funcCost = map[string]int64{}
+	}
+	for k, v := range funcCost {
+		c.funcCost[k] += int64(v)
+	}
+}
+
+func (c *scriptCost) read() (int64, uint64, map[string]int64) {
+	c.Lock()
+	defer c.Unlock()
+
+	var funcCost map[string]int64
+	if len(c.funcCost) > 0 {
+		funcCost = make(map[string]int64, len(c.funcCost))
+		for k, v := range c.funcCost {
+			funcCost[k] = v
+		}
+	}
+
+	return atomic.LoadInt64(&c.maxCost), atomic.LoadUint64(&c.ptTimeout), funcCost
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package stats
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxErrorSampleCount int = 20
+	MaxErrorSampleLen   int = 1024
+)
+
+// RunError is the structured error of a failed script run.
+type RunError struct {
+	// Func is the name of the function failed, empty if the error
+	// not raised within any function call.
+	Func  string `json:"func"`
+	Pos   string `json:"pos"` // file:line:column
+	Error string `json:"error"`
+}
+
+// ErrorSample is a recent failed run with the input.
+type ErrorSample struct {
+	RunError
+	Time  time.Time `json:"time"`
+	Input string    `json:"input"`
+}
+
+type scriptErrors struct {
+	// function name: count of errors
+	funcErr map[string]uint64
+
+	samples [MaxErrorSampleCount]ErrorSample
+	pos     int
+	count   int
+
+	sync.Mutex
+}
+
+// TruncateSample returns the prefix of s within MaxErrorSampleLen bytes,
+// not cutting any UTF-8 character.
+func TruncateSample(s string) string {
+	if len(s) <= MaxErrorSampleLen {
+		return s
+	}
+
+	n := MaxErrorSampleLen
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func (errs *scriptErrors) write(e *RunError, input string, ts time.Time) {
+	input = TruncateSample(input)
+
+	errs.Lock()
+	defer errs.Unlock()
+
+	if errs.funcErr == nil {
+		errs.funcErr = map[string]uint64{}
+	}
+	errs.funcErr[e.Func]++
+
+	errs.samples[errs.pos] = ErrorSample{RunError: *e, Time: ts, Input: input}
+	errs.pos = (errs.pos + 1) % MaxErrorSampleCount
+	if errs.count < MaxErrorSampleCount {
+		errs.count++
+	}
+}
+
+// read returns the error count of functions and samples, the latest first.
+func (errs *scriptErrors) read() (map[string]uint64, []ErrorSample) {
+	errs.Lock()
+	defer errs.Unlock()
+
+	if errs.count == 0 {
+		return nil, nil
+	}
+
+	funcErr := make(map[string]uint64, len(errs.funcErr))
+	for k, v := range errs.funcErr {
+		funcErr[k] = v
+	}
+
+	samples := make([]ErrorSample, 0, errs.count)
+	for i := 1; i <= errs.count; i++ {
+		samples = append(samples, errs.samples[(errs.pos-i+MaxErrorSampleCount)%MaxErrorSampleCount])
+	}
+
+	return funcErr, samples
+}
@@ -69,6 +69,7 @@ type ChangeEvent struct {
 	Op EventOP //
 
 	CompileError string
+	Reason       string // reason of rollback/canary
 	Time         time.Time
 }
 
@@ -82,5 +83,8 @@ func (event ChangeEvent) String() string {
 	if event.CompileError != "" {
 		ret += ", compile_error: " + event.CompileError
 	}
+	if event.Reason != "" {
+		ret += ", reason: " + event.Reason
+	}
 	return ret
 }
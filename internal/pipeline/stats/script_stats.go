@@ -43,6 +43,10 @@ type ScriptStats struct {
 		sync.RWMutex
 	}
 
+	runErrors scriptErrors
+
+	cost scriptCost
+
 	meta ScriptMeta
 }
 
@@ -50,6 +54,8 @@ type ScriptStatsROnly struct {
 	Pt, PtDrop, PtError uint64
 
 	TotalCost int64 // ns
+	MaxCost   int64 // ns, the max time spent on a point
+	PtTimeout uint64
 	MetaTS    time.Time
 
 	Script            string
@@ -63,6 +69,13 @@ type ScriptStatsROnly struct {
 	Enable       bool
 	Deleted      bool
 	CompileError string
+
+	// count of run errors by function name, and the recent failed inputs
+	FuncErrors   map[string]uint64
+	ErrorSamples []ErrorSample
+
+	// total time spent by function name, ns
+	FuncCost map[string]int64
 }
 
 func (statsR ScriptStatsROnly) String() string {
@@ -100,6 +113,25 @@ func (stats *ScriptStats) WriteErr(err string) {
 	stats.lastRunErr.pos += 1
 }
 
+// WriteRunError records the structured error and the input of a failed run.
+func (stats *ScriptStats) WriteRunError(e *RunError, input string) {
+	if e == nil {
+		return
+	}
+	stats.WriteErr(e.Error)
+	stats.runErrors.write(e, input, time.Now())
+}
+
+// WriteCost records the time spent on a point and by each function.
+func (stats *ScriptStats) WriteCost(cost time.Duration, funcCost map[string]time.Duration) {
+	atomic.AddInt64(&stats.totalCost, int64(cost))
+	stats.cost.write(cost, funcCost)
+}
+
+func (stats *ScriptStats) WriteTimeout() {
+	atomic.AddUint64(&stats.cost.ptTimeout, 1)
+}
+
 func (stats *ScriptStats) Read() *ScriptStatsROnly {
 	ret := &ScriptStatsROnly{
 		Pt:        atomic.LoadUint64(&stats.pt),
@@ -108,6 +140,9 @@ func (stats *ScriptStats) Read() *ScriptStatsROnly {
 		TotalCost: atomic.LoadInt64(&stats.totalCost),
 	}
 
+	ret.FuncErrors, ret.ErrorSamples = stats.runErrors.read()
+	ret.MaxCost, ret.PtTimeout, ret.FuncCost = stats.cost.read()
+
 	stats.meta.RLock()
 	defer stats.meta.RUnlock()
 	ret.Category = stats.meta.category
@@ -7,8 +7,13 @@ package stats
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPlStats(t *testing.T) {
@@ -40,3 +45,52 @@ func TestPlStats(t *testing.T) {
 	l.Info("")
 	stats = ScriptStats{}
 }
+
+func TestScriptRunError(t *testing.T) {
+	var stats ScriptStats
+
+	r := stats.Read()
+	assert.Nil(t, r.FuncErrors)
+	assert.Nil(t, r.ErrorSamples)
+
+	long := strings.Repeat("x", MaxErrorSampleLen*2)
+	for i := 0; i < MaxErrorSampleCount+5; i++ {
+		fn := "grok"
+		if i%5 == 0 {
+			fn = "cast"
+		}
+		stats.WriteRunError(&RunError{Func: fn, Pos: "a.p:1:1", Error: fmt.Sprint(i)}, long)
+	}
+	stats.WriteRunError(nil, "")
+
+	r = stats.Read()
+	assert.Equal(t, map[string]uint64{"grok": 20, "cast": 5}, r.FuncErrors)
+	assert.Len(t, r.ErrorSamples, MaxErrorSampleCount)
+	assert.Equal(t, fmt.Sprint(MaxErrorSampleCount+4), r.ErrorSamples[0].Error)
+	assert.Equal(t, "5", r.ErrorSamples[MaxErrorSampleCount-1].Error)
+	assert.Len(t, r.ErrorSamples[0].Input, MaxErrorSampleLen)
+
+	// not cut within a character
+	stats.WriteRunError(&RunError{Func: "grok"}, "x"+strings.Repeat("中", MaxErrorSampleLen))
+	r = stats.Read()
+	assert.True(t, utf8.ValidString(r.ErrorSamples[0].Input))
+	assert.Len(t, r.ErrorSamples[0].Input, 1+(MaxErrorSampleLen-1)/3*3)
+}
+
+func TestScriptCost(t *testing.T) {
+	var stats ScriptStats
+
+	r := stats.Read()
+	assert.Nil(t, r.FuncCost)
+
+	stats.WriteCost(3*time.Millisecond, map[string]time.Duration{"grok": 2 * time.Millisecond})
+	stats.WriteCost(time.Millisecond, map[string]time.Duration{"grok": time.Millisecond, "cast": 100})
+	stats.WriteCost(2*time.Millisecond, nil)
+	stats.WriteTimeout()
+
+	r = stats.Read()
+	assert.Equal(t, int64(6*time.Millisecond), r.TotalCost)
+	assert.Equal(t, int64(3*time.Millisecond), r.MaxCost)
+	assert.Equal(t, uint64(1), r.PtTimeout)
+	assert.Equal(t, map[string]int64{"grok": int64(3 * time.Millisecond), "cast": 100}, r.FuncCost)
+}
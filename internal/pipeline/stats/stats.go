@@ -31,6 +31,9 @@ const (
 	EventOpIndexDelete        EventOP = "INDEX_DELETE"
 	EventOpIndexDeleteAndBack EventOP = "INDEX_DELETE_AND_BACK"
 	EventOpCompileError       EventOP = "COMPILE_ERROR"
+	EventOpRollback           EventOP = "ROLLBACK"
+	EventOpCanaryPromote      EventOP = "CANARY_PROMOTE"
+	EventOpCanaryReject       EventOP = "CANARY_REJECT"
 )
 
 var (
@@ -96,7 +99,7 @@ func (stats *Stats) WriteScriptStats(category point.Category, ns, name string, p
 	}
 
 	if ptError > 0 {
-		plErrPtsVec.WithLabelValues(catStr, name, ns).Add(float64(ptDrop))
+		plErrPtsVec.WithLabelValues(catStr, name, ns).Add(float64(ptError))
 	}
 
 	if cost > 0 {
@@ -104,6 +107,74 @@ func (stats *Stats) WriteScriptStats(category point.Category, ns, name string, p
 	}
 }
 
+func (stats *Stats) WriteScriptError(category point.Category, ns, name string, e *RunError, input string) {
+	if e == nil {
+		return
+	}
+
+	plFuncErrVec.WithLabelValues(category.String(), name, ns, e.Func).Inc()
+
+	if s := stats.scriptStats(category, ns, name); s != nil {
+		s.WriteRunError(e, input)
+	}
+}
+
+// WriteScriptCost records the time spent on a point, funcCost is the time
+// spent by each function, aggregated within the script stats and exported
+// on collecting metrics.
+func (stats *Stats) WriteScriptCost(category point.Category, ns, name string,
+	cost time.Duration, funcCost map[string]time.Duration,
+) {
+	if s := stats.scriptStats(category, ns, name); s != nil {
+		s.WriteCost(cost, funcCost)
+	}
+}
+
+func (stats *Stats) WriteScriptTimeout(category point.Category, ns, name string) {
+	plTimeoutVec.WithLabelValues(category.String(), name, ns).Inc()
+
+	if s := stats.scriptStats(category, ns, name); s != nil {
+		s.WriteTimeout()
+	}
+}
+
+// scriptStats returns stats of the script, created if not found.
+func (stats *Stats) scriptStats(category point.Category, ns, name string) *ScriptStats {
+	v, ok := stats.stats.Load(StatsKey(category, ns, name))
+	if !ok {
+		ts := time.Now()
+		v, _ = stats.stats.LoadOrStore(StatsKey(category, ns, name), &ScriptStats{
+			meta: ScriptMeta{
+				startTS:      ts,
+				category:     category,
+				ns:           ns,
+				name:         name,
+				metaUpdateTS: ts,
+			},
+		})
+	}
+
+	s, _ := v.(*ScriptStats)
+	return s
+}
+
+func (stats *Stats) ReadScriptStats(category point.Category, ns, name string) (*ScriptStatsROnly, bool) {
+	if v, ok := stats.stats.Load(StatsKey(category, ns, name)); ok {
+		if s, ok := v.(*ScriptStats); ok && s != nil {
+			return s.Read(), true
+		}
+	}
+	return nil, false
+}
+
+func (stats *Stats) WriteRollback(category point.Category, ns, name, reason string) {
+	plRollbackVec.WithLabelValues(category.String(), name, ns, reason).Inc()
+}
+
+func (stats *Stats) WriteCanary(category point.Category, name, result string) {
+	plCanaryVec.WithLabelValues(category.String(), name, result).Inc()
+}
+
 func (stats *Stats) ReadStats() []ScriptStatsROnly {
 	ret := []ScriptStatsROnly{}
 	stats.stats.Range(func(key, value interface{}) bool {
@@ -133,6 +204,35 @@ func WriteScriptStats(category point.Category, ns, name string, pt, ptDrop, ptEr
 	_plstats.WriteScriptStats(category, ns, name, pt, ptDrop, ptError, cost, err)
 }
 
+// WriteScriptError records the structured error of a failed run, input
+// is a sample of the input data.
+func WriteScriptError(category point.Category, ns, name string, e *RunError, input string) {
+	_plstats.WriteScriptError(category, ns, name, e, input)
+}
+
+func WriteScriptCost(category point.Category, ns, name string,
+	cost time.Duration, funcCost map[string]time.Duration,
+) {
+	_plstats.WriteScriptCost(category, ns, name, cost, funcCost)
+}
+
+// WriteScriptTimeout records a point exceeded the time budget.
+func WriteScriptTimeout(category point.Category, ns, name string) {
+	_plstats.WriteScriptTimeout(category, ns, name)
+}
+
+func ReadScriptStats(category point.Category, ns, name string) (*ScriptStatsROnly, bool) {
+	return _plstats.ReadScriptStats(category, ns, name)
+}
+
+func WriteRollback(category point.Category, ns, name, reason string) {
+	_plstats.WriteRollback(category, ns, name, reason)
+}
+
+func WriteCanary(category point.Category, name, result string) {
+	_plstats.WriteCanary(category, name, result)
+}
+
 func StatsKey(category point.Category, ns, name string) string {
 	var b strings.Builder
 
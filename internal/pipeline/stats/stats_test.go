@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/GuanceCloud/cliutils/point"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +52,30 @@ func TestStats(t *testing.T) {
 		t.Fatal("len(stats)", len(statsRL))
 	}
 }
+
+func TestFuncCostCollector(t *testing.T) {
+	var stats Stats
+	c := &funcCostCollector{desc: plFuncCost.desc, stats: &stats}
+
+	stats.WriteScriptCost(point.Logging, "default", "a.p", 3*time.Millisecond,
+		map[string]time.Duration{"grok": 2 * time.Millisecond})
+	stats.WriteScriptCost(point.Logging, "default", "a.p", time.Millisecond,
+		map[string]time.Duration{"grok": time.Millisecond, "cast": time.Millisecond})
+	stats.WriteScriptCost(point.Logging, "default", "b.p", time.Millisecond, nil)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	mfs, err := reg.Gather()
+	assert.NoError(t, err)
+	assert.Len(t, mfs, 1)
+
+	got := map[string]float64{}
+	for _, m := range mfs[0].GetMetric() {
+		for _, lb := range m.GetLabel() {
+			if lb.GetName() == "function" {
+				got[lb.GetValue()] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.InDeltaMapValues(t, map[string]float64{"grok": 0.003, "cast": 0.001}, got, 1e-9)
+}
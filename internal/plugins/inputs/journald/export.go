@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package journald
+
+import "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+
+func (*Input) Dashboard(lang inputs.I18n) map[string]string {
+	switch lang {
+	case inputs.I18nZh:
+		return map[string]string{
+			//nolint:lll
+		}
+	case inputs.I18nEn:
+		return map[string]string{
+			//nolint:lll
+		}
+	default:
+		return nil
+	}
+}
+
+func (*Input) DashboardList() []string {
+	return nil
+}
+
+func (*Input) Monitor(lang inputs.I18n) map[string]string {
+	switch lang {
+	case inputs.I18nZh:
+		return map[string]string{
+			//nolint:lll
+		}
+	case inputs.I18nEn:
+		return map[string]string{
+			//nolint:lll
+		}
+	default:
+		return nil
+	}
+}
+
+func (*Input) MonitorList() []string {
+	return nil
+}
@@ -0,0 +1,522 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package journald collects logs from systemd journal files.
+package journald
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/logger"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/datakit"
+	iod "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/plugins/inputs"
+)
+
+const (
+	inputName = "journald"
+
+	defaultSource   = "journald"
+	defaultInterval = time.Second
+	// interval to discover new and rotated journal files
+	scanInterval = time.Second * 10
+	// max points fed at once
+	maxBatchSize = 1000
+	// entries without PRIORITY are treated as LOG_INFO
+	defaultPriority = 6
+
+	sampleCfg = `
+[[inputs.journald]]
+  ## Directories of the journal files, files "*.journal" in the directories
+  ## and their subdirectories (named by the machine ID) are read.
+  paths = ["/var/log/journal", "/run/log/journal"]
+
+  ## Only collect entries of the units, glob patterns supported,
+  ## e.g. "nginx.service", "docker*". Collect all units if empty.
+  units = []
+
+  ## Only collect entries with the priority or higher,
+  ##   "emerg","alert","crit","err","warning","notice","info","debug" or 0-7.
+  ## Collect all entries if empty.
+  priority = ""
+
+  ## Read the journal files from beginning, only the entries written after
+  ## datakit started are collected by default.
+  from_beginning = false
+
+  ## Your logging source, if it's empty, use 'journald'.
+  source = "journald"
+
+  ## Add service tag, if it's empty, use $source.
+  service = ""
+
+  ## Grok pipeline script name.
+  pipeline = ""
+
+  ## Interval to read new entries.
+  interval = "1s"
+
+  ## If the data sent failure, will retry forevery.
+  blocking_mode = true
+
+  [inputs.journald.tags]
+  # some_tag = "some_value"
+  # more_tag = "some_other_value"
+`
+)
+
+var (
+	l = logger.DefaultSLogger(inputName)
+
+	// statuses of the priorities, see syslog(3)
+	statuses = []string{"emerg", "alert", "critical", "error", "warning", "notice", "info", "debug"}
+
+	priorityNames = map[string]int{
+		"emerg":    0,
+		"alert":    1,
+		"crit":     2,
+		"critical": 2,
+		"err":      3,
+		"error":    3,
+		"warning":  4,
+		"warn":     4,
+		"notice":   5,
+		"info":     6,
+		"debug":    7,
+	}
+)
+
+type Input struct {
+	Paths         []string          `toml:"paths"`
+	Units         []string          `toml:"units"`
+	Priority      string            `toml:"priority"`
+	FromBeginning bool              `toml:"from_beginning"`
+	Source        string            `toml:"source"`
+	Service       string            `toml:"service"`
+	Pipeline      string            `toml:"pipeline"`
+	Interval      datakit.Duration  `toml:"interval"`
+	BlockingMode  bool              `toml:"blocking_mode"`
+	Tags          map[string]string `toml:"tags"`
+
+	maxPriority int
+	// opened journal files by the file ID
+	journals map[string]*journalFile
+	// files failed to open, not logged again
+	failed   map[string]bool
+	lastScan time.Time
+
+	semStop *cliutils.Sem // start stop signal
+}
+
+func (ipt *Input) Run() {
+	l = logger.SLogger(inputName)
+
+	if err := ipt.setup(); err != nil {
+		l.Errorf("invalid config: %s", err)
+		return
+	}
+
+	if err := logtail.InitDefault(); err != nil {
+		l.Warnf("cursors of the journal files are not persisted: %s", err)
+	}
+
+	ipt.scan(true)
+
+	tick := time.NewTicker(ipt.Interval.Duration)
+	defer tick.Stop()
+
+	for {
+		if time.Since(ipt.lastScan) >= scanInterval {
+			ipt.scan(false)
+		}
+
+		ipt.collect()
+
+		select {
+		case <-tick.C:
+
+		case <-datakit.Exit.Wait():
+			ipt.exit()
+			l.Infof("%s exit", inputName)
+			return
+
+		case <-ipt.semStop.Wait():
+			ipt.exit()
+			l.Infof("%s terminate", inputName)
+			return
+		}
+	}
+}
+
+func (ipt *Input) setup() error {
+	if ipt.Source == "" {
+		ipt.Source = defaultSource
+	}
+	if ipt.Service == "" {
+		ipt.Service = ipt.Source
+	}
+	if ipt.Interval.Duration <= 0 {
+		ipt.Interval.Duration = defaultInterval
+	}
+
+	priority, err := parsePriority(ipt.Priority)
+	if err != nil {
+		return err
+	}
+	ipt.maxPriority = priority
+
+	for _, unit := range ipt.Units {
+		if _, err := path.Match(unit, ""); err != nil {
+			return fmt.Errorf("invalid unit pattern %q: %w", unit, err)
+		}
+	}
+
+	ipt.journals = make(map[string]*journalFile)
+	ipt.failed = make(map[string]bool)
+	return nil
+}
+
+// parsePriority returns the priority number of the name or number,
+// all priorities (LOG_DEBUG) if empty.
+func parsePriority(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return len(statuses) - 1, nil
+	}
+
+	if priority, ok := priorityNames[s]; ok {
+		return priority, nil
+	}
+
+	if priority, err := strconv.Atoi(s); err == nil && priority >= 0 && priority < len(statuses) {
+		return priority, nil
+	}
+
+	return 0, fmt.Errorf("invalid priority %q", s)
+}
+
+func cursorKey(j *journalFile) string {
+	return "journald::" + j.id()
+}
+
+// scan opens the journal files not opened yet, and closes those removed.
+// On the initial scan, files without cursor are read from the tail unless
+// from_beginning, files created later are always read from the head.
+func (ipt *Input) scan(initial bool) {
+	ipt.lastScan = time.Now()
+
+	var paths []string
+	for _, dir := range ipt.Paths {
+		for _, pattern := range []string{"*.journal", "*/*.journal"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				l.Warnf("invalid path %s: %s", dir, err)
+				continue
+			}
+			paths = append(paths, matches...)
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+
+		// archived by journald, the file renamed
+		if id := ipt.opened(fi); id != "" {
+			ipt.journals[id].path = p
+			found[id] = true
+			continue
+		}
+
+		j, err := openJournal(p)
+		if err != nil {
+			if !ipt.failed[p] {
+				l.Warnf("open journal: %s, ignored", err)
+				ipt.failed[p] = true
+			}
+			continue
+		}
+		delete(ipt.failed, p)
+
+		id := j.id()
+		if _, ok := ipt.journals[id]; ok {
+			j.close()
+			found[id] = true
+			continue
+		}
+
+		if data := register.Get(cursorKey(j)); data != nil && data.Cursor != "" {
+			if seqnum, ok := cursorSeqnum(data.Cursor); ok {
+				j.seqnum, j.cursor = seqnum, data.Cursor
+			}
+		} else if initial && !ipt.FromBeginning {
+			if err := j.seekTail(); err != nil {
+				l.Warnf("seek tail of %s: %s, ignored", p, err)
+				j.close()
+				continue
+			}
+		}
+
+		l.Infof("read journal %s from seqnum %d", p, j.seqnum)
+		ipt.journals[id] = j
+		found[id] = true
+	}
+
+	for id, j := range ipt.journals {
+		if !found[id] {
+			l.Infof("journal %s removed", j.path)
+			ipt.saveCursor(j, true)
+			j.close()
+			delete(ipt.journals, id)
+		}
+	}
+}
+
+func (ipt *Input) opened(fi os.FileInfo) string {
+	for id, j := range ipt.journals {
+		if jfi, err := j.f.Stat(); err == nil && os.SameFile(fi, jfi) {
+			return id
+		}
+	}
+	return ""
+}
+
+func (ipt *Input) collect() {
+	for _, j := range ipt.journals {
+		for !ipt.stopped() {
+			pts, err := ipt.read(j)
+			if err != nil {
+				if j.corrupted {
+					l.Warnf("read journal %s: %s, entries after it ignored", j.path, err)
+				} else {
+					l.Warnf("read journal %s: %s", j.path, err)
+				}
+			}
+
+			if len(pts) != 0 {
+				ipt.feed(pts)
+			}
+			ipt.saveCursor(j, false)
+
+			if err != nil || len(pts) < maxBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// read returns at most maxBatchSize points of the entries matched.
+func (ipt *Input) read(j *journalFile) ([]*point.Point, error) {
+	var pts []*point.Point
+	for len(pts) < maxBatchSize {
+		e, err := j.next()
+		if err != nil {
+			return pts, err
+		}
+		if e == nil {
+			break
+		}
+
+		if !ipt.match(e) {
+			continue
+		}
+
+		pt, err := ipt.makePoint(e)
+		if err != nil {
+			l.Warn(err)
+			continue
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+func (ipt *Input) match(e *journalEntry) bool {
+	if entryPriority(e) > ipt.maxPriority {
+		return false
+	}
+
+	if len(ipt.Units) == 0 {
+		return true
+	}
+
+	unit := entryUnit(e)
+	for _, pattern := range ipt.Units {
+		if ok, _ := path.Match(pattern, unit); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func entryPriority(e *journalEntry) int {
+	if priority, err := strconv.Atoi(e.fields["PRIORITY"]); err == nil && priority >= 0 && priority < len(statuses) {
+		return priority
+	}
+	return defaultPriority
+}
+
+func entryUnit(e *journalEntry) string {
+	if unit := e.fields["_SYSTEMD_UNIT"]; unit != "" {
+		return unit
+	}
+	return e.fields["_SYSTEMD_USER_UNIT"]
+}
+
+func (ipt *Input) makePoint(e *journalEntry) (*point.Point, error) {
+	tags := map[string]string{"service": ipt.Service}
+	for k, v := range ipt.Tags {
+		tags[k] = v
+	}
+
+	for tag, field := range map[string]string{
+		"unit":              entryUnit(e),
+		"pid":               e.fields["_PID"],
+		"hostname":          e.fields["_HOSTNAME"],
+		"syslog_identifier": e.fields["SYSLOG_IDENTIFIER"],
+	} {
+		if field != "" {
+			tags[tag] = field
+		}
+	}
+
+	return point.NewPoint(ipt.Source, tags,
+		map[string]interface{}{
+			pipeline.FieldMessage: e.fields["MESSAGE"],
+			pipeline.FieldStatus:  statuses[entryPriority(e)],
+		},
+		&point.PointOption{
+			Time:     time.Unix(0, int64(e.realtime)*int64(time.Microsecond)),
+			Category: datakit.Logging,
+			Strict:   true,
+		})
+}
+
+func (ipt *Input) feed(pts []*point.Point) {
+	if err := iod.Feed("logging/"+ipt.Source, datakit.Logging, pts, &iod.Option{
+		PlScript: map[string]string{ipt.Source: ipt.Pipeline},
+		Blocking: ipt.BlockingMode,
+	}); err != nil {
+		l.Errorf("feed %d pts failed: %s, logging block-mode off, ignored", len(pts), err)
+	}
+}
+
+func (ipt *Input) saveCursor(j *journalFile, flush bool) {
+	if j.cursor == "" {
+		return
+	}
+
+	c := &register.MetaData{Source: ipt.Source, Cursor: j.cursor}
+
+	set := register.Set
+	if flush {
+		set = register.SetAndFlush
+	}
+	if err := set(cursorKey(j), c); err != nil {
+		l.Debugf("save cursor of %s: %s", j.path, err)
+	}
+}
+
+func (ipt *Input) stopped() bool {
+	select {
+	case <-datakit.Exit.Wait():
+		return true
+	case <-ipt.semStop.Wait():
+		return true
+	default:
+		return false
+	}
+}
+
+func (ipt *Input) exit() {
+	for id, j := range ipt.journals {
+		ipt.saveCursor(j, true)
+		j.close()
+		delete(ipt.journals, id)
+	}
+}
+
+func (ipt *Input) Terminate() {
+	if ipt.semStop != nil {
+		ipt.semStop.Close()
+	}
+}
+
+func (*Input) Catalog() string {
+	return "log"
+}
+
+func (*Input) SampleConfig() string {
+	return sampleCfg
+}
+
+func (*Input) AvailableArchs() []string {
+	return []string{datakit.OSLabelLinux}
+}
+
+func (*Input) SampleMeasurement() []inputs.Measurement {
+	return []inputs.Measurement{
+		&journaldMeasurement{},
+	}
+}
+
+type journaldMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+func (m *journaldMeasurement) LineProto() (*point.Point, error) {
+	return point.NewPoint(m.name, m.tags, m.fields, point.LOpt())
+}
+
+//nolint:lll
+func (*journaldMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "journald",
+		Type: "logging",
+		Desc: "Use the `source` of the config, if empty then use `journald`",
+		Tags: map[string]interface{}{
+			"host":              inputs.NewTagInfo("Host name"),
+			"service":           inputs.NewTagInfo("Use the `service` of the config."),
+			"unit":              inputs.NewTagInfo("The systemd unit of the entry, `_SYSTEMD_UNIT` or `_SYSTEMD_USER_UNIT`."),
+			"pid":               inputs.NewTagInfo("The process ID, `_PID` of the entry."),
+			"hostname":          inputs.NewTagInfo("The host name written the entry, `_HOSTNAME` of the entry."),
+			"syslog_identifier": inputs.NewTagInfo("The syslog identifier, `SYSLOG_IDENTIFIER` of the entry."),
+		},
+		Fields: map[string]interface{}{
+			"message": &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The `MESSAGE` of the entry."},
+			"status":  &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The status mapped from `PRIORITY` of the entry, `info` if not set."},
+		},
+	}
+}
+
+func init() { //nolint:gochecknoinits
+	inputs.Add(inputName, func() inputs.Input {
+		return &Input{
+			Paths:        []string{"/var/log/journal", "/run/log/journal"},
+			Source:       defaultSource,
+			Interval:     datakit.Duration{Duration: defaultInterval},
+			BlockingMode: true,
+			Tags:         make(map[string]string),
+
+			semStop: cliutils.NewSem(),
+		}
+	})
+}
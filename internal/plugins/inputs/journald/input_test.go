@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package journald
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+)
+
+func TestParsePriority(t *testing.T) {
+	cases := []struct {
+		in       string
+		priority int
+		fail     bool
+	}{
+		{"", 7, false},
+		{"err", 3, false},
+		{"Warning", 4, false},
+		{"critical", 2, false},
+		{"0", 0, false},
+		{"7", 7, false},
+		{"8", 0, true},
+		{"-1", 0, true},
+		{"fatal", 0, true},
+	}
+
+	for _, tc := range cases {
+		priority, err := parsePriority(tc.in)
+		if tc.fail {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.priority, priority, tc.in)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	entry := func(fields map[string]string) *journalEntry {
+		return &journalEntry{fields: fields}
+	}
+
+	ipt := &Input{Units: []string{"nginx.service", "docker*"}, Priority: "warning"}
+	require.NoError(t, ipt.setup())
+
+	assert.True(t, ipt.match(entry(map[string]string{"_SYSTEMD_UNIT": "nginx.service", "PRIORITY": "3"})))
+	assert.True(t, ipt.match(entry(map[string]string{"_SYSTEMD_UNIT": "docker.service", "PRIORITY": "4"})))
+	assert.True(t, ipt.match(entry(map[string]string{"_SYSTEMD_USER_UNIT": "docker-rootless.service", "PRIORITY": "0"})))
+	assert.False(t, ipt.match(entry(map[string]string{"_SYSTEMD_UNIT": "nginx.service", "PRIORITY": "6"})))
+	assert.False(t, ipt.match(entry(map[string]string{"_SYSTEMD_UNIT": "sshd.service", "PRIORITY": "3"})))
+	// LOG_INFO if no priority
+	assert.False(t, ipt.match(entry(map[string]string{"_SYSTEMD_UNIT": "nginx.service"})))
+
+	ipt = &Input{}
+	require.NoError(t, ipt.setup())
+	assert.True(t, ipt.match(entry(map[string]string{"PRIORITY": "7"})))
+
+	ipt = &Input{Units: []string{"[nginx"}}
+	assert.Error(t, ipt.setup())
+}
+
+func TestMakePoint(t *testing.T) {
+	ipt := &Input{Tags: map[string]string{"env": "test"}}
+	require.NoError(t, ipt.setup())
+
+	pt, err := ipt.makePoint(&journalEntry{
+		realtime: 1680000000123456,
+		fields: map[string]string{
+			"MESSAGE":           "connection refused",
+			"PRIORITY":          "3",
+			"_SYSTEMD_UNIT":     "nginx.service",
+			"_PID":              "100",
+			"_HOSTNAME":         "host-a",
+			"SYSLOG_IDENTIFIER": "nginx",
+			"_COMM":             "nginx",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "journald", pt.Name())
+	assert.Equal(t, map[string]string{
+		"service":           "journald",
+		"env":               "test",
+		"unit":              "nginx.service",
+		"pid":               "100",
+		"hostname":          "host-a",
+		"syslog_identifier": "nginx",
+	}, pt.Tags())
+
+	fields, err := pt.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"message": "connection refused", "status": "error"}, fields)
+	assert.Equal(t, time.Unix(1680000000, 123456000), pt.Time())
+}
+
+func TestScan(t *testing.T) {
+	register.AssertTesting()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "machine-id"), 0o700))
+
+	system := &testJournal{fileID: [16]byte{1}}
+	system.add("MESSAGE=first")
+	system.write(t, filepath.Join(dir, "machine-id", "system.journal"))
+
+	user := &testJournal{fileID: [16]byte{2}}
+	user.add("MESSAGE=first")
+	user.write(t, filepath.Join(dir, "machine-id", "user-1000.journal"))
+
+	// not journal files
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "machine-id", "system.journal~"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.journal"), nil, 0o600))
+
+	ipt := &Input{Paths: []string{dir}}
+	require.NoError(t, ipt.setup())
+	defer ipt.exit()
+
+	// existed entries skipped
+	ipt.scan(true)
+	require.Len(t, ipt.journals, 2)
+	for _, j := range ipt.journals {
+		assert.Empty(t, readAll(t, j))
+	}
+
+	// rotated
+	require.NoError(t, os.Rename(filepath.Join(dir, "machine-id", "system.journal"),
+		filepath.Join(dir, "machine-id", "system@0001-0002.journal")))
+	system.add("MESSAGE=second")
+	system.write(t, filepath.Join(dir, "machine-id", "system@0001-0002.journal"))
+
+	rotated := &testJournal{fileID: [16]byte{3}}
+	rotated.add("MESSAGE=third")
+	rotated.write(t, filepath.Join(dir, "machine-id", "system.journal"))
+
+	ipt.scan(false)
+	require.Len(t, ipt.journals, 3)
+
+	j := ipt.journals["01000000000000000000000000000000"]
+	assert.Equal(t, filepath.Join(dir, "machine-id", "system@0001-0002.journal"), j.path)
+	assert.Equal(t, []string{"second"}, readAll(t, j))
+
+	// created later, read from the head
+	assert.Equal(t, []string{"third"}, readAll(t, ipt.journals["03000000000000000000000000000000"]))
+
+	// removed
+	require.NoError(t, os.Remove(filepath.Join(dir, "machine-id", "user-1000.journal")))
+	ipt.scan(false)
+	assert.Len(t, ipt.journals, 2)
+}
@@ -0,0 +1,392 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Journal file format, see https://systemd.io/JOURNAL_FILE_FORMAT/
+const (
+	headerSignature = "LPKSHHRH"
+	// size of the header fields used, the header is larger in newer versions
+	headerMinSize = 208
+
+	incompatibleCompressedXZ   = 1 << 0
+	incompatibleCompressedLZ4  = 1 << 1
+	incompatibleKeyedHash      = 1 << 2
+	incompatibleCompressedZstd = 1 << 3
+	incompatibleCompact        = 1 << 4
+	incompatibleSupported      = incompatibleCompressedXZ | incompatibleCompressedLZ4 |
+		incompatibleKeyedHash | incompatibleCompressedZstd | incompatibleCompact
+
+	objectData       = 1
+	objectEntry      = 3
+	objectEntryArray = 6
+
+	objectCompressedXZ   = 1 << 0
+	objectCompressedLZ4  = 1 << 1
+	objectCompressedZstd = 1 << 2
+
+	objectHeaderSize = 16
+	// object header and next_entry_array_offset
+	entryArrayHeaderSize = 24
+	// objects larger than this are considered corrupted
+	maxObjectSize = 64 * 1024 * 1024
+)
+
+var (
+	errNotJournal         = errors.New("not a journal file")
+	errUnsupportedJournal = errors.New("unsupported journal file")
+	errCorruptedJournal   = errors.New("corrupted journal file")
+)
+
+type journalHeader struct {
+	incompatibleFlags uint32
+	fileID            [16]byte
+	seqnumID          [16]byte
+	nEntries          uint64
+	tailEntrySeqnum   uint64
+	entryArrayOffset  uint64
+}
+
+func (h *journalHeader) compact() bool {
+	return h.incompatibleFlags&incompatibleCompact != 0
+}
+
+type journalEntry struct {
+	seqnum    uint64
+	realtime  uint64 // microseconds
+	monotonic uint64
+	bootID    [16]byte
+	xorHash   uint64
+	fields    map[string]string
+}
+
+// cursor returns the cursor of the entry, the same as journalctl.
+func (e *journalEntry) cursor(seqnumID [16]byte) string {
+	return fmt.Sprintf("s=%x;i=%x;b=%x;m=%x;t=%x;x=%x",
+		seqnumID, e.seqnum, e.bootID, e.monotonic, e.realtime, e.xorHash)
+}
+
+// cursorSeqnum returns the seqnum of the cursor.
+func cursorSeqnum(cursor string) (uint64, bool) {
+	for _, kv := range strings.Split(cursor, ";") {
+		if v := strings.TrimPrefix(kv, "i="); v != kv {
+			seqnum, err := strconv.ParseUint(v, 16, 64)
+			return seqnum, err == nil
+		}
+	}
+	return 0, false
+}
+
+// journalFile reads entries of the journal file in order, the file may be
+// still being written by journald.
+type journalFile struct {
+	path   string
+	f      *os.File
+	header journalHeader
+
+	// position of the next entry
+	arrayOffset uint64
+	index       uint64
+	// seqnum of the last entry read, entries not after it are skipped
+	seqnum uint64
+	// cursor of the last entry read
+	cursor string
+	// the entry array chain is corrupted, no more entries can be read
+	corrupted bool
+
+	zstd *zstd.Decoder
+}
+
+func openJournal(path string) (*journalFile, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	j := &journalFile{path: path, f: f}
+	if err := j.readHeader(); err != nil {
+		j.close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return j, nil
+}
+
+func (j *journalFile) id() string {
+	return hex.EncodeToString(j.header.fileID[:])
+}
+
+func (j *journalFile) close() {
+	if j.zstd != nil {
+		j.zstd.Close()
+	}
+	_ = j.f.Close()
+}
+
+// seekTail skips all entries existed.
+func (j *journalFile) seekTail() error {
+	if err := j.readHeader(); err != nil {
+		return err
+	}
+	j.seqnum = j.header.tailEntrySeqnum
+	return nil
+}
+
+func (j *journalFile) readHeader() error {
+	b := make([]byte, headerMinSize)
+	if _, err := j.f.ReadAt(b, 0); err != nil {
+		return err
+	}
+
+	if string(b[:8]) != headerSignature {
+		return errNotJournal
+	}
+
+	h := journalHeader{
+		incompatibleFlags: binary.LittleEndian.Uint32(b[12:]),
+		nEntries:          binary.LittleEndian.Uint64(b[152:]),
+		tailEntrySeqnum:   binary.LittleEndian.Uint64(b[160:]),
+		entryArrayOffset:  binary.LittleEndian.Uint64(b[176:]),
+	}
+	copy(h.fileID[:], b[24:40])
+	copy(h.seqnumID[:], b[72:88])
+
+	if h.incompatibleFlags&^incompatibleSupported != 0 {
+		return fmt.Errorf("%w: incompatible flags %#x", errUnsupportedJournal, h.incompatibleFlags)
+	}
+
+	j.header = h
+	return nil
+}
+
+// next returns the next entry, nil if no more entries written yet.
+// Corrupted entries are skipped. If the entry array chain is corrupted,
+// the entries after it are not reachable, the same as journalctl, and
+// no more entries are returned.
+func (j *journalFile) next() (*journalEntry, error) {
+	if j.corrupted {
+		return nil, nil
+	}
+
+	for {
+		if j.arrayOffset == 0 {
+			if err := j.readHeader(); err != nil {
+				return nil, err
+			}
+			if j.header.entryArrayOffset == 0 {
+				return nil, nil
+			}
+			j.arrayOffset = j.header.entryArrayOffset
+		}
+
+		b, err := j.readObject(j.arrayOffset, objectEntryArray)
+		if err == nil && len(b) < entryArrayHeaderSize {
+			err = fmt.Errorf("%w: entry array at %d too small", errCorruptedJournal, j.arrayOffset)
+		}
+		if err != nil {
+			if errors.Is(err, errCorruptedJournal) {
+				j.corrupted = true
+			}
+			return nil, err
+		}
+
+		nextArray := binary.LittleEndian.Uint64(b[16:])
+		items := b[entryArrayHeaderSize:]
+
+		for ; j.index < j.itemCount(items); j.index++ {
+			offset := j.item(items, j.index)
+			if offset == 0 {
+				return nil, nil // not written yet
+			}
+
+			seqnum, err := j.readUint64(offset + 16)
+			if err != nil {
+				return nil, j.skipCorrupted(err)
+			}
+			if seqnum <= j.seqnum {
+				continue
+			}
+
+			e, err := j.readEntry(offset)
+			if err != nil {
+				return nil, j.skipCorrupted(err)
+			}
+
+			j.index++
+			j.seqnum = e.seqnum
+			j.cursor = e.cursor(j.header.seqnumID)
+			return e, nil
+		}
+
+		if nextArray == 0 {
+			return nil, nil
+		}
+		j.arrayOffset, j.index = nextArray, 0
+	}
+}
+
+// skipCorrupted skips the current entry if it is corrupted.
+func (j *journalFile) skipCorrupted(err error) error {
+	if errors.Is(err, errCorruptedJournal) {
+		j.index++
+	}
+	return err
+}
+
+func (j *journalFile) itemCount(items []byte) uint64 {
+	if j.header.compact() {
+		return uint64(len(items) / 4)
+	}
+	return uint64(len(items) / 8)
+}
+
+func (j *journalFile) item(items []byte, i uint64) uint64 {
+	if j.header.compact() {
+		return uint64(binary.LittleEndian.Uint32(items[i*4:]))
+	}
+	return binary.LittleEndian.Uint64(items[i*8:])
+}
+
+func (j *journalFile) readEntry(offset uint64) (*journalEntry, error) {
+	b, err := j.readObject(offset, objectEntry)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 64 {
+		return nil, fmt.Errorf("%w: entry object at %d too small", errCorruptedJournal, offset)
+	}
+
+	e := &journalEntry{
+		seqnum:    binary.LittleEndian.Uint64(b[16:]),
+		realtime:  binary.LittleEndian.Uint64(b[24:]),
+		monotonic: binary.LittleEndian.Uint64(b[32:]),
+		xorHash:   binary.LittleEndian.Uint64(b[56:]),
+		fields:    map[string]string{},
+	}
+	copy(e.bootID[:], b[40:56])
+
+	itemSize := 16
+	if j.header.compact() {
+		itemSize = 4
+	}
+
+	for items := b[64:]; len(items) >= itemSize; items = items[itemSize:] {
+		var dataOffset uint64
+		if j.header.compact() {
+			dataOffset = uint64(binary.LittleEndian.Uint32(items))
+		} else {
+			dataOffset = binary.LittleEndian.Uint64(items)
+		}
+
+		payload, err := j.readData(dataOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		if idx := bytes.IndexByte(payload, '='); idx > 0 {
+			e.fields[string(payload[:idx])] = string(payload[idx+1:])
+		}
+	}
+
+	return e, nil
+}
+
+func (j *journalFile) readData(offset uint64) ([]byte, error) {
+	b, err := j.readObject(offset, objectData)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadOffset := 64
+	if j.header.compact() {
+		payloadOffset = 72
+	}
+	if len(b) < payloadOffset {
+		return nil, fmt.Errorf("%w: data object at %d too small", errCorruptedJournal, offset)
+	}
+	payload := b[payloadOffset:]
+
+	switch flags := b[1]; {
+	case flags&objectCompressedZstd != 0:
+		if j.zstd == nil {
+			if j.zstd, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+				return nil, err
+			}
+		}
+		return j.zstd.DecodeAll(payload, nil)
+
+	case flags&objectCompressedLZ4 != 0:
+		// the size decompressed followed by the lz4 block
+		if len(payload) < 8 {
+			return nil, fmt.Errorf("%w: lz4 data object at %d too small", errCorruptedJournal, offset)
+		}
+		size := binary.LittleEndian.Uint64(payload)
+		if size > maxObjectSize {
+			return nil, fmt.Errorf("%w: lz4 data object at %d too large", errCorruptedJournal, offset)
+		}
+		dst := make([]byte, size)
+		n, err := lz4.UncompressBlock(payload[8:], dst)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:n], nil
+
+	case flags&objectCompressedXZ != 0:
+		return nil, fmt.Errorf("%w: xz compressed data", errUnsupportedJournal)
+
+	default:
+		return payload, nil
+	}
+}
+
+// readObject returns the whole object at the offset.
+func (j *journalFile) readObject(offset uint64, typ uint8) ([]byte, error) {
+	head := make([]byte, objectHeaderSize)
+	if _, err := j.f.ReadAt(head, int64(offset)); err != nil {
+		return nil, objectReadError(offset, err)
+	}
+
+	size := binary.LittleEndian.Uint64(head[8:])
+	if head[0] != typ || size < objectHeaderSize || size > maxObjectSize {
+		return nil, fmt.Errorf("%w: unexpected object type %d size %d at %d", errCorruptedJournal, head[0], size, offset)
+	}
+
+	b := make([]byte, size)
+	if _, err := j.f.ReadAt(b, int64(offset)); err != nil {
+		return nil, objectReadError(offset, err)
+	}
+	return b, nil
+}
+
+// objectReadError returns errCorruptedJournal if the object is beyond the
+// end of file, objects are always written before linked by journald.
+func objectReadError(offset uint64, err error) error {
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: object at %d beyond the end of file", errCorruptedJournal, offset)
+	}
+	return err
+}
+
+func (j *journalFile) readUint64(offset uint64) (uint64, error) {
+	b := make([]byte, 8)
+	if _, err := j.f.ReadAt(b, int64(offset)); err != nil {
+		return 0, objectReadError(offset, err)
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
@@ -0,0 +1,302 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package journald
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testJournal writes journal files in the regular (not compact) format,
+// with entry arrays of 2 items to test the chain.
+type testJournal struct {
+	fileID   [16]byte
+	seqnumID [16]byte
+	entries  [][]string
+}
+
+func (tj *testJournal) add(fields ...string) {
+	tj.entries = append(tj.entries, fields)
+}
+
+func (tj *testJournal) write(t *testing.T, path string) {
+	t.Helper()
+
+	b := make([]byte, 256)
+	appendObject := func(typ, flags uint8, body []byte) uint64 {
+		offset := uint64(len(b))
+		head := make([]byte, objectHeaderSize)
+		head[0], head[1] = typ, flags
+		binary.LittleEndian.PutUint64(head[8:], uint64(objectHeaderSize+len(body)))
+		b = append(b, head...)
+		b = append(b, body...)
+		for len(b)%8 != 0 {
+			b = append(b, 0)
+		}
+		return offset
+	}
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer enc.Close() //nolint:errcheck
+
+	var firstArray, array uint64
+	for i, fields := range tj.entries {
+		seqnum := uint64(i + 1)
+
+		var items []byte
+		for k, field := range fields {
+			var flags uint8
+			payload := []byte(field)
+
+			// compress some of the fields
+			switch k {
+			case 1:
+				flags, payload = objectCompressedZstd, enc.EncodeAll(payload, nil)
+			case 2:
+				dst := make([]byte, lz4.CompressBlockBound(len(payload)))
+				n, err := lz4.CompressBlock(payload, dst, nil)
+				require.NoError(t, err)
+				if n > 0 {
+					size := make([]byte, 8)
+					binary.LittleEndian.PutUint64(size, uint64(len(payload)))
+					flags, payload = objectCompressedLZ4, append(size, dst[:n]...)
+				}
+			}
+
+			offset := appendObject(objectData, flags, append(make([]byte, 48), payload...))
+			item := make([]byte, 16)
+			binary.LittleEndian.PutUint64(item, offset)
+			items = append(items, item...)
+		}
+
+		body := make([]byte, 48)
+		binary.LittleEndian.PutUint64(body[0:], seqnum)
+		binary.LittleEndian.PutUint64(body[8:], 1680000000000000+seqnum)
+		binary.LittleEndian.PutUint64(body[16:], seqnum)
+		entry := appendObject(objectEntry, 0, append(body, items...))
+
+		if i%2 == 0 {
+			next := appendObject(objectEntryArray, 0, make([]byte, 8+2*8))
+			if array == 0 {
+				firstArray = next
+			} else {
+				binary.LittleEndian.PutUint64(b[array+16:], next)
+			}
+			array = next
+		}
+		binary.LittleEndian.PutUint64(b[array+24+uint64(i%2)*8:], entry)
+	}
+
+	copy(b, headerSignature)
+	copy(b[24:], tj.fileID[:])
+	copy(b[72:], tj.seqnumID[:])
+	binary.LittleEndian.PutUint64(b[88:], 256)
+	binary.LittleEndian.PutUint64(b[152:], uint64(len(tj.entries)))
+	binary.LittleEndian.PutUint64(b[160:], uint64(len(tj.entries)))
+	binary.LittleEndian.PutUint64(b[176:], firstArray)
+
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+}
+
+func readAll(t *testing.T, j *journalFile) []string {
+	t.Helper()
+
+	var messages []string
+	for {
+		e, err := j.next()
+		require.NoError(t, err)
+		if e == nil {
+			return messages
+		}
+		messages = append(messages, e.fields["MESSAGE"])
+	}
+}
+
+func TestJournalNext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.journal")
+
+	tj := &testJournal{fileID: [16]byte{1}, seqnumID: [16]byte{2}}
+	tj.write(t, path)
+
+	j, err := openJournal(path)
+	require.NoError(t, err)
+	defer j.close()
+
+	assert.Equal(t, "01000000000000000000000000000000", j.id())
+	assert.Empty(t, readAll(t, j))
+
+	cmdline := "_CMDLINE=nginx" + strings.Repeat(" -g daemon", 16)
+	tj.add("MESSAGE=first", "_SYSTEMD_UNIT=nginx.service", cmdline, "_PID=100", "PRIORITY=3")
+	tj.add("MESSAGE=second", "_SYSTEMD_UNIT=nginx.service")
+	tj.add("MESSAGE=third", "_HOSTNAME=host-a")
+	tj.write(t, path)
+
+	e, err := j.next()
+	require.NoError(t, err)
+	require.NotNil(t, e)
+	assert.Equal(t, map[string]string{
+		"MESSAGE":       "first",
+		"_SYSTEMD_UNIT": "nginx.service",
+		"_CMDLINE":      cmdline[len("_CMDLINE="):],
+		"_PID":          "100",
+		"PRIORITY":      "3",
+	}, e.fields)
+	assert.Equal(t, uint64(1680000000000001), e.realtime)
+	assert.Equal(t, e.cursor(tj.seqnumID), j.cursor)
+
+	assert.Equal(t, []string{"second", "third"}, readAll(t, j))
+
+	// appended to the next entry array
+	tj.add("MESSAGE=fourth")
+	tj.add("MESSAGE=fifth")
+	tj.write(t, path)
+	assert.Equal(t, []string{"fourth", "fifth"}, readAll(t, j))
+
+	// reopened from the cursor
+	seqnum, ok := cursorSeqnum(j.cursor)
+	require.True(t, ok)
+	assert.Equal(t, uint64(5), seqnum)
+
+	j2, err := openJournal(path)
+	require.NoError(t, err)
+	defer j2.close()
+
+	j2.seqnum = 3
+	assert.Equal(t, []string{"fourth", "fifth"}, readAll(t, j2))
+
+	// from the tail
+	j3, err := openJournal(path)
+	require.NoError(t, err)
+	defer j3.close()
+
+	require.NoError(t, j3.seekTail())
+	assert.Empty(t, readAll(t, j3))
+
+	tj.add("MESSAGE=sixth")
+	tj.write(t, path)
+	assert.Equal(t, []string{"sixth"}, readAll(t, j3))
+}
+
+func TestOpenJournal(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "a.journal")
+	require.NoError(t, os.WriteFile(path, make([]byte, 256), 0o600))
+	_, err := openJournal(path)
+	assert.ErrorIs(t, err, errNotJournal)
+
+	// unknown incompatible flags
+	b := make([]byte, 256)
+	copy(b, headerSignature)
+	b[12] = 1 << 7
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+	_, err = openJournal(path)
+	assert.ErrorIs(t, err, errUnsupportedJournal)
+}
+
+func TestCursorSeqnum(t *testing.T) {
+	cases := []struct {
+		cursor string
+		seqnum uint64
+		ok     bool
+	}{
+		{"s=0a1b;i=1f;b=2c;m=3d;t=4e;x=5f", 0x1f, true},
+		{"s=0a1b;b=2c", 0, false},
+		{"s=0a1b;i=zz", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tc := range cases {
+		seqnum, ok := cursorSeqnum(tc.cursor)
+		assert.Equal(t, tc.ok, ok, tc.cursor)
+		assert.Equal(t, tc.seqnum, seqnum, tc.cursor)
+	}
+}
+
+func TestJournalCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.journal")
+
+	tj := &testJournal{fileID: [16]byte{1}}
+	tj.add("MESSAGE=first")
+	tj.add("MESSAGE=second")
+	tj.add("MESSAGE=third")
+	tj.write(t, path)
+
+	b, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	array := binary.LittleEndian.Uint64(b[176:])
+
+	next := func(j *journalFile) string {
+		e, err := j.next()
+		require.NoError(t, err)
+		if e == nil {
+			return ""
+		}
+		return e.fields["MESSAGE"]
+	}
+
+	t.Run("entry", func(t *testing.T) {
+		c := append([]byte(nil), b...)
+		entry := binary.LittleEndian.Uint64(c[array+24+8:])
+		c[entry] = objectData
+		require.NoError(t, os.WriteFile(path, c, 0o600))
+
+		j, err := openJournal(path)
+		require.NoError(t, err)
+		defer j.close()
+
+		assert.Equal(t, "first", next(j))
+
+		_, err = j.next()
+		assert.ErrorIs(t, err, errCorruptedJournal)
+		assert.False(t, j.corrupted)
+
+		// skipped
+		assert.Equal(t, "third", next(j))
+	})
+
+	t.Run("entry-beyond-eof", func(t *testing.T) {
+		c := append([]byte(nil), b...)
+		binary.LittleEndian.PutUint64(c[array+24+8:], uint64(len(c))+1024)
+		require.NoError(t, os.WriteFile(path, c, 0o600))
+
+		j, err := openJournal(path)
+		require.NoError(t, err)
+		defer j.close()
+
+		assert.Equal(t, "first", next(j))
+
+		_, err = j.next()
+		assert.ErrorIs(t, err, errCorruptedJournal)
+		assert.Equal(t, "third", next(j))
+	})
+
+	t.Run("entry-array", func(t *testing.T) {
+		c := append([]byte(nil), b...)
+		binary.LittleEndian.PutUint64(c[array+8:], 20)
+		require.NoError(t, os.WriteFile(path, c, 0o600))
+
+		j, err := openJournal(path)
+		require.NoError(t, err)
+		defer j.close()
+
+		_, err = j.next()
+		assert.ErrorIs(t, err, errCorruptedJournal)
+		assert.True(t, j.corrupted)
+
+		// not retried
+		assert.Equal(t, "", next(j))
+	})
+}
@@ -64,6 +64,16 @@ const (
   ## regexp link: https://golang.org/pkg/regexp/syntax/#hdr-Syntax
   # multiline_match = '''^\S'''
 
+  ## Lines matched are appended to the previous record, others start new records.
+  # multiline_continue_match = '''^(\s+at |\s*\.\.\. \d+ more|Caused by:)'''
+  ## Lines matched end records.
+  # multiline_end_match = '''^\w+(Error|Exception): '''
+  ## Max lines and bytes of a record, flushed if exceeded.
+  # multiline_max_lines = 1000
+  # multiline_max_bytes = 33554432
+  ## Flush the record if no lines appended within the timeout, for each stream.
+  # multiline_flush_timeout = "5s"
+
   auto_multiline_detection = true
   auto_multiline_extra_patterns = []
 
@@ -77,6 +87,10 @@ const (
   ## Read file from beginning.
   from_beginning = false
 
+  ## Read the existing files (including gzip/zstd/bzip2 archives) to the end
+  ## and stop, new files are not discovered any more.
+  # backfill = false
+
   [inputs.logging.tags]
   # some_tag = "some_value"
   # more_tag = "some_other_value"
@@ -93,12 +107,18 @@ type Input struct {
 	IgnoreStatus                    []string          `toml:"ignore_status"`
 	CharacterEncoding               string            `toml:"character_encoding"`
 	MultilineMatch                  string            `toml:"multiline_match"`
+	MultilineContinueMatch          string            `toml:"multiline_continue_match,omitempty"`
+	MultilineEndMatch               string            `toml:"multiline_end_match,omitempty"`
+	MultilineMaxLines               int               `toml:"multiline_max_lines,omitempty"`
+	MultilineMaxBytes               int               `toml:"multiline_max_bytes,omitempty"`
+	MultilineFlushTimeout           string            `toml:"multiline_flush_timeout,omitempty"`
 	AutoMultilineDetection          bool              `toml:"auto_multiline_detection"`
 	AutoMultilineExtraPatterns      []string          `toml:"auto_multiline_extra_patterns"`
 	DeprecatedRemoveAnsiEscapeCodes bool              `toml:"remove_ansi_escape_codes"`
 	Tags                            map[string]string `toml:"tags"`
 	BlockingMode                    bool              `toml:"blocking_mode"`
 	FromBeginning                   bool              `toml:"from_beginning,omitempty"`
+	Backfill                        bool              `toml:"backfill,omitempty"`
 	DockerMode                      bool              `toml:"docker_mode,omitempty"`
 	IgnoreDeadLog                   string            `toml:"ignore_dead_log"`
 	MinFlushInterval                time.Duration     `toml:"-"`
@@ -141,18 +161,29 @@ func (ipt *Input) Run() {
 		ignoreDuration = dur
 	}
 
+	var flushTimeout time.Duration
+	if dur, err := timex.ParseDuration(ipt.MultilineFlushTimeout); err == nil {
+		flushTimeout = dur
+	}
+
 	opt := &tailer.Option{
-		Source:            ipt.Source,
-		Service:           ipt.Service,
-		Pipeline:          ipt.Pipeline,
-		Sockets:           ipt.Sockets,
-		IgnoreStatus:      ipt.IgnoreStatus,
-		FromBeginning:     ipt.FromBeginning,
-		CharacterEncoding: ipt.CharacterEncoding,
-		IgnoreDeadLog:     ignoreDuration,
-		GlobalTags:        ipt.Tags,
-		BlockingMode:      ipt.BlockingMode,
-		Done:              ipt.semStop.Wait(),
+		Source:                   ipt.Source,
+		Service:                  ipt.Service,
+		Pipeline:                 ipt.Pipeline,
+		Sockets:                  ipt.Sockets,
+		IgnoreStatus:             ipt.IgnoreStatus,
+		FromBeginning:            ipt.FromBeginning,
+		Backfill:                 ipt.Backfill,
+		CharacterEncoding:        ipt.CharacterEncoding,
+		IgnoreDeadLog:            ignoreDuration,
+		GlobalTags:               ipt.Tags,
+		BlockingMode:             ipt.BlockingMode,
+		MultilineContinuePattern: ipt.MultilineContinueMatch,
+		MultilineEndPattern:      ipt.MultilineEndMatch,
+		MultilineMaxLines:        ipt.MultilineMaxLines,
+		MultilineMaxBytes:        ipt.MultilineMaxBytes,
+		MultilineFlushTimeout:    flushTimeout,
+		Done:                     ipt.semStop.Wait(),
 	}
 
 	if ipt.DockerMode {
@@ -162,6 +193,7 @@ func (ipt *Input) Run() {
 	if ipt.MultilineMatch != "" {
 		opt.MultilinePatterns = []string{ipt.MultilineMatch}
 	} else if ipt.AutoMultilineDetection {
+		opt.AutoMultiline = true
 		if len(ipt.AutoMultilineExtraPatterns) != 0 {
 			opt.MultilinePatterns = ipt.AutoMultilineExtraPatterns
 			l.Infof("source %s automatic-multiline on, patterns %v", ipt.Source, ipt.AutoMultilineExtraPatterns)
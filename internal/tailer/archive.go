@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats of archives, detected by the magic number.
+const (
+	compressionNone  = ""
+	compressionGzip  = "gzip"
+	compressionZstd  = "zstd"
+	compressionBzip2 = "bzip2"
+)
+
+// archiveSettleDuration is the time an archive not modified before read,
+// to avoid reading the archive being compressed.
+const archiveSettleDuration = 3 * time.Second
+
+var (
+	// errArchiveDone returned if the archive has been read to the end before.
+	errArchiveDone = errors.New("archive has been read")
+	// errArchiveNotReady returned if the archive modified recently.
+	errArchiveNotReady = errors.New("archive not ready")
+)
+
+var compressionMagics = []struct {
+	magic       []byte
+	compression string
+}{
+	{[]byte{0x1f, 0x8b}, compressionGzip},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, compressionZstd},
+	{[]byte("BZh"), compressionBzip2},
+}
+
+// detectCompression returns the compression format of the file, the
+// offset of the file not changed.
+func detectCompression(f *os.File) (string, error) {
+	head := make([]byte, 4)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return compressionNone, err
+	}
+
+	for _, m := range compressionMagics {
+		if bytes.HasPrefix(head[:n], m.magic) {
+			return m.compression, nil
+		}
+	}
+	return compressionNone, nil
+}
+
+// newDecompressReader returns the reader of the decompressed content.
+func newDecompressReader(compression string, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case compressionGzip:
+		return gzip.NewReader(r)
+	case compressionZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	case compressionBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	default:
+		return nil, fmt.Errorf("unknown compression %q", compression)
+	}
+}
+
+// archiveKey returns the register key of the archive, archives are
+// identified by the fingerprint instead of the path, so they are not read
+// again after renamed or moved.
+func archiveKey(fingerprint string) string {
+	return "archive::" + fingerprint
+}
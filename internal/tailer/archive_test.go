@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	content := []byte("hello\nworld\n")
+
+	gz := func() []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write(content)
+		_ = w.Close()
+		return buf.Bytes()
+	}
+
+	zst := func() []byte {
+		var buf bytes.Buffer
+		w, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		_, _ = w.Write(content)
+		_ = w.Close()
+		return buf.Bytes()
+	}
+
+	// bz2.compress(b"hello\nworld\n"), no bzip2 writer in stdlib
+	bz2, err := hex.DecodeString("425a68393141592653596b5fb1dd00000241800010064490802000310c0821a369080723ae878bb9229c284835afd8ee80")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		data        []byte
+		compression string
+	}{
+		{"plain", content, compressionNone},
+		{"empty", nil, compressionNone},
+		{"gzip", gz(), compressionGzip},
+		{"zstd", zst(), compressionZstd},
+		{"bzip2", bz2, compressionBzip2},
+	}
+
+	dir := t.TempDir()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			require.NoError(t, os.WriteFile(path, tc.data, 0o600))
+
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer f.Close() //nolint:errcheck,gosec
+
+			compression, err := detectCompression(f)
+			require.NoError(t, err)
+			assert.Equal(t, tc.compression, compression)
+
+			fp, _, err := readFingerprint(f, fingerprintSize)
+			require.NoError(t, err)
+			assert.NotEmpty(t, fp)
+
+			if compression == compressionNone {
+				return
+			}
+
+			r, err := newDecompressReader(compression, f)
+			require.NoError(t, err)
+			defer r.Close() //nolint:errcheck
+
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, content, got)
+		})
+	}
+}
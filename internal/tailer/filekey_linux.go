@@ -9,7 +9,10 @@
 package tailer
 
 import (
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 )
 
@@ -22,3 +25,38 @@ func getFileKey(file string) string {
 	}
 	return file + "::" + inodeStr
 }
+
+// originalExists returns whether the file of the key still exists other than
+// the file opened, at the path of the key or renamed within its directory,
+// such as rotated by logrotate with create.
+func originalExists(key string, opened os.FileInfo) bool {
+	i := strings.LastIndex(key, "::")
+	if i < 0 {
+		return false
+	}
+
+	ino, err := strconv.ParseUint(key[i+2:], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	// renamed to the file opened
+	if stat, ok := opened.Sys().(*syscall.Stat_t); ok && stat.Ino == ino {
+		return false
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(key[:i]))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Ino == ino {
+			return true
+		}
+	}
+	return false
+}
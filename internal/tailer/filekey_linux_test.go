@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+//go:build linux
+// +build linux
+
+package tailer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginalExists(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("banner\n"), 0o600))
+	key := getFileKey(path)
+
+	stat := func(path string) os.FileInfo {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info
+	}
+
+	// renamed to the file opened
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "app2.log")))
+	assert.False(t, originalExists(key, stat(filepath.Join(dir, "app2.log"))))
+
+	// rotated with create, the new file of the same head is not the original one
+	require.NoError(t, os.Rename(filepath.Join(dir, "app2.log"), filepath.Join(dir, "app.log.1")))
+	require.NoError(t, os.WriteFile(path, []byte("banner\n"), 0o600))
+	assert.True(t, originalExists(key, stat(path)))
+
+	// removed, such as the inode changed on overlayfs
+	require.NoError(t, os.Remove(filepath.Join(dir, "app.log.1")))
+	assert.False(t, originalExists(key, stat(path)))
+
+	assert.False(t, originalExists("app.log", stat(path)))
+}
@@ -8,7 +8,16 @@
 
 package tailer
 
+import "os"
+
 //nolint
 func getFileKey(file string) string {
 	return file
 }
+
+// originalExists returns whether the file of the key still exists other than
+// the file opened.
+func originalExists(key string, opened os.FileInfo) bool {
+	info, err := os.Stat(key)
+	return err == nil && !os.SameFile(info, opened)
+}
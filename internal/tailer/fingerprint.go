@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"io"
+	"os"
+)
+
+// fingerprintSize is the max size of the head of the file hashed as
+// fingerprint.
+const fingerprintSize = 4096
+
+// readFingerprint returns the hash of the first size bytes of the file at
+// most, and the number of bytes hashed.
+//
+// Logs are append only, the head of the file is not changed unless the
+// file truncated or re-created, so the fingerprint identifies the file
+// regardless of the path and inode.
+func readFingerprint(f *os.File, size int64) (string, int64, error) {
+	head := make([]byte, size)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, err
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(head[:n])
+	return hex.EncodeToString(h.Sum(nil)), int64(n), nil
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFingerprint(t *testing.T) {
+	dir := t.TempDir()
+
+	head := bytes.Repeat([]byte("x"), fingerprintSize)
+	fingerprint := func(name string, data []byte, size int64) (string, int64) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close() //nolint:errcheck,gosec
+
+		fp, n, err := readFingerprint(f, size)
+		require.NoError(t, err)
+		return fp, n
+	}
+
+	fp, n := fingerprint("a", head, fingerprintSize)
+	assert.Equal(t, int64(fingerprintSize), n)
+
+	// renamed or moved
+	fp2, _ := fingerprint("b", head, fingerprintSize)
+	assert.Equal(t, fp, fp2)
+
+	// only the head used
+	fp2, _ = fingerprint("c", append(head, 'y'), fingerprintSize)
+	assert.Equal(t, fp, fp2)
+
+	// smaller than the size
+	fp2, n = fingerprint("d", head[1:], fingerprintSize)
+	assert.NotEqual(t, fp, fp2)
+	assert.Equal(t, int64(fingerprintSize-1), n)
+
+	_, n = fingerprint("e", nil, fingerprintSize)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestSingleTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.log")
+	require.NoError(t, os.WriteFile(path, []byte("2023-01-01 first line\n"), 0o600))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	tl := &Single{opt: &Option{log: l}, file: f, filepath: path}
+	defer tl.closeFile()
+
+	tl.updateFingerprint()
+	assert.Equal(t, int64(22), tl.fingerprintSize)
+	assert.False(t, tl.truncated())
+
+	// appended
+	w, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = w.WriteString("2023-01-01 second line\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.False(t, tl.truncated())
+	tl.updateFingerprint()
+	assert.Equal(t, int64(45), tl.fingerprintSize)
+
+	// copytruncate, rewritten larger than before
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("2023-01-02 new line\n"), 10), 0o600))
+	assert.True(t, tl.truncated())
+
+	// not updated until reopened
+	fp := tl.fingerprint
+	tl.updateFingerprint()
+	assert.Equal(t, fp, tl.fingerprint)
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/multiline"
+)
+
+func TestMultilinePerStream(t *testing.T) {
+	tl := &Single{
+		opt:     &Option{log: l, MultilinePatterns: []string{`^\S`}},
+		multOpt: &multiline.Option{},
+		mults:   map[string]*multiline.Multiline{},
+	}
+
+	// the stack trace of stderr interleaved with stdout
+	logs := tl.generateJSONLogs([]string{
+		`{"log":"Exception in thread main\n","stream":"stderr","time":"2022-06-30T03:22:20.055429751Z"}`,
+		`{"log":"GET /index 200\n","stream":"stdout","time":"2022-06-30T03:22:20.055429751Z"}`,
+		`{"log":"\tat A.run(A.java:10)\n","stream":"stderr","time":"2022-06-30T03:22:20.055429751Z"}`,
+		`{"log":"GET /about 200\n","stream":"stdout","time":"2022-06-30T03:22:20.055429751Z"}`,
+		`{"log":"\tat B.run(B.java:20)\n","stream":"stderr","time":"2022-06-30T03:22:20.055429751Z"}`,
+		`{"log":"next error\n","stream":"stderr","time":"2022-06-30T03:22:20.055429751Z"}`,
+	})
+
+	assert.Equal(t, []string{
+		"GET /index 200",
+		"Exception in thread main\n\tat A.run(A.java:10)\n\tat B.run(B.java:20)",
+	}, logs)
+
+	m, err := tl.multilineOf("stdout")
+	assert.NoError(t, err)
+	assert.Equal(t, "GET /about 200", m.FlushString())
+
+	m, err = tl.multilineOf("stderr")
+	assert.NoError(t, err)
+	assert.Equal(t, "next error", m.FlushString())
+}
+
+func TestMultilineFlushTimeoutOnIdle(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(f, []byte("Exception in thread main\n\tat A.run(A.java:10)\n"), 0o600))
+
+	var (
+		mtx  sync.Mutex
+		logs []string
+	)
+
+	done := make(chan interface{})
+	defer close(done)
+
+	opt := &Option{
+		Source:                t.Name(),
+		FromBeginning:         true,
+		MultilinePatterns:     []string{`^\S`},
+		MultilineFlushTimeout: 100 * time.Millisecond,
+		MinFlushInterval:      time.Hour, // not flushed by the ticker
+		Done:                  done,
+		ForwardFunc: func(_, text string) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			logs = append(logs, text)
+			return nil
+		},
+	}
+	require.NoError(t, opt.Init())
+
+	tl, err := NewTailerSingle(f, opt)
+	require.NoError(t, err)
+	go tl.Run()
+
+	// no more data written, the record flushed on its timeout
+	require.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(logs) == 1
+	}, 3*time.Second, 50*time.Millisecond)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, "Exception in thread main\n\tat A.run(A.java:10)", logs[0])
+}
@@ -8,6 +8,7 @@ package tailer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sync"
@@ -20,6 +21,8 @@ import (
 const (
 	// 定期寻找符合条件的新文件.
 	scanNewFileInterval = time.Second * 10
+	// 新文件由 inotify 发现时，定期扫描仅用于兜底.
+	scanNewFileIntervalWithWatcher = time.Minute
 
 	defaultSource    = "default"
 	minflushInterval = time.Second * 5
@@ -71,6 +74,9 @@ type Option struct {
 	// 是否从文件起始处开始读取
 	// 注意，如果打开此项，可能会导致大量数据重复
 	FromBeginning bool
+	// 是否为回填模式，从头读取现有文件（包括压缩归档）直到末尾后退出，不再发现新文件
+	// 已记录的位置仍然生效，重复执行不会导致数据重复
+	Backfill bool
 	// 是否删除文本中的ansi转义码，默认为false，即不删除
 	RemoveAnsiEscapeCodes bool
 	// 是否关闭添加默认status字段列，包括status字段的固定转换行为，例如'd'->'debug'
@@ -87,6 +93,17 @@ type Option struct {
 	MinFlushInterval         time.Duration
 	MaxMultilineLifeDuration time.Duration
 
+	// 多行续行正则和结束正则，详见 multiline.Option
+	MultilineContinuePattern string
+	MultilineEndPattern      string
+	// 一段多行数据的最大行数和字节数，超出限制会执行 flush
+	MultilineMaxLines int
+	MultilineMaxBytes int
+	// 一段多行数据在此时长内没有新的行，执行 flush，docker/containerd 日志的每个 stream 分别计时
+	MultilineFlushTimeout time.Duration
+	// 是否为自动多行模式，学习到的规则会以 source 为单位记录，重启后直接使用
+	AutoMultiline bool
+
 	Done <-chan interface{}
 
 	Mode Mode
@@ -138,9 +155,14 @@ type Tailer struct {
 	filePatterns   []string
 	ignorePatterns []string
 
+	// nil if not supported or failed to create
+	watcher *fileWatcher
+
 	stop chan interface{}
 	mu   sync.Mutex
 	g    *goroutine.Group
+	// tailers running, waited in backfill mode
+	wg sync.WaitGroup
 }
 
 func NewTailer(filePatterns []string, opt *Option, ignorePatterns ...[]string) (*Tailer, error) {
@@ -181,13 +203,25 @@ func NewTailer(filePatterns []string, opt *Option, ignorePatterns ...[]string) (
 }
 
 func (t *Tailer) Start() {
-	ticker := time.NewTicker(scanNewFileInterval)
+	if t.opt.Backfill {
+		t.backfill()
+		return
+	}
+
+	interval := scanNewFileInterval
+	if w, err := newFileWatcher(t.filePatterns, t.ignorePatterns, t.opt.log); err != nil {
+		t.opt.log.Infof("file watcher disabled: %s, scan new files every %s", err, interval)
+	} else {
+		t.watcher = w
+		defer w.close()
+		interval = scanNewFileIntervalWithWatcher
+	}
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	t.scan()
 	for {
-		t.scan()
-		t.opt.log.Debugf("list of recivering: %v", t.getFileList())
-
 		select {
 		case <-t.stop:
 			t.opt.log.Infof("waiting for all tailers to exit")
@@ -195,11 +229,25 @@ func (t *Tailer) Start() {
 			t.opt.log.Info("all exit")
 			return
 
+		case filename := <-t.watcher.found():
+			t.tail(filename)
+
 		case <-ticker.C:
+			t.scan()
 		}
 	}
 }
 
+// backfill reads the files existed to the end and exits.
+func (t *Tailer) backfill() {
+	t.opt.log.Infof("backfill files of %v", t.filePatterns)
+
+	t.scan()
+	t.wg.Wait()
+
+	t.opt.log.Infof("backfill finished")
+}
+
 func (t *Tailer) scan() {
 	filelist, err := NewProvider().SearchFiles(t.filePatterns).IgnoreFiles(t.ignorePatterns).Result()
 	if err != nil {
@@ -207,35 +255,50 @@ func (t *Tailer) scan() {
 	}
 
 	for _, filename := range filelist {
-		if !t.opt.FromBeginning {
-			if t.opt.IgnoreDeadLog > 0 && !FileIsActive(filename, t.opt.IgnoreDeadLog) {
-				continue
-			}
-		}
+		t.tail(filename)
+	}
+
+	t.opt.log.Debugf("list of recivering: %v", t.getFileList())
+}
 
-		if t.inFileList(filename) {
-			continue
+func (t *Tailer) tail(filename string) {
+	if !t.opt.FromBeginning && !t.opt.Backfill {
+		if t.opt.IgnoreDeadLog > 0 && !FileIsActive(filename, t.opt.IgnoreDeadLog) {
+			return
 		}
+	}
+
+	if t.inFileList(filename) {
+		return
+	}
 
-		t.opt.log.Infof("new logging file %s with source %s", filename, t.opt.Source)
+	t.opt.log.Infof("new logging file %s with source %s", filename, t.opt.Source)
 
-		func(filename string) {
-			g.Go(func(ctx context.Context) error {
-				defer t.removeFromFileList(filename)
+	// added before the goroutine started, the file may be found by both
+	// the watcher and scan.
+	t.addToFileList(filename)
 
-				tl, err := NewTailerSingle(filename, t.opt)
-				if err != nil {
-					t.opt.log.Errorf("new tailer file %s error: %s", filename, err)
-					return nil
-				}
+	t.wg.Add(1)
+	g.Go(func(ctx context.Context) error {
+		defer t.wg.Done()
+		defer t.removeFromFileList(filename)
 
-				t.addToFileList(filename)
+		tl, err := NewTailerSingle(filename, t.opt)
+		if err != nil {
+			if errors.Is(err, errArchiveDone) || errors.Is(err, errArchiveNotReady) {
+				t.opt.log.Debugf("skip archive %s: %s", filename, err)
+			} else {
+				t.opt.log.Errorf("new tailer file %s error: %s", filename, err)
+			}
+			return nil
+		}
 
-				tl.Run()
-				return nil
-			})
-		}(filename)
-	}
+		tl.wakeup = t.watcher.subscribe(tl.filepath)
+		defer t.watcher.unsubscribe(tl.filepath)
+
+		tl.Run()
+		return nil
+	})
 }
 
 func (t *Tailer) Close() {
@@ -43,7 +43,10 @@ type Single struct {
 	filepath, filename string
 
 	decoder *encoding.Decoder
-	mult    *multiline.Multiline
+	// multiline of each stream, such as stdout and stderr of containers
+	mults   map[string]*multiline.Multiline
+	multOpt *multiline.Option
+	learned string
 
 	readBuff  []byte
 	readLines int64
@@ -55,6 +58,20 @@ type Single struct {
 
 	enableDiskCache bool
 
+	// hash of the first fingerprintSize bytes of the file, the register key
+	// of archives
+	fingerprint     string
+	fingerprintSize int64
+
+	// compression format of the archive, the decompressed content read
+	// by reader. done if read to the end.
+	compression string
+	reader      io.ReadCloser
+	done        bool
+
+	// notified if the file written, nil to poll
+	wakeup <-chan struct{}
+
 	tags map[string]string
 }
 
@@ -82,11 +99,22 @@ func NewTailerSingle(filename string, opt *Option) (*Single, error) {
 			return nil, err
 		}
 	}
-	t.mult, err = multiline.New(
-		opt.MultilinePatterns,
-		&multiline.Option{MaxLifeDuration: opt.MaxMultilineLifeDuration},
-	)
-	if err != nil {
+	t.multOpt = &multiline.Option{
+		MaxLength:       opt.MultilineMaxBytes,
+		MaxLifeDuration: opt.MaxMultilineLifeDuration,
+		MaxLines:        opt.MultilineMaxLines,
+		ContinuePattern: opt.MultilineContinuePattern,
+		EndPattern:      opt.MultilineEndPattern,
+		FlushTimeout:    opt.MultilineFlushTimeout,
+	}
+	if opt.AutoMultiline {
+		if data := register.Get(multilineKey(opt.Source)); data != nil {
+			t.learned = data.MultilinePattern
+			t.multOpt.Learned = t.learned
+		}
+	}
+	t.mults = make(map[string]*multiline.Multiline)
+	if _, err = t.multilineOf(""); err != nil {
 		return nil, err
 	}
 
@@ -97,7 +125,17 @@ func NewTailerSingle(filename string, opt *Option) (*Single, error) {
 	t.filepath = t.file.Name()
 	t.filename = filepath.Base(t.filepath)
 
-	if err := t.seekOffset(); err != nil {
+	if t.compression, err = detectCompression(t.file); err != nil {
+		t.closeFile()
+		return nil, err
+	}
+
+	if t.compression != compressionNone {
+		if err := t.openArchive(); err != nil {
+			t.closeFile()
+			return nil, err
+		}
+	} else if err := t.seekOffset(); err != nil {
 		return nil, err
 	}
 
@@ -130,7 +168,7 @@ func (t *Single) seekOffset() error {
 		ret, err = t.file.Seek(pos, io.SeekStart)
 		t.opt.log.Debugf("setting position %d to filename %s", pos, t.filepath)
 	} else {
-		if t.opt.FromBeginning {
+		if t.opt.FromBeginning || t.opt.Backfill {
 			ret, err = t.file.Seek(0, io.SeekStart)
 			t.opt.log.Debugf("setting 'from_beginning' to filename %s", t.filepath)
 		} else {
@@ -139,20 +177,68 @@ func (t *Single) seekOffset() error {
 	}
 
 	t.offset = ret
+	t.updateFingerprint()
 	return err
 }
 
+// sameFingerprint reports whether the head of the file is the same as the
+// fingerprint, true if failed to read.
+func (t *Single) sameFingerprint(fingerprint string, size int64) bool {
+	fp, n, err := readFingerprint(t.file, size)
+	if err != nil {
+		t.opt.log.Warnf("read fingerprint of file %s err: %s, ignored", t.filepath, err)
+		return true
+	}
+	return n == size && fp == fingerprint
+}
+
+// truncated reports whether the file truncated or re-created at the same
+// inode, even if the size grown larger than the offset since then, such
+// as rotated by copytruncate.
+func (t *Single) truncated() bool {
+	return t.compression == compressionNone && t.fingerprintSize > 0 &&
+		!t.sameFingerprint(t.fingerprint, t.fingerprintSize)
+}
+
+// updateFingerprint hashes more of the head of the file until
+// fingerprintSize bytes.
+func (t *Single) updateFingerprint() {
+	if t.compression != compressionNone || t.file == nil || t.fingerprintSize >= fingerprintSize {
+		return
+	}
+
+	// changed, left to the check of rotation
+	if t.truncated() {
+		return
+	}
+
+	fp, n, err := readFingerprint(t.file, fingerprintSize)
+	if err != nil || n == 0 {
+		return
+	}
+	t.fingerprint, t.fingerprintSize = fp, n
+}
+
 func (t *Single) getPosition() (pos int64) {
 	// default -1
 	pos = -1
 
 	data := register.Get(getFileKey(t.filepath))
+	if data == nil {
+		data = t.getByFingerprint()
+	}
 	if data == nil {
 		t.opt.log.Infof("not found logtail cache for file %s, skip", t.filepath)
 		return
 	}
 	t.opt.log.Debugf("hit offset %d from filename %s", data.Offset, t.filepath)
 
+	if data.Fingerprint != "" && !t.sameFingerprint(data.Fingerprint, data.FingerprintSize) {
+		// the content recorded not exists any more, all of the file is new
+		t.opt.log.Infof("file %s has been truncated or re-created, read from the beginning", t.filepath)
+		return 0
+	}
+
 	stat, err := t.file.Stat()
 	if err != nil {
 		t.opt.log.Warnf("open file %s err %s, ignored", t.filepath, err)
@@ -167,14 +253,118 @@ func (t *Single) getPosition() (pos int64) {
 	return data.Offset
 }
 
+// getByFingerprint returns the data of the file renamed, moved or whose
+// inode changed (such as on overlayfs), found by the fingerprint. Only the
+// data of the same source whose original file not exists any more is used,
+// files of the same head are not the same file, such as the same banner.
+func (t *Single) getByFingerprint() *register.MetaData {
+	fp, n, err := readFingerprint(t.file, fingerprintSize)
+	if err != nil || n != fingerprintSize {
+		return nil
+	}
+
+	candidates := register.GetByFingerprint(fp)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	info, err := t.file.Stat()
+	if err != nil {
+		return nil
+	}
+
+	var res *register.MetaData
+	for key, data := range candidates {
+		if data.Source != t.opt.Source || originalExists(key, info) {
+			continue
+		}
+		if res == nil || data.Offset > res.Offset {
+			res = data
+		}
+	}
+
+	if res != nil {
+		t.opt.log.Infof("hit logtail cache for file %s by fingerprint %s", t.filepath, fp)
+	}
+	return res
+}
+
+// openArchive positions the decompressed content of the archive by its
+// fingerprint, errArchiveDone returned if read to the end before.
+func (t *Single) openArchive() error {
+	fingerprint, _, err := readFingerprint(t.file, fingerprintSize)
+	if err != nil {
+		return err
+	}
+	t.fingerprint = fingerprint
+
+	if !t.opt.Backfill {
+		// the archive may be still being written, such as compressed by logrotate
+		if info, err := t.file.Stat(); err == nil && time.Since(info.ModTime()) < archiveSettleDuration {
+			return errArchiveNotReady
+		}
+	}
+
+	var pos int64
+	if data := register.Get(t.cacheKey()); data != nil {
+		if data.Done {
+			return errArchiveDone
+		}
+		pos = data.Offset
+	} else if !t.opt.FromBeginning && !t.opt.Backfill {
+		// the same as seeking to the end of plain text files
+		t.done = true
+		t.recordingLastCache()
+		return errArchiveDone
+	}
+
+	if t.reader, err = newDecompressReader(t.compression, t.file); err != nil {
+		return err
+	}
+
+	if pos > 0 {
+		n, err := io.CopyN(io.Discard, t.reader, pos)
+		if err != nil {
+			return fmt.Errorf("skip to position %d of %s archive: %w", pos, t.compression, err)
+		}
+		t.offset = n
+		t.opt.log.Debugf("setting position %d to %s archive %s", pos, t.compression, t.filepath)
+	}
+
+	return nil
+}
+
+func (t *Single) cacheKey() string {
+	if t.compression != compressionNone {
+		return archiveKey(t.fingerprint)
+	}
+	return getFileKey(t.filepath)
+}
+
+func multilineKey(source string) string {
+	return "multiline::" + source
+}
+
+func (t *Single) metaData() *register.MetaData {
+	c := &register.MetaData{Source: t.opt.Source, Offset: t.offset, Done: t.done}
+
+	if t.compression == compressionNone {
+		t.updateFingerprint()
+		c.Fingerprint, c.FingerprintSize = t.fingerprint, t.fingerprintSize
+	}
+	return c
+}
+
 func (t *Single) recordingCache() {
-	if t.offset <= 0 {
+	t.recordingLearned()
+
+	if t.offset <= 0 && !t.done {
 		return
 	}
 
-	c := &register.MetaData{Source: t.opt.Source, Offset: t.offset}
+	c := t.metaData()
 
-	if err := register.Set(getFileKey(t.filepath), c); err != nil {
+	if err := register.Set(t.cacheKey(), c); err != nil {
 		t.opt.log.Warnf("recording cache %s err: %s", c, err)
 		return
 	}
@@ -183,13 +373,13 @@ func (t *Single) recordingCache() {
 }
 
 func (t *Single) recordingLastCache() {
-	if t.offset <= 0 {
+	if t.offset <= 0 && !t.done {
 		return
 	}
 
-	c := &register.MetaData{Source: t.opt.Source, Offset: t.offset}
+	c := t.metaData()
 
-	if err := register.SetAndFlush(getFileKey(t.filepath), c); err != nil {
+	if err := register.SetAndFlush(t.cacheKey(), c); err != nil {
 		t.opt.log.Warnf("recording last cache %s err: %s", c, err)
 		return
 	}
@@ -198,6 +388,13 @@ func (t *Single) recordingLastCache() {
 }
 
 func (t *Single) closeFile() {
+	if t.reader != nil {
+		if err := t.reader.Close(); err != nil {
+			t.opt.log.Warnf("close %s reader err: %s, ignored", t.compression, err)
+		}
+		t.reader = nil
+	}
+
 	if t.file == nil {
 		return
 	}
@@ -221,6 +418,7 @@ func (t *Single) reopen() error {
 		return err
 	}
 
+	t.fingerprint, t.fingerprintSize = "", 0
 	t.offset = ret
 	t.opt.log.Infof("reopen file %s, offset %d", t.filepath, t.offset)
 	return nil
@@ -230,7 +428,6 @@ func (t *Single) reopen() error {
 func (t *Single) forwardMessage() {
 	var (
 		b       = &buffer{}
-		lines   []string
 		readNum int
 		err     error
 
@@ -250,15 +447,24 @@ func (t *Single) forwardMessage() {
 			return
 
 		case <-flushTicker.C:
-			if t.mult != nil && t.mult.BuffLength() > 0 {
-				t.feed([]string{t.mult.FlushString()})
-			}
+			// with flush timeout, each stream flushed on its own timeout
+			t.flushMultiline(t.multOpt != nil && t.multOpt.FlushTimeout > 0)
 
 		case <-checkTicker.C:
+			if t.compression != compressionNone {
+				break // archives are never rotated
+			}
+
 			did, _ := DidRotate(t.file, t.offset)
 			exist := FileExists(t.filepath)
 
-			if did || !exist {
+			// the rest of the file truncated is new content, not read here
+			truncated := !did && t.truncated()
+			if truncated {
+				t.opt.log.Infof("file %s has been truncated, current offset %d", t.filepath, t.offset)
+			}
+
+			if (did || !exist) && !truncated {
 				t.opt.log.Infof("file %s has been rotated or removed, current offset %d, try to read EOF", t.filepath, t.offset)
 				for {
 					b.buf, readNum, err = t.read()
@@ -275,23 +481,13 @@ func (t *Single) forwardMessage() {
 					}
 					t.readTime = time.Now()
 
-					lines = b.split()
-					switch t.opt.Mode {
-					case FileMode:
-						t.defaultHandler(lines)
-					case DockerMode:
-						t.dockerHandler(lines)
-					case ContainerdMode:
-						t.containerdHandler(lines)
-					default:
-						t.defaultHandler(lines)
-					}
+					t.handle(b.split())
 					// 数据处理完成，再记录 offset
 					t.offset += int64(readNum)
 				}
 			}
 
-			if did { // 只有文件 rotated 才会 reopen
+			if did || truncated { // 只有文件 rotated 才会 reopen
 				t.opt.log.Infof("reopen the file %s", t.filepath)
 				if err = t.reopen(); err != nil {
 					t.opt.log.Warnf("failed to reopen the file %s, err: %s", t.filepath, err)
@@ -299,7 +495,7 @@ func (t *Single) forwardMessage() {
 				}
 			}
 
-			if !FileIsActive(t.filepath, t.opt.IgnoreDeadLog) {
+			if !t.opt.Backfill && !FileIsActive(t.filepath, t.opt.IgnoreDeadLog) {
 				t.opt.log.Infof("file %s has been inactive for larger than %s or has been removed, exit", t.filepath, t.opt.IgnoreDeadLog)
 				return
 			}
@@ -310,36 +506,66 @@ func (t *Single) forwardMessage() {
 		b.buf, readNum, err = t.read()
 		if err != nil {
 			t.opt.log.Warnf("failed to read data from file %s, error: %s", t.filename, err)
+			if t.compression != compressionNone {
+				// corrupted or incomplete archive, continue from the position next time
+				return
+			}
 			continue
 		}
 
 		t.opt.log.Debugf("read %d bytes from file %s, offset %d", readNum, t.filepath, t.offset)
 
 		if readNum == 0 {
+			if t.compression != compressionNone || t.opt.Backfill {
+				t.finish(b)
+				return
+			}
+
+			// no more data, the pending record may be the last one before
+			// the file goes quiet.
+			t.flushMultiline(true)
 			t.wait()
 			continue
 		}
 		t.readTime = time.Now()
 		flushTicker.Reset(t.opt.MinFlushInterval)
 
-		lines = b.split()
-
-		switch t.opt.Mode {
-		case FileMode:
-			t.defaultHandler(lines)
-		case DockerMode:
-			t.dockerHandler(lines)
-		case ContainerdMode:
-			t.containerdHandler(lines)
-		default:
-			t.defaultHandler(lines)
-		}
+		t.handle(b.split())
+		t.flushMultiline(true)
 
 		// 数据处理完成，再记录 offset
 		t.offset += int64(readNum)
 	}
 }
 
+func (t *Single) handle(lines []string) {
+	switch t.opt.Mode {
+	case FileMode:
+		t.defaultHandler(lines)
+	case DockerMode:
+		t.dockerHandler(lines)
+	case ContainerdMode:
+		t.containerdHandler(lines)
+	default:
+		t.defaultHandler(lines)
+	}
+}
+
+// finish flushes the pending content at the end of archives or files
+// backfilled, which are not read any more.
+func (t *Single) finish(b *buffer) {
+	if len(b.previousBlock) != 0 {
+		line := string(b.previousBlock)
+		b.previousBlock = nil
+		t.handle([]string{line})
+	}
+
+	t.flushMultiline(false)
+
+	t.done = t.compression != compressionNone
+	t.opt.log.Infof("read to the end of file %s, offset %d", t.filepath, t.offset)
+}
+
 type dockerMessage struct {
 	Log    string `json:"log"`
 	Stream string `json:"stream"`
@@ -400,7 +626,7 @@ func (t *Single) generateJSONLogs(lines []string) []string {
 			t.opt.log.Debugf("decode '%s' error: %s", t.opt.CharacterEncoding, err)
 		}
 
-		text = t.multiline(multiline.TrimRightSpace(text))
+		text = t.multiline(msg.Stream, multiline.TrimRightSpace(text))
 		if text == "" {
 			continue
 		}
@@ -431,7 +657,7 @@ func (t *Single) generateCRILogs(lines []string) []string {
 			t.opt.log.Warnf("parse cri-o log err: %s, data: %s", err, line)
 			continue
 		}
-		text = t.multiline(criMsg.log)
+		text = t.multiline(string(criMsg.stream), criMsg.log)
 
 		if text == "" {
 			continue
@@ -458,7 +684,7 @@ func (t *Single) defaultHandler(lines []string) {
 			t.opt.log.Debugf("decode '%s' error: %s", t.opt.CharacterEncoding, err)
 		}
 
-		text = t.multiline(multiline.TrimRightSpace(text))
+		text = t.multiline("", multiline.TrimRightSpace(text))
 		if text == "" {
 			continue
 		}
@@ -602,7 +828,12 @@ func (t *Single) feedToIO(pending []string) {
 }
 
 func (t *Single) read() ([]byte, int, error) {
-	n, err := t.file.Read(t.readBuff)
+	var r io.Reader = t.file
+	if t.reader != nil {
+		r = t.reader
+	}
+
+	n, err := r.Read(t.readBuff)
 	if err != nil && err != io.EOF {
 		// an unexpected error occurred, stop the tailor
 		t.opt.log.Warnf("Unexpected error occurred while reading file: %s", err)
@@ -612,7 +843,18 @@ func (t *Single) read() ([]byte, int, error) {
 }
 
 func (t *Single) wait() {
-	time.Sleep(defaultSleepDuration)
+	if t.wakeup == nil {
+		time.Sleep(defaultSleepDuration)
+		return
+	}
+
+	timer := time.NewTimer(defaultSleepDuration)
+	defer timer.Stop()
+
+	select {
+	case <-t.wakeup:
+	case <-timer.C:
+	}
 }
 
 func (t *Single) buildTags(globalTags map[string]string) map[string]string {
@@ -636,11 +878,73 @@ func (t *Single) decode(text string) (str string, err error) {
 	return t.decoder.String(text)
 }
 
-func (t *Single) multiline(text string) string {
-	if t.mult == nil {
+func (t *Single) multiline(stream, text string) string {
+	m, err := t.multilineOf(stream)
+	if err != nil {
 		return text
 	}
-	return t.mult.ProcessLineString(text)
+	return m.ProcessLineString(text)
+}
+
+func (t *Single) multilineOf(stream string) (*multiline.Multiline, error) {
+	if m, ok := t.mults[stream]; ok {
+		return m, nil
+	}
+
+	opt := *t.multOpt
+	m, err := multiline.New(t.opt.MultilinePatterns, &opt)
+	if err != nil {
+		return nil, err
+	}
+	t.mults[stream] = m
+	return m, nil
+}
+
+// flushMultiline flushes the pending multiline data of all streams, only
+// the ones expired if expiredOnly.
+func (t *Single) flushMultiline(expiredOnly bool) {
+	var pending []string
+	for _, m := range t.mults {
+		var text string
+		if expiredOnly {
+			text = m.FlushExpired()
+		} else {
+			text = m.FlushString()
+		}
+
+		if text != "" {
+			pending = append(pending, text)
+		}
+	}
+
+	if len(pending) != 0 {
+		t.feed(pending)
+	}
+}
+
+// recordingLearned records the pattern learned by auto multiline of the
+// source, used by files of the source after restarted.
+func (t *Single) recordingLearned() {
+	if !t.opt.AutoMultiline {
+		return
+	}
+
+	for _, m := range t.mults {
+		learned := m.Learned()
+		if learned == "" || learned == t.learned {
+			continue
+		}
+
+		t.learned = learned
+		c := &register.MetaData{Source: t.opt.Source, MultilinePattern: learned}
+		if err := register.Set(multilineKey(t.opt.Source), c); err != nil {
+			t.opt.log.Warnf("recording multiline pattern %s err: %s", learned, err)
+			return
+		}
+
+		t.opt.log.Infof("learned multiline pattern %s of source %s", learned, t.opt.Source)
+		return
+	}
 }
 
 type buffer struct {
@@ -0,0 +1,334 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+//go:build linux
+// +build linux
+
+package tailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gobwas/glob"
+)
+
+const foundChanSize = 1024
+
+// fileWatcher watches directories of the file patterns with inotify, to
+// find new files and wake up tailers of files written.
+//
+// Directories which may contain matched files are watched, including
+// directories created later. Events may be lost if the queue overflowed
+// or watches exceeded the limit, the periodic scan is still required.
+type fileWatcher struct {
+	w   *fsnotify.Watcher
+	log *logger.Logger
+
+	patterns []string
+	ignores  []glob.Glob
+
+	foundCh chan string
+
+	mu      sync.Mutex
+	wakeups map[string]chan struct{}
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newFileWatcher(patterns, ignorePatterns []string, log *logger.Logger) (*fileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	fw := &fileWatcher{
+		w:       w,
+		log:     log,
+		foundCh: make(chan string, foundChanSize),
+		wakeups: map[string]chan struct{}{},
+		done:    make(chan struct{}),
+	}
+
+	for _, p := range patterns {
+		fw.patterns = append(fw.patterns, filepath.Clean(p))
+	}
+
+	for _, p := range ignorePatterns {
+		if g, err := glob.Compile(p); err == nil {
+			fw.ignores = append(fw.ignores, g)
+		}
+	}
+
+	for _, p := range fw.patterns {
+		dir := staticDir(p)
+		// the directory not created yet, watch the nearest one existed
+		for !isDir(dir) && dir != filepath.Dir(dir) {
+			dir = filepath.Dir(dir)
+		}
+		fw.addDir(dir, false)
+	}
+
+	fw.wg.Add(1)
+	go func() {
+		defer fw.wg.Done()
+		fw.run()
+	}()
+
+	return fw, nil
+}
+
+// found returns paths of new files matched, nil if w is nil.
+func (w *fileWatcher) found() <-chan string {
+	if w == nil {
+		return nil
+	}
+	return w.foundCh
+}
+
+// subscribe returns the channel notified if the file written or created.
+func (w *fileWatcher) subscribe(path string) <-chan struct{} {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	w.wakeups[path] = ch
+	return ch
+}
+
+func (w *fileWatcher) unsubscribe(path string) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.wakeups, path)
+}
+
+func (w *fileWatcher) close() {
+	if w == nil {
+		return
+	}
+
+	select {
+	case <-w.done:
+		return
+	default:
+		close(w.done)
+	}
+
+	if err := w.w.Close(); err != nil {
+		w.log.Warnf("close file watcher: %s, ignored", err)
+	}
+	w.wg.Wait()
+}
+
+func (w *fileWatcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case e, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			w.handle(e)
+
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+			// such as queue overflow, the files missed are found by scan
+			w.log.Warnf("file watcher: %s", err)
+		}
+	}
+}
+
+func (w *fileWatcher) handle(e fsnotify.Event) {
+	path := filepath.Clean(e.Name)
+
+	switch {
+	case e.Has(fsnotify.Create):
+		if isDir(path) {
+			w.addDir(path, true)
+			return
+		}
+		if !w.wakeup(path) {
+			w.notifyFound(path)
+		}
+
+	case e.Has(fsnotify.Write):
+		// the file not tailed may be inactive before
+		if !w.wakeup(path) {
+			w.notifyFound(path)
+		}
+
+	case e.Has(fsnotify.Remove), e.Has(fsnotify.Rename):
+		w.wakeup(path)
+	}
+}
+
+// addDir watches the directory and its sub directories wanted, files
+// within are reported as found if emit.
+func (w *fileWatcher) addDir(dir string, emit bool) {
+	if !w.wantDir(dir) {
+		return
+	}
+
+	if err := w.w.Add(dir); err != nil {
+		// such as ENOSPC if max_user_watches exceeded
+		w.log.Warnf("watch directory %s: %s, ignored", dir, err)
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		w.log.Debugf("read directory %s: %s", dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			w.addDir(path, emit)
+		} else if emit {
+			w.notifyFound(path)
+		}
+	}
+}
+
+func (w *fileWatcher) wakeup(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ch, ok := w.wakeups[path]
+	if !ok {
+		return false
+	}
+
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (w *fileWatcher) notifyFound(path string) {
+	if !w.match(path) {
+		return
+	}
+
+	select {
+	case w.foundCh <- path:
+	default: // the tailer busy, leave it to scan
+		w.log.Debugf("found channel full, drop %s", path)
+	}
+}
+
+// match reports whether the path matched by any of the patterns and not
+// ignored, the same as the result of Provider.
+func (w *fileWatcher) match(path string) bool {
+	matched := false
+	for _, p := range w.patterns {
+		if ok, _ := doublestar.PathMatch(p, path); ok {
+			matched = true
+			break
+		}
+	}
+
+	if !matched {
+		return false
+	}
+
+	for _, g := range w.ignores {
+		if g.Match(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// wantDir reports whether files matched may be within the directory or
+// its sub directories.
+func (w *fileWatcher) wantDir(dir string) bool {
+	dirSegs := splitPath(dir)
+
+	for _, p := range w.patterns {
+		// patterns of the parent directory of files
+		segs := splitPath(filepath.Dir(p))
+
+		if len(dirSegs) > len(segs) && !containsSuperAsterisk(segs) {
+			continue
+		}
+
+		wanted := true
+		for i, seg := range dirSegs {
+			if i >= len(segs) {
+				break
+			}
+			if segs[i] == "**" {
+				break // any directory below
+			}
+
+			if ok, _ := doublestar.Match(segs[i], seg); !ok {
+				wanted = false
+				break
+			}
+		}
+
+		if wanted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// staticDir returns the directory before any meta characters.
+func staticDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for hasMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[{\\")
+}
+
+func containsSuperAsterisk(segs []string) bool {
+	for _, seg := range segs {
+		if seg == "**" {
+			return true
+		}
+	}
+	return false
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
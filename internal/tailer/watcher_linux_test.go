@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+//go:build linux
+// +build linux
+
+package tailer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := newFileWatcher([]string{filepath.Join(dir, "*", "*.log")},
+		[]string{filepath.Join(dir, "*", "ignore.log")}, logger.DefaultSLogger("test"))
+	require.NoError(t, err)
+	defer w.close()
+
+	expectFound := func(path string) {
+		t.Helper()
+		select {
+		case x := <-w.found():
+			assert.Equal(t, path, x)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("%s not found", path)
+		}
+	}
+
+	// the file may be reported more than once, by both the directory read
+	// and its events, duplicates are ignored by the tailer
+	expectNone := func(dup string) {
+		t.Helper()
+		for {
+			select {
+			case x := <-w.found():
+				if x != dup {
+					t.Fatalf("unexpected %s found", x)
+				}
+			case <-time.After(100 * time.Millisecond):
+				return
+			}
+		}
+	}
+
+	// directory and file created later
+	sub := filepath.Join(dir, "pod-1")
+	require.NoError(t, os.Mkdir(sub, 0o755))
+
+	logfile := filepath.Join(sub, "a.log")
+	require.NoError(t, os.WriteFile(logfile, []byte("hello\n"), 0o600))
+	expectFound(logfile)
+
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "ignore.log"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "a.txt"), nil, 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(sub, "nested"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "nested", "b.log"), nil, 0o600))
+	expectNone(logfile)
+
+	// file written is waked up once subscribed
+	wakeup := w.subscribe(logfile)
+
+	f, err := os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString("world\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	select {
+	case <-wakeup:
+	case <-time.After(3 * time.Second):
+		t.Fatal("not waked up")
+	}
+	expectNone("")
+
+	// written but not tailed, may be inactive before
+	w.unsubscribe(logfile)
+	require.NoError(t, os.WriteFile(logfile, []byte("again\n"), 0o600))
+	expectFound(logfile)
+}
+
+func TestFileWatcherWantDir(t *testing.T) {
+	w := &fileWatcher{patterns: []string{
+		"/var/log/pods/*/*/*.log",
+		"/data/app/**/*.log",
+		"/tmp/a.log",
+	}}
+
+	cases := []struct {
+		dir  string
+		want bool
+	}{
+		{"/var", true},
+		{"/var/log/pods", true},
+		{"/var/log/pods/ns_pod", true},
+		{"/var/log/pods/ns_pod/container", true},
+		{"/var/log/pods/ns_pod/container/nested", false},
+		{"/var/log/other", false},
+		{"/data/app", true},
+		{"/data/app/x/y/z", true},
+		{"/data/other", false},
+		{"/tmp", true},
+		{"/tmp/x", false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, w.wantDir(tc.dir), tc.dir)
+	}
+
+	assert.Equal(t, "/var/log/pods", staticDir("/var/log/pods/*/*/*.log"))
+	assert.Equal(t, "/data/app", staticDir("/data/app/**/*.log"))
+	assert.Equal(t, "/tmp", staticDir("/tmp/a.log"))
+}
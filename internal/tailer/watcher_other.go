@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+//go:build !linux
+// +build !linux
+
+package tailer
+
+import (
+	"fmt"
+
+	"github.com/GuanceCloud/cliutils/logger"
+)
+
+// fileWatcher is only available on Linux, files are found by scan and
+// read by polling on other platforms.
+type fileWatcher struct{}
+
+//nolint:unparam
+func newFileWatcher(patterns, ignorePatterns []string, log *logger.Logger) (*fileWatcher, error) {
+	return nil, fmt.Errorf("file watcher not supported")
+}
+
+func (w *fileWatcher) found() <-chan string { return nil }
+
+func (w *fileWatcher) subscribe(path string) <-chan struct{} { return nil }
+
+func (w *fileWatcher) unsubscribe(path string) {}
+
+func (w *fileWatcher) close() {}